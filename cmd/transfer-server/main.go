@@ -6,54 +6,93 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg/adding"
 	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
 	"github.com/pedroyremolo/transfer-api/pkg/gatekeeper/jwt"
+	"github.com/pedroyremolo/transfer-api/pkg/hasher/argon2"
 	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
 	ah "github.com/pedroyremolo/transfer-api/pkg/http/rest/adding"
 	auh "github.com/pedroyremolo/transfer-api/pkg/http/rest/authenticating"
+	ih "github.com/pedroyremolo/transfer-api/pkg/http/rest/idempotency"
+	lih "github.com/pedroyremolo/transfer-api/pkg/http/rest/limiting"
 	lh "github.com/pedroyremolo/transfer-api/pkg/http/rest/listing"
+	rh "github.com/pedroyremolo/transfer-api/pkg/http/rest/receipting"
+	sh "github.com/pedroyremolo/transfer-api/pkg/http/rest/scheduling"
 	th "github.com/pedroyremolo/transfer-api/pkg/http/rest/transferring"
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
 	"github.com/pedroyremolo/transfer-api/pkg/listing"
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/notifier/logging"
+	"github.com/pedroyremolo/transfer-api/pkg/notifier/webhook"
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/memory"
 	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
 	"github.com/pedroyremolo/transfer-api/pkg/transferring"
 	"github.com/pedroyremolo/transfer-api/pkg/updating"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	memoryStorageType = "memory"
+	webhookNotifier   = "webhook"
+	logNotifier       = "log"
+)
+
+// repository gathers every domain repository a storage must implement to back the server
+type repository interface {
+	adding.Repository
+	listing.Repository
+	updating.Repository
+	authenticating.Repository
+	transferring.Repository
+	idempotency.Repository
+	scheduling.Repository
+	limiting.Repository
+}
+
 func main() {
 	log := lgr.NewDefaultLogger()
 	logger := logrus.NewEntry(log)
 
-	var port int
+	dbCtx := context.Background()
+	storage, closeStorage := newStorageFromEnv(dbCtx, logger)
+	defer closeStorage()
 
-	storage, err := mongodb.NewStorageFromEnv()
+	gatekeeper, err := jwt.NewGatekeeperFromEnv()
 	if err != nil {
-		logger.Fatalf("failed to get storage: %s", err)
+		logger.Fatalf("failed to get gatekeeper: %s", err)
 	}
 
-	gatekeeper := jwt.NewGatekeeperFromEnv()
-
-	dbCtx := context.Background()
-	storage.Connect(dbCtx)
-	storage.CreateIndexes(dbCtx)
-	defer storage.Disconnect(dbCtx)
-
-	adder := adding.NewService(storage)
+	hasher := argon2.NewHasher(argon2.DefaultParams)
+	adder := adding.NewService(storage, hasher)
 	lister := listing.NewService(storage)
-	authenticator := authenticating.NewService(storage, gatekeeper)
-	transferor := transferring.NewService()
-	updater := updating.NewService(storage)
+	authenticator := authenticating.NewService(storage, gatekeeper, newNotifierFromEnv(logger), hasher)
+	limiter := limiting.NewService(storage)
+	transferor := transferring.NewService(storage, limiter)
+	idempotencyKeeper := idempotency.NewService(storage)
+	scheduler := scheduling.NewService(storage, transferor)
+	receipter := receipting.NewService(lister, gatekeeper)
+
+	go scheduling.NewExecutor(scheduler, schedulerIntervalFromEnv(logger)).Run(dbCtx)
 
 	addingHandler := ah.NewHandler(logger, adder)
-	transferringHandler := th.NewHandler(logger, transferor, lister, adder, updater)
-	listingHandler := lh.NewHandler(logger, lister)
+	totpTransferThreshold := totpTransferThresholdFromEnv(logger)
+	transferringHandler := th.NewHandler(logger, transferor, authenticator, totpTransferThreshold)
+	listingHandler := lh.NewHandler(logger, lister, limiter, authenticator)
 	authenticatingHandler := auh.NewHandler(logger, authenticator, lister)
+	idempotencyHandler := ih.NewHandler(logger, idempotencyKeeper)
+	schedulingHandler := sh.NewHandler(logger, scheduler, authenticator, totpTransferThreshold)
+	limitingHandler := lih.NewHandler(logger, limiter)
+	receiptingHandler := rh.NewHandler(logger, receipter)
 
-	handler := rest.Handler(logger, addingHandler, transferringHandler, authenticatingHandler, listingHandler)
-	port, err = strconv.Atoi(os.Getenv("APP_PORT"))
+	handler := rest.Handler(logger, addingHandler, transferringHandler, authenticatingHandler, listingHandler, idempotencyHandler, schedulingHandler, limitingHandler, receiptingHandler)
+	port, err := strconv.Atoi(os.Getenv("APP_PORT"))
 	if err != nil {
 		port = 8080
 	}
@@ -61,3 +100,76 @@ func main() {
 	log.Infof("Starting server at port %s", portStr)
 	log.Fatal(http.ListenAndServe(portStr, handler))
 }
+
+// newStorageFromEnv picks the storage named by APP_STORAGE_TYPE, defaulting to mongodb,
+// and returns it along with the func that releases it
+func newStorageFromEnv(ctx context.Context, logger *logrus.Entry) (repository, func()) {
+	storageType := strings.ToLower(os.Getenv("APP_STORAGE_TYPE"))
+	if storageType == memoryStorageType {
+		logger.Warn("Using in-memory storage, every data will be lost when the server stops")
+		return memory.NewStorage(), func() {}
+	}
+
+	storage, err := mongodb.NewStorageFromEnv()
+	if err != nil {
+		logger.Fatalf("failed to get storage: %s", err)
+	}
+	storage.Connect(ctx)
+	storage.CreateIndexes(ctx)
+	if err = storage.BackfillLedger(ctx); err != nil {
+		logger.Fatalf("failed to backfill ledger: %s", err)
+	}
+	return storage, func() { storage.Disconnect(ctx) }
+}
+
+// schedulerIntervalFromEnv reads how often due scheduled transfers are run from APP_SCHEDULER_INTERVAL,
+// falling back to scheduling.DefaultInterval when it is not set
+func schedulerIntervalFromEnv(logger *logrus.Entry) time.Duration {
+	value := os.Getenv("APP_SCHEDULER_INTERVAL")
+	if value == "" {
+		return scheduling.DefaultInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Fatalf("invalid APP_SCHEDULER_INTERVAL %s: %s", value, err)
+	}
+	return interval
+}
+
+// totpTransferThresholdFromEnv reads the amount above which transfers need a TOTP code from
+// APP_TOTP_TRANSFER_THRESHOLD, falling back to authenticating.DefaultTOTPTransferThreshold when it is not set
+func totpTransferThresholdFromEnv(logger *logrus.Entry) money.Money {
+	value := os.Getenv("APP_TOTP_TRANSFER_THRESHOLD")
+	if value == "" {
+		return authenticating.DefaultTOTPTransferThreshold
+	}
+	threshold, err := money.Parse(value)
+	if err != nil {
+		logger.Fatalf("invalid APP_TOTP_TRANSFER_THRESHOLD %s: %s", value, err)
+	}
+	return threshold
+}
+
+// newNotifierFromEnv picks the notifier named by APP_NOTIFIER, defaulting to webhook, which POSTs notices to
+// APP_NOTIFIER_WEBHOOK_URL. Logging them, password reset tokens included, is only fit for local development, so it
+// has to be asked for, unless the storage is in memory, and a missing webhook URL fails the startup instead
+func newNotifierFromEnv(logger *logrus.Entry) authenticating.Notifier {
+	notifier := strings.ToLower(os.Getenv("APP_NOTIFIER"))
+	if notifier == "" && strings.ToLower(os.Getenv("APP_STORAGE_TYPE")) == memoryStorageType {
+		notifier = logNotifier
+	}
+	switch notifier {
+	case logNotifier:
+		logger.Warn("Using the log notifier, password reset tokens will be logged instead of delivered")
+		return logging.NewNotifier()
+	case "", webhookNotifier:
+		url := os.Getenv("APP_NOTIFIER_WEBHOOK_URL")
+		if url == "" {
+			logger.Fatalf("APP_NOTIFIER_WEBHOOK_URL must be set, or APP_NOTIFIER=%s for local development", logNotifier)
+		}
+		return webhook.NewNotifier(url)
+	default:
+		logger.Fatalf("invalid APP_NOTIFIER %s, it must be either %s or %s", notifier, webhookNotifier, logNotifier)
+		return nil
+	}
+}
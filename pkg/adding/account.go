@@ -7,7 +7,7 @@ import (
 
 	"github.com/Nhanderu/brdoc"
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 )
 
 // Account is the representation of an account to be added
@@ -24,10 +24,10 @@ type (
 	name string
 	// cpf represents the brazilian id
 	cpf string
-	// secret represents the user authentication Password
-	secret []byte
+	// secret represents the user authentication Password, hashed by the service before the account is added
+	secret string
 	// balance represents the initial account Balance
-	balance float64
+	balance money.Money
 )
 
 func (n *name) UnmarshalJSON(b []byte) error {
@@ -79,7 +79,7 @@ func (c *cpf) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// UnmarshalJSON Unmarshaler implementation that takes the sent secret, as string, and encrypt it
+// UnmarshalJSON Unmarshaler implementation that takes the sent secret as string
 func (s *secret) UnmarshalJSON(b []byte) error {
 	var log = lgr.NewDefaultLogger()
 	var pswStr string
@@ -93,26 +93,19 @@ func (s *secret) UnmarshalJSON(b []byte) error {
 		}
 	}
 
-	password, err := bcrypt.GenerateFromPassword(b, bcrypt.DefaultCost)
-
-	if err != nil {
-		log.Error("An err occurred when encrypting secret")
-		return err
-	}
-
-	*s = password
+	*s = secret(pswStr)
 
 	return nil
 }
 
 func (bc *balance) UnmarshalJSON(b []byte) error {
 	var log = lgr.NewDefaultLogger()
-	var incomingBalance float64
+	var incomingBalance money.Money
 	if err := json.Unmarshal(b, &incomingBalance); err != nil {
 		log.Errorf("Err %v happened when unmarshal balance", err)
 		return &ErrInvalidAccountField{
 			field:   "balance",
-			message: "the informed balance is not a number",
+			message: "the informed balance is not a number with at most two decimal places",
 		}
 	}
 	if incomingBalance < 0 {
@@ -134,13 +134,13 @@ func Test_secret_UnmarshalJSON(t *testing.T) {
 	}{
 		{
 			name:    "When runs smoothly",
-			s:       nil,
+			s:       "",
 			input:   []byte(`"254855"`),
 			wantErr: false,
 		},
 		{
 			name:    "When input is not of string type",
-			s:       nil,
+			s:       "",
 			input:   []byte("123456"),
 			wantErr: true,
 		},
@@ -173,6 +173,12 @@ func Test_balance_UnmarshalJSON(t *testing.T) {
 			input:   []byte(`-42.42`),
 			wantErr: true,
 		},
+		{
+			name:    "When input has more than two decimal places",
+			b:       0,
+			input:   []byte(`42.421`),
+			wantErr: true,
+		},
 		{
 			name:    "When input is not of numeric type",
 			b:       0,
@@ -10,20 +10,30 @@ import (
 
 type Service interface {
 	AddAccount(ctx context.Context, account Account) (string, error)
-	AddTransfer(ctx context.Context, transfer Transfer) (string, error)
 }
 
 type Repository interface {
 	AddAccount(ctx context.Context, account Account) (string, error)
-	AddTransfer(ctx context.Context, transfer Transfer) (string, error)
+}
+
+// Hasher hashes the passwords of accounts
+type Hasher interface {
+	Hash(password string) (string, error)
 }
 
 type service struct {
 	r   Repository
+	h   Hasher
 	log *logrus.Logger
 }
 
 func (s *service) AddAccount(ctx context.Context, account Account) (string, error) {
+	digest, err := s.h.Hash(string(account.Secret))
+	if err != nil {
+		s.log.Errorf("err %v when hashing secret of account of cpf %s", err, account.CPF)
+		return "", err
+	}
+	account.Secret = secret(digest)
 	s.log.Infof("adding account %v", account)
 	account.CreatedAt = time.Now().UTC()
 	id, err := s.r.AddAccount(ctx, account)
@@ -34,17 +44,6 @@ func (s *service) AddAccount(ctx context.Context, account Account) (string, erro
 	return id, err
 }
 
-func (s *service) AddTransfer(ctx context.Context, transfer Transfer) (string, error) {
-	s.log.Infof("adding transfer %v", transfer)
-	transfer.CreatedAt = time.Now().UTC()
-	id, err := s.r.AddTransfer(ctx, transfer)
-	if err != nil {
-		s.log.Errorf("err %v when adding transfer to repository", err)
-	}
-	s.log.Infof("transfer %v added with success", transfer)
-	return id, err
-}
-
-func NewService(r Repository) Service {
-	return &service{r, lgr.NewDefaultLogger()}
+func NewService(r Repository, h Hasher) Service {
+	return &service{r, h, lgr.NewDefaultLogger()}
 }
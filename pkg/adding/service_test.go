@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -22,8 +23,8 @@ func TestService_AddAccount(t *testing.T) {
 			account: Account{
 				Name:      "Gopher",
 				CPF:       "11111111030",
-				Secret:    []byte("g0rul&zz"),
-				Balance:   8000.00,
+				Secret:    "g0rul&zz",
+				Balance:   balance(money.FromCents(800000)),
 				CreatedAt: time.Time{},
 			},
 		},
@@ -32,8 +33,8 @@ func TestService_AddAccount(t *testing.T) {
 			account: Account{
 				Name:      "Gopher",
 				CPF:       "11111111030",
-				Secret:    []byte("g0rul&zz"),
-				Balance:   8000.00,
+				Secret:    "g0rul&zz",
+				Balance:   balance(money.FromCents(800000)),
 				CreatedAt: time.Time{},
 			},
 			expectedErr: errors.New("foo"),
@@ -43,7 +44,7 @@ func TestService_AddAccount(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRepo := new(mockStorage)
 			mockRepo.expectedErr = tc.expectedErr
-			s := NewService(mockRepo)
+			s := NewService(mockRepo, mockHasher{})
 			ctx := context.TODO()
 			id, err := s.AddAccount(ctx, tc.account)
 
@@ -58,54 +59,9 @@ func TestService_AddAccount(t *testing.T) {
 			if id != mockRepo.oid.Hex() && tc.expectedErr == nil {
 				t.Errorf("Expected id %s, got %s", id, mockRepo.oid.Hex())
 			}
-		})
-	}
-}
 
-func TestService_AddTransfer(t *testing.T) {
-	tt := []struct {
-		name        string
-		transfer    Transfer
-		expectedErr error
-	}{
-		{
-			name: "When successfully adds",
-			transfer: Transfer{
-				OriginAccountID:      "4f89a4fs9864a",
-				DestinationAccountID: "fas64fa684fa9",
-				Amount:               50.00,
-				CreatedAt:            time.Time{},
-			},
-		},
-		{
-			name: "When an error occurs",
-			transfer: Transfer{
-				OriginAccountID:      "4f89a4fs9864a",
-				DestinationAccountID: "fas64fa684fa9",
-				Amount:               50.00,
-				CreatedAt:            time.Time{},
-			},
-			expectedErr: errors.New("foo"),
-		},
-	}
-	for _, tc := range tt {
-		t.Run(tc.name, func(t *testing.T) {
-			mockRepo := new(mockStorage)
-			mockRepo.expectedErr = tc.expectedErr
-			s := NewService(mockRepo)
-			ctx := context.TODO()
-			id, err := s.AddTransfer(ctx, tc.transfer)
-
-			if err != nil && tc.expectedErr == nil {
-				t.Errorf("Expected nil, got %s", err)
-			}
-
-			if mockRepo.t.CreatedAt == zeroTime && tc.expectedErr == nil {
-				t.Errorf("Expected account with CreatedAt near %s, got %s", time.Now().UTC(), mockRepo.t.CreatedAt)
-			}
-
-			if id != mockRepo.oid.Hex() && tc.expectedErr == nil {
-				t.Errorf("Expected id %s, got %s", id, mockRepo.oid.Hex())
+			if mockRepo.a.Secret != "hashed:"+tc.account.Secret {
+				t.Errorf("Expected account to be added with its secret hashed, got %s", mockRepo.a.Secret)
 			}
 		})
 	}
@@ -113,7 +69,6 @@ func TestService_AddTransfer(t *testing.T) {
 
 type mockStorage struct {
 	a           Account
-	t           Transfer
 	expectedErr error
 	oid         primitive.ObjectID
 }
@@ -123,11 +78,6 @@ func (m *mockStorage) AddAccount(_ context.Context, account Account) (string, er
 	return m.idOrErr()
 }
 
-func (m *mockStorage) AddTransfer(_ context.Context, transfer Transfer) (string, error) {
-	m.t = transfer
-	return m.idOrErr()
-}
-
 func (m *mockStorage) idOrErr() (string, error) {
 	m.oid = primitive.NewObjectID()
 
@@ -137,3 +87,9 @@ func (m *mockStorage) idOrErr() (string, error) {
 
 	return m.oid.Hex(), nil
 }
+
+type mockHasher struct{}
+
+func (m mockHasher) Hash(password string) (string, error) {
+	return "hashed:" + password, nil
+}
@@ -0,0 +1,54 @@
+package authenticating
+
+import (
+	"time"
+)
+
+// ClientCredentialsGrant is the only OAuth2 grant type API clients can get tokens through
+const ClientCredentialsGrant = "client_credentials"
+
+// TokenKind tells tokens signed to accounts apart from tokens signed to API clients
+type TokenKind string
+
+const (
+	// UserToken is signed to an account, its ClientID being the id of the account
+	UserToken TokenKind = "user"
+	// ClientToken is signed to an API client, its ClientID being the id of the client, which has no account
+	ClientToken TokenKind = "client"
+)
+
+// APIClient is a system registered to call the API on its own behalf, as the internal batch systems
+type APIClient struct {
+	ID   string `json:"client_id" bson:"_id"`
+	Name string `json:"name" bson:"name"`
+	// SecretDigest is the bcrypt digest of the client secret, which is never stored in plain text
+	SecretDigest string `json:"-" bson:"secret"`
+	// Secret is only known when the client is registered
+	Secret    string    `json:"client_secret,omitempty" bson:"-"`
+	Scopes    []Scope   `json:"scopes" bson:"scopes"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ClientCredentials is a request of the client_credentials grant of RFC 6749
+type ClientCredentials struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	// Scope is the space separated scopes requested, every scope of the client being granted when it's empty
+	Scope string
+}
+
+// grantedScopes returns the scopes of requested, or every one of allowed when none is, failing when any of
+// requested isn't allowed
+func grantedScopes(allowed []Scope, requested string) ([]Scope, error) {
+	scopes := SplitScopes(requested)
+	if len(scopes) == 0 {
+		return allowed, nil
+	}
+	for _, scope := range scopes {
+		if !containsScope(allowed, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,89 @@
+package authenticating
+
+import "time"
+
+const (
+	// FailureWindow is for how long failed login attempts are remembered after the last of them
+	FailureWindow = time.Minute * 15
+	// LockoutDuration is for how long a CPF or IP is locked out once it fails too many times
+	LockoutDuration = time.Minute * 15
+	// BaseBackoff is the wait after the first failure that causes backoff, each further failure doubling it
+	BaseBackoff = time.Second
+	MaxBackoff  = time.Minute
+)
+
+// lockoutPolicy tells after how many failed attempts a key starts backing off and is locked out, for how long, and
+// which event records it. IPs are more tolerant than CPFs, as many customers may share the same IP
+type lockoutPolicy struct {
+	backoffAfter int
+	lockAfter    int
+	lockFor      time.Duration
+	lockedEvent  SecurityEventType
+}
+
+var cpfPolicy = lockoutPolicy{backoffAfter: 3, lockAfter: 5, lockFor: LockoutDuration, lockedEvent: LoginLocked}
+var ipPolicy = lockoutPolicy{backoffAfter: 10, lockAfter: 20, lockFor: LockoutDuration, lockedEvent: LoginLocked}
+
+// LoginAttempts are the recent failed attempts of a key, which is either the CPF or the IP of logins or the account
+// of transaction PINs or TOTP codes, or the recent password resets requested for a CPF or from an IP
+type LoginAttempts struct {
+	Key           string     `bson:"_id"`
+	Failures      int        `bson:"failures"`
+	LastFailureAt time.Time  `bson:"last_failure_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty"`
+	// ExpiresAt is when the attempts are forgotten, FailureWindow after the last failure or once the lockout ends
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// SecurityEventType identifies what a SecurityEvent records
+type SecurityEventType string
+
+const (
+	LoginLocked      SecurityEventType = "login_locked"
+	LoginUnlocked    SecurityEventType = "login_unlocked"
+	PasswordChanged  SecurityEventType = "password_changed"
+	PasswordWasReset SecurityEventType = "password_reset"
+	PINLocked        SecurityEventType = "pin_locked"
+	PINChanged       SecurityEventType = "pin_changed"
+	PINDisabled      SecurityEventType = "pin_disabled"
+	TOTPLocked       SecurityEventType = "totp_locked"
+
+	// PasswordResetThrottled records that a CPF or IP requested too many password resets
+	PasswordResetThrottled SecurityEventType = "password_reset_throttled"
+)
+
+// SecurityEvent records, for security review, something that happened to the logins of a key
+type SecurityEvent struct {
+	Type SecurityEventType `json:"type" bson:"type"`
+	Key  string            `json:"key" bson:"key"`
+	// IP is the one the login attempt that caused the event came from
+	IP string `json:"ip,omitempty" bson:"ip,omitempty"`
+	// ActorID is the account that caused the event, as the operator that unlocked a CPF
+	ActorID   string    `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Failures  int       `json:"failures,omitempty" bson:"failures,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+func cpfKey(cpf string) string {
+	return "cpf:" + cpf
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// blockedUntil tells until when further attempts of a key with attempts are refused under policy, being zero
+// when they aren't
+func (policy lockoutPolicy) blockedUntil(attempts LoginAttempts) (time.Time, error) {
+	if attempts.LockedUntil != nil {
+		return *attempts.LockedUntil, ErrLoginLocked
+	}
+	if attempts.Failures < policy.backoffAfter {
+		return time.Time{}, nil
+	}
+	backoff := BaseBackoff << uint(attempts.Failures-policy.backoffAfter)
+	if backoff > MaxBackoff || backoff <= 0 {
+		backoff = MaxBackoff
+	}
+	return attempts.LastFailureAt.Add(backoff), ErrLoginBackoff
+}
@@ -3,4 +3,7 @@ package authenticating
 type Login struct {
 	CPF    string `json:"cpf"`
 	Secret string `json:"secret"`
+	// IP is the one the login attempt came from
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
@@ -0,0 +1,96 @@
+package authenticating
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// PasswordResetTTL is for how long a reset token can be used, requesting another one invalidating it earlier
+	PasswordResetTTL  = time.Minute * 30
+	MinPasswordLength = 8
+	// MaxPasswordLength comes from the 72 bytes bcrypt took into account along with the quotes secrets were hashed
+	// with, before Argon2id
+	MaxPasswordLength = 70
+)
+
+// resetCPFPolicy and resetIPPolicy throttle the password resets requested for a CPF and from an IP
+var resetCPFPolicy = lockoutPolicy{backoffAfter: 3, lockAfter: 5, lockFor: LockoutDuration, lockedEvent: PasswordResetThrottled}
+var resetIPPolicy = lockoutPolicy{backoffAfter: 10, lockAfter: 20, lockFor: LockoutDuration, lockedEvent: PasswordResetThrottled}
+
+func resetCPFKey(cpf string) string {
+	return "reset:" + cpfKey(cpf)
+}
+
+func resetIPKey(ip string) string {
+	return "reset:" + ipKey(ip)
+}
+
+// PasswordChange is the change of the password of the account of a session, which must know the current one
+type PasswordChange struct {
+	Secret    string `json:"secret"`
+	NewSecret string `json:"new_secret"`
+	AccountID string `json:"-"`
+	CPF       string `json:"-"`
+	// TokenID is the token of the session asking for the change, the only one that isn't revoked
+	TokenID primitive.ObjectID `json:"-"`
+	// IP is the one the change came from
+	IP string `json:"-"`
+}
+
+// PasswordReset is a one-time token that sets a new password without the current one
+type PasswordReset struct {
+	// Hash is the SHA-256 of the reset token handed to the notifier
+	Hash      string    `bson:"_id"`
+	AccountID string    `bson:"account_id"`
+	CPF       string    `bson:"cpf"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Recipient is the account a notice is about
+type Recipient struct {
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+	CPF       string `json:"cpf"`
+}
+
+// PasswordResetNotice carries a reset token to the owner of an account
+type PasswordResetNotice struct {
+	Recipient
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Hasher hashes the passwords of accounts, still verifying digests of the formats it hashed to before
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Matches tells whether digest was made of password
+	Matches(digest string, password string) bool
+	// NeedsRehash tells whether digest is of a format, or parameters, other than the ones Hash makes
+	NeedsRehash(digest string) bool
+}
+
+// Notifier delivers notices to the owners of accounts, through whichever channel it implements
+type Notifier interface {
+	NotifyPasswordReset(ctx context.Context, notice PasswordResetNotice) error
+}
+
+// validatePassword enforces the password policy on secret, the new password of the account of cpf
+func validatePassword(secret string, cpf string) error {
+	if len(secret) < MinPasswordLength || len(secret) > MaxPasswordLength || (cpf != "" && strings.Contains(secret, cpf)) {
+		return ErrPasswordPolicy
+	}
+	var letter, digit bool
+	for _, c := range secret {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	if !letter || !digit {
+		return ErrPasswordPolicy
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package authenticating
+
+import "testing"
+
+func TestValidatePassword(t *testing.T) {
+	cpf := "11111111030"
+	tt := []struct {
+		name    string
+		secret  string
+		wantErr error
+	}{
+		{name: "When password follows the policy", secret: "brandNew2"},
+		{name: "When password is too short", secret: "short1", wantErr: ErrPasswordPolicy},
+		{name: "When password is too long", secret: "a1234567890123456789012345678901234567890123456789012345678901234567890", wantErr: ErrPasswordPolicy},
+		{name: "When password has no letter", secret: "1234567890", wantErr: ErrPasswordPolicy},
+		{name: "When password has no digit", secret: "brandNewOne", wantErr: ErrPasswordPolicy},
+		{name: "When password contains the cpf", secret: "pw" + cpf, wantErr: ErrPasswordPolicy},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validatePassword(tc.secret, cpf); err != tc.wantErr {
+				t.Errorf("Expected err %v; got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
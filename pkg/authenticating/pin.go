@@ -0,0 +1,63 @@
+package authenticating
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+const (
+	MinPINLength = 4
+	MaxPINLength = 6
+	// PINLockoutDuration is for how long the transaction PIN of an account is locked once it's wrong too many times,
+	// longer than the lockout of logins as PINs are much easier to guess than passwords
+	PINLockoutDuration = time.Hour
+)
+
+// pinPolicy locks transaction PINs on the third wrong attempt in a row, without backing off before that
+var pinPolicy = lockoutPolicy{backoffAfter: 3, lockAfter: 3, lockFor: PINLockoutDuration, lockedEvent: PINLocked}
+
+// TransactionPIN is the PIN an account requires to authorize its transfers, apart from its login password
+type TransactionPIN struct {
+	AccountID string `bson:"_id"`
+	// Digest is the bcrypt digest of the PIN, which is never stored in plain text
+	Digest    string    `bson:"digest"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// PINChange sets or disables the transaction PIN of an account, which must know its login password
+type PINChange struct {
+	Secret string `json:"secret"`
+	// PIN is the new PIN, being ignored when disabling it
+	PIN       string `json:"pin"`
+	AccountID string `json:"-"`
+	CPF       string `json:"-"`
+	// IP is the one the change came from
+	IP string `json:"-"`
+}
+
+// TransferAuthorization holds the credentials an outgoing transfer of its account carries besides its token, whether
+// it is made right away, scheduled or set up as a standing order
+type TransferAuthorization struct {
+	AccountID string
+	Amount    money.Money
+	PIN       string
+	TOTPCode  string
+}
+
+// validatePIN enforces that PINs are made of MinPINLength to MaxPINLength digits
+func validatePIN(pin string) error {
+	if len(pin) < MinPINLength || len(pin) > MaxPINLength {
+		return ErrPINPolicy
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return ErrPINPolicy
+		}
+	}
+	return nil
+}
+
+func pinKey(accountID string) string {
+	return "pin:" + accountID
+}
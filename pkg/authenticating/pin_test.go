@@ -0,0 +1,25 @@
+package authenticating
+
+import "testing"
+
+func TestValidatePIN(t *testing.T) {
+	tt := []struct {
+		name    string
+		pin     string
+		wantErr error
+	}{
+		{name: "When pin has 4 digits", pin: "4821"},
+		{name: "When pin has 6 digits", pin: "482193"},
+		{name: "When pin is too short", pin: "482", wantErr: ErrPINPolicy},
+		{name: "When pin is too long", pin: "4821937", wantErr: ErrPINPolicy},
+		{name: "When pin has a letter", pin: "48a1", wantErr: ErrPINPolicy},
+		{name: "When pin has a full-width digit", pin: "48２1", wantErr: ErrPINPolicy},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validatePIN(tc.pin); err != tc.wantErr {
+				t.Errorf("Expected err %v; got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
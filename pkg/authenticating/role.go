@@ -0,0 +1,90 @@
+package authenticating
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+)
+
+// Role is what an account is in the bank, accounts with no role being Customer ones
+type Role string
+
+const (
+	Customer Role = "customer"
+	Operator Role = "operator"
+	Admin    Role = "admin"
+)
+
+// Scope is a permission granted by a token, carried in its scope claim
+type Scope string
+
+const (
+	// ScopeAccount grants access to the data and operations of the account the token was signed to
+	ScopeAccount Scope = "account"
+	// ScopeAccountsRead grants access to every account and its balance
+	ScopeAccountsRead Scope = "accounts:read"
+	// ScopeLimitsManage grants setting the tier and limits of any account, raising them as well
+	ScopeLimitsManage Scope = "limits:manage"
+	// ScopeReversalsManage grants reversing any transfer, not only received ones
+	ScopeReversalsManage Scope = "reversals:manage"
+	// ScopeLoginsUnlock grants lifting the login lockout of any account
+	ScopeLoginsUnlock Scope = "logins:unlock"
+	// ScopeClientsManage grants registering API clients
+	ScopeClientsManage Scope = "clients:manage"
+	// ScopeTokensIntrospect grants introspecting tokens of others, as API gateways validating them do
+	ScopeTokensIntrospect Scope = "tokens:introspect"
+)
+
+// RoleScopes are the scopes granted to tokens signed to accounts of each role
+var RoleScopes = map[Role][]Scope{
+	Customer: {ScopeAccount},
+	Operator: {ScopeAccount, ScopeAccountsRead, ScopeLoginsUnlock},
+	Admin:    {ScopeAccount, ScopeAccountsRead, ScopeLimitsManage, ScopeReversalsManage, ScopeLoginsUnlock, ScopeClientsManage, ScopeTokensIntrospect},
+}
+
+// ClientScopes are the scopes API clients can be granted, the ones of routes that act on no account of the caller.
+// Clients have no account, so they can never be granted ScopeAccount
+var ClientScopes = []Scope{ScopeAccountsRead, ScopeLimitsManage, ScopeLoginsUnlock, ScopeTokensIntrospect}
+
+// ScopesOf returns the scopes of role, unknown roles being taken as Customer
+func ScopesOf(role Role) []Scope {
+	if scopes, ok := RoleScopes[role]; ok {
+		return scopes
+	}
+	return RoleScopes[Customer]
+}
+
+// HasScope tells whether the token a request was authenticated with, whose scopes are in ctx, grants scope
+func HasScope(ctx context.Context, scope Scope) bool {
+	scopes, _ := ctx.Value(pkg.Scopes).([]Scope)
+	return containsScope(scopes, scope)
+}
+
+func containsScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinScopes returns scopes space separated, as carried by the scope claim and parameter of OAuth2
+func JoinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, " ")
+}
+
+// SplitScopes returns the scopes of the space separated scope
+func SplitScopes(scope string) []Scope {
+	names := strings.Fields(scope)
+	scopes := make([]Scope, len(names))
+	for i, name := range names {
+		scopes[i] = Scope(name)
+	}
+	return scopes
+}
@@ -0,0 +1,34 @@
+package authenticating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+)
+
+func TestScopesOf(t *testing.T) {
+	tt := []struct {
+		name      string
+		role      Role
+		scope     Scope
+		wantGrant bool
+	}{
+		{name: "When customer reads its own account", role: Customer, scope: ScopeAccount, wantGrant: true},
+		{name: "When customer reads every account", role: Customer, scope: ScopeAccountsRead},
+		{name: "When operator reads every account", role: Operator, scope: ScopeAccountsRead, wantGrant: true},
+		{name: "When operator manages limits", role: Operator, scope: ScopeLimitsManage},
+		{name: "When admin manages limits", role: Admin, scope: ScopeLimitsManage, wantGrant: true},
+		{name: "When admin manages reversals", role: Admin, scope: ScopeReversalsManage, wantGrant: true},
+		{name: "When account has no role", scope: ScopeAccount, wantGrant: true},
+		{name: "When account has no role and reads every account", scope: ScopeAccountsRead},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.WithValue(context.TODO(), pkg.Scopes, ScopesOf(tc.role))
+			if got := HasScope(ctx, tc.scope); got != tc.wantGrant {
+				t.Errorf("Expected scope %s to be granted to %q to be %v, got %v", tc.scope, tc.role, tc.wantGrant, got)
+			}
+		})
+	}
+}
@@ -2,10 +2,16 @@ package authenticating
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"fmt"
+	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
@@ -13,53 +19,207 @@ import (
 
 var InvalidLoginErr = errors.New("it seems your login credentials are invalid, verify them and try again")
 var ProtectedRouteErr = errors.New("it seems you don't have or didn't pass valid credentials to this route")
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired, login again")
+var ErrMissingScope = errors.New("your credentials don't grant access to this route")
+var ErrLoginLocked = errors.New("login is locked after too many failed attempts, try again later or ask an operator to unlock it")
+var ErrLoginBackoff = errors.New("too many failed login attempts, wait a moment before trying again")
+var ErrRefreshTokenReused = errors.New("refresh token was already used, every token of its session was revoked, login again")
+var ErrTOTPAlreadyEnrolled = errors.New("two-factor authentication is already enabled for this account")
+var ErrTOTPNotEnrolled = errors.New("two-factor authentication enrollment was not started for this account")
+var ErrInvalidTOTPCode = errors.New("two-factor authentication code is invalid or was already used")
+var ErrTOTPRequired = errors.New("a two-factor authentication code is required for this operation")
+var ErrTOTPLocked = errors.New("two-factor authentication is locked after too many wrong codes, try again later")
+var ErrPasswordResetThrottled = errors.New("too many password resets were requested, try again later")
+var ErrInvalidLoginChallenge = errors.New("login challenge is invalid or expired, login again")
+var ErrWrongPassword = errors.New("current password doesn't match, verify it and try again")
+var ErrPasswordPolicy = errors.New("password must have from 8 to 70 characters, letters and digits among them, and must not contain the cpf")
+var ErrSamePassword = errors.New("new password must differ from the current one")
+var ErrInvalidResetToken = errors.New("password reset token is invalid or expired, request a new one")
+var ErrPINPolicy = errors.New("transaction pin must have from 4 to 6 digits")
+var ErrPINNotSet = errors.New("transaction pin is not enabled for this account")
+var ErrPINRequired = errors.New("the transaction pin is required to authorize transfers of this account")
+var ErrInvalidPIN = errors.New("transaction pin doesn't match, verify it and try again")
+var ErrPINLocked = errors.New("transaction pin is locked after too many wrong attempts, try again later")
+var ErrInvalidClient = errors.New("client authentication failed, verify the client id and secret")
+var ErrUnsupportedGrantType = errors.New("grant type is not supported, only client_credentials is")
+var ErrInvalidScope = errors.New("requested scope is unknown or exceeds the scopes of the client")
+var ErrInvalidClientName = errors.New("client name must not be empty")
+var ErrInactiveToken = errors.New("token is invalid, expired or was revoked")
+var ErrSessionNotFound = errors.New("no active session was found with the given id")
+
+// RefreshTokenTTL is for how long a refresh token can be exchanged, the session ending if it isn't meanwhile
+const RefreshTokenTTL = time.Hour * 24 * 7
 
 type Service interface {
-	Sign(ctx context.Context, login Login, secretDigest string, clientID string) (Token, error)
+	// Sign signs a token to clientID, granting the scopes of role, when login.Secret matches secretDigest. An empty
+	// secretDigest, as of CPFs with no account, never matches. Failed attempts are counted per CPF and per IP, which
+	// back off and then are locked out after too many of them, ErrLoginBackoff and ErrLoginLocked being returned.
+	// Accounts enrolled in TOTP get a token holding only a TOTPChallenge, to be completed through SignTOTP
+	Sign(ctx context.Context, login Login, secretDigest string, clientID string, role Role) (Token, error)
+	// SignTOTP completes the login whose challenge is login.Challenge with a TOTP or recovery code, wrong codes being
+	// counted as failed attempts of its CPF and IP
+	SignTOTP(ctx context.Context, login TOTPLogin) (Token, error)
+	// EnrollTOTP starts, or starts over, the TOTP enrollment of accountID, labeled accountName in authenticator apps
+	EnrollTOTP(ctx context.Context, accountID string, accountName string) (TOTPEnrollment, error)
+	// ConfirmTOTP confirms the enrollment of accountID with its first code, returning its recovery codes, which
+	// are never known again
+	ConfirmTOTP(ctx context.Context, accountID string, code string) ([]string, error)
+	// CheckTOTP requires a fresh TOTP or recovery code from accountID when it's enrolled, ErrTOTPRequired being
+	// returned when code is empty. Wrong codes are counted per account, which is locked out after too many of them,
+	// ErrTOTPLocked being returned
+	CheckTOTP(ctx context.Context, accountID string, code string) error
+	// Unlock forgets the failed login attempts of cpf, lifting its lockout on behalf of operatorID
+	Unlock(ctx context.Context, cpf string, operatorID string) error
+	// LockedUntil tells until when the login of cpf is locked out, being zero when it isn't
+	LockedUntil(ctx context.Context, cpf string) (time.Time, error)
 	Verify(ctx context.Context, tokenDigest string) (Token, error)
+	// Introspect verifies tokenDigest on behalf of others, ErrInactiveToken being returned when it's invalid, expired
+	// or revoked, and any other error only when it couldn't be told
+	Introspect(ctx context.Context, tokenDigest string) (Token, error)
+	// Refresh exchanges refreshToken for a new token and refresh token. A refresh token exchanged before is taken as
+	// stolen, every token of its family being revoked
+	Refresh(ctx context.Context, refreshToken string) (Token, error)
+	// Logout revokes the token id along with every other token of its family
+	Logout(ctx context.Context, id primitive.ObjectID) error
+	// Sessions lists the active sessions of accountID, flagging the one of currentTokenID
+	Sessions(ctx context.Context, accountID string, currentTokenID primitive.ObjectID) ([]Session, error)
+	// RevokeSession revokes every token of the session id of accountID
+	RevokeSession(ctx context.Context, accountID string, id string) error
+	// RevokeSessions revokes every token of accountID, the one of the request included
+	RevokeSessions(ctx context.Context, accountID string) error
+	// PublicKeys returns the keys through which others can verify the tokens signed by the gatekeeper
+	PublicKeys(ctx context.Context) JWKSet
+	// ChangePassword sets change.NewSecret as the password of its account when change.Secret matches secretDigest,
+	// revoking every token of the account but the ones of the session of change.TokenID. Wrong passwords are
+	// counted as failed login attempts
+	ChangePassword(ctx context.Context, change PasswordChange, secretDigest string) error
+	// ThrottlePasswordReset counts a password reset requested for cpf from ip, failing with
+	// ErrPasswordResetThrottled when either of them requested too many lately, whether cpf has an account or not
+	ThrottlePasswordReset(ctx context.Context, cpf string, ip string) error
+	// RequestPasswordReset hands a one-time reset token of recipient to the notifier, invalidating earlier ones
+	RequestPasswordReset(ctx context.Context, recipient Recipient) error
+	// ResetPassword sets newSecret as the password of the account of the reset token, revoking every token of the
+	// account and lifting the lockout of its CPF
+	ResetPassword(ctx context.Context, token string, newSecret string) error
+	// SetPIN enables, or changes, the transaction PIN of the account of change when change.Secret matches
+	// secretDigest, wrong passwords being counted as failed login attempts
+	SetPIN(ctx context.Context, change PINChange, secretDigest string) error
+	// DisablePIN disables the transaction PIN of the account of change when change.Secret matches secretDigest
+	DisablePIN(ctx context.Context, change PINChange, secretDigest string) error
+	// CheckPIN verifies pin against the transaction PIN of accountID, if it has one enabled, locking it once it is
+	// wrong too many times
+	CheckPIN(ctx context.Context, accountID string, pin string) error
+	// AuthorizeTransfer checks the transaction PIN of authorization and, when its amount is above totpThreshold, its
+	// TOTP code, being the check every entry point that moves money out of an account goes through
+	AuthorizeTransfer(ctx context.Context, authorization TransferAuthorization, totpThreshold money.Money) error
+	// RegisterClient registers an API client granted scopes, which must be among ClientScopes, returning it along
+	// with its secret, which is never known again
+	RegisterClient(ctx context.Context, name string, scopes []Scope) (APIClient, error)
+	// SignClient signs a token to the API client of credentials, granting the scopes requested by it
+	SignClient(ctx context.Context, credentials ClientCredentials) (Token, error)
 }
 
 type Repository interface {
 	AddToken(ctx context.Context, token Token) error
 	GetTokenByID(ctx context.Context, id primitive.ObjectID) (Token, error)
+	DeleteToken(ctx context.Context, id primitive.ObjectID) error
+	// ListClientTokens returns the unexpired tokens of clientID
+	ListClientTokens(ctx context.Context, clientID string) ([]Token, error)
+	AddRefreshToken(ctx context.Context, refreshToken RefreshToken) error
+	// UseRefreshToken sets UsedAt of the refresh token hash to now unless it's already set, returning it as it was
+	UseRefreshToken(ctx context.Context, hash string, now time.Time) (RefreshToken, error)
+	// RevokeTokenFamily deletes every token and refresh token of the family
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	GetLoginAttempts(ctx context.Context, key string) (LoginAttempts, error)
+	// AddFailedLogin counts a failed attempt of key at at, keeping its attempts at least until expiresAt, returning them
+	AddFailedLogin(ctx context.Context, key string, at time.Time, expiresAt time.Time) (LoginAttempts, error)
+	// LockLogin locks key out until until, keeping its attempts until then at least
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	AddSecurityEvent(ctx context.Context, event SecurityEvent) error
+	// SetTwoFactor adds or replaces the TOTP enrollment of twoFactor.AccountID
+	SetTwoFactor(ctx context.Context, twoFactor TwoFactor) error
+	GetTwoFactor(ctx context.Context, accountID string) (TwoFactor, error)
+	// UseTOTPStep sets the last used step of accountID to step when it's later than the current one, telling whether it was
+	UseTOTPStep(ctx context.Context, accountID string, step int64) (bool, error)
+	// UseRecoveryCode removes digest from the recovery codes of accountID, telling whether it was still there
+	UseRecoveryCode(ctx context.Context, accountID string, digest string) (bool, error)
+	AddLoginChallenge(ctx context.Context, challenge LoginChallenge) error
+	// GetLoginChallenge returns the unexpired login challenge hash
+	GetLoginChallenge(ctx context.Context, hash string) (LoginChallenge, error)
+	DeleteLoginChallenge(ctx context.Context, hash string) error
+	SetAccountSecret(ctx context.Context, accountID string, secretDigest string) error
+	// RevokeClientTokens deletes every token and refresh token of clientID, but the ones of exceptFamilyID when given
+	RevokeClientTokens(ctx context.Context, clientID string, exceptFamilyID string) error
+	// AddPasswordReset adds reset, deleting every other reset of its account
+	AddPasswordReset(ctx context.Context, reset PasswordReset) error
+	// GetPasswordReset returns the unexpired password reset hash
+	GetPasswordReset(ctx context.Context, hash string) (PasswordReset, error)
+	DeletePasswordReset(ctx context.Context, hash string) error
+	// SetTransactionPIN adds or replaces the transaction PIN of its account
+	SetTransactionPIN(ctx context.Context, pin TransactionPIN) error
+	GetTransactionPIN(ctx context.Context, accountID string) (TransactionPIN, error)
+	DeleteTransactionPIN(ctx context.Context, accountID string) error
+	AddAPIClient(ctx context.Context, client APIClient) error
+	GetAPIClient(ctx context.Context, id string) (APIClient, error)
 }
 
 type Gatekeeper interface {
-	Sign(clientID string) (Token, error)
+	// SignClient signs a token of kind ClientToken to the API client clientID, granting scopes
+	SignClient(clientID string, scopes []Scope) (Token, error)
+	Sign(clientID string, role Role) (Token, error)
 	Verify(tokenDigest string) (Token, error)
+	PublicKeys() []JWK
 }
 
 type service struct {
 	r   Repository
 	g   Gatekeeper
+	n   Notifier
+	h   Hasher
 	log *logrus.Logger
 }
 
-func NewService(repository Repository, gatekeeper Gatekeeper) Service {
+func NewService(repository Repository, gatekeeper Gatekeeper, notifier Notifier, hasher Hasher) Service {
 	return &service{
 		repository,
 		gatekeeper,
+		notifier,
+		hasher,
 		lgr.NewDefaultLogger(),
 	}
 }
 
-func (s *service) Sign(ctx context.Context, login Login, secretDigest string, clientID string) (Token, error) {
+func (s *service) Sign(ctx context.Context, login Login, secretDigest string, clientID string, role Role) (Token, error) {
 	s.log.Infof("Signing token to clientID %s", clientID)
-	secret := []byte(fmt.Sprintf(`"%s"`, login.Secret))
-	if err := bcrypt.CompareHashAndPassword([]byte(secretDigest), secret); err != nil {
-		s.log.Errorf("Err %v occurred when validating login secret", err)
+	now := time.Now().UTC()
+	if err := s.checkAttempts(ctx, loginKeys(login), now); err != nil {
+		return Token{}, err
+	}
+	if !s.h.Matches(secretDigest, login.Secret) {
+		s.log.Errorf("Login secret of clientID %s doesn't match", clientID)
+		if err := s.failAttempt(ctx, loginKeys(login), login.IP, now); err != nil {
+			return Token{}, err
+		}
 		return Token{}, InvalidLoginErr
 	}
+	s.rehash(ctx, clientID, login.Secret, secretDigest)
 
-	token, err := s.g.Sign(clientID)
+	twoFactor, err := s.getTwoFactor(ctx, clientID)
 	if err != nil {
-		s.log.Errorf("Err %v occurred when gatekeeper signs token", err)
-		return Token{}, InvalidLoginErr
+		return Token{}, err
+	}
+	if twoFactor.Confirmed() {
+		// failed attempts are kept until the second step, otherwise guessing codes would never lock the CPF out
+		return s.challenge(ctx, login, clientID, role)
+	}
+	if err = s.resetLoginAttempts(ctx, login.CPF); err != nil {
+		return Token{}, err
 	}
 
-	err = s.r.AddToken(ctx, token)
+	origin := Origin{LoggedInAt: now, UserAgent: login.UserAgent, IP: login.IP}
+	token, err := s.issue(ctx, clientID, role, primitive.NewObjectID().Hex(), origin)
 	if err != nil {
-		s.log.Errorf("Err %v occurred when repo tried to add token", err)
 		return Token{}, err
 	}
 
@@ -67,6 +227,575 @@ func (s *service) Sign(ctx context.Context, login Login, secretDigest string, cl
 	return token, nil
 }
 
+func (s *service) SignTOTP(ctx context.Context, login TOTPLogin) (Token, error) {
+	s.log.Info("Signing token through the second step of a login")
+	now := time.Now().UTC()
+	hash := hashRefreshToken(login.Challenge)
+	challenge, err := s.r.GetLoginChallenge(ctx, hash)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving login challenge", err)
+		if err == storage.ErrNoLoginChallengeWasFound {
+			return Token{}, ErrInvalidLoginChallenge
+		}
+		return Token{}, err
+	}
+	// the attempts are counted as of the IP of the second step, which may differ from the first one's
+	attempt := Login{CPF: challenge.CPF, IP: login.IP}
+	if err = s.checkAttempts(ctx, loginKeys(attempt), now); err != nil {
+		return Token{}, err
+	}
+
+	twoFactor, err := s.getTwoFactor(ctx, challenge.ClientID)
+	if err != nil {
+		return Token{}, err
+	}
+	if !twoFactor.Confirmed() {
+		s.log.Errorf("Account %s of login challenge is no longer enrolled in TOTP", challenge.ClientID)
+		return Token{}, ErrInvalidLoginChallenge
+	}
+	if err = s.verifyTOTP(ctx, twoFactor, login.Code, now); err != nil {
+		if err == ErrInvalidTOTPCode {
+			if failErr := s.failAttempt(ctx, loginKeys(attempt), attempt.IP, now); failErr != nil {
+				return Token{}, failErr
+			}
+		}
+		return Token{}, err
+	}
+
+	// the challenge is deleted only now, so that it can be retried with another code until it expires
+	if err = s.r.DeleteLoginChallenge(ctx, hash); err != nil {
+		s.log.Errorf("Err %v when deleting login challenge of %s", err, challenge.ClientID)
+		if err == storage.ErrNoLoginChallengeWasFound {
+			return Token{}, ErrInvalidLoginChallenge
+		}
+		return Token{}, err
+	}
+	if err = s.resetLoginAttempts(ctx, challenge.CPF); err != nil {
+		return Token{}, err
+	}
+
+	// the session is as of the second step, the one the tokens are handed to
+	origin := Origin{LoggedInAt: now, UserAgent: login.UserAgent, IP: login.IP}
+	token, err := s.issue(ctx, challenge.ClientID, challenge.Role, primitive.NewObjectID().Hex(), origin)
+	if err != nil {
+		return Token{}, err
+	}
+	s.log.Infof("Successfully signed token %v", token)
+	return token, nil
+}
+
+func (s *service) EnrollTOTP(ctx context.Context, accountID string, accountName string) (TOTPEnrollment, error) {
+	s.log.Infof("Enrolling account %s in TOTP", accountID)
+	twoFactor, err := s.getTwoFactor(ctx, accountID)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if twoFactor.Confirmed() {
+		s.log.Errorf("Account %s is already enrolled in TOTP", accountID)
+		return TOTPEnrollment{}, ErrTOTPAlreadyEnrolled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		s.log.Errorf("Err %v occurred when generating TOTP secret", err)
+		return TOTPEnrollment{}, err
+	}
+	if err = s.r.SetTwoFactor(ctx, TwoFactor{AccountID: accountID, Secret: secret}); err != nil {
+		s.log.Errorf("Err %v when adding TOTP enrollment of account %s", err, accountID)
+		return TOTPEnrollment{}, err
+	}
+	return TOTPEnrollment{Secret: secret, URI: totpURI(secret, accountName)}, nil
+}
+
+func (s *service) ConfirmTOTP(ctx context.Context, accountID string, code string) ([]string, error) {
+	s.log.Infof("Confirming TOTP enrollment of account %s", accountID)
+	twoFactor, err := s.getTwoFactor(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Secret == "" {
+		s.log.Errorf("Account %s never started a TOTP enrollment", accountID)
+		return nil, ErrTOTPNotEnrolled
+	}
+	if twoFactor.Confirmed() {
+		s.log.Errorf("Account %s is already enrolled in TOTP", accountID)
+		return nil, ErrTOTPAlreadyEnrolled
+	}
+	now := time.Now().UTC()
+	step, ok := matchTOTP(twoFactor.Secret, code, now)
+	if !ok {
+		s.log.Errorf("Invalid first TOTP code of account %s", accountID)
+		return nil, ErrInvalidTOTPCode
+	}
+
+	codes, digests, err := newRecoveryCodes()
+	if err != nil {
+		s.log.Errorf("Err %v occurred when generating recovery codes", err)
+		return nil, err
+	}
+	twoFactor.ConfirmedAt = &now
+	twoFactor.RecoveryCodes = digests
+	twoFactor.LastUsedStep = step
+	if err = s.r.SetTwoFactor(ctx, twoFactor); err != nil {
+		s.log.Errorf("Err %v when confirming TOTP enrollment of account %s", err, accountID)
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *service) CheckTOTP(ctx context.Context, accountID string, code string) error {
+	s.log.Infof("Checking TOTP of account %s", accountID)
+	twoFactor, err := s.getTwoFactor(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Confirmed() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	keys := map[string]lockoutPolicy{totpKey(accountID): totpPolicy}
+	if err = s.checkAttempts(ctx, keys, now); err != nil {
+		if err == ErrLoginLocked || err == ErrLoginBackoff {
+			return ErrTOTPLocked
+		}
+		return err
+	}
+	if code == "" {
+		s.log.Errorf("No TOTP code was given by account %s", accountID)
+		return ErrTOTPRequired
+	}
+	if err = s.verifyTOTP(ctx, twoFactor, code, now); err != nil {
+		if err == ErrInvalidTOTPCode {
+			if failErr := s.failAttempt(ctx, keys, "", now); failErr != nil {
+				return failErr
+			}
+		}
+		return err
+	}
+	if err = s.r.ResetLoginAttempts(ctx, totpKey(accountID)); err != nil {
+		s.log.Errorf("Err %v when resetting TOTP attempts of account %s", err, accountID)
+		return err
+	}
+	return nil
+}
+
+func (s *service) ChangePassword(ctx context.Context, change PasswordChange, secretDigest string) error {
+	s.log.Infof("Changing password of account %s", change.AccountID)
+	attempt := Login{CPF: change.CPF, Secret: change.Secret, IP: change.IP}
+	if err := s.checkPassword(ctx, attempt, change.AccountID, secretDigest); err != nil {
+		return err
+	}
+	if change.NewSecret == change.Secret {
+		return ErrSamePassword
+	}
+	if err := validatePassword(change.NewSecret, change.CPF); err != nil {
+		s.log.Errorf("New password of account %s doesn't follow the password policy", change.AccountID)
+		return err
+	}
+
+	if err := s.setSecret(ctx, change.AccountID, change.NewSecret); err != nil {
+		return err
+	}
+	var familyID string
+	token, err := s.r.GetTokenByID(ctx, change.TokenID)
+	if err != nil && err != storage.ErrNoTokenWasFound {
+		s.log.Errorf("Err %v when retrieving token %s", err, change.TokenID.Hex())
+		return err
+	}
+	if err == nil {
+		familyID = token.FamilyID
+	}
+	if err = s.r.RevokeClientTokens(ctx, change.AccountID, familyID); err != nil {
+		s.log.Errorf("Err %v when revoking tokens of account %s", err, change.AccountID)
+		return err
+	}
+	event := SecurityEvent{Type: PasswordChanged, Key: cpfKey(change.CPF), IP: change.IP, ActorID: change.AccountID}
+	return s.addSecurityEvent(ctx, event)
+}
+
+func (s *service) ThrottlePasswordReset(ctx context.Context, cpf string, ip string) error {
+	now := time.Now().UTC()
+	// every request counts, as they all notify the owner of the account, and resets keep keys of their own, so that
+	// requesting them doesn't lock the logins of the cpf out
+	keys := map[string]lockoutPolicy{resetCPFKey(cpf): resetCPFPolicy}
+	if ip != "" {
+		keys[resetIPKey(ip)] = resetIPPolicy
+	}
+	if err := s.checkAttempts(ctx, keys, now); err != nil {
+		if err == ErrLoginLocked || err == ErrLoginBackoff {
+			return ErrPasswordResetThrottled
+		}
+		return err
+	}
+	return s.failAttempt(ctx, keys, ip, now)
+}
+
+func (s *service) RequestPasswordReset(ctx context.Context, recipient Recipient) error {
+	s.log.Infof("Requesting password reset of account %s", recipient.AccountID)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		s.log.Errorf("Err %v occurred when generating password reset token", err)
+		return err
+	}
+	notice := PasswordResetNotice{
+		Recipient: recipient,
+		Token:     base64.RawURLEncoding.EncodeToString(secret),
+		ExpiresAt: time.Now().UTC().Add(PasswordResetTTL),
+	}
+	err := s.r.AddPasswordReset(ctx, PasswordReset{
+		Hash:      hashRefreshToken(notice.Token),
+		AccountID: recipient.AccountID,
+		CPF:       recipient.CPF,
+		ExpiresAt: notice.ExpiresAt,
+	})
+	if err != nil {
+		s.log.Errorf("Err %v occurred when repo tried to add password reset", err)
+		return err
+	}
+	if err = s.n.NotifyPasswordReset(ctx, notice); err != nil {
+		s.log.Errorf("Err %v when notifying password reset of account %s", err, recipient.AccountID)
+		return err
+	}
+	return nil
+}
+
+func (s *service) ResetPassword(ctx context.Context, token string, newSecret string) error {
+	s.log.Info("Resetting password")
+	hash := hashRefreshToken(token)
+	reset, err := s.r.GetPasswordReset(ctx, hash)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving password reset", err)
+		if err == storage.ErrNoPasswordResetWasFound {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	// the policy is enforced before the token is used, so that it can be retried with a stronger password
+	if err = validatePassword(newSecret, reset.CPF); err != nil {
+		s.log.Errorf("New password of account %s doesn't follow the password policy", reset.AccountID)
+		return err
+	}
+	if err = s.r.DeletePasswordReset(ctx, hash); err != nil {
+		s.log.Errorf("Err %v when deleting password reset of account %s", err, reset.AccountID)
+		if err == storage.ErrNoPasswordResetWasFound {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	if err = s.setSecret(ctx, reset.AccountID, newSecret); err != nil {
+		return err
+	}
+	if err = s.r.RevokeClientTokens(ctx, reset.AccountID, ""); err != nil {
+		s.log.Errorf("Err %v when revoking tokens of account %s", err, reset.AccountID)
+		return err
+	}
+	if err = s.resetLoginAttempts(ctx, reset.CPF); err != nil {
+		return err
+	}
+	return s.addSecurityEvent(ctx, SecurityEvent{Type: PasswordWasReset, Key: cpfKey(reset.CPF), ActorID: reset.AccountID})
+}
+
+func (s *service) SetPIN(ctx context.Context, change PINChange, secretDigest string) error {
+	s.log.Infof("Setting transaction pin of account %s", change.AccountID)
+	attempt := Login{CPF: change.CPF, Secret: change.Secret, IP: change.IP}
+	if err := s.checkPassword(ctx, attempt, change.AccountID, secretDigest); err != nil {
+		return err
+	}
+	if err := validatePIN(change.PIN); err != nil {
+		s.log.Errorf("Transaction pin of account %s doesn't follow the pin policy", change.AccountID)
+		return err
+	}
+	digest, err := bcrypt.GenerateFromPassword([]byte(change.PIN), bcrypt.DefaultCost)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when hashing transaction pin of account %s", err, change.AccountID)
+		return err
+	}
+	pin := TransactionPIN{AccountID: change.AccountID, Digest: string(digest), UpdatedAt: time.Now().UTC()}
+	if err = s.r.SetTransactionPIN(ctx, pin); err != nil {
+		s.log.Errorf("Err %v when setting transaction pin of account %s", err, change.AccountID)
+		return err
+	}
+	// a new pin starts with no wrong attempts, lifting the lockout of the former one
+	if err = s.r.ResetLoginAttempts(ctx, pinKey(change.AccountID)); err != nil {
+		s.log.Errorf("Err %v when resetting transaction pin attempts of account %s", err, change.AccountID)
+		return err
+	}
+	event := SecurityEvent{Type: PINChanged, Key: pinKey(change.AccountID), IP: change.IP, ActorID: change.AccountID}
+	return s.addSecurityEvent(ctx, event)
+}
+
+func (s *service) DisablePIN(ctx context.Context, change PINChange, secretDigest string) error {
+	s.log.Infof("Disabling transaction pin of account %s", change.AccountID)
+	attempt := Login{CPF: change.CPF, Secret: change.Secret, IP: change.IP}
+	if err := s.checkPassword(ctx, attempt, change.AccountID, secretDigest); err != nil {
+		return err
+	}
+	if err := s.r.DeleteTransactionPIN(ctx, change.AccountID); err != nil {
+		s.log.Errorf("Err %v when deleting transaction pin of account %s", err, change.AccountID)
+		if err == storage.ErrNoTransactionPINWasFound {
+			return ErrPINNotSet
+		}
+		return err
+	}
+	event := SecurityEvent{Type: PINDisabled, Key: pinKey(change.AccountID), IP: change.IP, ActorID: change.AccountID}
+	return s.addSecurityEvent(ctx, event)
+}
+
+func (s *service) AuthorizeTransfer(ctx context.Context, authorization TransferAuthorization, totpThreshold money.Money) error {
+	if err := s.CheckPIN(ctx, authorization.AccountID, authorization.PIN); err != nil {
+		return err
+	}
+	if authorization.Amount <= totpThreshold {
+		return nil
+	}
+	return s.CheckTOTP(ctx, authorization.AccountID, authorization.TOTPCode)
+}
+
+func (s *service) CheckPIN(ctx context.Context, accountID string, pin string) error {
+	s.log.Infof("Checking transaction pin of account %s", accountID)
+	transactionPIN, err := s.r.GetTransactionPIN(ctx, accountID)
+	if err == storage.ErrNoTransactionPINWasFound {
+		return nil
+	}
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving transaction pin of account %s", err, accountID)
+		return err
+	}
+
+	now := time.Now().UTC()
+	keys := map[string]lockoutPolicy{pinKey(accountID): pinPolicy}
+	if err = s.checkAttempts(ctx, keys, now); err != nil {
+		if err == ErrLoginLocked || err == ErrLoginBackoff {
+			return ErrPINLocked
+		}
+		return err
+	}
+	if pin == "" {
+		return ErrPINRequired
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(transactionPIN.Digest), []byte(pin)); err != nil {
+		s.log.Errorf("Err %v occurred when validating transaction pin of account %s", err, accountID)
+		if err = s.failAttempt(ctx, keys, "", now); err != nil {
+			return err
+		}
+		return ErrInvalidPIN
+	}
+	if err = s.r.ResetLoginAttempts(ctx, pinKey(accountID)); err != nil {
+		s.log.Errorf("Err %v when resetting transaction pin attempts of account %s", err, accountID)
+		return err
+	}
+	return nil
+}
+
+// checkPassword verifies the secret of attempt against secretDigest, the password of accountID, counting wrong ones
+// as failed login attempts
+func (s *service) checkPassword(ctx context.Context, attempt Login, accountID string, secretDigest string) error {
+	now := time.Now().UTC()
+	if err := s.checkAttempts(ctx, loginKeys(attempt), now); err != nil {
+		return err
+	}
+	if !s.h.Matches(secretDigest, attempt.Secret) {
+		s.log.Errorf("Password of account %s doesn't match", accountID)
+		if err := s.failAttempt(ctx, loginKeys(attempt), attempt.IP, now); err != nil {
+			return err
+		}
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// rehash replaces secretDigest, just verified to be the one of secret, as the password of accountID when it's of an
+// outdated format. Failing to do so doesn't fail the login, as it's tried again on the next one
+func (s *service) rehash(ctx context.Context, accountID string, secret string, secretDigest string) {
+	if !s.h.NeedsRehash(secretDigest) {
+		return
+	}
+	digest, err := s.h.Hash(secret)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when rehashing password of account %s", err, accountID)
+		return
+	}
+	if err = s.r.SetAccountSecret(ctx, accountID, digest); err != nil {
+		s.log.Errorf("Err %v when setting rehashed password of account %s", err, accountID)
+		return
+	}
+	s.log.Infof("Rehashed password of account %s", accountID)
+}
+
+func (s *service) RegisterClient(ctx context.Context, name string, scopes []Scope) (APIClient, error) {
+	s.log.Infof("Registering API client %s", name)
+	if name == "" {
+		return APIClient{}, ErrInvalidClientName
+	}
+	if len(scopes) == 0 {
+		return APIClient{}, ErrInvalidScope
+	}
+	if _, err := grantedScopes(ClientScopes, JoinScopes(scopes)); err != nil {
+		s.log.Errorf("API client %s was asked scopes %v beyond the ones of clients", name, scopes)
+		return APIClient{}, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		s.log.Errorf("Err %v occurred when generating client secret", err)
+		return APIClient{}, err
+	}
+	client := APIClient{
+		ID:        primitive.NewObjectID().Hex(),
+		Name:      name,
+		Secret:    base64.RawURLEncoding.EncodeToString(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	digest, err := bcrypt.GenerateFromPassword([]byte(client.Secret), bcrypt.DefaultCost)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when hashing client secret", err)
+		return APIClient{}, err
+	}
+	client.SecretDigest = string(digest)
+	if err = s.r.AddAPIClient(ctx, client); err != nil {
+		s.log.Errorf("Err %v occurred when repo tried to add API client", err)
+		return APIClient{}, err
+	}
+	return client, nil
+}
+
+func (s *service) SignClient(ctx context.Context, credentials ClientCredentials) (Token, error) {
+	s.log.Infof("Signing token to API client %s", credentials.ClientID)
+	if credentials.GrantType != ClientCredentialsGrant {
+		return Token{}, ErrUnsupportedGrantType
+	}
+	client, err := s.r.GetAPIClient(ctx, credentials.ClientID)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving API client %s", err, credentials.ClientID)
+		if err == storage.ErrNoAPIClientWasFound {
+			return Token{}, ErrInvalidClient
+		}
+		return Token{}, err
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(client.SecretDigest), []byte(credentials.ClientSecret)); err != nil {
+		s.log.Errorf("Err %v occurred when validating secret of API client %s", err, client.ID)
+		return Token{}, ErrInvalidClient
+	}
+	scopes, err := grantedScopes(client.Scopes, credentials.Scope)
+	if err != nil {
+		s.log.Errorf("API client %s requested scopes %s beyond its own", client.ID, credentials.Scope)
+		return Token{}, err
+	}
+
+	token, err := s.g.SignClient(client.ID, scopes)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when gatekeeper signs client token", err)
+		return Token{}, err
+	}
+	if err = s.r.AddToken(ctx, token); err != nil {
+		s.log.Errorf("Err %v occurred when repo tried to add token", err)
+		return Token{}, err
+	}
+	return token, nil
+}
+
+func (s *service) setSecret(ctx context.Context, accountID string, secret string) error {
+	digest, err := s.h.Hash(secret)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when hashing new password of account %s", err, accountID)
+		return err
+	}
+	if err = s.r.SetAccountSecret(ctx, accountID, digest); err != nil {
+		s.log.Errorf("Err %v when setting password of account %s", err, accountID)
+		return err
+	}
+	return nil
+}
+
+// getTwoFactor returns the TOTP enrollment of accountID, a zero one meaning it never enrolled
+func (s *service) getTwoFactor(ctx context.Context, accountID string) (TwoFactor, error) {
+	twoFactor, err := s.r.GetTwoFactor(ctx, accountID)
+	if err == storage.ErrNoTwoFactorWasFound {
+		return TwoFactor{}, nil
+	}
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving TOTP enrollment of account %s", err, accountID)
+		return TwoFactor{}, err
+	}
+	return twoFactor, nil
+}
+
+// verifyTOTP accepts code once, be it a TOTP code of twoFactor at now or one of its recovery codes
+func (s *service) verifyTOTP(ctx context.Context, twoFactor TwoFactor, code string, now time.Time) error {
+	if isTOTPCode(code) {
+		step, ok := matchTOTP(twoFactor.Secret, code, now)
+		if !ok {
+			s.log.Errorf("Invalid TOTP code of account %s", twoFactor.AccountID)
+			return ErrInvalidTOTPCode
+		}
+		used, err := s.r.UseTOTPStep(ctx, twoFactor.AccountID, step)
+		if err != nil {
+			s.log.Errorf("Err %v when using TOTP step of account %s", err, twoFactor.AccountID)
+			return err
+		}
+		if !used {
+			s.log.Errorf("TOTP code of account %s was already used", twoFactor.AccountID)
+			return ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	recoveryCode := []byte(normalizeRecoveryCode(code))
+	for _, digest := range twoFactor.RecoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(digest), recoveryCode) != nil {
+			continue
+		}
+		used, err := s.r.UseRecoveryCode(ctx, twoFactor.AccountID, digest)
+		if err != nil {
+			s.log.Errorf("Err %v when using recovery code of account %s", err, twoFactor.AccountID)
+			return err
+		}
+		if !used {
+			break
+		}
+		s.log.Warnf("Account %s used a recovery code, %d are left", twoFactor.AccountID, len(twoFactor.RecoveryCodes)-1)
+		return nil
+	}
+	s.log.Errorf("Invalid recovery code of account %s", twoFactor.AccountID)
+	return ErrInvalidTOTPCode
+}
+
+// challenge stores and returns the second step of the login of clientID, which is enrolled in TOTP
+func (s *service) challenge(ctx context.Context, login Login, clientID string, role Role) (Token, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		s.log.Errorf("Err %v occurred when generating login challenge", err)
+		return Token{}, err
+	}
+	challenge := base64.RawURLEncoding.EncodeToString(secret)
+	err := s.r.AddLoginChallenge(ctx, LoginChallenge{
+		Hash:      hashRefreshToken(challenge),
+		ClientID:  clientID,
+		Role:      role,
+		CPF:       login.CPF,
+		IP:        login.IP,
+		ExpiresAt: time.Now().UTC().Add(LoginChallengeTTL),
+	})
+	if err != nil {
+		s.log.Errorf("Err %v occurred when repo tried to add login challenge", err)
+		return Token{}, err
+	}
+	s.log.Infof("Challenged login of %s for a TOTP code", clientID)
+	return Token{TOTPChallenge: challenge}, nil
+}
+
+// resetLoginAttempts forgets the failed attempts of cpf once it logs in. IP attempts aren't reset, as an attacker
+// could then reset them by logging into an account of its own
+func (s *service) resetLoginAttempts(ctx context.Context, cpf string) error {
+	if err := s.r.ResetLoginAttempts(ctx, cpfKey(cpf)); err != nil {
+		s.log.Errorf("Err %v when resetting login attempts of cpf %s", err, cpf)
+		return err
+	}
+	return nil
+}
+
 func (s *service) Verify(ctx context.Context, tokenDigest string) (Token, error) {
 	s.log.Infof("Verifying tokenDigest %v", tokenDigest)
 	token, err := s.g.Verify(tokenDigest)
@@ -83,3 +812,253 @@ func (s *service) Verify(ctx context.Context, tokenDigest string) (Token, error)
 	s.log.Infof("Token %v successfully verified", token)
 	return token, nil
 }
+
+func (s *service) Introspect(ctx context.Context, tokenDigest string) (Token, error) {
+	s.log.Info("Introspecting token")
+	token, err := s.g.Verify(tokenDigest)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when gatekeeper verified introspected token", err)
+		return Token{}, ErrInactiveToken
+	}
+
+	stored, err := s.r.GetTokenByID(ctx, *token.ID)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving introspected token %s from repository", err, token.ID.Hex())
+		if err == storage.ErrNoTokenWasFound {
+			return Token{}, ErrInactiveToken
+		}
+		return Token{}, err
+	}
+	token.FamilyID = stored.FamilyID
+	token.Origin = stored.Origin
+	return token, nil
+}
+
+func (s *service) Sessions(ctx context.Context, accountID string, currentTokenID primitive.ObjectID) ([]Session, error) {
+	s.log.Infof("Listing sessions of account %s", accountID)
+	tokens, err := s.r.ListClientTokens(ctx, accountID)
+	if err != nil {
+		s.log.Errorf("Err %v when listing tokens of account %s", err, accountID)
+		return nil, err
+	}
+	return sessionsOf(tokens, currentTokenID.Hex()), nil
+}
+
+func (s *service) RevokeSession(ctx context.Context, accountID string, id string) error {
+	s.log.Infof("Revoking session %s of account %s", id, accountID)
+	tokens, err := s.r.ListClientTokens(ctx, accountID)
+	if err != nil {
+		s.log.Errorf("Err %v when listing tokens of account %s", err, accountID)
+		return err
+	}
+	// sessions are only looked up among the tokens of the account, so that no one revokes the sessions of others
+	for _, token := range tokens {
+		if sessionID(token) != id {
+			continue
+		}
+		if token.FamilyID == "" {
+			err = s.r.DeleteToken(ctx, *token.ID)
+		} else {
+			err = s.r.RevokeTokenFamily(ctx, token.FamilyID)
+		}
+		if err != nil {
+			s.log.Errorf("Err %v when revoking session %s of account %s", err, id, accountID)
+			return err
+		}
+		return nil
+	}
+	s.log.Errorf("No active session %s was found for account %s", id, accountID)
+	return ErrSessionNotFound
+}
+
+func (s *service) RevokeSessions(ctx context.Context, accountID string) error {
+	s.log.Infof("Revoking every session of account %s", accountID)
+	if err := s.r.RevokeClientTokens(ctx, accountID, ""); err != nil {
+		s.log.Errorf("Err %v when revoking tokens of account %s", err, accountID)
+		return err
+	}
+	return nil
+}
+
+func (s *service) Refresh(ctx context.Context, refreshToken string) (Token, error) {
+	s.log.Info("Refreshing token")
+	now := time.Now().UTC()
+	stored, err := s.r.UseRefreshToken(ctx, hashRefreshToken(refreshToken), now)
+	if err != nil {
+		s.log.Errorf("Err %v when using refresh token", err)
+		if err == storage.ErrNoRefreshTokenWasFound {
+			return Token{}, ErrInvalidRefreshToken
+		}
+		return Token{}, err
+	}
+	if stored.UsedAt != nil {
+		s.log.Warnf("Refresh token of family %s was reused after being used at %s, revoking its family", stored.FamilyID, stored.UsedAt)
+		if err = s.r.RevokeTokenFamily(ctx, stored.FamilyID); err != nil {
+			s.log.Errorf("Err %v when revoking token family %s", err, stored.FamilyID)
+			return Token{}, err
+		}
+		return Token{}, ErrRefreshTokenReused
+	}
+	if !stored.ExpiresAt.After(now) {
+		s.log.Errorf("Refresh token of family %s expired at %s", stored.FamilyID, stored.ExpiresAt)
+		return Token{}, ErrInvalidRefreshToken
+	}
+
+	token, err := s.issue(ctx, stored.ClientID, stored.Role, stored.FamilyID, stored.Origin)
+	if err != nil {
+		return Token{}, err
+	}
+	s.log.Infof("Successfully refreshed token %v", token)
+	return token, nil
+}
+
+func (s *service) Logout(ctx context.Context, id primitive.ObjectID) error {
+	s.log.Infof("Logging out token %s", id.Hex())
+	token, err := s.r.GetTokenByID(ctx, id)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving token %s from repository", err, id.Hex())
+		return err
+	}
+	if err = s.r.DeleteToken(ctx, id); err != nil {
+		s.log.Errorf("Err %v when deleting token %s", err, id.Hex())
+		return err
+	}
+	// tokens issued before refresh tokens existed have no family
+	if token.FamilyID == "" {
+		return nil
+	}
+	if err = s.r.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+		s.log.Errorf("Err %v when revoking token family %s", err, token.FamilyID)
+		return err
+	}
+	return nil
+}
+
+func (s *service) Unlock(ctx context.Context, cpf string, operatorID string) error {
+	s.log.Infof("Unlocking login of cpf %s on behalf of %s", cpf, operatorID)
+	if err := s.resetLoginAttempts(ctx, cpf); err != nil {
+		return err
+	}
+	return s.addSecurityEvent(ctx, SecurityEvent{Type: LoginUnlocked, Key: cpfKey(cpf), ActorID: operatorID})
+}
+
+func (s *service) LockedUntil(ctx context.Context, cpf string) (time.Time, error) {
+	attempts, err := s.r.GetLoginAttempts(ctx, cpfKey(cpf))
+	if err == storage.ErrNoLoginAttemptsWereFound {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving login attempts of cpf %s", err, cpf)
+		return time.Time{}, err
+	}
+	if attempts.LockedUntil == nil || !time.Now().UTC().Before(*attempts.LockedUntil) {
+		return time.Time{}, nil
+	}
+	return *attempts.LockedUntil, nil
+}
+
+// loginKeys returns the keys whose attempts login counts as, along with their policies
+func loginKeys(login Login) map[string]lockoutPolicy {
+	keys := map[string]lockoutPolicy{cpfKey(login.CPF): cpfPolicy}
+	if login.IP != "" {
+		keys[ipKey(login.IP)] = ipPolicy
+	}
+	return keys
+}
+
+// checkAttempts fails when any of keys is backing off or locked out at now under its policy
+func (s *service) checkAttempts(ctx context.Context, keys map[string]lockoutPolicy, now time.Time) error {
+	for key, policy := range keys {
+		attempts, err := s.r.GetLoginAttempts(ctx, key)
+		if err == storage.ErrNoLoginAttemptsWereFound {
+			continue
+		}
+		if err != nil {
+			s.log.Errorf("Err %v when retrieving login attempts of %s", err, key)
+			return err
+		}
+		if until, blockErr := policy.blockedUntil(attempts); now.Before(until) {
+			s.log.Warnf("Refusing login attempt of %s until %s after %d failures", key, until, attempts.Failures)
+			return blockErr
+		}
+	}
+	return nil
+}
+
+// failAttempt counts a failed attempt from ip at now against each of keys, locking them out once they fail too many
+// times under their policy
+func (s *service) failAttempt(ctx context.Context, keys map[string]lockoutPolicy, ip string, now time.Time) error {
+	for key, policy := range keys {
+		attempts, err := s.r.AddFailedLogin(ctx, key, now, now.Add(FailureWindow))
+		if err != nil {
+			s.log.Errorf("Err %v when counting failed login attempt of %s", err, key)
+			return err
+		}
+		if attempts.Failures < policy.lockAfter || (attempts.LockedUntil != nil && attempts.LockedUntil.After(now)) {
+			continue
+		}
+		s.log.Warnf("Locking out %s after %d failed attempts", key, attempts.Failures)
+		if err = s.r.LockLogin(ctx, key, now.Add(policy.lockFor)); err != nil {
+			s.log.Errorf("Err %v when locking out %s", err, key)
+			return err
+		}
+		event := SecurityEvent{Type: policy.lockedEvent, Key: key, IP: ip, Failures: attempts.Failures}
+		if err = s.addSecurityEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) addSecurityEvent(ctx context.Context, event SecurityEvent) error {
+	event.CreatedAt = time.Now().UTC()
+	if err := s.r.AddSecurityEvent(ctx, event); err != nil {
+		s.log.Errorf("Err %v when recording security event %v", err, event)
+		return err
+	}
+	return nil
+}
+
+func (s *service) PublicKeys(_ context.Context) JWKSet {
+	return JWKSet{Keys: s.g.PublicKeys()}
+}
+
+// issue signs a token of familyID, which logged in at origin, to clientID along with a refresh token, storing both
+func (s *service) issue(ctx context.Context, clientID string, role Role, familyID string, origin Origin) (Token, error) {
+	token, err := s.g.Sign(clientID, role)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when gatekeeper signs token", err)
+		return Token{}, InvalidLoginErr
+	}
+	token.FamilyID = familyID
+	token.Origin = origin
+	if err = s.r.AddToken(ctx, token); err != nil {
+		s.log.Errorf("Err %v occurred when repo tried to add token", err)
+		return Token{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		s.log.Errorf("Err %v occurred when generating refresh token", err)
+		return Token{}, err
+	}
+	token.RefreshToken = base64.RawURLEncoding.EncodeToString(secret)
+	err = s.r.AddRefreshToken(ctx, RefreshToken{
+		Hash:      hashRefreshToken(token.RefreshToken),
+		ClientID:  clientID,
+		FamilyID:  familyID,
+		Role:      role,
+		Origin:    origin,
+		ExpiresAt: time.Now().UTC().Add(RefreshTokenTTL),
+	})
+	if err != nil {
+		s.log.Errorf("Err %v occurred when repo tried to add refresh token", err)
+		return Token{}, err
+	}
+	return token, nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
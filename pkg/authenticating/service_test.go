@@ -5,8 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -99,15 +103,174 @@ func TestService_Sign(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			s := NewService(&tc.repository, &tc.gatekeeper)
-			token, err := s.Sign(context.TODO(), tc.args.login, tc.args.secretDigest, tc.args.clientID)
+			s := NewService(&tc.repository, &tc.gatekeeper, &mockNotifier{}, mockHasher{})
+			token, err := s.Sign(context.TODO(), tc.args.login, tc.args.secretDigest, tc.args.clientID, Customer)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("Sign() error = %v, wantErr %v", err, tc.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(tc.gatekeeper.expectedToken, token) && !tc.wantErr {
+			if tc.wantErr {
+				return
+			}
+			if token.ID != tc.gatekeeper.expectedToken.ID || token.Digest != tc.gatekeeper.expectedToken.Digest {
 				t.Errorf("Expected token %v, got %v", tc.gatekeeper.expectedToken, token)
 			}
+			if token.RefreshToken == "" || token.FamilyID == "" {
+				t.Errorf("Expected token %v to have a refresh token and a family", token)
+			}
+			if tc.repository.refreshToken.Hash != hashRefreshToken(token.RefreshToken) || tc.repository.refreshToken.FamilyID != token.FamilyID {
+				t.Errorf("Expected the hash of refresh token %s to be stored, got %v", token.RefreshToken, tc.repository.refreshToken)
+			}
+		})
+	}
+}
+
+func TestService_SignLockout(t *testing.T) {
+	login := Login{CPF: "11111111030", Secret: "65416949", IP: "203.0.113.7"}
+	secretDigestBytes, _ := bcrypt.GenerateFromPassword([]byte(fmt.Sprintf(`"%s"`, login.Secret)), bcrypt.MinCost)
+	secretDigest := string(secretDigestBytes)
+	wrongLogin := login
+	wrongLogin.Secret = "deuruim"
+	past := time.Now().UTC().Add(-time.Hour)
+	future := time.Now().UTC().Add(time.Hour)
+	tt := []struct {
+		name          string
+		login         Login
+		loginAttempts map[string]LoginAttempts
+		wantErr       error
+		wantFailures  int
+		wantLocked    bool
+		wantEvents    int
+	}{
+		{
+			name:         "When a wrong secret is counted as a failure of the cpf",
+			login:        wrongLogin,
+			wantErr:      InvalidLoginErr,
+			wantFailures: 1,
+		},
+		{
+			name:  "When the cpf is backing off after recent failures",
+			login: login,
+			loginAttempts: map[string]LoginAttempts{
+				cpfKey(login.CPF): {Failures: cpfPolicy.backoffAfter, LastFailureAt: time.Now().UTC()},
+			},
+			wantErr:      ErrLoginBackoff,
+			wantFailures: cpfPolicy.backoffAfter,
+		},
+		{
+			name:  "When the backoff of the cpf is over",
+			login: login,
+			loginAttempts: map[string]LoginAttempts{
+				cpfKey(login.CPF): {Failures: cpfPolicy.backoffAfter, LastFailureAt: past},
+			},
+		},
+		{
+			name:  "When the cpf fails once too many times and is locked out",
+			login: wrongLogin,
+			loginAttempts: map[string]LoginAttempts{
+				cpfKey(login.CPF): {Failures: cpfPolicy.lockAfter - 1, LastFailureAt: past},
+			},
+			wantErr:      InvalidLoginErr,
+			wantFailures: cpfPolicy.lockAfter,
+			wantLocked:   true,
+			wantEvents:   1,
+		},
+		{
+			name:  "When the cpf is locked out, even with the right secret",
+			login: login,
+			loginAttempts: map[string]LoginAttempts{
+				cpfKey(login.CPF): {Failures: cpfPolicy.lockAfter, LastFailureAt: past, LockedUntil: &future},
+			},
+			wantErr:      ErrLoginLocked,
+			wantFailures: cpfPolicy.lockAfter,
+			wantLocked:   true,
+		},
+		{
+			name:  "When the ip is locked out, whichever cpf it tries",
+			login: login,
+			loginAttempts: map[string]LoginAttempts{
+				ipKey(login.IP): {Failures: ipPolicy.lockAfter, LastFailureAt: past, LockedUntil: &future},
+			},
+			wantErr: ErrLoginLocked,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{loginAttempts: tc.loginAttempts}
+			gatekeeper := &mockGatekeeper{expectedToken: Token{Digest: "sa1685fd4w1a489f49asf.fasofapogkapog.gasjkgpoaskgpoa"}}
+			s := NewService(repository, gatekeeper, &mockNotifier{}, mockHasher{})
+
+			_, err := s.Sign(context.TODO(), tc.login, secretDigest, "sa1685fd4w1a489f49asf", Customer)
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Errorf("Expected err %v; got %v", tc.wantErr, err)
+			}
+			attempts := repository.loginAttempts[cpfKey(tc.login.CPF)]
+			if attempts.Failures != tc.wantFailures {
+				t.Errorf("Expected %d failures of the cpf; got %d", tc.wantFailures, attempts.Failures)
+			}
+			if locked := attempts.LockedUntil != nil; locked != tc.wantLocked {
+				t.Errorf("Expected cpf lockout to be %v; got %v", tc.wantLocked, locked)
+			}
+			if len(repository.events) != tc.wantEvents {
+				t.Errorf("Expected %d security events to be recorded; got %v", tc.wantEvents, repository.events)
+			}
+			for _, event := range repository.events {
+				if event.Type != LoginLocked || event.Key != cpfKey(tc.login.CPF) || event.IP != tc.login.IP {
+					t.Errorf("Expected a lockout event of the cpf from ip %s; got %v", tc.login.IP, event)
+				}
+			}
+		})
+	}
+}
+
+func TestService_Unlock(t *testing.T) {
+	until := time.Now().UTC().Add(LockoutDuration)
+	repository := &mockRepository{loginAttempts: map[string]LoginAttempts{
+		cpfKey("11111111030"): {Failures: cpfPolicy.lockAfter, LockedUntil: &until},
+	}}
+	s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+
+	if err := s.Unlock(context.TODO(), "11111111030", "5f8f8ccb30a1cd7511c5cb70"); err != nil {
+		t.Fatalf("Expected no err; got %v", err)
+	}
+	if _, ok := repository.loginAttempts[cpfKey("11111111030")]; ok {
+		t.Errorf("Expected login attempts of the cpf to be reset")
+	}
+	if len(repository.events) != 1 || repository.events[0].Type != LoginUnlocked || repository.events[0].ActorID != "5f8f8ccb30a1cd7511c5cb70" {
+		t.Errorf("Expected an unlock event by the operator to be recorded; got %v", repository.events)
+	}
+}
+
+func TestService_LockedUntil(t *testing.T) {
+	until := time.Now().UTC().Add(LockoutDuration)
+	expired := time.Now().UTC().Add(-time.Minute)
+	repository := &mockRepository{loginAttempts: map[string]LoginAttempts{
+		cpfKey("11111111030"): {Failures: cpfPolicy.lockAfter, LockedUntil: &until},
+		cpfKey("22222222202"): {Failures: cpfPolicy.lockAfter, LockedUntil: &expired},
+		cpfKey("33333333303"): {Failures: cpfPolicy.backoffAfter},
+	}}
+	s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+
+	tt := []struct {
+		name string
+		cpf  string
+		want time.Time
+	}{
+		{name: "When the cpf is locked out", cpf: "11111111030", want: until},
+		{name: "When the lockout of the cpf is over", cpf: "22222222202"},
+		{name: "When the cpf is only backing off", cpf: "33333333303"},
+		{name: "When the cpf has no failed attempts", cpf: "44444444404"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.LockedUntil(context.TODO(), tc.cpf)
+			if err != nil {
+				t.Fatalf("Expected no err; got %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("Expected locked until %v; got %v", tc.want, got)
+			}
 		})
 	}
 }
@@ -159,7 +322,7 @@ func TestService_Verify(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			s := NewService(&tc.repository, &tc.gatekeeper)
+			s := NewService(&tc.repository, &tc.gatekeeper, &mockNotifier{}, mockHasher{})
 			token, err := s.Verify(context.TODO(), tc.tokenDigest)
 
 			if err != nil && !tc.wantErr {
@@ -174,12 +337,138 @@ func TestService_Verify(t *testing.T) {
 	}
 }
 
+func TestService_Refresh(t *testing.T) {
+	oid := primitive.NewObjectID()
+	usedAt := time.Now().UTC().Add(-time.Minute)
+	valid := RefreshToken{
+		Hash:      hashRefreshToken("fa98sf4a98sf4a9s8f4a"),
+		ClientID:  "4a89f4a1s98fa",
+		FamilyID:  "5f8f8ccb30a1cd7511c5cb70",
+		Role:      Operator,
+		ExpiresAt: time.Now().UTC().Add(time.Hour),
+	}
+	used := valid
+	used.UsedAt = &usedAt
+	expired := valid
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Hour)
+
+	tt := []struct {
+		name         string
+		refreshToken string
+		stored       RefreshToken
+		wantErr      error
+		wantChangeed string
+	}{
+		{
+			name:         "When refresh token is exchanged for the first time",
+			refreshToken: "fa98sf4a98sf4a9s8f4a",
+			stored:       valid,
+		},
+		{
+			name:         "When refresh token is reused",
+			refreshToken: "fa98sf4a98sf4a9s8f4a",
+			stored:       used,
+			wantErr:      ErrRefreshTokenReused,
+			wantChangeed: valid.FamilyID,
+		},
+		{
+			name:         "When refresh token expired",
+			refreshToken: "fa98sf4a98sf4a9s8f4a",
+			stored:       expired,
+			wantErr:      ErrInvalidRefreshToken,
+		},
+		{
+			name:         "When refresh token is unknown",
+			refreshToken: "a9s8f4a98sf4a98sf4a9",
+			stored:       valid,
+			wantErr:      ErrInvalidRefreshToken,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{refreshToken: tc.stored}
+			gatekeeper := &mockGatekeeper{expectedToken: Token{ID: &oid, ClientID: tc.stored.ClientID, Digest: "a9ifa09sfamfk90asf.fafajrqr9qkf0mas09f.fqj09fj0ajf0a"}}
+			s := NewService(repository, gatekeeper, &mockNotifier{}, mockHasher{})
+			token, err := s.Refresh(context.TODO(), tc.refreshToken)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("Refresh() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if repository.revoked != tc.wantChangeed {
+				t.Errorf("Expected family %q to be revoked, got %q", tc.wantChangeed, repository.revoked)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if token.FamilyID != tc.stored.FamilyID || token.RefreshToken == "" || token.RefreshToken == tc.refreshToken {
+				t.Errorf("Expected a new refresh token of family %s, got %v", tc.stored.FamilyID, token)
+			}
+			if repository.refreshToken.Hash != hashRefreshToken(token.RefreshToken) || repository.refreshToken.Role != Operator {
+				t.Errorf("Expected the new refresh token to be stored, got %v", repository.refreshToken)
+			}
+			if gatekeeper.signedRole != Operator {
+				t.Errorf("Expected token to be signed with the role of its family, got %q", gatekeeper.signedRole)
+			}
+		})
+	}
+}
+
+func TestService_Logout(t *testing.T) {
+	oid := primitive.NewObjectID()
+	tt := []struct {
+		name         string
+		repository   mockRepository
+		wantErr      error
+		wantChangeed string
+	}{
+		{
+			name:         "When token has a family",
+			repository:   mockRepository{expectedToken: Token{ID: &oid, FamilyID: "5f8f8ccb30a1cd7511c5cb70"}},
+			wantChangeed: "5f8f8ccb30a1cd7511c5cb70",
+		},
+		{
+			name:       "When token was issued without a family",
+			repository: mockRepository{expectedToken: Token{ID: &oid}},
+		},
+		{
+			name:       "When token doesn't exist",
+			repository: mockRepository{expectedErr: storage.ErrNoTokenWasFound},
+			wantErr:    storage.ErrNoTokenWasFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(&tc.repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+			err := s.Logout(context.TODO(), oid)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("Logout() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && (tc.repository.deleted == nil || *tc.repository.deleted != oid) {
+				t.Errorf("Expected token %s to be deleted, got %v", oid.Hex(), tc.repository.deleted)
+			}
+			if tc.repository.revoked != tc.wantChangeed {
+				t.Errorf("Expected family %q to be revoked, got %q", tc.wantChangeed, tc.repository.revoked)
+			}
+		})
+	}
+}
+
 type mockGatekeeper struct {
 	expectedToken Token
+	expectedKeys  []JWK
 	expectedErr   error
+	signedRole    Role
+	signedScopes  []Scope
 }
 
-func (m *mockGatekeeper) Sign(_ string) (Token, error) {
+func (m *mockGatekeeper) SignClient(clientID string, scopes []Scope) (Token, error) {
+	m.signedScopes = scopes
+	return Token{ClientID: clientID, Kind: ClientToken, Scopes: scopes}, m.expectedErr
+}
+
+func (m *mockGatekeeper) Sign(_ string, role Role) (Token, error) {
+	m.signedRole = role
 	return m.expectedToken, m.expectedErr
 }
 
@@ -187,15 +476,945 @@ func (m *mockGatekeeper) Verify(_ string) (Token, error) {
 	return m.expectedToken, m.expectedErr
 }
 
+func (m *mockGatekeeper) PublicKeys() []JWK {
+	return m.expectedKeys
+}
+
 type mockRepository struct {
 	expectedToken Token
 	expectedErr   error
+	refreshToken  RefreshToken
+	deleted       *primitive.ObjectID
+	revoked       string
+	loginAttempts map[string]LoginAttempts
+	events        []SecurityEvent
+	twoFactor     *TwoFactor
+	challenges    map[string]LoginChallenge
+	secretDigest  string
+	// revokedClient and revokedExcept are the account and the family kept when tokens were last revoked
+	revokedClient string
+	revokedExcept string
+	resets        map[string]PasswordReset
+	pin           *TransactionPIN
+	clients       map[string]APIClient
+	tokens        []Token
 }
 
-func (m *mockRepository) AddToken(_ context.Context, _ Token) error {
+func (m *mockRepository) AddToken(_ context.Context, token Token) error {
+	m.tokens = append(m.tokens, token)
 	return m.expectedErr
 }
 
 func (m *mockRepository) GetTokenByID(_ context.Context, _ primitive.ObjectID) (Token, error) {
 	return m.expectedToken, m.expectedErr
 }
+
+func (m *mockRepository) DeleteToken(_ context.Context, id primitive.ObjectID) error {
+	m.deleted = &id
+	return m.expectedErr
+}
+
+func (m *mockRepository) ListClientTokens(_ context.Context, clientID string) ([]Token, error) {
+	var tokens []Token
+	for _, token := range m.tokens {
+		if token.ClientID == clientID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, m.expectedErr
+}
+
+func (m *mockRepository) AddRefreshToken(_ context.Context, refreshToken RefreshToken) error {
+	m.refreshToken = refreshToken
+	return m.expectedErr
+}
+
+func (m *mockRepository) UseRefreshToken(_ context.Context, hash string, now time.Time) (RefreshToken, error) {
+	if m.expectedErr != nil {
+		return RefreshToken{}, m.expectedErr
+	}
+	if m.refreshToken.Hash != hash {
+		return RefreshToken{}, storage.ErrNoRefreshTokenWasFound
+	}
+	used := m.refreshToken
+	if m.refreshToken.UsedAt == nil {
+		m.refreshToken.UsedAt = &now
+	}
+	return used, nil
+}
+
+func (m *mockRepository) RevokeTokenFamily(_ context.Context, familyID string) error {
+	m.revoked = familyID
+	return m.expectedErr
+}
+
+func (m *mockRepository) GetLoginAttempts(_ context.Context, key string) (LoginAttempts, error) {
+	attempts, ok := m.loginAttempts[key]
+	if !ok {
+		return LoginAttempts{}, storage.ErrNoLoginAttemptsWereFound
+	}
+	return attempts, nil
+}
+
+func (m *mockRepository) AddFailedLogin(_ context.Context, key string, at time.Time, expiresAt time.Time) (LoginAttempts, error) {
+	if m.loginAttempts == nil {
+		m.loginAttempts = make(map[string]LoginAttempts)
+	}
+	attempts := m.loginAttempts[key]
+	attempts.Key = key
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	attempts.ExpiresAt = expiresAt
+	m.loginAttempts[key] = attempts
+	return attempts, nil
+}
+
+func (m *mockRepository) LockLogin(_ context.Context, key string, until time.Time) error {
+	attempts := m.loginAttempts[key]
+	attempts.LockedUntil = &until
+	m.loginAttempts[key] = attempts
+	return nil
+}
+
+func (m *mockRepository) ResetLoginAttempts(_ context.Context, key string) error {
+	delete(m.loginAttempts, key)
+	return nil
+}
+
+func (m *mockRepository) AddSecurityEvent(_ context.Context, event SecurityEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockRepository) SetTwoFactor(_ context.Context, twoFactor TwoFactor) error {
+	m.twoFactor = &twoFactor
+	return nil
+}
+
+func (m *mockRepository) GetTwoFactor(_ context.Context, _ string) (TwoFactor, error) {
+	if m.twoFactor == nil {
+		return TwoFactor{}, storage.ErrNoTwoFactorWasFound
+	}
+	return *m.twoFactor, nil
+}
+
+func (m *mockRepository) UseTOTPStep(_ context.Context, _ string, step int64) (bool, error) {
+	if step <= m.twoFactor.LastUsedStep {
+		return false, nil
+	}
+	m.twoFactor.LastUsedStep = step
+	return true, nil
+}
+
+func (m *mockRepository) UseRecoveryCode(_ context.Context, _ string, digest string) (bool, error) {
+	for i, recoveryCode := range m.twoFactor.RecoveryCodes {
+		if recoveryCode == digest {
+			m.twoFactor.RecoveryCodes = append(m.twoFactor.RecoveryCodes[:i:i], m.twoFactor.RecoveryCodes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepository) AddLoginChallenge(_ context.Context, challenge LoginChallenge) error {
+	if m.challenges == nil {
+		m.challenges = make(map[string]LoginChallenge)
+	}
+	m.challenges[challenge.Hash] = challenge
+	return nil
+}
+
+func (m *mockRepository) GetLoginChallenge(_ context.Context, hash string) (LoginChallenge, error) {
+	challenge, ok := m.challenges[hash]
+	if !ok {
+		return LoginChallenge{}, storage.ErrNoLoginChallengeWasFound
+	}
+	return challenge, nil
+}
+
+func (m *mockRepository) DeleteLoginChallenge(_ context.Context, hash string) error {
+	if _, ok := m.challenges[hash]; !ok {
+		return storage.ErrNoLoginChallengeWasFound
+	}
+	delete(m.challenges, hash)
+	return nil
+}
+
+func (m *mockRepository) SetAccountSecret(_ context.Context, _ string, secretDigest string) error {
+	m.secretDigest = secretDigest
+	return nil
+}
+
+func (m *mockRepository) RevokeClientTokens(_ context.Context, clientID string, exceptFamilyID string) error {
+	m.revokedClient = clientID
+	m.revokedExcept = exceptFamilyID
+	return nil
+}
+
+func (m *mockRepository) AddPasswordReset(_ context.Context, reset PasswordReset) error {
+	m.resets = map[string]PasswordReset{reset.Hash: reset}
+	return nil
+}
+
+func (m *mockRepository) GetPasswordReset(_ context.Context, hash string) (PasswordReset, error) {
+	reset, ok := m.resets[hash]
+	if !ok {
+		return PasswordReset{}, storage.ErrNoPasswordResetWasFound
+	}
+	return reset, nil
+}
+
+func (m *mockRepository) DeletePasswordReset(_ context.Context, hash string) error {
+	if _, ok := m.resets[hash]; !ok {
+		return storage.ErrNoPasswordResetWasFound
+	}
+	delete(m.resets, hash)
+	return nil
+}
+
+func (m *mockRepository) SetTransactionPIN(_ context.Context, pin TransactionPIN) error {
+	m.pin = &pin
+	return nil
+}
+
+func (m *mockRepository) GetTransactionPIN(_ context.Context, _ string) (TransactionPIN, error) {
+	if m.pin == nil {
+		return TransactionPIN{}, storage.ErrNoTransactionPINWasFound
+	}
+	return *m.pin, nil
+}
+
+func (m *mockRepository) DeleteTransactionPIN(_ context.Context, _ string) error {
+	if m.pin == nil {
+		return storage.ErrNoTransactionPINWasFound
+	}
+	m.pin = nil
+	return nil
+}
+
+func (m *mockRepository) AddAPIClient(_ context.Context, client APIClient) error {
+	if m.clients == nil {
+		m.clients = make(map[string]APIClient)
+	}
+	m.clients[client.ID] = client
+	return nil
+}
+
+func (m *mockRepository) GetAPIClient(_ context.Context, id string) (APIClient, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return APIClient{}, storage.ErrNoAPIClientWasFound
+	}
+	return client, nil
+}
+
+// mockHasher hashes as accounts were hashed before Argon2id, with bcrypt and along with the JSON quotes of the
+// secret, digests of costs other than bcrypt.MinCost needing a rehash
+type mockHasher struct{}
+
+func (m mockHasher) Hash(password string) (string, error) {
+	digest, err := bcrypt.GenerateFromPassword(quotedSecret(password), bcrypt.MinCost)
+	return string(digest), err
+}
+
+func (m mockHasher) Matches(digest string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(digest), quotedSecret(password)) == nil
+}
+
+func (m mockHasher) NeedsRehash(digest string) bool {
+	cost, err := bcrypt.Cost([]byte(digest))
+	return err == nil && cost != bcrypt.MinCost
+}
+
+func quotedSecret(secret string) []byte {
+	return []byte(fmt.Sprintf(`"%s"`, secret))
+}
+
+type mockNotifier struct {
+	notices []PasswordResetNotice
+	err     error
+}
+
+func (m *mockNotifier) NotifyPasswordReset(_ context.Context, notice PasswordResetNotice) error {
+	m.notices = append(m.notices, notice)
+	return m.err
+}
+
+// currentTOTPCode returns the code of secret at the given offset, in steps, from the current one
+func currentTOTPCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("Could not decode TOTP secret %s: %v", secret, err)
+	}
+	return totpCode(key, totpStepAt(time.Now().UTC())+offset)
+}
+
+func TestService_TOTP(t *testing.T) {
+	login := Login{CPF: "11111111030", Secret: "65416949", IP: "203.0.113.7"}
+	secretDigest, _ := bcrypt.GenerateFromPassword([]byte(fmt.Sprintf(`"%s"`, login.Secret)), bcrypt.MinCost)
+	clientID := "sa1685fd4w1a489f49asf"
+	oid := primitive.NewObjectID()
+	repository := &mockRepository{}
+	gatekeeper := &mockGatekeeper{expectedToken: Token{ID: &oid, Digest: "sa1685fd4w1a489f49asf.fasofapogkapog.gasjkgpoaskgpoa"}}
+	s := NewService(repository, gatekeeper, &mockNotifier{}, mockHasher{})
+	ctx := context.TODO()
+
+	if err := s.CheckTOTP(ctx, clientID, ""); err != nil {
+		t.Fatalf("Expected no code to be required before enrollment; got %v", err)
+	}
+	if _, err := s.ConfirmTOTP(ctx, clientID, "123456"); err != ErrTOTPNotEnrolled {
+		t.Fatalf("Expected err %v confirming before enrollment; got %v", ErrTOTPNotEnrolled, err)
+	}
+
+	enrollment, err := s.EnrollTOTP(ctx, clientID, login.CPF)
+	if err != nil {
+		t.Fatalf("EnrollTOTP() err = %v", err)
+	}
+	if enrollment.Secret == "" || enrollment.URI != totpURI(enrollment.Secret, login.CPF) {
+		t.Fatalf("Expected enrollment to have a secret and its uri; got %v", enrollment)
+	}
+	if token, err := s.Sign(ctx, login, string(secretDigest), clientID, Customer); err != nil || token.Digest == "" {
+		t.Fatalf("Expected unconfirmed enrollments not to challenge logins; got %v, %v", token, err)
+	}
+
+	if _, err = s.ConfirmTOTP(ctx, clientID, currentTOTPCode(t, enrollment.Secret, 5)); err != ErrInvalidTOTPCode {
+		t.Fatalf("Expected err %v confirming with a wrong code; got %v", ErrInvalidTOTPCode, err)
+	}
+	recoveryCodes, err := s.ConfirmTOTP(ctx, clientID, currentTOTPCode(t, enrollment.Secret, -1))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() err = %v", err)
+	}
+	if len(recoveryCodes) != RecoveryCodesCount {
+		t.Fatalf("Expected %d recovery codes; got %v", RecoveryCodesCount, recoveryCodes)
+	}
+	if _, err = s.EnrollTOTP(ctx, clientID, login.CPF); err != ErrTOTPAlreadyEnrolled {
+		t.Fatalf("Expected err %v enrolling again; got %v", ErrTOTPAlreadyEnrolled, err)
+	}
+
+	challenged, err := s.Sign(ctx, login, string(secretDigest), clientID, Customer)
+	if err != nil {
+		t.Fatalf("Sign() err = %v", err)
+	}
+	if challenged.TOTPChallenge == "" || challenged.Digest != "" || challenged.RefreshToken != "" {
+		t.Fatalf("Expected only a TOTP challenge once enrolled; got %v", challenged)
+	}
+
+	tt := []struct {
+		name      string
+		challenge string
+		code      string
+		wantErr   error
+	}{
+		{name: "When challenge is unknown", challenge: "foo", code: currentTOTPCode(t, enrollment.Secret, 0), wantErr: ErrInvalidLoginChallenge},
+		{name: "When code was already used to confirm the enrollment", challenge: challenged.TOTPChallenge, code: currentTOTPCode(t, enrollment.Secret, -1), wantErr: ErrInvalidTOTPCode},
+		{name: "When recovery code is wrong", challenge: challenged.TOTPChallenge, code: "aaaaa-aaaaa", wantErr: ErrInvalidTOTPCode},
+		{name: "When code is fresh", challenge: challenged.TOTPChallenge, code: currentTOTPCode(t, enrollment.Secret, 0)},
+		{name: "When challenge was already completed", challenge: challenged.TOTPChallenge, code: currentTOTPCode(t, enrollment.Secret, 1), wantErr: ErrInvalidLoginChallenge},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := s.SignTOTP(ctx, TOTPLogin{Challenge: tc.challenge, Code: tc.code, IP: login.IP})
+			if err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+			if tc.wantErr == nil && (token.Digest == "" || token.RefreshToken == "") {
+				t.Errorf("Expected token and refresh token; got %v", token)
+			}
+		})
+	}
+	if attempts, ok := repository.loginAttempts[cpfKey(login.CPF)]; ok {
+		t.Errorf("Expected failed attempts of the cpf to be reset once logged in; got %v", attempts)
+	}
+	if attempts := repository.loginAttempts[ipKey(login.IP)]; attempts.Failures != 2 {
+		t.Errorf("Expected wrong codes to be counted as failures of the ip; got %v", attempts)
+	}
+
+	if err = s.CheckTOTP(ctx, clientID, ""); err != ErrTOTPRequired {
+		t.Errorf("Expected err %v without a code; got %v", ErrTOTPRequired, err)
+	}
+	if err = s.CheckTOTP(ctx, clientID, strings.ToUpper(recoveryCodes[0])); err != nil {
+		t.Errorf("Expected recovery code to be accepted; got %v", err)
+	}
+	if err = s.CheckTOTP(ctx, clientID, recoveryCodes[0]); err != ErrInvalidTOTPCode {
+		t.Errorf("Expected err %v reusing a recovery code; got %v", ErrInvalidTOTPCode, err)
+	}
+	if left := len(repository.twoFactor.RecoveryCodes); left != RecoveryCodesCount-1 {
+		t.Errorf("Expected %d recovery codes to be left; got %d", RecoveryCodesCount-1, left)
+	}
+}
+
+func TestService_CheckTOTPLockout(t *testing.T) {
+	accountID := "5f8b1c2d3e4f5a6b7c8d9e0f"
+	repository := &mockRepository{}
+	s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+	ctx := context.TODO()
+
+	enrollment, err := s.EnrollTOTP(ctx, accountID, "11111111030")
+	if err != nil {
+		t.Fatalf("EnrollTOTP() err = %v", err)
+	}
+	recoveryCodes, err := s.ConfirmTOTP(ctx, accountID, currentTOTPCode(t, enrollment.Secret, -1))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() err = %v", err)
+	}
+
+	tt := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{name: "When code is wrong for the first time", code: "000000", wantErr: ErrInvalidTOTPCode},
+		{name: "When a right code resets the failures", code: recoveryCodes[0]},
+		{name: "When code is wrong for the first time again", code: "000000", wantErr: ErrInvalidTOTPCode},
+		{name: "When code is wrong for the second time", code: "000001", wantErr: ErrInvalidTOTPCode},
+		{name: "When code is wrong for the third time", code: "aaaaa-aaaaa", wantErr: ErrInvalidTOTPCode},
+		{name: "When code is wrong for the fourth time", code: "000002", wantErr: ErrInvalidTOTPCode},
+		{name: "When code is wrong for the fifth time", code: "000003", wantErr: ErrInvalidTOTPCode},
+		{name: "When codes are locked even if right", code: recoveryCodes[1], wantErr: ErrTOTPLocked},
+		{name: "When codes are locked even if missing", code: "", wantErr: ErrTOTPLocked},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.CheckTOTP(ctx, accountID, tc.code); err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+		})
+	}
+	if attempts := repository.loginAttempts[totpKey(accountID)]; attempts.LockedUntil == nil || attempts.Failures != 5 {
+		t.Errorf("Expected the codes of the account to be locked after 5 failures; got %v", attempts)
+	}
+	if last := repository.events[len(repository.events)-1]; last.Type != TOTPLocked || last.Key != totpKey(accountID) {
+		t.Errorf("Expected the TOTP lockout to be recorded; got %v", last)
+	}
+	if left := len(repository.twoFactor.RecoveryCodes); left != RecoveryCodesCount-1 {
+		t.Errorf("Expected no recovery code to be used while locked; got %d left", left)
+	}
+}
+
+func TestService_ThrottlePasswordReset(t *testing.T) {
+	repository := &mockRepository{}
+	s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+	ctx := context.TODO()
+
+	tt := []struct {
+		name    string
+		cpf     string
+		wantErr error
+	}{
+		{name: "When the first reset is requested", cpf: "11111111030"},
+		{name: "When the second reset is requested", cpf: "11111111030"},
+		{name: "When the third reset is requested", cpf: "11111111030"},
+		{name: "When resets of the cpf back off", cpf: "11111111030", wantErr: ErrPasswordResetThrottled},
+		{name: "When another cpf requests from the same ip", cpf: "22222222051"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.ThrottlePasswordReset(ctx, tc.cpf, "203.0.113.7"); err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+		})
+	}
+	if attempts := repository.loginAttempts[resetIPKey("203.0.113.7")]; attempts.Failures != 4 {
+		t.Errorf("Expected 4 resets counted for the ip; got %v", attempts)
+	}
+	if _, ok := repository.loginAttempts[cpfKey("11111111030")]; ok {
+		t.Error("Expected resets not to count towards the logins of the cpf")
+	}
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	secretDigest, _ := bcrypt.GenerateFromPassword([]byte(`"current1"`), bcrypt.MinCost)
+	familyID := "sessionFamily"
+	change := PasswordChange{
+		Secret:    "current1",
+		NewSecret: "brandNew2",
+		AccountID: "5f8b1c2d3e4f5a6b7c8d9e0f",
+		CPF:       "11111111030",
+		TokenID:   primitive.NewObjectID(),
+		IP:        "203.0.113.7",
+	}
+	tt := []struct {
+		name       string
+		change     func(c PasswordChange) PasswordChange
+		wantErr    error
+		wantChange bool
+	}{
+		{
+			name:    "When current password is wrong",
+			change:  func(c PasswordChange) PasswordChange { c.Secret = "current2"; return c },
+			wantErr: ErrWrongPassword,
+		},
+		{
+			name:    "When new password is the current one",
+			change:  func(c PasswordChange) PasswordChange { c.NewSecret = c.Secret; return c },
+			wantErr: ErrSamePassword,
+		},
+		{
+			name:    "When new password has no digit",
+			change:  func(c PasswordChange) PasswordChange { c.NewSecret = "brandNewOne"; return c },
+			wantErr: ErrPasswordPolicy,
+		},
+		{
+			name:    "When new password contains the cpf",
+			change:  func(c PasswordChange) PasswordChange { c.NewSecret = "a" + c.CPF; return c },
+			wantErr: ErrPasswordPolicy,
+		},
+		{
+			name:       "When password is changed",
+			change:     func(c PasswordChange) PasswordChange { return c },
+			wantChange: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{expectedToken: Token{FamilyID: familyID}}
+			s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+			c := tc.change(change)
+
+			if err := s.ChangePassword(context.TODO(), c, string(secretDigest)); err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+			if !tc.wantChange {
+				if repository.secretDigest != "" || repository.revokedClient != "" {
+					t.Errorf("Expected password and tokens to be kept; got digest %s and revoked %s", repository.secretDigest, repository.revokedClient)
+				}
+				return
+			}
+			if err := bcrypt.CompareHashAndPassword([]byte(repository.secretDigest), quotedSecret(c.NewSecret)); err != nil {
+				t.Errorf("Expected new password to be stored hashed; got err %v", err)
+			}
+			if repository.revokedClient != c.AccountID || repository.revokedExcept != familyID {
+				t.Errorf("Expected tokens of %s but family %s to be revoked; got %s but %s", c.AccountID, familyID, repository.revokedClient, repository.revokedExcept)
+			}
+			if len(repository.events) != 1 || repository.events[0].Type != PasswordChanged {
+				t.Errorf("Expected a %s security event; got %v", PasswordChanged, repository.events)
+			}
+		})
+	}
+
+	t.Run("When wrong passwords are counted towards the lockout", func(t *testing.T) {
+		repository := &mockRepository{}
+		s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+		wrong := change
+		wrong.Secret = "current2"
+		_ = s.ChangePassword(context.TODO(), wrong, string(secretDigest))
+		if attempts := repository.loginAttempts[cpfKey(change.CPF)]; attempts.Failures != 1 {
+			t.Errorf("Expected a failure of the cpf; got %v", attempts)
+		}
+	})
+}
+
+func TestService_ResetPassword(t *testing.T) {
+	recipient := Recipient{AccountID: "5f8b1c2d3e4f5a6b7c8d9e0f", Name: "Alice", CPF: "11111111030"}
+	repository := &mockRepository{loginAttempts: map[string]LoginAttempts{cpfKey(recipient.CPF): {Failures: 5}}}
+	notifier := &mockNotifier{}
+	s := NewService(repository, &mockGatekeeper{}, notifier, mockHasher{})
+	ctx := context.TODO()
+
+	if err := s.RequestPasswordReset(ctx, recipient); err != nil {
+		t.Fatalf("RequestPasswordReset() err = %v", err)
+	}
+	if len(notifier.notices) != 1 || notifier.notices[0].Recipient != recipient || notifier.notices[0].Token == "" {
+		t.Fatalf("Expected a notice with a token to %v; got %v", recipient, notifier.notices)
+	}
+	token := notifier.notices[0].Token
+	if _, ok := repository.resets[token]; ok {
+		t.Fatal("Expected reset token not to be stored in plain text")
+	}
+
+	tt := []struct {
+		name      string
+		token     string
+		newSecret string
+		wantErr   error
+	}{
+		{name: "When token is unknown", token: "foo", newSecret: "brandNew2", wantErr: ErrInvalidResetToken},
+		{name: "When new password is too short", token: token, newSecret: "short1", wantErr: ErrPasswordPolicy},
+		{name: "When password is reset", token: token, newSecret: "brandNew2"},
+		{name: "When token was already used", token: token, newSecret: "brandNew3", wantErr: ErrInvalidResetToken},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.ResetPassword(ctx, tc.token, tc.newSecret); err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(repository.secretDigest), quotedSecret("brandNew2")); err != nil {
+		t.Errorf("Expected new password to be stored hashed; got err %v", err)
+	}
+	if repository.revokedClient != recipient.AccountID || repository.revokedExcept != "" {
+		t.Errorf("Expected every token of %s to be revoked; got %s but %s", recipient.AccountID, repository.revokedClient, repository.revokedExcept)
+	}
+	if attempts, ok := repository.loginAttempts[cpfKey(recipient.CPF)]; ok {
+		t.Errorf("Expected failed attempts of the cpf to be reset; got %v", attempts)
+	}
+	if len(repository.events) != 1 || repository.events[0].Type != PasswordWasReset {
+		t.Errorf("Expected a %s security event; got %v", PasswordWasReset, repository.events)
+	}
+}
+
+func TestService_PIN(t *testing.T) {
+	secretDigest, _ := bcrypt.GenerateFromPassword([]byte(`"current1"`), bcrypt.MinCost)
+	accountID := "5f8b1c2d3e4f5a6b7c8d9e0f"
+	change := PINChange{Secret: "current1", PIN: "4821", AccountID: accountID, CPF: "11111111030", IP: "203.0.113.7"}
+	repository := &mockRepository{}
+	s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+	ctx := context.TODO()
+
+	if err := s.CheckPIN(ctx, accountID, ""); err != nil {
+		t.Fatalf("Expected no pin to be required before it is enabled; got %v", err)
+	}
+	if err := s.DisablePIN(ctx, change, string(secretDigest)); err != ErrPINNotSet {
+		t.Fatalf("Expected err %v disabling before it is enabled; got %v", ErrPINNotSet, err)
+	}
+
+	wrongPassword := change
+	wrongPassword.Secret = "current2"
+	if err := s.SetPIN(ctx, wrongPassword, string(secretDigest)); err != ErrWrongPassword {
+		t.Fatalf("Expected err %v with a wrong password; got %v", ErrWrongPassword, err)
+	}
+	for _, pin := range []string{"123", "1234567", "12a4"} {
+		weak := change
+		weak.PIN = pin
+		if err := s.SetPIN(ctx, weak, string(secretDigest)); err != ErrPINPolicy {
+			t.Fatalf("Expected err %v for pin %s; got %v", ErrPINPolicy, pin, err)
+		}
+	}
+	if err := s.SetPIN(ctx, change, string(secretDigest)); err != nil {
+		t.Fatalf("SetPIN() err = %v", err)
+	}
+	if repository.pin.Digest == change.PIN || bcrypt.CompareHashAndPassword([]byte(repository.pin.Digest), []byte(change.PIN)) != nil {
+		t.Fatalf("Expected pin to be stored hashed; got %s", repository.pin.Digest)
+	}
+
+	tt := []struct {
+		name    string
+		pin     string
+		wantErr error
+	}{
+		{name: "When pin is missing", wantErr: ErrPINRequired},
+		{name: "When pin is wrong", pin: "0000", wantErr: ErrInvalidPIN},
+		{name: "When pin is right, resetting the wrong attempts", pin: change.PIN},
+		{name: "When pin is wrong for the first time", pin: "0000", wantErr: ErrInvalidPIN},
+		{name: "When pin is wrong for the second time", pin: "0001", wantErr: ErrInvalidPIN},
+		{name: "When pin is wrong for the third time", pin: "0002", wantErr: ErrInvalidPIN},
+		{name: "When pin is locked", pin: change.PIN, wantErr: ErrPINLocked},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.CheckPIN(ctx, accountID, tc.pin); err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+		})
+	}
+	if attempts, ok := repository.loginAttempts[cpfKey(change.CPF)]; !ok || attempts.Failures != 1 {
+		t.Errorf("Expected only the wrong password to count as a failed login of the cpf; got %v", attempts)
+	}
+	if last := repository.events[len(repository.events)-1]; last.Type != PINLocked || last.Key != pinKey(accountID) {
+		t.Errorf("Expected the pin lockout to be recorded; got %v", last)
+	}
+
+	if err := s.SetPIN(ctx, change, string(secretDigest)); err != nil {
+		t.Fatalf("SetPIN() err = %v", err)
+	}
+	if err := s.CheckPIN(ctx, accountID, change.PIN); err != nil {
+		t.Errorf("Expected a new pin to lift the lockout; got %v", err)
+	}
+	if err := s.DisablePIN(ctx, change, string(secretDigest)); err != nil {
+		t.Fatalf("DisablePIN() err = %v", err)
+	}
+	if err := s.CheckPIN(ctx, accountID, ""); err != nil {
+		t.Errorf("Expected no pin to be required once disabled; got %v", err)
+	}
+}
+
+func TestService_AuthorizeTransfer(t *testing.T) {
+	secretDigest, _ := bcrypt.GenerateFromPassword([]byte(`"current1"`), bcrypt.MinCost)
+	accountID := "5f8b1c2d3e4f5a6b7c8d9e0f"
+	repository := &mockRepository{}
+	s := NewService(repository, &mockGatekeeper{}, &mockNotifier{}, mockHasher{})
+	ctx := context.TODO()
+	threshold := money.FromCents(100000)
+
+	if err := s.AuthorizeTransfer(ctx, TransferAuthorization{AccountID: accountID, Amount: money.FromCents(150000)}, threshold); err != nil {
+		t.Fatalf("Expected nothing to be required before pin and TOTP are enabled; got %v", err)
+	}
+	change := PINChange{Secret: "current1", PIN: "4821", AccountID: accountID, CPF: "11111111030"}
+	if err := s.SetPIN(ctx, change, string(secretDigest)); err != nil {
+		t.Fatalf("SetPIN() err = %v", err)
+	}
+	enrollment, err := s.EnrollTOTP(ctx, accountID, change.CPF)
+	if err != nil {
+		t.Fatalf("EnrollTOTP() err = %v", err)
+	}
+	recoveryCodes, err := s.ConfirmTOTP(ctx, accountID, currentTOTPCode(t, enrollment.Secret, -1))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() err = %v", err)
+	}
+
+	tt := []struct {
+		name          string
+		authorization TransferAuthorization
+		wantErr       error
+	}{
+		{
+			name:          "When pin is missing",
+			authorization: TransferAuthorization{AccountID: accountID, Amount: money.FromCents(1000)},
+			wantErr:       ErrPINRequired,
+		},
+		{
+			name:          "When pin is wrong, even along with a TOTP code",
+			authorization: TransferAuthorization{AccountID: accountID, Amount: money.FromCents(150000), PIN: "0000", TOTPCode: recoveryCodes[0]},
+			wantErr:       ErrInvalidPIN,
+		},
+		{
+			name:          "When amount is up to the threshold and pin is right",
+			authorization: TransferAuthorization{AccountID: accountID, Amount: threshold, PIN: change.PIN},
+		},
+		{
+			name:          "When amount is above the threshold and TOTP code is missing",
+			authorization: TransferAuthorization{AccountID: accountID, Amount: money.FromCents(150000), PIN: change.PIN},
+			wantErr:       ErrTOTPRequired,
+		},
+		{
+			name:          "When amount is above the threshold and TOTP code is wrong",
+			authorization: TransferAuthorization{AccountID: accountID, Amount: money.FromCents(150000), PIN: change.PIN, TOTPCode: "aaaaa-aaaaa"},
+			wantErr:       ErrInvalidTOTPCode,
+		},
+		{
+			name:          "When amount is above the threshold and both are right",
+			authorization: TransferAuthorization{AccountID: accountID, Amount: money.FromCents(150000), PIN: change.PIN, TOTPCode: recoveryCodes[1]},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.AuthorizeTransfer(ctx, tc.authorization, threshold); err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestService_Client(t *testing.T) {
+	repository := &mockRepository{}
+	gatekeeper := &mockGatekeeper{}
+	s := NewService(repository, gatekeeper, &mockNotifier{}, mockHasher{})
+	ctx := context.TODO()
+
+	if _, err := s.RegisterClient(ctx, "", []Scope{ScopeAccountsRead}); err != ErrInvalidClientName {
+		t.Fatalf("Expected err %v without a name; got %v", ErrInvalidClientName, err)
+	}
+	for _, scopes := range [][]Scope{nil, {ScopeAccount}, {ScopeAccountsRead, ScopeReversalsManage}} {
+		if _, err := s.RegisterClient(ctx, "batch", scopes); err != ErrInvalidScope {
+			t.Fatalf("Expected err %v for scopes %v; got %v", ErrInvalidScope, scopes, err)
+		}
+	}
+	client, err := s.RegisterClient(ctx, "batch", []Scope{ScopeAccountsRead, ScopeLimitsManage})
+	if err != nil {
+		t.Fatalf("RegisterClient() err = %v", err)
+	}
+	stored := repository.clients[client.ID]
+	if client.Secret == "" || stored.SecretDigest == client.Secret ||
+		bcrypt.CompareHashAndPassword([]byte(stored.SecretDigest), []byte(client.Secret)) != nil {
+		t.Fatalf("Expected secret to be answered and stored hashed; got %s and %s", client.Secret, stored.SecretDigest)
+	}
+
+	credentials := ClientCredentials{GrantType: ClientCredentialsGrant, ClientID: client.ID, ClientSecret: client.Secret}
+	tt := []struct {
+		name       string
+		mutate     func(c *ClientCredentials)
+		wantScopes []Scope
+		wantErr    error
+	}{
+		{
+			name:       "When no scope is requested, granting every scope of the client",
+			mutate:     func(c *ClientCredentials) {},
+			wantScopes: []Scope{ScopeAccountsRead, ScopeLimitsManage},
+		},
+		{
+			name:       "When a scope of the client is requested",
+			mutate:     func(c *ClientCredentials) { c.Scope = "accounts:read" },
+			wantScopes: []Scope{ScopeAccountsRead},
+		},
+		{
+			name:    "When a scope beyond the client is requested",
+			mutate:  func(c *ClientCredentials) { c.Scope = "accounts:read logins:unlock" },
+			wantErr: ErrInvalidScope,
+		},
+		{
+			name:    "When grant type is not client_credentials",
+			mutate:  func(c *ClientCredentials) { c.GrantType = "password" },
+			wantErr: ErrUnsupportedGrantType,
+		},
+		{
+			name:    "When secret is wrong",
+			mutate:  func(c *ClientCredentials) { c.ClientSecret = "wrong" },
+			wantErr: ErrInvalidClient,
+		},
+		{
+			name:    "When client is unknown",
+			mutate:  func(c *ClientCredentials) { c.ClientID = primitive.NewObjectID().Hex() },
+			wantErr: ErrInvalidClient,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := credentials
+			tc.mutate(&c)
+			token, err := s.SignClient(ctx, c)
+			if err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !token.IsClient() || token.ClientID != client.ID || !reflect.DeepEqual(token.Scopes, tc.wantScopes) {
+				t.Errorf("Expected client token of %s with scopes %v; got %+v", client.ID, tc.wantScopes, token)
+			}
+		})
+	}
+}
+
+func TestService_Sessions(t *testing.T) {
+	login := Login{CPF: "11111111030", Secret: "65416949", IP: "203.0.113.7", UserAgent: "transfer-app/1.0"}
+	secretDigest, _ := bcrypt.GenerateFromPassword(quotedSecret(login.Secret), bcrypt.MinCost)
+	accountID := "5f8b1c2d3e4f5a6b7c8d9e0f"
+	oid := primitive.NewObjectID()
+	repository := &mockRepository{}
+	gatekeeper := &mockGatekeeper{expectedToken: Token{ID: &oid, ClientID: accountID, Digest: "sa1685fd4w1a489f49asf.fasofapogkapog.gasjkgpoaskgpoa"}}
+	s := NewService(repository, gatekeeper, &mockNotifier{}, mockHasher{})
+	ctx := context.TODO()
+
+	signed, err := s.Sign(ctx, login, string(secretDigest), accountID, Customer)
+	if err != nil {
+		t.Fatalf("Sign() err = %v", err)
+	}
+	origin := repository.tokens[0].Origin
+	if origin.UserAgent != login.UserAgent || origin.IP != login.IP || origin.LoggedInAt.IsZero() {
+		t.Fatalf("Expected login to be recorded as the origin of its session; got %+v", origin)
+	}
+	if _, err = s.Refresh(ctx, signed.RefreshToken); err != nil {
+		t.Fatalf("Refresh() err = %v", err)
+	}
+	if refreshed := repository.tokens[1]; refreshed.Origin != origin || refreshed.FamilyID != repository.tokens[0].FamilyID {
+		t.Fatalf("Expected refreshed token to keep the origin of its session; got %+v", refreshed.Origin)
+	}
+
+	now := time.Now().UTC()
+	first, second, legacy, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	repository.tokens = []Token{
+		{ID: &first, ClientID: accountID, FamilyID: "family", Origin: origin, ExpiresAt: now.Add(time.Minute)},
+		{ID: &second, ClientID: accountID, FamilyID: "family", Origin: origin, ExpiresAt: now.Add(time.Minute * 20)},
+		{ID: &legacy, ClientID: accountID, IssuedAt: origin.LoggedInAt.Add(time.Second), ExpiresAt: now.Add(time.Minute * 10)},
+		{ID: &other, ClientID: "5f8f8ccb30a1cd7511c5cb70", FamilyID: "others", Origin: origin, ExpiresAt: now.Add(time.Minute)},
+	}
+
+	sessions, err := s.Sessions(ctx, accountID, first)
+	if err != nil {
+		t.Fatalf("Sessions() err = %v", err)
+	}
+	want := []Session{
+		{ID: legacy.Hex(), IssuedAt: origin.LoggedInAt.Add(time.Second), ExpiresAt: now.Add(time.Minute * 10)},
+		{ID: "family", IssuedAt: origin.LoggedInAt, UserAgent: login.UserAgent, IP: login.IP, ExpiresAt: now.Add(time.Minute * 20), Current: true},
+	}
+	if !reflect.DeepEqual(sessions, want) {
+		t.Fatalf("Expected sessions %+v; got %+v", want, sessions)
+	}
+
+	if err = s.RevokeSession(ctx, accountID, "others"); err != ErrSessionNotFound {
+		t.Errorf("Expected err %v revoking a session of another account; got %v", ErrSessionNotFound, err)
+	}
+	if err = s.RevokeSession(ctx, accountID, "family"); err != nil || repository.revoked != "family" {
+		t.Errorf("Expected family of the session to be revoked; got %s, %v", repository.revoked, err)
+	}
+	if err = s.RevokeSession(ctx, accountID, legacy.Hex()); err != nil || repository.deleted == nil || *repository.deleted != legacy {
+		t.Errorf("Expected token of the session without family to be deleted; got %v, %v", repository.deleted, err)
+	}
+	if err = s.RevokeSessions(ctx, accountID); err != nil || repository.revokedClient != accountID || repository.revokedExcept != "" {
+		t.Errorf("Expected every token of the account to be revoked; got %s but %s, %v", repository.revokedClient, repository.revokedExcept, err)
+	}
+}
+
+func TestService_Introspect(t *testing.T) {
+	oid := primitive.NewObjectID()
+	origin := Origin{LoggedInAt: time.Now().UTC(), IP: "203.0.113.7"}
+	tt := []struct {
+		name       string
+		repository mockRepository
+		gatekeeper mockGatekeeper
+		want       Token
+		wantErr    error
+	}{
+		{
+			name:       "When token is active",
+			repository: mockRepository{expectedToken: Token{ID: &oid, FamilyID: "family", Origin: origin}},
+			gatekeeper: mockGatekeeper{expectedToken: Token{ID: &oid, ClientID: "4sfa9684fsa698"}},
+			want:       Token{ID: &oid, ClientID: "4sfa9684fsa698", FamilyID: "family", Origin: origin},
+		},
+		{
+			name:       "When token is invalid or expired",
+			gatekeeper: mockGatekeeper{expectedErr: errors.New("jwt: exp claim is invalid")},
+			wantErr:    ErrInactiveToken,
+		},
+		{
+			name:       "When token was revoked",
+			repository: mockRepository{expectedErr: storage.ErrNoTokenWasFound},
+			gatekeeper: mockGatekeeper{expectedToken: Token{ID: &oid}},
+			wantErr:    ErrInactiveToken,
+		},
+		{
+			name:       "When fails to retrieve the token",
+			repository: mockRepository{expectedErr: errors.New("foo")},
+			gatekeeper: mockGatekeeper{expectedToken: Token{ID: &oid}},
+			wantErr:    errors.New("foo"),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(&tc.repository, &tc.gatekeeper, &mockNotifier{}, mockHasher{})
+			got, err := s.Introspect(context.TODO(), "sa1685fd4w1a489f49asf.fasofapogkapog.gasjkgpoaskgpoa")
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected token %+v; got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestService_SignRehash(t *testing.T) {
+	login := Login{CPF: "11111111030", Secret: "65416949"}
+	outdated, _ := bcrypt.GenerateFromPassword(quotedSecret(login.Secret), bcrypt.DefaultCost)
+	current, _ := mockHasher{}.Hash(login.Secret)
+	oid := primitive.NewObjectID()
+
+	tt := []struct {
+		name         string
+		secretDigest string
+		wantRehash   bool
+	}{
+		{name: "When digest is of an outdated format", secretDigest: string(outdated), wantRehash: true},
+		{name: "When digest is of the current format", secretDigest: current},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{}
+			gatekeeper := &mockGatekeeper{expectedToken: Token{ID: &oid, Digest: "sa1685fd4w1a489f49asf.fasofapogkapog.gasjkgpoaskgpoa"}}
+			s := NewService(repository, gatekeeper, &mockNotifier{}, mockHasher{})
+
+			if _, err := s.Sign(context.TODO(), login, tc.secretDigest, "sa1685fd4w1a489f49asf", Customer); err != nil {
+				t.Fatalf("Sign() err = %v", err)
+			}
+			if rehashed := repository.secretDigest != ""; rehashed != tc.wantRehash {
+				t.Fatalf("Expected rehash to be %v; got digest %q", tc.wantRehash, repository.secretDigest)
+			}
+			if tc.wantRehash && (mockHasher{}.NeedsRehash(repository.secretDigest) || !(mockHasher{}).Matches(repository.secretDigest, login.Secret)) {
+				t.Errorf("Expected password to be rehashed to the current format; got %s", repository.secretDigest)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package authenticating
+
+import (
+	"sort"
+	"time"
+)
+
+// Origin is when and from where a session logged in, carried by every token and refresh token of it
+type Origin struct {
+	LoggedInAt time.Time `bson:"logged_in_at"`
+	UserAgent  string    `bson:"user_agent,omitempty"`
+	IP         string    `bson:"ip,omitempty"`
+}
+
+// Session is a login along with the refreshes that followed it, being revoked as a whole
+type Session struct {
+	// ID is the family of the tokens of the session, or the id of the token for tokens signed before families existed
+	ID        string    `json:"id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	// ExpiresAt is when the latest token of the session expires, the session still being refreshable afterwards
+	ExpiresAt time.Time `json:"expires_at"`
+	// Current tells whether the session is the one of the request listing it
+	Current bool `json:"current"`
+}
+
+// sessionID returns the id of the session of token
+func sessionID(token Token) string {
+	if token.FamilyID == "" {
+		return token.ID.Hex()
+	}
+	return token.FamilyID
+}
+
+// sessionsOf groups tokens into their sessions, latest logins first, flagging the one of currentTokenID
+func sessionsOf(tokens []Token, currentTokenID string) []Session {
+	byID := make(map[string]*Session)
+	for _, token := range tokens {
+		id := sessionID(token)
+		session, ok := byID[id]
+		if !ok {
+			session = &Session{
+				ID:        id,
+				IssuedAt:  token.Origin.LoggedInAt,
+				UserAgent: token.Origin.UserAgent,
+				IP:        token.Origin.IP,
+			}
+			// tokens signed before origins were recorded only know when they were issued themselves
+			if session.IssuedAt.IsZero() {
+				session.IssuedAt = token.IssuedAt
+			}
+			byID[id] = session
+		}
+		if token.ExpiresAt.After(session.ExpiresAt) {
+			session.ExpiresAt = token.ExpiresAt
+		}
+		session.Current = session.Current || token.ID.Hex() == currentTokenID
+	}
+
+	sessions := make([]Session, 0, len(byID))
+	for _, session := range byID {
+		sessions = append(sessions, *session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].IssuedAt.Equal(sessions[j].IssuedAt) {
+			return sessions[i].ID > sessions[j].ID
+		}
+		return sessions[i].IssuedAt.After(sessions[j].IssuedAt)
+	})
+	return sessions
+}
@@ -1,9 +1,61 @@
 package authenticating
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IsClient tells whether the token was signed to an API client instead of an account
+func (t Token) IsClient() bool {
+	return t.Kind == ClientToken
+}
+
+// HasScope tells whether token grants scope
+func (t Token) HasScope(scope Scope) bool {
+	return containsScope(t.Scopes, scope)
+}
 
 type Token struct {
 	ID       *primitive.ObjectID `json:"id,omitempty" bson:"_id"`
 	ClientID string              `json:"client_id,omitempty" bson:"client_id"`
 	Digest   string              `json:"token,omitempty" bson:"token"`
+	Role     Role                `json:"-" bson:"role,omitempty"`
+	Scopes   []Scope             `json:"-" bson:"-"`
+	Kind     TokenKind           `json:"-" bson:"kind,omitempty"`
+	// FamilyID is shared by the tokens issued from a login and from every refresh that follows it
+	FamilyID  string    `json:"-" bson:"family_id,omitempty"`
+	ExpiresAt time.Time `json:"-" bson:"expires_at"`
+	IssuedAt  time.Time `json:"-" bson:"issued_at"`
+	// Origin is the one of the login of the session of the token
+	Origin Origin `json:"-" bson:"origin"`
+	// RefreshToken is only known when the token is issued, being stored as a hash
+	RefreshToken string `json:"refresh_token,omitempty" bson:"-"`
+	// TOTPChallenge is set instead of every other field when the login still needs a TOTP code, through SignTOTP
+	TOTPChallenge string `json:"totp_challenge,omitempty" bson:"-"`
+}
+
+// String leaves out the access token, the refresh token and the TOTP challenge, since whoever reads them, as from
+// the logs, can take over the session
+func (t Token) String() string {
+	id := ""
+	if t.ID != nil {
+		id = t.ID.Hex()
+	}
+	return fmt.Sprintf("{ID:%s ClientID:%s Kind:%s Role:%s FamilyID:%s ExpiresAt:%s}", id, t.ClientID, t.Kind, t.Role, t.FamilyID, t.ExpiresAt)
+}
+
+// RefreshToken can be exchanged, only once, for a new Token and RefreshToken of its family
+type RefreshToken struct {
+	// Hash is the SHA-256 of the refresh token handed to the client
+	Hash     string `bson:"_id"`
+	ClientID string `bson:"client_id"`
+	FamilyID string `bson:"family_id"`
+	// Role is the one of the account when the family was created, the tokens it refreshes to keeping it
+	Role      Role      `bson:"role,omitempty"`
+	Origin    Origin    `bson:"origin"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	// UsedAt is set once the refresh token is exchanged, its later use being a reuse
+	UsedAt *time.Time `bson:"used_at,omitempty"`
 }
@@ -0,0 +1,31 @@
+package authenticating
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestToken_String(t *testing.T) {
+	id := primitive.NewObjectID()
+	token := Token{
+		ID:            &id,
+		ClientID:      "5f8f8ccb30a1cd7511c5cb70",
+		Digest:        "eyJhbGciOiJIUzI1NiJ9.access.signature",
+		FamilyID:      "family",
+		RefreshToken:  "refresh-secret",
+		TOTPChallenge: "challenge-secret",
+	}
+
+	got := fmt.Sprintf("%v", token)
+	for _, secret := range []string{token.Digest, token.RefreshToken, token.TOTPChallenge} {
+		if strings.Contains(got, secret) {
+			t.Errorf("Expected %q to be left out of %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, id.Hex()) || !strings.Contains(got, token.FamilyID) {
+		t.Errorf("Expected token id and family to be kept in %s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package authenticating
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// TOTPIssuer names the bank in the authenticator apps of the users
+	TOTPIssuer = "transfer-api"
+	// TOTPPeriod is for how long each RFC 6238 code is valid, codes of the periods right before and after the
+	// current one being accepted as well, as clocks drift
+	TOTPPeriod = time.Second * 30
+	totpDigits = 6
+	totpSkew   = 1
+	// RecoveryCodesCount is how many single-use recovery codes are handed once an enrollment is confirmed
+	RecoveryCodesCount = 10
+	// LoginChallengeTTL is for how long the second step of a login can be taken after its first one
+	LoginChallengeTTL = time.Minute * 5
+	// TOTPLockoutDuration is for how long CheckTOTP refuses the codes of an account once they're wrong too many times
+	TOTPLockoutDuration = time.Hour
+)
+
+// totpPolicy locks the codes CheckTOTP is given on the fifth wrong one in a row, as with three valid codes at a time
+// a million guesses would otherwise be a matter of hours
+var totpPolicy = lockoutPolicy{backoffAfter: 5, lockAfter: 5, lockFor: TOTPLockoutDuration, lockedEvent: TOTPLocked}
+
+// DefaultTOTPTransferThreshold is the amount above which transfers of accounts enrolled in TOTP need a code
+var DefaultTOTPTransferThreshold = money.FromCents(100000)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactor is the TOTP enrollment of an account, only required once confirmed with a first code
+type TwoFactor struct {
+	AccountID string `bson:"_id"`
+	// Secret is the base32 shared secret, which must be known to check codes and so can't be hashed
+	Secret      string     `bson:"secret"`
+	ConfirmedAt *time.Time `bson:"confirmed_at,omitempty"`
+	// RecoveryCodes are the bcrypt digests of the recovery codes left, each of them being removed once used
+	RecoveryCodes []string `bson:"recovery_codes,omitempty"`
+	// LastUsedStep is the time step of the last code accepted, no code of it or of an earlier one being accepted again
+	LastUsedStep int64 `bson:"last_used_step"`
+}
+
+// Confirmed tells whether codes are required from the account
+func (t TwoFactor) Confirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+// TOTPEnrollment is what authenticator apps need to generate the codes of an account
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// LoginChallenge is the pending second step of the login of an account enrolled in TOTP
+type LoginChallenge struct {
+	// Hash is the SHA-256 of the challenge handed to the client
+	Hash      string    `bson:"_id"`
+	ClientID  string    `bson:"client_id"`
+	Role      Role      `bson:"role,omitempty"`
+	CPF       string    `bson:"cpf"`
+	IP        string    `bson:"ip,omitempty"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// TOTPLogin is the second step of a login, proving the possession of the authenticator or of a recovery code
+type TOTPLogin struct {
+	Challenge string `json:"totp_challenge"`
+	Code      string `json:"code"`
+	// IP is the one the login attempt came from
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+func newTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// totpURI builds the Key URI Format authenticator apps read, usually through a QR code
+func totpURI(secret string, accountName string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	label := url.PathEscape(TOTPIssuer + ":" + accountName)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+func totpStepAt(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// totpCode generates the HOTP code of secret at step, as of RFC 4226
+func totpCode(secret []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP returns the step, around the one of now, whose code of secret is code, being false when there's none
+func matchTOTP(secret string, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+	current := totpStepAt(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// isTOTPCode tells apart TOTP codes from recovery codes, which are never all digits
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecoveryCodes returns RecoveryCodesCount codes, as xxxxx-xxxxx, along with their bcrypt digests
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodesCount)
+	digests := make([]string, RecoveryCodesCount)
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(raw))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		digest, err := bcrypt.GenerateFromPassword([]byte(normalizeRecoveryCode(codes[i])), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+		digests[i] = string(digest)
+	}
+	return codes, digests, nil
+}
+
+// normalizeRecoveryCode lets recovery codes be typed without the dash or in upper case
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func totpKey(accountID string) string {
+	return "totp:" + accountID
+}
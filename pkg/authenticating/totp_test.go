@@ -0,0 +1,77 @@
+package authenticating
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// test vectors of RFC 6238, appendix B, for SHA-1, truncated to 6 digits
+	secret := []byte("12345678901234567890")
+	tt := []struct {
+		at   int64
+		want string
+	}{
+		{at: 59, want: "287082"},
+		{at: 1111111109, want: "081804"},
+		{at: 1234567890, want: "005924"},
+		{at: 2000000000, want: "279037"},
+	}
+	for _, tc := range tt {
+		if got := totpCode(secret, totpStepAt(time.Unix(tc.at, 0))); got != tc.want {
+			t.Errorf("Expected code %s at %d; got %s", tc.want, tc.at, got)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+	step := totpStepAt(now)
+	tt := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "When code is of the current step", code: "081804", wantStep: step, wantOK: true},
+		{name: "When code is of the step before, as clocks drift", code: totpCode([]byte("12345678901234567890"), step-1), wantStep: step - 1, wantOK: true},
+		{name: "When code is of two steps before", code: totpCode([]byte("12345678901234567890"), step-2)},
+		{name: "When code is not even of 6 digits", code: "81804"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gotStep, ok := matchTOTP(secret, tc.code, now)
+			if ok != tc.wantOK || gotStep != tc.wantStep {
+				t.Errorf("Expected step %d and %v; got %d and %v", tc.wantStep, tc.wantOK, gotStep, ok)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("JBSWY3DPEHPK3PXP", "11111111030")
+	want := "otpauth://totp/transfer-api:11111111030?algorithm=SHA1&digits=6&issuer=transfer-api&period=30&secret=JBSWY3DPEHPK3PXP"
+	if uri != want {
+		t.Errorf("Expected uri %s; got %s", want, uri)
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, digests, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatalf("Expected no err; got %v", err)
+	}
+	if len(codes) != RecoveryCodesCount || len(digests) != RecoveryCodesCount {
+		t.Fatalf("Expected %d codes and digests; got %d and %d", RecoveryCodesCount, len(codes), len(digests))
+	}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || isTOTPCode(code) {
+			t.Errorf("Expected code %s to be as xxxxx-xxxxx", code)
+		}
+		if strings.Contains(strings.Join(digests, ""), code) {
+			t.Errorf("Expected code %s not to be kept in plain text", code)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package jwt
 
 import (
+	"crypto"
+	"errors"
+	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gbrlsnchs/jwt/v3"
@@ -11,50 +15,168 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// AccessTokenTTL is for how long a signed token is valid, a new one being got through its refresh token
+const AccessTokenTTL = time.Minute * 30
+
+var ErrNoSigningKey = errors.New("either APP_JWT_GATEKEEPER_SIGNING_KEY_FILE or APP_JWT_GATEKEEPER_SECRET must be set")
+
+// Gatekeeper signs tokens with a single key, verifying them with any of its keys so that tokens signed by
+// previous keys remain valid while they rotate
 type Gatekeeper struct {
-	hs  *jwt.HMACSHA
-	iss string
-	log *logrus.Logger
+	signer key
+	keys   map[string]key
+	iss    string
+	log    *logrus.Logger
 }
 
-func NewGatekeeperFromEnv() *Gatekeeper {
+// NewGatekeeperFromEnv signs tokens with the RS256 or ES256 private key in the PEM file APP_JWT_GATEKEEPER_SIGNING_KEY_FILE,
+// also verifying them with the public keys in the comma separated PEM files APP_JWT_GATEKEEPER_VERIFICATION_KEY_FILES.
+// Without a signing key file it signs HS256 tokens with APP_JWT_GATEKEEPER_SECRET, which otherwise only verifies the
+// HS256 tokens signed before the signing key was set
+func NewGatekeeperFromEnv() (*Gatekeeper, error) {
 	secret := os.Getenv("APP_JWT_GATEKEEPER_SECRET")
 	issuer := os.Getenv("APP_JWT_GATEKEEPER_ISSUER")
+	signingKeyFile := os.Getenv("APP_JWT_GATEKEEPER_SIGNING_KEY_FILE")
 
-	return &Gatekeeper{
-		hs:  jwt.NewHS256([]byte(secret)),
-		iss: issuer,
-		log: lgr.NewDefaultLogger(),
+	if signingKeyFile == "" {
+		if secret == "" {
+			return nil, ErrNoSigningKey
+		}
+		return NewGatekeeper(secret, issuer), nil
+	}
+
+	data, err := ioutil.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, err
 	}
+	signingKey, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	var verificationKeys []crypto.PublicKey
+	for _, file := range strings.Split(os.Getenv("APP_JWT_GATEKEEPER_VERIFICATION_KEY_FILES"), ",") {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+		if data, err = ioutil.ReadFile(file); err != nil {
+			return nil, err
+		}
+		public, parseErr := ParsePublicKeyPEM(data)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		verificationKeys = append(verificationKeys, public)
+	}
+
+	g, err := NewGatekeeperWithKeys(issuer, signingKey, verificationKeys...)
+	if err != nil {
+		return nil, err
+	}
+	if secret != "" {
+		g.addKey(newHMACKey(secret))
+	}
+	return g, nil
 }
 
+// NewGatekeeper signs and verifies HS256 tokens with tokenSecret
 func NewGatekeeper(tokenSecret string, iss string) *Gatekeeper {
-	return &Gatekeeper{
-		hs:  jwt.NewHS256([]byte(tokenSecret)),
-		iss: iss,
-		log: lgr.NewDefaultLogger(),
+	g := &Gatekeeper{
+		signer: newHMACKey(tokenSecret),
+		keys:   make(map[string]key),
+		iss:    iss,
+		log:    lgr.NewDefaultLogger(),
 	}
+	g.addKey(g.signer)
+	return g
+}
+
+// NewGatekeeperWithKeys signs tokens with signingKey, verifying them with it or with any of verificationKeys
+func NewGatekeeperWithKeys(iss string, signingKey crypto.Signer, verificationKeys ...crypto.PublicKey) (*Gatekeeper, error) {
+	signer, err := newPrivateKey(signingKey)
+	if err != nil {
+		return nil, err
+	}
+	g := &Gatekeeper{
+		signer: signer,
+		keys:   make(map[string]key),
+		iss:    iss,
+		log:    lgr.NewDefaultLogger(),
+	}
+	g.addKey(signer)
+	for _, public := range verificationKeys {
+		k, keyErr := newPublicKey(public)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		g.addKey(k)
+	}
+	return g, nil
+}
+
+// newHMACKey has no id, HS256 tokens having always been signed without a kid header
+func newHMACKey(secret string) key {
+	return key{alg: jwt.NewHS256([]byte(secret))}
+}
+
+func (g *Gatekeeper) addKey(k key) {
+	g.keys[k.id] = k
 }
 
-func (g *Gatekeeper) Sign(clientID string) (authenticating.Token, error) {
+func (g *Gatekeeper) Sign(clientID string, role authenticating.Role) (authenticating.Token, error) {
 	g.log.Infof("Trying to emit a token for clientID %s", clientID)
+	return g.sign(clientID, role, authenticating.UserToken, authenticating.ScopesOf(role))
+}
+
+func (g *Gatekeeper) SignClient(clientID string, scopes []authenticating.Scope) (authenticating.Token, error) {
+	g.log.Infof("Trying to emit a token for API client %s", clientID)
+	return g.sign(clientID, "", authenticating.ClientToken, scopes)
+}
+
+// sign signs a token of kind to clientID granting scopes, along with role for tokens of accounts
+func (g *Gatekeeper) sign(clientID string, role authenticating.Role, kind authenticating.TokenKind, scopes []authenticating.Scope) (authenticating.Token, error) {
 	currentTime := time.Now().UTC()
 	id := primitive.NewObjectID()
-	token, err := jwt.Sign(Token{
+	expirationTime := jwt.NumericDate(currentTime.Add(AccessTokenTTL))
+	issuedAt := jwt.NumericDate(currentTime)
+	claims := Token{
 		Payload: jwt.Payload{
 			Issuer:         g.iss,
-			ExpirationTime: jwt.NumericDate(currentTime.Add(time.Minute * 30)),
-			IssuedAt:       jwt.NumericDate(currentTime),
+			ExpirationTime: expirationTime,
+			IssuedAt:       issuedAt,
 			JWTID:          id.Hex(),
 		},
 		ClientID: clientID,
-	}, g.hs)
+		Role:     string(role),
+		Scope:    authenticating.JoinScopes(scopes),
+	}
+	// the claim is left out of tokens of accounts, as they were all signed without it
+	if kind == authenticating.ClientToken {
+		claims.Kind = string(kind)
+	}
+	token, err := jwt.Sign(claims, g.signer.alg, g.signOptions()...)
 	if err != nil {
 		g.log.Errorf("Error %v when signing token", err)
 		return authenticating.Token{}, err
 	}
 
-	return authenticating.Token{ID: &id, ClientID: clientID, Digest: string(token)}, nil
+	return authenticating.Token{
+		ID:        &id,
+		ClientID:  clientID,
+		Digest:    string(token),
+		Role:      role,
+		Scopes:    scopes,
+		Kind:      kind,
+		ExpiresAt: expirationTime.UTC(),
+		IssuedAt:  issuedAt.UTC(),
+	}, nil
+}
+
+// signOptions sets the id of the signing key, HS256 tokens having always been signed without it
+func (g *Gatekeeper) signOptions() []jwt.SignOption {
+	if g.signer.id == "" {
+		return nil
+	}
+	return []jwt.SignOption{jwt.KeyID(g.signer.id)}
 }
 
 func (g *Gatekeeper) Verify(tokenDigest string) (authenticating.Token, error) {
@@ -68,7 +190,7 @@ func (g *Gatekeeper) Verify(tokenDigest string) (authenticating.Token, error) {
 	issValidator := jwt.IssuerValidator(g.iss)
 	validatePayload := jwt.ValidatePayload(&jwtToken.Payload, issValidator, expValidator)
 
-	_, err := jwt.Verify([]byte(tokenDigest), g.hs, &jwtToken, validatePayload)
+	_, err := jwt.Verify([]byte(tokenDigest), &keyResolver{keys: g.keys}, &jwtToken, validatePayload)
 	if err != nil {
 		g.log.Errorf("Error %v when verifying tokenDigest %s", err, tokenDigest)
 		return authenticating.Token{}, err
@@ -81,11 +203,39 @@ func (g *Gatekeeper) Verify(tokenDigest string) (authenticating.Token, error) {
 	}
 
 	token := authenticating.Token{
-		ID:       &oid,
-		ClientID: jwtToken.ClientID,
-		Digest:   tokenDigest,
+		ID:        &oid,
+		ClientID:  jwtToken.ClientID,
+		Digest:    tokenDigest,
+		Role:      authenticating.Role(jwtToken.Role),
+		Scopes:    authenticating.SplitScopes(jwtToken.Scope),
+		Kind:      authenticating.UserToken,
+		ExpiresAt: jwtToken.ExpirationTime.UTC(),
+	}
+	if jwtToken.IssuedAt != nil {
+		token.IssuedAt = jwtToken.IssuedAt.UTC()
+	}
+	if jwtToken.Kind == string(authenticating.ClientToken) {
+		token.Kind = authenticating.ClientToken
+	}
+	// tokens signed before scopes existed were all of customers
+	if jwtToken.Scope == "" && !token.IsClient() {
+		token.Scopes = authenticating.ScopesOf(authenticating.Customer)
 	}
 
 	g.log.Infof("tokenDigest %s successfully verified", tokenDigest)
 	return token, nil
 }
+
+// PublicKeys returns every asymmetric key tokens are verified with, the signing one first
+func (g *Gatekeeper) PublicKeys() []authenticating.JWK {
+	keys := make([]authenticating.JWK, 0, len(g.keys))
+	if g.signer.public != nil {
+		keys = append(keys, g.signer.jwk())
+	}
+	for id, k := range g.keys {
+		if k.public != nil && id != g.signer.id {
+			keys = append(keys, k.jwk())
+		}
+	}
+	return keys
+}
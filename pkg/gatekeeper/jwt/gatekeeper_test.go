@@ -1,10 +1,27 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestGatekeeper_Sign(t *testing.T) {
@@ -30,7 +47,7 @@ func TestGatekeeper_Sign(t *testing.T) {
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := gk.Sign(tc.args.clientID)
+			got, err := gk.Sign(tc.args.clientID, authenticating.Customer)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("Sign() error = %v, wantErr %v", err, tc.wantErr)
 				return
@@ -45,7 +62,7 @@ func TestGatekeeper_Sign(t *testing.T) {
 func TestGatekeeper_Verify(t *testing.T) {
 	gk := NewGatekeeper("testSecret", "test")
 	clientID := "4sfa9684fsa698"
-	token, _ := gk.Sign(clientID)
+	token, _ := gk.Sign(clientID, authenticating.Customer)
 	tt := []struct {
 		name        string
 		tokenDigest string
@@ -82,3 +99,223 @@ func TestGatekeeper_Verify(t *testing.T) {
 		})
 	}
 }
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Could not generate RSA key: %v", err)
+	}
+	return k
+}
+
+func newECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not generate ECDSA key: %v", err)
+	}
+	return k
+}
+
+func TestGatekeeper_AsymmetricKeys(t *testing.T) {
+	rsaKey, ecdsaKey := newRSAKey(t), newECDSAKey(t)
+	tt := []struct {
+		name    string
+		signing crypto.Signer
+		wantAlg string
+	}{
+		{name: "When signing with an RSA key", signing: rsaKey, wantAlg: "RS256"},
+		{name: "When signing with an ECDSA key", signing: ecdsaKey, wantAlg: "ES256"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gk, err := NewGatekeeperWithKeys("test", tc.signing)
+			if err != nil {
+				t.Fatalf("NewGatekeeperWithKeys() err = %v", err)
+			}
+			token, err := gk.Sign("4sfa9684fsa698", authenticating.Customer)
+			if err != nil {
+				t.Fatalf("Sign() err = %v", err)
+			}
+			got, err := gk.Verify(token.Digest)
+			if err != nil {
+				t.Fatalf("Verify() err = %v", err)
+			}
+			if !reflect.DeepEqual(token, got) {
+				t.Errorf("Expected token %v, got %v", token, got)
+			}
+
+			keys := gk.PublicKeys()
+			if len(keys) != 1 || keys[0].Algorithm != tc.wantAlg || keys[0].KeyID == "" {
+				t.Fatalf("Expected a single %s public key, got %v", tc.wantAlg, keys)
+			}
+			var header jwt.Header
+			rawHeader, _ := base64.RawURLEncoding.DecodeString(strings.Split(token.Digest, ".")[0])
+			_ = json.Unmarshal(rawHeader, &header)
+			if header.Algorithm != tc.wantAlg || header.KeyID != keys[0].KeyID {
+				t.Errorf("Expected token to be signed %s by key %s, got header %v", tc.wantAlg, keys[0].KeyID, header)
+			}
+		})
+	}
+}
+
+func TestGatekeeper_Verify_KeyRotation(t *testing.T) {
+	retired, current := newECDSAKey(t), newRSAKey(t)
+	previous, _ := NewGatekeeperWithKeys("test", retired)
+	signedByRetired, _ := previous.Sign("4sfa9684fsa698", authenticating.Customer)
+	gk, err := NewGatekeeperWithKeys("test", current, &retired.PublicKey)
+	if err != nil {
+		t.Fatalf("NewGatekeeperWithKeys() err = %v", err)
+	}
+	signedByCurrent, _ := gk.Sign("4sfa9684fsa698", authenticating.Customer)
+	unknown, _ := NewGatekeeperWithKeys("test", newECDSAKey(t))
+	signedByUnknown, _ := unknown.Sign("4sfa9684fsa698", authenticating.Customer)
+
+	// an HS256 token whose secret is the PEM of the current public key, trying to pass as signed by it
+	der, _ := x509.MarshalPKIXPublicKey(&current.PublicKey)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	forged, _ := jwt.Sign(Token{
+		Payload:  jwt.Payload{Issuer: "test", ExpirationTime: jwt.NumericDate(time.Now().Add(time.Minute)), JWTID: primitive.NewObjectID().Hex()},
+		ClientID: "4sfa9684fsa698",
+	}, jwt.NewHS256(publicPEM), jwt.KeyID(gk.signer.id))
+
+	tt := []struct {
+		name        string
+		tokenDigest string
+		wantErr     bool
+	}{
+		{name: "When token was signed by the current key", tokenDigest: signedByCurrent.Digest},
+		{name: "When token was signed by a retired key", tokenDigest: signedByRetired.Digest},
+		{name: "When token was signed by an unknown key", tokenDigest: signedByUnknown.Digest, wantErr: true},
+		{name: "When token claims the algorithm of another key", tokenDigest: string(forged), wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := gk.Verify(tc.tokenDigest)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	if keys := gk.PublicKeys(); len(keys) != 2 || keys[0].KeyID != gk.signer.id {
+		t.Errorf("Expected the signing key to be published first along with the retired one, got %v", keys)
+	}
+}
+
+func TestGatekeeper_Verify_SecretAlongsideKeys(t *testing.T) {
+	legacy := NewGatekeeper("testSecret", "test")
+	signedBySecret, _ := legacy.Sign("4sfa9684fsa698", authenticating.Customer)
+	gk, _ := NewGatekeeperWithKeys("test", newECDSAKey(t))
+
+	if _, err := gk.Verify(signedBySecret.Digest); err == nil {
+		t.Error("Expected HS256 token not to be verified without the secret")
+	}
+	gk.addKey(newHMACKey("testSecret"))
+	if _, err := gk.Verify(signedBySecret.Digest); err != nil {
+		t.Errorf("Expected HS256 token to be verified with the secret, got %v", err)
+	}
+	if keys := gk.PublicKeys(); len(keys) != 1 {
+		t.Errorf("Expected the secret not to be published, got %v", keys)
+	}
+}
+
+func TestGatekeeper_Verify_Scopes(t *testing.T) {
+	gk := NewGatekeeper("testSecret", "test")
+	admin, _ := gk.Sign("4sfa9684fsa698", authenticating.Admin)
+	customer, _ := gk.Sign("4sfa9684fsa698", "")
+	client, _ := gk.SignClient("5f8b1c2d3e4f5a6b7c8d9e0f", []authenticating.Scope{authenticating.ScopeAccountsRead})
+	unscoped, _ := jwt.Sign(Token{
+		Payload:  jwt.Payload{Issuer: "test", ExpirationTime: jwt.NumericDate(time.Now().Add(time.Minute)), JWTID: primitive.NewObjectID().Hex()},
+		ClientID: "4sfa9684fsa698",
+	}, jwt.NewHS256([]byte("testSecret")))
+
+	tt := []struct {
+		name        string
+		tokenDigest string
+		scope       authenticating.Scope
+		wantGrant   bool
+	}{
+		{name: "When admin token manages limits", tokenDigest: admin.Digest, scope: authenticating.ScopeLimitsManage, wantGrant: true},
+		{name: "When token of an account with no role manages limits", tokenDigest: customer.Digest, scope: authenticating.ScopeLimitsManage},
+		{name: "When token of an account with no role reads its account", tokenDigest: customer.Digest, scope: authenticating.ScopeAccount, wantGrant: true},
+		{name: "When token signed before scopes reads its account", tokenDigest: string(unscoped), scope: authenticating.ScopeAccount, wantGrant: true},
+		{name: "When token signed before scopes reads every account", tokenDigest: string(unscoped), scope: authenticating.ScopeAccountsRead},
+		{name: "When client token reads every account", tokenDigest: client.Digest, scope: authenticating.ScopeAccountsRead, wantGrant: true},
+		{name: "When client token reads an account of its own", tokenDigest: client.Digest, scope: authenticating.ScopeAccount},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := gk.Verify(tc.tokenDigest)
+			if err != nil {
+				t.Fatalf("Verify() err = %v", err)
+			}
+			if got.IsClient() != (tc.tokenDigest == client.Digest) {
+				t.Errorf("Expected only client tokens to be of kind %s, got %s", authenticating.ClientToken, got.Kind)
+			}
+			if got.HasScope(tc.scope) != tc.wantGrant {
+				t.Errorf("Expected scope %s to be granted to be %v, got scopes %v", tc.scope, tc.wantGrant, got.Scopes)
+			}
+		})
+	}
+}
+
+func TestGatekeeper_Receipt(t *testing.T) {
+	rsaGatekeeper, err := NewGatekeeperWithKeys("test", newRSAKey(t))
+	if err != nil {
+		t.Fatalf("NewGatekeeperWithKeys() err = %v", err)
+	}
+	completedAt := time.Date(2020, 10, 21, 10, 0, 1, 0, time.UTC)
+	receipt := receipting.Receipt{
+		TransferID:  "5f8f8ccb30a1cd7511c5cb72",
+		Origin:      listing.Party{AccountID: "g4a68vf6a4g96ws84g", Name: "Monkey D. Luffy", CPF: "***.111.110-**"},
+		Destination: listing.Party{AccountID: "a6sf46af6af", Name: "Roronoa Zoro", CPF: "***.982.247-**"},
+		Amount:      money.FromCents(1050),
+		Status:      transferstatus.Completed,
+		CreatedAt:   time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC),
+		CompletedAt: &completedAt,
+		IssuedAt:    time.Date(2020, 10, 22, 8, 30, 0, 0, time.UTC),
+	}
+	tt := []struct {
+		name string
+		gk   *Gatekeeper
+	}{
+		{name: "When signing with a secret", gk: NewGatekeeper("testSecret", "test")},
+		{name: "When signing with an RSA key", gk: rsaGatekeeper},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := tc.gk.SignReceipt(receipt)
+			if err != nil {
+				t.Fatalf("SignReceipt() err = %v", err)
+			}
+			got, err := tc.gk.VerifyReceipt(code)
+			if err != nil {
+				t.Fatalf("VerifyReceipt() err = %v", err)
+			}
+			if !reflect.DeepEqual(receipt, got) {
+				t.Errorf("Expected receipt %+v, got %+v", receipt, got)
+			}
+
+			parts := strings.Split(code, ".")
+			forged := receipt
+			forged.Amount = money.FromCents(105000)
+			forgedPayload, _ := json.Marshal(Receipt{Payload: jwt.Payload{Issuer: "test", Audience: jwt.Audience{ReceiptAudience}}, Receipt: forged})
+			parts[1] = base64.RawURLEncoding.EncodeToString(forgedPayload)
+			if _, err = tc.gk.VerifyReceipt(strings.Join(parts, ".")); err == nil {
+				t.Error("Expected receipt with a tampered payload not to be verified")
+			}
+			if _, err = NewGatekeeper("anotherSecret", "test").VerifyReceipt(code); err == nil {
+				t.Error("Expected receipt not to be verified with another key")
+			}
+			if _, err = tc.gk.Verify(code); err == nil {
+				t.Error("Expected receipt not to be taken as a token")
+			}
+			token, _ := tc.gk.Sign("4sfa9684fsa698", authenticating.Customer)
+			if _, err = tc.gk.VerifyReceipt(token.Digest); err == nil {
+				t.Error("Expected token not to be taken as a receipt")
+			}
+		})
+	}
+}
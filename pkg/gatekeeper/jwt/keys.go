@@ -0,0 +1,169 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+)
+
+var ErrUnsupportedKey = errors.New("keys must be either RSA, signing RS256, or ECDSA P-256, signing ES256")
+var ErrUnknownKey = errors.New("token was signed by an unknown key or with an algorithm other than the one of its key")
+
+// key is a verification key, and a signing one as well when alg holds its private key. HMAC keys have no public
+// key, as they can't be published
+type key struct {
+	id     string
+	alg    jwt.Algorithm
+	public crypto.PublicKey
+}
+
+// newPrivateKey returns the signing key of private, which must be an *rsa.PrivateKey or a P-256 *ecdsa.PrivateKey
+func newPrivateKey(private crypto.Signer) (key, error) {
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		return newKey(&k.PublicKey, jwt.NewRS256(jwt.RSAPrivateKey(k))), nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return key{}, ErrUnsupportedKey
+		}
+		return newKey(&k.PublicKey, jwt.NewES256(jwt.ECDSAPrivateKey(k))), nil
+	default:
+		return key{}, ErrUnsupportedKey
+	}
+}
+
+// newPublicKey returns the verification key of public, which must be an *rsa.PublicKey or a P-256 *ecdsa.PublicKey
+func newPublicKey(public crypto.PublicKey) (key, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		return newKey(k, jwt.NewRS256(jwt.RSAPublicKey(k))), nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return key{}, ErrUnsupportedKey
+		}
+		return newKey(k, jwt.NewES256(jwt.ECDSAPublicKey(k))), nil
+	default:
+		return key{}, ErrUnsupportedKey
+	}
+}
+
+func newKey(public crypto.PublicKey, alg jwt.Algorithm) key {
+	k := key{alg: alg, public: public}
+	thumbprint := sha256.Sum256([]byte(k.thumbprintInput()))
+	k.id = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	return k
+}
+
+// thumbprintInput is the JSON of the required members of the JWK of k in lexicographic order, whose SHA-256 is its
+// thumbprint as of RFC 7638, used as its key id
+func (k key) thumbprintInput() string {
+	jwk := k.jwk()
+	if jwk.KeyType == "RSA" {
+		return fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	}
+	return fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Curve, jwk.X, jwk.Y)
+}
+
+func (k key) jwk() authenticating.JWK {
+	enc := base64.RawURLEncoding
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		return authenticating.JWK{
+			KeyType:   "RSA",
+			KeyID:     k.id,
+			Use:       "sig",
+			Algorithm: k.alg.Name(),
+			N:         enc.EncodeToString(public.N.Bytes()),
+			E:         enc.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		// coordinates are padded to the size of the curve, as RFC 7518 requires
+		size := (public.Curve.Params().BitSize + 7) / 8
+		return authenticating.JWK{
+			KeyType:   "EC",
+			KeyID:     k.id,
+			Use:       "sig",
+			Algorithm: k.alg.Name(),
+			Curve:     public.Curve.Params().Name,
+			X:         enc.EncodeToString(public.X.FillBytes(make([]byte, size))),
+			Y:         enc.EncodeToString(public.Y.FillBytes(make([]byte, size))),
+		}
+	default:
+		return authenticating.JWK{}
+	}
+}
+
+// ParsePrivateKeyPEM reads a PKCS #1, PKCS #8 or SEC 1 PEM encoded private key
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrUnsupportedKey
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := k.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return signer, nil
+}
+
+// ParsePublicKeyPEM reads a PKIX PEM encoded public key
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrUnsupportedKey
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// keyResolver is the jwt.Algorithm of a single verification, resolving the key that signed the token from its kid
+type keyResolver struct {
+	keys map[string]key
+	key
+}
+
+func (r *keyResolver) Resolve(hd jwt.Header) error {
+	k, ok := r.keys[hd.KeyID]
+	// the algorithm of a key is fixed, so a token can't make an RSA public key be taken as an HMAC secret
+	if !ok || k.alg.Name() != hd.Algorithm {
+		return ErrUnknownKey
+	}
+	r.key = k
+	return nil
+}
+
+func (r *keyResolver) Name() string {
+	return r.alg.Name()
+}
+
+func (r *keyResolver) Sign(headerPayload []byte) ([]byte, error) {
+	return nil, ErrUnknownKey
+}
+
+func (r *keyResolver) Size() int {
+	return r.alg.Size()
+}
+
+func (r *keyResolver) Verify(headerPayload []byte, sig []byte) error {
+	return r.alg.Verify(headerPayload, sig)
+}
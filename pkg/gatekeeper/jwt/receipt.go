@@ -0,0 +1,51 @@
+package jwt
+
+import (
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+)
+
+// ReceiptAudience tells receipts apart from tokens, which are signed with the same keys
+const ReceiptAudience = "receipts"
+
+// Receipt holds a transfer receipt. Having no expiration, it is never taken as a token by Verify
+type Receipt struct {
+	jwt.Payload
+	receipting.Receipt
+}
+
+// SignReceipt signs receipt with the signing key, so that it can be verified for as long as the key is among the
+// ones of the gatekeeper
+func (g *Gatekeeper) SignReceipt(receipt receipting.Receipt) (string, error) {
+	g.log.Infof("Trying to sign receipt of transfer %s", receipt.TransferID)
+	receipt.VerificationCode = ""
+	claims := Receipt{
+		Payload: jwt.Payload{
+			Issuer:   g.iss,
+			Subject:  receipt.TransferID,
+			Audience: jwt.Audience{ReceiptAudience},
+			IssuedAt: jwt.NumericDate(receipt.IssuedAt),
+		},
+		Receipt: receipt,
+	}
+	code, err := jwt.Sign(claims, g.signer.alg, g.signOptions()...)
+	if err != nil {
+		g.log.Errorf("Error %v when signing receipt of transfer %s", err, receipt.TransferID)
+		return "", err
+	}
+	return string(code), nil
+}
+
+func (g *Gatekeeper) VerifyReceipt(code string) (receipting.Receipt, error) {
+	g.log.Infof("Trying to verify receipt %s", code)
+	var claims Receipt
+	issValidator := jwt.IssuerValidator(g.iss)
+	audValidator := jwt.AudienceValidator(jwt.Audience{ReceiptAudience})
+	validatePayload := jwt.ValidatePayload(&claims.Payload, issValidator, audValidator)
+
+	if _, err := jwt.Verify([]byte(code), &keyResolver{keys: g.keys}, &claims, validatePayload); err != nil {
+		g.log.Errorf("Error %v when verifying receipt %s", err, code)
+		return receipting.Receipt{}, err
+	}
+	return claims.Receipt, nil
+}
@@ -5,4 +5,9 @@ import "github.com/gbrlsnchs/jwt/v3"
 type Token struct {
 	jwt.Payload
 	ClientID string `json:"client_id"`
+	Role     string `json:"role,omitempty"`
+	// Kind is only set to tokens of API clients, tokens without it being signed to accounts
+	Kind string `json:"kind,omitempty"`
+	// Scope holds the space separated scopes granted by the token, as of RFC 8693
+	Scope string `json:"scope,omitempty"`
 }
@@ -0,0 +1,117 @@
+package argon2
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errMalformedDigest = errors.New("digest is not of the argon2id PHC string format")
+
+// Params are the Argon2id parameters passwords are hashed with, Memory being in KiB
+type Params struct {
+	Time       uint32
+	Memory     uint32
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultParams are the second recommended option of RFC 9106, meant for when 2 GiB of memory per hash can't be
+// afforded
+var DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4, SaltLength: 16, KeyLength: 32}
+
+// Hasher hashes passwords with Argon2id into PHC strings, still verifying the bcrypt digests accounts had before it,
+// which it tells to be rehashed
+type Hasher struct {
+	params Params
+	log    *logrus.Logger
+}
+
+func NewHasher(params Params) *Hasher {
+	return &Hasher{params: params, log: lgr.NewDefaultLogger()}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		h.log.Errorf("Err %v occurred when generating salt", err)
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Threads, h.params.KeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory,
+		h.params.Time,
+		h.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Hasher) Matches(digest string, password string) bool {
+	if isBcrypt(digest) {
+		// bcrypt digests were made of the secret as it came in the JSON body, quotes included
+		return bcrypt.CompareHashAndPassword([]byte(digest), []byte(fmt.Sprintf(`"%s"`, password))) == nil
+	}
+	params, salt, key, err := decode(digest)
+	if err != nil {
+		// empty digests, as of CPFs with no account, are expected to never match
+		if digest != "" {
+			h.log.Errorf("Err %v occurred when decoding digest", err)
+		}
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+func (h *Hasher) NeedsRehash(digest string) bool {
+	if isBcrypt(digest) {
+		return true
+	}
+	params, salt, key, err := decode(digest)
+	if err != nil {
+		return false
+	}
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+	return params != h.params
+}
+
+func isBcrypt(digest string) bool {
+	return strings.HasPrefix(digest, "$2a$") || strings.HasPrefix(digest, "$2b$") || strings.HasPrefix(digest, "$2y$")
+}
+
+// decode parses digest, a PHC string as made by Hash, into the parameters, salt and key it was made of
+func decode(digest string) (Params, []byte, []byte, error) {
+	parts := strings.Split(digest, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Params{}, nil, nil, errMalformedDigest
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, errMalformedDigest
+	}
+	var params Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return Params{}, nil, nil, errMalformedDigest
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, errMalformedDigest
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, errMalformedDigest
+	}
+	return params, salt, key, nil
+}
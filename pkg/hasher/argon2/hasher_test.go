@@ -0,0 +1,52 @@
+package argon2
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testParams = Params{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHasher(t *testing.T) {
+	h := NewHasher(testParams)
+	digest, err := h.Hash("g0rul&zz")
+	if err != nil {
+		t.Fatalf("Hash() err = %v", err)
+	}
+	if !strings.HasPrefix(digest, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("Expected digest to be an argon2id PHC string; got %s", digest)
+	}
+	if again, _ := h.Hash("g0rul&zz"); again == digest {
+		t.Errorf("Expected digests of the same password to differ by their salt")
+	}
+	legacy, _ := bcrypt.GenerateFromPassword([]byte(`"g0rul&zz"`), bcrypt.MinCost)
+	stronger, _ := NewHasher(Params{Time: 2, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}).Hash("g0rul&zz")
+
+	tt := []struct {
+		name       string
+		digest     string
+		password   string
+		wantMatch  bool
+		wantRehash bool
+	}{
+		{name: "When password matches", digest: digest, password: "g0rul&zz", wantMatch: true},
+		{name: "When password doesn't match", digest: digest, password: "g0rul&zZ"},
+		{name: "When password matches a bcrypt digest", digest: string(legacy), password: "g0rul&zz", wantMatch: true, wantRehash: true},
+		{name: "When password doesn't match a bcrypt digest", digest: string(legacy), password: "g0rul&zZ", wantRehash: true},
+		{name: "When password matches a digest of other parameters", digest: stronger, password: "g0rul&zz", wantMatch: true, wantRehash: true},
+		{name: "When digest is empty", digest: "", password: ""},
+		{name: "When digest is malformed", digest: "$argon2id$v=19$m=1024$c2FsdA$a2V5", password: "g0rul&zz"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := h.Matches(tc.digest, tc.password); got != tc.wantMatch {
+				t.Errorf("Expected match to be %v; got %v", tc.wantMatch, got)
+			}
+			if got := h.NeedsRehash(tc.digest); got != tc.wantRehash {
+				t.Errorf("Expected rehash to be needed to be %v; got %v", tc.wantRehash, got)
+			}
+		})
+	}
+}
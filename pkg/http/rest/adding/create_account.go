@@ -30,6 +30,6 @@ func (h Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", rest.DefaultContentType)
-	w.Header().Set("Location", fmt.Sprintf("/%s", id))
+	w.Header().Set("Location", fmt.Sprintf("/accounts/%s", id))
 	w.WriteHeader(http.StatusCreated)
 }
@@ -78,8 +78,8 @@ func TestCreateAccount(t *testing.T) {
 				helpers.AssertResponseJSON(t, w, tc.expectedErrResponse)
 				return
 			}
-			if w.Header().Get("Location") != fmt.Sprintf("/%s", tc.service.ID) {
-				t.Errorf("Expected Location header /%s; got %v", tc.service.ID, w.Header().Get("Location"))
+			if w.Header().Get("Location") != fmt.Sprintf("/accounts/%s", tc.service.ID) {
+				t.Errorf("Expected Location header /accounts/%s; got %v", tc.service.ID, w.Header().Get("Location"))
 			}
 		})
 	}
@@ -12,7 +12,10 @@ import (
 
 const bearerAuthType = "Bearer"
 
-func (h Handler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+// Authenticate serves next only to requests bearing a valid token that grants scope. Tokens of accounts put the
+// account in pkg.AccountID, while tokens of API clients put the client in pkg.ClientID and never pass ScopeAccount,
+// as clients have no account to act on
+func (h Handler) Authenticate(scope authenticating.Scope, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -44,7 +47,19 @@ func (h Handler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		r = r.WithContext(context.WithValue(ctx, pkg.AccountID, token.ClientID))
+		if !token.HasScope(scope) || (token.IsClient() && scope == authenticating.ScopeAccount) {
+			h.logger.Errorf("Token %s of role %q lacks scope %s", token.ID.Hex(), token.Role, scope)
+			rest.SetJSONError(h.logger, authenticating.ErrMissingScope, http.StatusForbidden, w)
+			return
+		}
+
+		if token.IsClient() {
+			ctx = context.WithValue(ctx, pkg.ClientID, token.ClientID)
+		} else {
+			ctx = context.WithValue(ctx, pkg.AccountID, token.ClientID)
+		}
+		ctx = context.WithValue(ctx, pkg.Scopes, token.Scopes)
+		r = r.WithContext(context.WithValue(ctx, pkg.TokenID, *token.ID))
 
 		next(w, r)
 	}
@@ -0,0 +1,54 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChangePassword sets a new password for the account of the request, which must know the current one, revoking
+// every other session of it
+func (h Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+
+	var change authenticating.PasswordChange
+	if err := decoder.Decode(&change); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	account, err := h.listingService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	change.AccountID = accountID
+	change.CPF = account.CPF
+	change.TokenID = ctx.Value(pkg.TokenID).(primitive.ObjectID)
+	change.IP = clientIP(r)
+
+	if err = h.service.ChangePassword(ctx, change, account.Secret); err != nil {
+		switch err.Error() {
+		case authenticating.ErrWrongPassword.Error():
+			rest.SetJSONError(h.logger, err, http.StatusForbidden, w)
+		case authenticating.ErrPasswordPolicy.Error(), authenticating.ErrSamePassword.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		case authenticating.ErrLoginLocked.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_locked", http.StatusTooManyRequests, w)
+		case authenticating.ErrLoginBackoff.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_backoff", http.StatusTooManyRequests, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
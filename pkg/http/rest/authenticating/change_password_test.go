@@ -0,0 +1,112 @@
+package authenticating
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChangePassword(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	account := listing.Account{ID: "hg94gs8a41v685s4g89", CPF: "11111111030", Secret: "$2a$10$digest"}
+	tokenID := primitive.NewObjectID()
+
+	tt := []struct {
+		name             string
+		body             string
+		listingService   *lm.MockService
+		authService      *aum.MockService
+		expectedChange   authenticating.PasswordChange
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When password is changed",
+			body:           `{"secret":"current1","new_secret":"brandNew2"}`,
+			listingService: &lm.MockService{Account: account},
+			authService:    &aum.MockService{},
+			expectedChange: authenticating.PasswordChange{
+				Secret:    "current1",
+				NewSecret: "brandNew2",
+				AccountID: account.ID,
+				CPF:       account.CPF,
+				TokenID:   tokenID,
+				IP:        "192.0.2.1",
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When current password is wrong",
+			body:             `{"secret":"current2","new_secret":"brandNew2"}`,
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{Err: authenticating.ErrWrongPassword},
+			expectedResponse: `{"status_code":403,"message":"current password doesn't match, verify it and try again"}`,
+			expectedStatus:   http.StatusForbidden,
+		},
+		{
+			name:             "When new password doesn't follow the policy",
+			body:             `{"secret":"current1","new_secret":"short"}`,
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{Err: authenticating.ErrPasswordPolicy},
+			expectedResponse: `{"status_code":400,"message":"password must have from 8 to 70 characters, letters and digits among them, and must not contain the cpf"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When the cpf is locked",
+			body:             `{"secret":"current1","new_secret":"brandNew2"}`,
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{Err: authenticating.ErrLoginLocked},
+			expectedResponse: `{"status_code":429,"message":"login is locked after too many failed attempts, try again later or ask an operator to unlock it","code":"login_locked"}`,
+			expectedStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:             "When fails to get the account",
+			body:             `{"secret":"current1","new_secret":"brandNew2"}`,
+			listingService:   &lm.MockService{Err: errors.New("foo")},
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, tc.listingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/accounts/me/password", bytes.NewBufferString(tc.body))
+			ctx := context.WithValue(r.Context(), pkg.AccountID, account.ID)
+			r = r.WithContext(context.WithValue(ctx, pkg.TokenID, tokenID))
+
+			handler.ChangePassword(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusNoContent {
+				if tc.authService.PasswordChange != tc.expectedChange {
+					t.Errorf("Expected change %v; got %v", tc.expectedChange, tc.authService.PasswordChange)
+				}
+				if tc.authService.SecretDigest != account.Secret {
+					t.Errorf("Expected secret digest %s; got %s", account.Secret, tc.authService.SecretDigest)
+				}
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+// RegisterClient registers an API client of the name and scopes in the body, answering its secret, which is never
+// shown again
+func (h Handler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+
+	var request authenticating.APIClient
+	if err := decoder.Decode(&request); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	client, err := h.service.RegisterClient(ctx, request.Name, request.Scopes)
+	if err != nil {
+		switch err.Error() {
+		case authenticating.ErrInvalidClientName.Error(), authenticating.ErrInvalidScope.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(client)
+}
@@ -0,0 +1,93 @@
+package authenticating
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRegisterClient(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	client := authenticating.APIClient{
+		ID:           "5f8b1c2d3e4f5a6b7c8d9e0f",
+		Name:         "batch",
+		SecretDigest: "$2a$10$digest",
+		Secret:       "c2VjcmV0",
+		Scopes:       []authenticating.Scope{authenticating.ScopeAccountsRead},
+		CreatedAt:    time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC),
+	}
+
+	tt := []struct {
+		name             string
+		body             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:             "When client is registered",
+			body:             `{"name":"batch","scopes":["accounts:read"]}`,
+			authService:      &aum.MockService{Client: client},
+			expectedResponse: `{"client_id":"5f8b1c2d3e4f5a6b7c8d9e0f","name":"batch","client_secret":"c2VjcmV0","scopes":["accounts:read"],"created_at":"2020-11-02T10:00:00Z"}`,
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:             "When scopes are beyond the ones of clients",
+			body:             `{"name":"batch","scopes":["account"]}`,
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidScope},
+			expectedResponse: `{"status_code":400,"message":"requested scope is unknown or exceeds the scopes of the client"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When name is empty",
+			body:             `{"scopes":["accounts:read"]}`,
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidClientName},
+			expectedResponse: `{"status_code":400,"message":"client name must not be empty"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When body has a wrong type",
+			body:             `{"name":1}`,
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":400,"message":"Invalid APIClient entity: expected type string, got number at field name"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When fails to register the client",
+			body:             `{"name":"batch","scopes":["accounts:read"]}`,
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/clients", bytes.NewBufferString(tc.body))
+
+			handler.RegisterClient(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusCreated && w.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("Expected client secret not to be cached; got Cache-Control %q", w.Header().Get("Cache-Control"))
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+var errMissingIntrospectedToken = errors.New("token to introspect is required")
+
+// introspectionResponse is the introspection response of RFC 7662, inactive tokens carrying nothing but active
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	JWTID     string `json:"jti,omitempty"`
+	Role      string `json:"role,omitempty"`
+}
+
+// Introspect tells others, as API gateways, whether the token form parameter is active, and what it grants
+func (h Handler) Introspect(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := r.ParseForm(); err != nil {
+		rest.SetJSONErrorWithCode(h.logger, errMalformedTokenRequest, "invalid_request", http.StatusBadRequest, w)
+		return
+	}
+	tokenDigest := r.PostForm.Get("token")
+	if tokenDigest == "" {
+		rest.SetJSONErrorWithCode(h.logger, errMissingIntrospectedToken, "invalid_request", http.StatusBadRequest, w)
+		return
+	}
+
+	var response introspectionResponse
+	token, err := h.service.Introspect(ctx, tokenDigest)
+	switch {
+	case err == nil:
+		response = introspectionResponse{
+			Active:    true,
+			Scope:     authenticating.JoinScopes(token.Scopes),
+			Subject:   token.ClientID,
+			TokenType: bearerAuthType,
+			ExpiresAt: token.ExpiresAt.Unix(),
+			JWTID:     token.ID.Hex(),
+			Role:      string(token.Role),
+		}
+		if !token.IssuedAt.IsZero() {
+			response.IssuedAt = token.IssuedAt.Unix()
+		}
+		// the client is the one the token was requested by, which accounts logging in have none of
+		if token.IsClient() {
+			response.ClientID = token.ClientID
+		}
+	case err.Error() != authenticating.ErrInactiveToken.Error():
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,102 @@
+package authenticating
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIntrospect(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	oid, _ := primitive.ObjectIDFromHex("5f8f8ccb30a1cd7511c5cb70")
+	issuedAt := time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC)
+	token := authenticating.Token{
+		ID:        &oid,
+		ClientID:  "5f8b1c2d3e4f5a6b7c8d9e0f",
+		Role:      authenticating.Operator,
+		Scopes:    authenticating.ScopesOf(authenticating.Operator),
+		Kind:      authenticating.UserToken,
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(time.Minute * 30),
+	}
+	clientToken := token
+	clientToken.Role = ""
+	clientToken.Scopes = []authenticating.Scope{authenticating.ScopeAccountsRead}
+	clientToken.Kind = authenticating.ClientToken
+
+	tt := []struct {
+		name             string
+		body             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:             "When token of an account is active",
+			body:             "token=eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30",
+			authService:      &aum.MockService{Token: token},
+			expectedResponse: `{"active":true,"scope":"account accounts:read logins:unlock","sub":"5f8b1c2d3e4f5a6b7c8d9e0f","token_type":"Bearer","exp":1604313000,"iat":1604311200,"jti":"5f8f8ccb30a1cd7511c5cb70","role":"operator"}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When token of an API client is active",
+			body:             "token=eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30&token_type_hint=access_token",
+			authService:      &aum.MockService{Token: clientToken},
+			expectedResponse: `{"active":true,"scope":"accounts:read","client_id":"5f8b1c2d3e4f5a6b7c8d9e0f","sub":"5f8b1c2d3e4f5a6b7c8d9e0f","token_type":"Bearer","exp":1604313000,"iat":1604311200,"jti":"5f8f8ccb30a1cd7511c5cb70"}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When token is inactive",
+			body:             "token=eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30",
+			authService:      &aum.MockService{Err: authenticating.ErrInactiveToken},
+			expectedResponse: `{"active":false}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When token is missing",
+			body:             "token_type_hint=access_token",
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":400,"message":"token to introspect is required","code":"invalid_request"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When fails to introspect the token",
+			body:             "token=eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30",
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			handler.Introspect(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus != http.StatusBadRequest && tc.authService.Introspected != "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30" {
+				t.Errorf("Expected the token of the form to be introspected; got %q", tc.authService.Introspected)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+// JWKS publishes the keys tokens are verified with, cached for a short while as keys may rotate
+func (h Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	keys := h.service.PublicKeys(r.Context())
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(keys)
+}
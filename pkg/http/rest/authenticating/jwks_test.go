@@ -0,0 +1,58 @@
+package authenticating
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+)
+
+func TestJWKS(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name             string
+		authService      *aum.MockService
+		expectedResponse string
+	}{
+		{
+			name: "When gatekeeper has public keys",
+			authService: &aum.MockService{Keys: authenticating.JWKSet{Keys: []authenticating.JWK{
+				{KeyType: "EC", KeyID: "Pq1nX5e", Use: "sig", Algorithm: "ES256", Curve: "P-256", X: "f83OJ3D2", Y: "x_FEzRu9"},
+				{KeyType: "RSA", KeyID: "NzbLsXh", Use: "sig", Algorithm: "RS256", N: "0vx7agoe", E: "AQAB"},
+			}}},
+			expectedResponse: `{"keys":[{"kty":"EC","kid":"Pq1nX5e","use":"sig","alg":"ES256","crv":"P-256","x":"f83OJ3D2","y":"x_FEzRu9"},{"kty":"RSA","kid":"NzbLsXh","use":"sig","alg":"RS256","n":"0vx7agoe","e":"AQAB"}]}`,
+		},
+		{
+			name:             "When gatekeeper only has secrets",
+			authService:      &aum.MockService{Keys: authenticating.JWKSet{Keys: []authenticating.JWK{}}},
+			expectedResponse: `{"keys":[]}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+
+			handler.JWKS(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected response status %v; got %v", http.StatusOK, w.Code)
+			}
+			if w.Header().Get("Cache-Control") == "" {
+				t.Error("Expected response to be cacheable")
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
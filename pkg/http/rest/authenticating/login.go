@@ -2,6 +2,7 @@ package authenticating
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 
 	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
@@ -19,27 +20,42 @@ func (h Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	login.IP = clientIP(r)
+	login.UserAgent = r.UserAgent()
+
+	// unknown cpfs are still signed with an empty secret, so their failed attempts count towards lockouts as well
 	account, err := h.listingService.GetAccountByCPF(ctx, login.CPF)
-	if err != nil {
-		if err.Error() == mongodb.ErrNoAccountWasFound.Error() {
-			rest.SetJSONError(h.logger, authenticating.InvalidLoginErr, http.StatusForbidden, w)
-			return
-		}
+	if err != nil && err.Error() != mongodb.ErrNoAccountWasFound.Error() {
 		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
 		return
 	}
 
 	var token authenticating.Token
-	token, err = h.service.Sign(ctx, login, account.Secret, account.ID)
+	token, err = h.service.Sign(ctx, login, account.Secret, account.ID, authenticating.Role(account.Role))
 	if err != nil {
-		if err.Error() == authenticating.InvalidLoginErr.Error() {
+		switch err.Error() {
+		case authenticating.InvalidLoginErr.Error():
 			rest.SetJSONError(h.logger, authenticating.InvalidLoginErr, http.StatusForbidden, w)
-			return
+		case authenticating.ErrLoginLocked.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_locked", http.StatusTooManyRequests, w)
+		case authenticating.ErrLoginBackoff.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_backoff", http.StatusTooManyRequests, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
 		}
-		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
 		return
 	}
 
 	w.Header().Set("Content-Type", rest.DefaultContentType)
-	_ = json.NewEncoder(w).Encode(authenticating.Token{Digest: token.Digest})
+	// accounts enrolled in TOTP get only a challenge, completed through LoginTOTP
+	_ = json.NewEncoder(w).Encode(authenticating.Token{Digest: token.Digest, RefreshToken: token.RefreshToken, TOTPChallenge: token.TOTPChallenge})
+}
+
+// clientIP returns the host of the remote address of r, or the whole address when it has no port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
@@ -36,6 +36,9 @@ func TestLogin(t *testing.T) {
 		ID:       &tokenOID,
 		ClientID: account.ID,
 		Digest:   "e4af98as986a96f84af.d8a694f6a5f1sa86f1a98g.4da89s4fda98f498ga",
+		FamilyID: "5f8f8ccb30a1cd7511c5cb70",
+		// refresh tokens are 32 random bytes in base64url
+		RefreshToken: "x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ",
 	}
 
 	tt := []struct {
@@ -55,7 +58,19 @@ func TestLogin(t *testing.T) {
 			authService: &aum.MockService{
 				Token: token,
 			},
-			expectedResponse: fmt.Sprintf(`{"token":"%s"}`, token.Digest),
+			expectedResponse: fmt.Sprintf(`{"token":"%s","refresh_token":"%s"}`, token.Digest, token.RefreshToken),
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:        "When account is enrolled in TOTP and the login needs a second step",
+			reqBodyJSON: fmt.Sprintf(`{"cpf":"%s","secret":"%s"}`, account.CPF, account.Secret),
+			listingService: &lm.MockService{
+				Account: account,
+			},
+			authService: &aum.MockService{
+				Token: authenticating.Token{TOTPChallenge: "Jc2nQm0bV8yXh4Lr7tPq1ZsW9eKd3uAf6gHi5oNj2Ry"},
+			},
+			expectedResponse: `{"totp_challenge":"Jc2nQm0bV8yXh4Lr7tPq1ZsW9eKd3uAf6gHi5oNj2Ry"}`,
 			expectedStatus:   http.StatusOK,
 		},
 		{
@@ -64,6 +79,9 @@ func TestLogin(t *testing.T) {
 			listingService: &lm.MockService{
 				Err: mongodb.ErrNoAccountWasFound,
 			},
+			authService: &aum.MockService{
+				Err: authenticating.InvalidLoginErr,
+			},
 			expectedResponse: `{"status_code":403,"message":"it seems your login credentials are invalid, verify them and try again"}`,
 			expectedStatus:   http.StatusForbidden,
 		},
@@ -79,6 +97,30 @@ func TestLogin(t *testing.T) {
 			expectedResponse: `{"status_code":403,"message":"it seems your login credentials are invalid, verify them and try again"}`,
 			expectedStatus:   http.StatusForbidden,
 		},
+		{
+			name:        "When the cpf is locked out after too many failed attempts",
+			reqBodyJSON: fmt.Sprintf(`{"cpf":"%s","secret":"%s"}`, account.CPF, account.Secret),
+			listingService: &lm.MockService{
+				Account: account,
+			},
+			authService: &aum.MockService{
+				Err: authenticating.ErrLoginLocked,
+			},
+			expectedResponse: `{"status_code":429,"message":"login is locked after too many failed attempts, try again later or ask an operator to unlock it","code":"login_locked"}`,
+			expectedStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:        "When the cpf is backing off after recent failed attempts",
+			reqBodyJSON: fmt.Sprintf(`{"cpf":"%s","secret":"%s"}`, account.CPF, account.Secret),
+			listingService: &lm.MockService{
+				Account: account,
+			},
+			authService: &aum.MockService{
+				Err: authenticating.ErrLoginBackoff,
+			},
+			expectedResponse: `{"status_code":429,"message":"too many failed login attempts, wait a moment before trying again","code":"login_backoff"}`,
+			expectedStatus:   http.StatusTooManyRequests,
+		},
 		{
 			name:             "When payload is invalid",
 			reqBodyJSON:      fmt.Sprintf(`{"cpf":"%s","secret":123498}`, account.CPF),
@@ -121,12 +163,24 @@ func TestLogin(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest(http.MethodPost, "/login", jsonBuffer)
+			r.Header.Set("User-Agent", "transfer-app/1.0")
 
 			handler.Login(w, r)
 
 			if w.Code != tc.expectedStatus {
 				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
 			}
+			if tc.authService != nil && tc.authService.Login.CPF != "" {
+				if tc.authService.Login.IP != "192.0.2.1" {
+					t.Errorf("Expected login to be signed from the client ip; got %q", tc.authService.Login.IP)
+				}
+				if tc.authService.Login.UserAgent != "transfer-app/1.0" {
+					t.Errorf("Expected login to be signed with the user agent of the request; got %q", tc.authService.Login.UserAgent)
+				}
+				if tc.authService.SecretDigest != tc.listingService.Account.Secret {
+					t.Errorf("Expected login to be signed with secret digest %q; got %q", tc.listingService.Account.Secret, tc.authService.SecretDigest)
+				}
+			}
 
 			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
 		})
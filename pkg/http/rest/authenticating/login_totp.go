@@ -0,0 +1,44 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+// LoginTOTP takes the second step of the login of an account enrolled in TOTP, exchanging the challenge of the
+// first one and a TOTP or recovery code for tokens
+func (h Handler) LoginTOTP(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+
+	var login authenticating.TOTPLogin
+	if err := decoder.Decode(&login); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+	login.IP = clientIP(r)
+	login.UserAgent = r.UserAgent()
+
+	token, err := h.service.SignTOTP(ctx, login)
+	if err != nil {
+		switch err.Error() {
+		case authenticating.ErrInvalidLoginChallenge.Error():
+			rest.SetJSONError(h.logger, err, http.StatusUnauthorized, w)
+		case authenticating.ErrInvalidTOTPCode.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "invalid_totp_code", http.StatusForbidden, w)
+		case authenticating.ErrLoginLocked.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_locked", http.StatusTooManyRequests, w)
+		case authenticating.ErrLoginBackoff.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_backoff", http.StatusTooManyRequests, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(authenticating.Token{Digest: token.Digest, RefreshToken: token.RefreshToken})
+}
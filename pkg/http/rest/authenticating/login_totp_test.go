@@ -0,0 +1,97 @@
+package authenticating
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoginTOTP(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	token := authenticating.Token{
+		Digest:       "e4af98as986a96f84af.d8a694f6a5f1sa86f1a98g.4da89s4fda98f498ga",
+		RefreshToken: "x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ",
+	}
+	challenge := "Jc2nQm0bV8yXh4Lr7tPq1ZsW9eKd3uAf6gHi5oNj2Ry"
+
+	tt := []struct {
+		name             string
+		reqBodyJSON      string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:             "When code completes the login",
+			reqBodyJSON:      fmt.Sprintf(`{"totp_challenge":"%s","code":"123456"}`, challenge),
+			authService:      &aum.MockService{Token: token},
+			expectedResponse: fmt.Sprintf(`{"token":"%s","refresh_token":"%s"}`, token.Digest, token.RefreshToken),
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When challenge is invalid or expired",
+			reqBodyJSON:      fmt.Sprintf(`{"totp_challenge":"%s","code":"123456"}`, challenge),
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidLoginChallenge},
+			expectedResponse: `{"status_code":401,"message":"login challenge is invalid or expired, login again"}`,
+			expectedStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:             "When code is invalid",
+			reqBodyJSON:      fmt.Sprintf(`{"totp_challenge":"%s","code":"654321"}`, challenge),
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidTOTPCode},
+			expectedResponse: `{"status_code":403,"message":"two-factor authentication code is invalid or was already used","code":"invalid_totp_code"}`,
+			expectedStatus:   http.StatusForbidden,
+		},
+		{
+			name:             "When the cpf is locked out after too many wrong codes",
+			reqBodyJSON:      fmt.Sprintf(`{"totp_challenge":"%s","code":"654321"}`, challenge),
+			authService:      &aum.MockService{Err: authenticating.ErrLoginLocked},
+			expectedResponse: `{"status_code":429,"message":"login is locked after too many failed attempts, try again later or ask an operator to unlock it","code":"login_locked"}`,
+			expectedStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:             "When payload is invalid",
+			reqBodyJSON:      fmt.Sprintf(`{"totp_challenge":"%s","code":123456}`, challenge),
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":400,"message":"Invalid TOTPLogin entity: expected type string, got number at field code"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When unexpected errors occurs",
+			reqBodyJSON:      fmt.Sprintf(`{"totp_challenge":"%s","code":"123456"}`, challenge),
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/login/totp", bytes.NewBufferString(tc.reqBodyJSON))
+
+			handler.LoginTOTP(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus != http.StatusBadRequest && (tc.authService.TOTPLogin.Challenge != challenge || tc.authService.TOTPLogin.IP != "192.0.2.1") {
+				t.Errorf("Expected challenge %s to be signed from the client ip; got %v", challenge, tc.authService.TOTPLogin)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package authenticating
+
+import (
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Logout revokes the token the request was authenticated with, ending its session
+func (h Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tokenID := ctx.Value(pkg.TokenID).(primitive.ObjectID)
+
+	if err := h.service.Logout(ctx, tokenID); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package authenticating
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLogout(t *testing.T) {
+	tokenOID := primitive.NewObjectID()
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When token is revoked",
+			authService:    &aum.MockService{},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When fails to revoke token",
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.TokenID, tokenOID))
+
+			handler.Logout(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			if tc.authService.LoggedOut != tokenOID {
+				t.Errorf("Expected token %s to be revoked, got %s", tokenOID.Hex(), tc.authService.LoggedOut.Hex())
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+var errMalformedTokenRequest = errors.New("token request must be form encoded")
+
+// oauthTokenResponse is the successful access token response of RFC 6749
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// OAuthToken implements the client_credentials grant of RFC 6749, API clients authenticating either through HTTP
+// Basic or through the client_id and client_secret form parameters. Failures carry the error codes of the RFC
+func (h Handler) OAuthToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := r.ParseForm(); err != nil {
+		rest.SetJSONErrorWithCode(h.logger, errMalformedTokenRequest, "invalid_request", http.StatusBadRequest, w)
+		return
+	}
+
+	credentials := authenticating.ClientCredentials{
+		GrantType: r.PostForm.Get("grant_type"),
+		Scope:     r.PostForm.Get("scope"),
+	}
+	clientID, clientSecret, basic := r.BasicAuth()
+	if !basic {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	credentials.ClientID, credentials.ClientSecret = clientID, clientSecret
+
+	token, err := h.service.SignClient(ctx, credentials)
+	if err != nil {
+		switch err.Error() {
+		case authenticating.ErrInvalidClient.Error():
+			if basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="transfer-api"`)
+			}
+			rest.SetJSONErrorWithCode(h.logger, err, "invalid_client", http.StatusUnauthorized, w)
+		case authenticating.ErrUnsupportedGrantType.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "unsupported_grant_type", http.StatusBadRequest, w)
+		case authenticating.ErrInvalidScope.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "invalid_scope", http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	_ = json.NewEncoder(w).Encode(oauthTokenResponse{
+		AccessToken: token.Digest,
+		TokenType:   bearerAuthType,
+		ExpiresIn:   int64(time.Until(token.ExpiresAt).Round(time.Second).Seconds()),
+		Scope:       authenticating.JoinScopes(token.Scopes),
+	})
+}
@@ -0,0 +1,123 @@
+package authenticating
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+)
+
+func TestOAuthToken(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	token := authenticating.Token{
+		ClientID:  "5f8b1c2d3e4f5a6b7c8d9e0f",
+		Kind:      authenticating.ClientToken,
+		Scopes:    []authenticating.Scope{authenticating.ScopeAccountsRead, authenticating.ScopeLimitsManage},
+		Digest:    "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30",
+		ExpiresAt: time.Now().Add(time.Minute * 30),
+	}
+
+	tt := []struct {
+		name                string
+		body                string
+		basicID, basicPass  string
+		authService         *aum.MockService
+		expectedCredentials authenticating.ClientCredentials
+		expectedResponse    string
+		expectedStatus      int
+	}{
+		{
+			name:        "When credentials are in the form",
+			body:        "grant_type=client_credentials&client_id=5f8b1c2d3e4f5a6b7c8d9e0f&client_secret=s3cr3t",
+			authService: &aum.MockService{Token: token},
+			expectedCredentials: authenticating.ClientCredentials{
+				GrantType:    authenticating.ClientCredentialsGrant,
+				ClientID:     "5f8b1c2d3e4f5a6b7c8d9e0f",
+				ClientSecret: "s3cr3t",
+			},
+			expectedResponse: `{"access_token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30","token_type":"Bearer","expires_in":1800,"scope":"accounts:read limits:manage"}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:        "When credentials are in basic auth",
+			body:        "grant_type=client_credentials&scope=accounts%3Aread",
+			basicID:     "5f8b1c2d3e4f5a6b7c8d9e0f",
+			basicPass:   "s3cr3t",
+			authService: &aum.MockService{Token: token},
+			expectedCredentials: authenticating.ClientCredentials{
+				GrantType:    authenticating.ClientCredentialsGrant,
+				ClientID:     "5f8b1c2d3e4f5a6b7c8d9e0f",
+				ClientSecret: "s3cr3t",
+				Scope:        "accounts:read",
+			},
+			expectedResponse: `{"access_token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.e30","token_type":"Bearer","expires_in":1800,"scope":"accounts:read limits:manage"}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When client authentication fails",
+			body:             "grant_type=client_credentials&client_id=5f8b1c2d3e4f5a6b7c8d9e0f&client_secret=wrong",
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidClient},
+			expectedResponse: `{"status_code":401,"message":"client authentication failed, verify the client id and secret","code":"invalid_client"}`,
+			expectedStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:             "When grant type is not supported",
+			body:             "grant_type=password",
+			authService:      &aum.MockService{Err: authenticating.ErrUnsupportedGrantType},
+			expectedResponse: `{"status_code":400,"message":"grant type is not supported, only client_credentials is","code":"unsupported_grant_type"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When scope exceeds the ones of the client",
+			body:             "grant_type=client_credentials&scope=reversals%3Amanage",
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidScope},
+			expectedResponse: `{"status_code":400,"message":"requested scope is unknown or exceeds the scopes of the client","code":"invalid_scope"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When fails to sign the token",
+			body:             "grant_type=client_credentials",
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.basicID != "" {
+				r.SetBasicAuth(tc.basicID, tc.basicPass)
+			}
+
+			handler.OAuthToken(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusOK {
+				if tc.authService.Credentials != tc.expectedCredentials {
+					t.Errorf("Expected credentials %+v; got %+v", tc.expectedCredentials, tc.authService.Credentials)
+				}
+				if w.Header().Get("Cache-Control") != "no-store" {
+					t.Errorf("Expected token not to be cached; got Cache-Control %q", w.Header().Get("Cache-Control"))
+				}
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+type passwordResetRequest struct {
+	CPF string `json:"cpf"`
+}
+
+type resetPasswordRequest struct {
+	Token     string `json:"token"`
+	NewSecret string `json:"new_secret"`
+}
+
+// RequestPasswordReset sends a reset token to the owner of the account of the cpf, being accepted even when there's
+// no such account or the token couldn't be sent, so that cpfs can't be told apart. Requests are throttled by cpf and
+// IP before the account is looked up, so the throttle doesn't tell them apart either
+func (h Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+
+	var request passwordResetRequest
+	if err := decoder.Decode(&request); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	if err := h.service.ThrottlePasswordReset(ctx, request.CPF, clientIP(r)); err != nil {
+		if err.Error() == authenticating.ErrPasswordResetThrottled.Error() {
+			rest.SetJSONErrorWithCode(h.logger, err, "password_reset_throttled", http.StatusTooManyRequests, w)
+			return
+		}
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	account, err := h.listingService.GetAccountByCPF(ctx, request.CPF)
+	switch {
+	case err == nil:
+		recipient := authenticating.Recipient{AccountID: account.ID, Name: account.Name, CPF: account.CPF}
+		if err = h.service.RequestPasswordReset(ctx, recipient); err != nil {
+			h.logger.Errorf("Err %v when requesting password reset of account %s, answered as accepted anyway", err, account.ID)
+		}
+	case err.Error() != mongodb.ErrNoAccountWasFound.Error():
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword sets a new password with a reset token, revoking every session of the account
+func (h Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+
+	var request resetPasswordRequest
+	if err := decoder.Decode(&request); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	if err := h.service.ResetPassword(ctx, request.Token, request.NewSecret); err != nil {
+		switch err.Error() {
+		case authenticating.ErrInvalidResetToken.Error():
+			rest.SetJSONError(h.logger, err, http.StatusUnauthorized, w)
+		case authenticating.ErrPasswordPolicy.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
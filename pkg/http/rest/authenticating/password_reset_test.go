@@ -0,0 +1,152 @@
+package authenticating
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRequestPasswordReset(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	account := listing.Account{ID: "hg94gs8a41v685s4g89", Name: "Alice", CPF: "11111111030"}
+
+	tt := []struct {
+		name              string
+		listingService    *lm.MockService
+		authService       *aum.MockService
+		expectedRecipient authenticating.Recipient
+		expectedResponse  string
+		expectedStatus    int
+	}{
+		{
+			name:              "When the reset is requested",
+			listingService:    &lm.MockService{Account: account},
+			authService:       &aum.MockService{},
+			expectedRecipient: authenticating.Recipient{AccountID: account.ID, Name: account.Name, CPF: account.CPF},
+			expectedStatus:    http.StatusAccepted,
+		},
+		{
+			name:           "When no account was found with the given cpf",
+			listingService: &lm.MockService{Err: mongodb.ErrNoAccountWasFound},
+			authService:    &aum.MockService{},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:              "When fails to notify the reset",
+			listingService:    &lm.MockService{Account: account},
+			authService:       &aum.MockService{Err: errors.New("foo")},
+			expectedRecipient: authenticating.Recipient{AccountID: account.ID, Name: account.Name, CPF: account.CPF},
+			expectedStatus:    http.StatusAccepted,
+		},
+		{
+			name:             "When too many resets were requested",
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{ThrottleErr: authenticating.ErrPasswordResetThrottled},
+			expectedResponse: `{"status_code":429,"message":"too many password resets were requested, try again later","code":"password_reset_throttled"}`,
+			expectedStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:             "When fails to throttle the reset",
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{ThrottleErr: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, tc.listingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/password-reset", bytes.NewBufferString(`{"cpf":"11111111030"}`))
+
+			handler.RequestPasswordReset(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.authService.ResetRecipient != tc.expectedRecipient {
+				t.Errorf("Expected reset to be sent to %v; got %v", tc.expectedRecipient, tc.authService.ResetRecipient)
+			}
+			if tc.authService.ThrottledCPF != "11111111030" || tc.authService.ThrottledIP != "192.0.2.1" {
+				t.Errorf("Expected reset of cpf 11111111030 from 192.0.2.1 to be throttled; got %s from %s", tc.authService.ThrottledCPF, tc.authService.ThrottledIP)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name             string
+		body             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When password is reset",
+			body:           `{"token":"resetToken","new_secret":"brandNew2"}`,
+			authService:    &aum.MockService{},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When token is invalid",
+			body:             `{"token":"resetToken","new_secret":"brandNew2"}`,
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidResetToken},
+			expectedResponse: `{"status_code":401,"message":"password reset token is invalid or expired, request a new one"}`,
+			expectedStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:             "When new password doesn't follow the policy",
+			body:             `{"token":"resetToken","new_secret":"short"}`,
+			authService:      &aum.MockService{Err: authenticating.ErrPasswordPolicy},
+			expectedResponse: `{"status_code":400,"message":"password must have from 8 to 70 characters, letters and digits among them, and must not contain the cpf"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When body has a wrong type",
+			body:             `{"token":1}`,
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":400,"message":"Invalid resetPasswordRequest entity: expected type string, got number at field token"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, &lm.MockService{})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/password-reset/confirm", bytes.NewBufferString(tc.body))
+
+			handler.ResetPassword(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusNoContent && (tc.authService.ResetToken != "resetToken" || tc.authService.NewSecret != "brandNew2") {
+				t.Errorf("Expected reset with token resetToken and secret brandNew2; got %s and %s", tc.authService.ResetToken, tc.authService.NewSecret)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package authenticating
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+// SetPIN enables, or changes, the transaction PIN of the account of the request, which must know its password
+func (h Handler) SetPIN(w http.ResponseWriter, r *http.Request) {
+	h.changePIN(w, r, h.service.SetPIN)
+}
+
+// DisablePIN disables the transaction PIN of the account of the request, which must know its password
+func (h Handler) DisablePIN(w http.ResponseWriter, r *http.Request) {
+	h.changePIN(w, r, h.service.DisablePIN)
+}
+
+// changePIN decodes the pin change of the request, completing it with the account of the request, and applies it
+// through change
+func (h Handler) changePIN(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, change authenticating.PINChange, secretDigest string) error) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+
+	var pinChange authenticating.PINChange
+	if err := decoder.Decode(&pinChange); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	account, err := h.listingService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	pinChange.AccountID = accountID
+	pinChange.CPF = account.CPF
+	pinChange.IP = clientIP(r)
+
+	if err = change(ctx, pinChange, account.Secret); err != nil {
+		switch err.Error() {
+		case authenticating.ErrWrongPassword.Error():
+			rest.SetJSONError(h.logger, err, http.StatusForbidden, w)
+		case authenticating.ErrPINPolicy.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		case authenticating.ErrPINNotSet.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		case authenticating.ErrLoginLocked.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_locked", http.StatusTooManyRequests, w)
+		case authenticating.ErrLoginBackoff.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "login_backoff", http.StatusTooManyRequests, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
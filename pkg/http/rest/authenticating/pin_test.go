@@ -0,0 +1,160 @@
+package authenticating
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetPIN(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	account := listing.Account{ID: "hg94gs8a41v685s4g89", CPF: "11111111030", Secret: "$2a$10$digest"}
+
+	tt := []struct {
+		name             string
+		body             string
+		listingService   *lm.MockService
+		authService      *aum.MockService
+		expectedChange   authenticating.PINChange
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When pin is set",
+			body:           `{"secret":"current1","pin":"4821"}`,
+			listingService: &lm.MockService{Account: account},
+			authService:    &aum.MockService{},
+			expectedChange: authenticating.PINChange{
+				Secret:    "current1",
+				PIN:       "4821",
+				AccountID: account.ID,
+				CPF:       account.CPF,
+				IP:        "192.0.2.1",
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When password is wrong",
+			body:             `{"secret":"current2","pin":"4821"}`,
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{Err: authenticating.ErrWrongPassword},
+			expectedResponse: `{"status_code":403,"message":"current password doesn't match, verify it and try again"}`,
+			expectedStatus:   http.StatusForbidden,
+		},
+		{
+			name:             "When pin doesn't follow the policy",
+			body:             `{"secret":"current1","pin":"48"}`,
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{Err: authenticating.ErrPINPolicy},
+			expectedResponse: `{"status_code":400,"message":"transaction pin must have from 4 to 6 digits"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When body has a wrong type",
+			body:             `{"secret":"current1","pin":4821}`,
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":400,"message":"Invalid PINChange entity: expected type string, got number at field pin"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When fails to get the account",
+			body:             `{"secret":"current1","pin":"4821"}`,
+			listingService:   &lm.MockService{Err: errors.New("foo")},
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, tc.listingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/pin", bytes.NewBufferString(tc.body))
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, account.ID))
+
+			handler.SetPIN(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusNoContent {
+				if tc.authService.PINChange != tc.expectedChange {
+					t.Errorf("Expected change %v; got %v", tc.expectedChange, tc.authService.PINChange)
+				}
+				if tc.authService.SecretDigest != account.Secret {
+					t.Errorf("Expected secret digest %s; got %s", account.Secret, tc.authService.SecretDigest)
+				}
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
+
+func TestDisablePIN(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	account := listing.Account{ID: "hg94gs8a41v685s4g89", CPF: "11111111030", Secret: "$2a$10$digest"}
+
+	tt := []struct {
+		name             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When pin is disabled",
+			authService:    &aum.MockService{},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When pin is not enabled",
+			authService:      &aum.MockService{Err: authenticating.ErrPINNotSet},
+			expectedResponse: `{"status_code":404,"message":"transaction pin is not enabled for this account"}`,
+			expectedStatus:   http.StatusNotFound,
+		},
+		{
+			name:             "When the cpf is backing off",
+			authService:      &aum.MockService{Err: authenticating.ErrLoginBackoff},
+			expectedResponse: `{"status_code":429,"message":"too many failed login attempts, wait a moment before trying again","code":"login_backoff"}`,
+			expectedStatus:   http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, &lm.MockService{Account: account})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/pin", bytes.NewBufferString(`{"secret":"current1"}`))
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, account.ID))
+
+			handler.DisablePIN(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.authService.PINChange.Secret != "current1" || tc.authService.PINChange.AccountID != account.ID {
+				t.Errorf("Expected pin of %s to be disabled with its password; got %v", account.ID, tc.authService.PINChange)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
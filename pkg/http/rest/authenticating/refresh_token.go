@@ -0,0 +1,39 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+
+	var request refreshRequest
+	if err := decoder.Decode(&request); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	token, err := h.service.Refresh(ctx, request.RefreshToken)
+	if err != nil {
+		switch err.Error() {
+		case authenticating.ErrInvalidRefreshToken.Error(),
+			authenticating.ErrRefreshTokenReused.Error():
+			rest.SetJSONError(h.logger, err, http.StatusUnauthorized, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(authenticating.Token{Digest: token.Digest, RefreshToken: token.RefreshToken})
+}
@@ -0,0 +1,99 @@
+package authenticating
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRefreshToken(t *testing.T) {
+	tokenOID := primitive.NewObjectID()
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name                 string
+		reqBodyJSON          string
+		authService          *aum.MockService
+		expectedRefreshToken string
+		expectedResponse     string
+		expectedStatus       int
+	}{
+		{
+			name:        "When refresh token is exchanged for new tokens",
+			reqBodyJSON: `{"refresh_token":"x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ"}`,
+			authService: &aum.MockService{
+				Token: authenticating.Token{
+					ID:           &tokenOID,
+					ClientID:     "hg94gs8a41v685s4g89",
+					Digest:       "e4af98as986a96f84af.d8a694f6a5f1sa86f1a98g.4da89s4fda98f498ga",
+					RefreshToken: "Jq3gF8e1Dc5Hy2Tp6N4m0rL8qw9k2b3z7VZ1S0qV7x",
+				},
+			},
+			expectedRefreshToken: "x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ",
+			expectedResponse:     `{"token":"e4af98as986a96f84af.d8a694f6a5f1sa86f1a98g.4da89s4fda98f498ga","refresh_token":"Jq3gF8e1Dc5Hy2Tp6N4m0rL8qw9k2b3z7VZ1S0qV7x"}`,
+			expectedStatus:       http.StatusOK,
+		},
+		{
+			name:                 "When refresh token was already used",
+			reqBodyJSON:          `{"refresh_token":"x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ"}`,
+			authService:          &aum.MockService{Err: authenticating.ErrRefreshTokenReused},
+			expectedRefreshToken: "x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ",
+			expectedResponse:     `{"status_code":401,"message":"refresh token was already used, every token of its session was revoked, login again"}`,
+			expectedStatus:       http.StatusUnauthorized,
+		},
+		{
+			name:                 "When refresh token is invalid",
+			reqBodyJSON:          `{"refresh_token":"foo"}`,
+			authService:          &aum.MockService{Err: authenticating.ErrInvalidRefreshToken},
+			expectedRefreshToken: "foo",
+			expectedResponse:     `{"status_code":401,"message":"refresh token is invalid or expired, login again"}`,
+			expectedStatus:       http.StatusUnauthorized,
+		},
+		{
+			name:             "When payload is invalid",
+			reqBodyJSON:      `{"refresh_token":123}`,
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":400,"message":"Invalid refreshRequest entity: expected type string, got number at field refresh_token"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:                 "When unexpected errors occurs at refresh",
+			reqBodyJSON:          `{"refresh_token":"x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ"}`,
+			authService:          &aum.MockService{Err: errors.New("foo unexpected")},
+			expectedRefreshToken: "x7Vq0S1ZV7z3b2k9wq8Lr0m4N6pT2yH5cD1eF8gJ3aQ",
+			expectedResponse:     `{"status_code":500,"message":"foo unexpected"}`,
+			expectedStatus:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBufferString(tc.reqBodyJSON))
+
+			handler.RefreshToken(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			if tc.authService.RefreshToken != tc.expectedRefreshToken {
+				t.Errorf("Expected refresh token %q to be exchanged, got %q", tc.expectedRefreshToken, tc.authService.RefreshToken)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sessions lists the active sessions of the account of the request
+func (h Handler) Sessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+	tokenID := ctx.Value(pkg.TokenID).(primitive.ObjectID)
+
+	sessions, err := h.service.Sessions(ctx, accountID, tokenID)
+	if err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession revokes the session in the path, which must be of the account of the request
+func (h Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+	id := httprouter.ParamsFromContext(ctx).ByName("id")
+
+	if err := h.service.RevokeSession(ctx, accountID, id); err != nil {
+		switch err.Error() {
+		case authenticating.ErrSessionNotFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeSessions revokes every session of the account of the request, its own included
+func (h Handler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+
+	if err := h.service.RevokeSessions(ctx, accountID); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,172 @@
+package authenticating
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSessions(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	accountID := "5f8b1c2d3e4f5a6b7c8d9e0f"
+	tokenID := primitive.NewObjectID()
+	issuedAt := time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name: "When sessions are listed",
+			authService: &aum.MockService{SessionList: []authenticating.Session{
+				{ID: "5f8f8ccb30a1cd7511c5cb70", IssuedAt: issuedAt, UserAgent: "transfer-app/1.0", IP: "203.0.113.7", ExpiresAt: issuedAt.Add(time.Minute * 30), Current: true},
+				{ID: "5f8f8ccb30a1cd7511c5cb71", IssuedAt: issuedAt.Add(-time.Hour), ExpiresAt: issuedAt.Add(-time.Minute * 30)},
+			}},
+			expectedResponse: `[{"id":"5f8f8ccb30a1cd7511c5cb70","issued_at":"2020-11-02T10:00:00Z","user_agent":"transfer-app/1.0","ip":"203.0.113.7","expires_at":"2020-11-02T10:30:00Z","current":true},{"id":"5f8f8ccb30a1cd7511c5cb71","issued_at":"2020-11-02T09:00:00Z","expires_at":"2020-11-02T09:30:00Z","current":false}]`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When fails to list sessions",
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+			ctx := context.WithValue(r.Context(), pkg.AccountID, accountID)
+			r = r.WithContext(context.WithValue(ctx, pkg.TokenID, tokenID))
+
+			handler.Sessions(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.authService.SessionsOf != accountID || tc.authService.CurrentToken != tokenID {
+				t.Errorf("Expected sessions of %s by token %s; got %s by %s", accountID, tokenID.Hex(), tc.authService.SessionsOf, tc.authService.CurrentToken.Hex())
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	accountID := "5f8b1c2d3e4f5a6b7c8d9e0f"
+	sessionID := "5f8f8ccb30a1cd7511c5cb70"
+
+	tt := []struct {
+		name             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When session is revoked",
+			authService:    &aum.MockService{},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When session is not of the account",
+			authService:      &aum.MockService{Err: authenticating.ErrSessionNotFound},
+			expectedResponse: `{"status_code":404,"message":"no active session was found with the given id"}`,
+			expectedStatus:   http.StatusNotFound,
+		},
+		{
+			name:             "When fails to revoke the session",
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/sessions/"+sessionID, nil)
+			ctx := context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: sessionID}})
+			r = r.WithContext(context.WithValue(ctx, pkg.AccountID, accountID))
+
+			handler.RevokeSession(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.authService.SessionsOf != accountID || tc.authService.RevokedSession != sessionID {
+				t.Errorf("Expected session %s of %s to be revoked; got %s of %s", sessionID, accountID, tc.authService.RevokedSession, tc.authService.SessionsOf)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	accountID := "5f8b1c2d3e4f5a6b7c8d9e0f"
+
+	tt := []struct {
+		name             string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When every session is revoked",
+			authService:    &aum.MockService{},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When fails to revoke the sessions",
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/sessions", nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, accountID))
+
+			handler.RevokeSessions(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.authService.SessionsOf != accountID {
+				t.Errorf("Expected sessions of %s to be revoked; got %s", accountID, tc.authService.SessionsOf)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package authenticating
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+)
+
+type confirmTOTPRequest struct {
+	Code string `json:"code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP starts the TOTP enrollment of the account of the request, answering what its authenticator app needs
+func (h Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+
+	account, err := h.listingService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	enrollment, err := h.service.EnrollTOTP(ctx, accountID, account.CPF)
+	if err != nil {
+		switch err.Error() {
+		case authenticating.ErrTOTPAlreadyEnrolled.Error():
+			rest.SetJSONError(h.logger, err, http.StatusConflict, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(enrollment)
+}
+
+// ConfirmTOTP confirms the TOTP enrollment of the account of the request with its first code, answering the
+// recovery codes, which are never shown again
+func (h Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+
+	var request confirmTOTPRequest
+	if err := decoder.Decode(&request); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(ctx, accountID, request.Code)
+	if err != nil {
+		switch err.Error() {
+		case authenticating.ErrInvalidTOTPCode.Error(), authenticating.ErrTOTPNotEnrolled.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		case authenticating.ErrTOTPAlreadyEnrolled.Error():
+			rest.SetJSONError(h.logger, err, http.StatusConflict, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(recoveryCodesResponse{RecoveryCodes: codes})
+}
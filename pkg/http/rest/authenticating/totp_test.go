@@ -0,0 +1,143 @@
+package authenticating
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEnrollTOTP(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	enrollment := authenticating.TOTPEnrollment{
+		Secret: "JBSWY3DPEHPK3PXP",
+		URI:    "otpauth://totp/transfer-api:11111111030?algorithm=SHA1&digits=6&issuer=transfer-api&period=30&secret=JBSWY3DPEHPK3PXP",
+	}
+
+	tt := []struct {
+		name             string
+		listingService   *lm.MockService
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:             "When enrollment starts",
+			listingService:   &lm.MockService{Account: listing.Account{ID: "hg94gs8a41v685s4g89", CPF: "11111111030"}},
+			authService:      &aum.MockService{Enrollment: enrollment},
+			expectedResponse: `{"secret":"JBSWY3DPEHPK3PXP","otpauth_uri":"otpauth://totp/transfer-api:11111111030?algorithm=SHA1\u0026digits=6\u0026issuer=transfer-api\u0026period=30\u0026secret=JBSWY3DPEHPK3PXP"}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When account is already enrolled",
+			listingService:   &lm.MockService{Account: listing.Account{ID: "hg94gs8a41v685s4g89", CPF: "11111111030"}},
+			authService:      &aum.MockService{Err: authenticating.ErrTOTPAlreadyEnrolled},
+			expectedResponse: `{"status_code":409,"message":"two-factor authentication is already enabled for this account"}`,
+			expectedStatus:   http.StatusConflict,
+		},
+		{
+			name:             "When fails to get the account",
+			listingService:   &lm.MockService{Err: errors.New("foo")},
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, tc.listingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/totp", nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "hg94gs8a41v685s4g89"))
+
+			handler.EnrollTOTP(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name             string
+		reqBodyJSON      string
+		authService      *aum.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:             "When enrollment is confirmed",
+			reqBodyJSON:      `{"code":"123456"}`,
+			authService:      &aum.MockService{RecoveryCodes: []string{"abcde-fghij", "klmno-pqrst"}},
+			expectedResponse: `{"recovery_codes":["abcde-fghij","klmno-pqrst"]}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When code is invalid",
+			reqBodyJSON:      `{"code":"123456"}`,
+			authService:      &aum.MockService{Err: authenticating.ErrInvalidTOTPCode},
+			expectedResponse: `{"status_code":400,"message":"two-factor authentication code is invalid or was already used"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When enrollment was never started",
+			reqBodyJSON:      `{"code":"123456"}`,
+			authService:      &aum.MockService{Err: authenticating.ErrTOTPNotEnrolled},
+			expectedResponse: `{"status_code":400,"message":"two-factor authentication enrollment was not started for this account"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When account is already enrolled",
+			reqBodyJSON:      `{"code":"123456"}`,
+			authService:      &aum.MockService{Err: authenticating.ErrTOTPAlreadyEnrolled},
+			expectedResponse: `{"status_code":409,"message":"two-factor authentication is already enabled for this account"}`,
+			expectedStatus:   http.StatusConflict,
+		},
+		{
+			name:             "When payload is invalid",
+			reqBodyJSON:      `{"code":123456}`,
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":400,"message":"Invalid confirmTOTPRequest entity: expected type string, got number at field code"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/totp/confirm", bytes.NewBufferString(tc.reqBodyJSON))
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "hg94gs8a41v685s4g89"))
+
+			handler.ConfirmTOTP(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package authenticating
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// Unlock lifts the login lockout and backoff of the account in the path, on behalf of the operator or API client
+// requesting it
+func (h Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := httprouter.ParamsFromContext(ctx).ByName("id")
+	operatorID, ok := ctx.Value(pkg.AccountID).(string)
+	if !ok {
+		operatorID = ctx.Value(pkg.ClientID).(string)
+	}
+
+	account, err := h.listingService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		switch err.Error() {
+		case mongodb.ErrNoAccountWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	if err = h.service.Unlock(ctx, account.CPF, operatorID); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package authenticating
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/sirupsen/logrus"
+)
+
+func TestUnlock(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	operatorID := "5f8f8ccb30a1cd7511c5cb70"
+	account := listing.Account{ID: "hg94gs8a41v685s4g89", CPF: "11111111030"}
+
+	tt := []struct {
+		name             string
+		listingService   *lm.MockService
+		authService      *aum.MockService
+		actorKey         pkg.ContextKey
+		expectedCPF      string
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:           "When the login of the account is unlocked",
+			listingService: &lm.MockService{Account: account},
+			authService:    &aum.MockService{},
+			expectedCPF:    account.CPF,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "When the login of the account is unlocked by an API client",
+			listingService: &lm.MockService{Account: account},
+			authService:    &aum.MockService{},
+			actorKey:       pkg.ClientID,
+			expectedCPF:    account.CPF,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:             "When no account was found with the given id",
+			listingService:   &lm.MockService{Err: mongodb.ErrNoAccountWasFound},
+			authService:      &aum.MockService{},
+			expectedResponse: `{"status_code":404,"message":"no account was found with the given filter parameters"}`,
+			expectedStatus:   http.StatusNotFound,
+		},
+		{
+			name:             "When fails to unlock the login",
+			listingService:   &lm.MockService{Account: account},
+			authService:      &aum.MockService{Err: errors.New("foo")},
+			expectedCPF:      account.CPF,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.authService, tc.listingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/accounts/"+account.ID+"/unlock", nil)
+			ctx := context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: account.ID}})
+			if tc.actorKey == "" {
+				tc.actorKey = pkg.AccountID
+			}
+			r = r.WithContext(context.WithValue(ctx, tc.actorKey, operatorID))
+
+			handler.Unlock(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.authService.UnlockedCPF != tc.expectedCPF {
+				t.Errorf("Expected cpf %q to be unlocked, got %q", tc.expectedCPF, tc.authService.UnlockedCPF)
+			}
+			if tc.expectedCPF != "" && tc.authService.UnlockedBy != operatorID {
+				t.Errorf("Expected unlock to be on behalf of %s, got %s", operatorID, tc.authService.UnlockedBy)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
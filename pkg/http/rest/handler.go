@@ -1,12 +1,14 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
 	"github.com/sirupsen/logrus"
 )
@@ -15,47 +17,183 @@ const (
 	DefaultContentType = "application/json"
 )
 
+type contextKey string
+
+// persistenceReportKey is the context key of the PersistenceReport of a request
+const persistenceReportKey contextKey = "persistence_report"
+
+// PersistenceReport is what the handler of a request tells whoever wraps it, as the idempotency middleware, about
+// what its failure left persisted
+type PersistenceReport struct {
+	NothingPersisted bool
+}
+
 type AddingHandler interface {
 	CreateAccount(w http.ResponseWriter, r *http.Request)
 }
 
 type TransferringHandler interface {
 	MakeTransfer(w http.ResponseWriter, r *http.Request)
+	ReverseTransfer(w http.ResponseWriter, r *http.Request)
 }
 
 type AuthenticatingHandler interface {
 	Login(w http.ResponseWriter, r *http.Request)
-	Authenticate(next http.HandlerFunc) http.HandlerFunc
+	RefreshToken(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	JWKS(w http.ResponseWriter, r *http.Request)
+	Unlock(w http.ResponseWriter, r *http.Request)
+	LoginTOTP(w http.ResponseWriter, r *http.Request)
+	EnrollTOTP(w http.ResponseWriter, r *http.Request)
+	ConfirmTOTP(w http.ResponseWriter, r *http.Request)
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+	RequestPasswordReset(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+	SetPIN(w http.ResponseWriter, r *http.Request)
+	DisablePIN(w http.ResponseWriter, r *http.Request)
+	OAuthToken(w http.ResponseWriter, r *http.Request)
+	RegisterClient(w http.ResponseWriter, r *http.Request)
+	Introspect(w http.ResponseWriter, r *http.Request)
+	Sessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+	RevokeSessions(w http.ResponseWriter, r *http.Request)
+	// Authenticate serves next only to requests bearing a valid token that grants scope
+	Authenticate(scope authenticating.Scope, next http.HandlerFunc) http.HandlerFunc
+}
+
+type IdempotencyHandler interface {
+	Idempotent(next http.HandlerFunc) http.HandlerFunc
 }
 
 type ListingHandler interface {
-	GetBalanceByID(w http.ResponseWriter, r *http.Request, p httprouter.Params)
+	GetAccount(w http.ResponseWriter, r *http.Request)
+	GetBalanceByID(w http.ResponseWriter, r *http.Request)
 	ListAllAccounts(w http.ResponseWriter, r *http.Request)
 	GetUserTransfers(w http.ResponseWriter, r *http.Request)
+	GetTransfer(w http.ResponseWriter, r *http.Request)
+	GetStatement(w http.ResponseWriter, r *http.Request)
+}
+
+type SchedulingHandler interface {
+	ScheduleTransfer(w http.ResponseWriter, r *http.Request)
+	ListScheduledTransfers(w http.ResponseWriter, r *http.Request)
+	CancelScheduledTransfer(w http.ResponseWriter, r *http.Request)
+	CreateStandingOrder(w http.ResponseWriter, r *http.Request)
+	ListStandingOrders(w http.ResponseWriter, r *http.Request)
+	PauseStandingOrder(w http.ResponseWriter, r *http.Request)
+	ResumeStandingOrder(w http.ResponseWriter, r *http.Request)
+	CancelStandingOrder(w http.ResponseWriter, r *http.Request)
+}
+
+type LimitingHandler interface {
+	GetLimits(w http.ResponseWriter, r *http.Request)
+	LowerLimits(w http.ResponseWriter, r *http.Request)
+	GetAccountLimits(w http.ResponseWriter, r *http.Request)
+	SetAccountLimits(w http.ResponseWriter, r *http.Request)
+}
+
+type ReceiptingHandler interface {
+	GetReceipt(w http.ResponseWriter, r *http.Request)
+	VerifyReceipt(w http.ResponseWriter, r *http.Request)
 }
 
 type ErrorResponse struct {
 	StatusCode int    `json:"status_code"`
 	Message    string `json:"message"`
+	// Code identifies errors clients are expected to handle, as the limits a transfer may exceed
+	Code string `json:"code,omitempty"`
 }
 
 var log *logrus.Logger
 
-func Handler(logger *logrus.Entry, addingHandler AddingHandler, transferringHandler TransferringHandler, authenticatingHandler AuthenticatingHandler, listingHandler ListingHandler) http.Handler {
+func Handler(logger *logrus.Entry, addingHandler AddingHandler, transferringHandler TransferringHandler, authenticatingHandler AuthenticatingHandler, listingHandler ListingHandler, idempotencyHandler IdempotencyHandler, schedulingHandler SchedulingHandler, limitingHandler LimitingHandler, receiptingHandler ReceiptingHandler) http.Handler {
 	router := httprouter.New()
 	log = lgr.NewDefaultLogger()
+	// every route but the public ones states the scope the token of the request must grant
+	auth := authenticatingHandler.Authenticate
 	router.HandlerFunc(http.MethodPost, "/accounts", addingHandler.CreateAccount)
-	router.HandlerFunc(http.MethodGet, "/accounts", listingHandler.ListAllAccounts)
-	router.GET("/accounts/:id/balance", listingHandler.GetBalanceByID)
+	router.HandlerFunc(http.MethodGet, "/accounts", auth(authenticating.ScopeAccountsRead, listingHandler.ListAllAccounts))
+	// customers can only get their own account and balance, ScopeAccountsRead granting any other. GET /accounts/me
+	// is told apart by the handler, which takes me as the account of the token
+	router.HandlerFunc(http.MethodGet, "/accounts/:id", auth(authenticating.ScopeAccount, listingHandler.GetAccount))
+	router.HandlerFunc(http.MethodGet, "/accounts/:id/balance", auth(authenticating.ScopeAccount, listingHandler.GetBalanceByID))
+	router.HandlerFunc(http.MethodGet, "/accounts/:id/limits", auth(authenticating.ScopeLimitsManage, limitingHandler.GetAccountLimits))
+	router.HandlerFunc(http.MethodPut, "/accounts/:id/limits", auth(authenticating.ScopeLimitsManage, limitingHandler.SetAccountLimits))
+	router.HandlerFunc(http.MethodPost, "/accounts/:id/unlock", auth(authenticating.ScopeLoginsUnlock, authenticatingHandler.Unlock))
+	router.HandlerFunc(http.MethodPut, "/accounts/:id/password", onlyParam("id", "me", auth(authenticating.ScopeAccount, authenticatingHandler.ChangePassword)))
+	router.HandlerFunc(http.MethodGet, "/accounts/:id/statement", onlyParam("id", "me", auth(authenticating.ScopeAccount, listingHandler.GetStatement)))
 
 	router.HandlerFunc(http.MethodPost, "/login", authenticatingHandler.Login)
-	router.HandlerFunc(http.MethodPost, "/transfers", authenticatingHandler.Authenticate(transferringHandler.MakeTransfer))
-	router.HandlerFunc(http.MethodGet, "/transfers", listingHandler.GetUserTransfers)
+	router.HandlerFunc(http.MethodPost, "/login/totp", authenticatingHandler.LoginTOTP)
+	router.HandlerFunc(http.MethodPost, "/password-reset", authenticatingHandler.RequestPasswordReset)
+	router.HandlerFunc(http.MethodPost, "/password-reset/confirm", authenticatingHandler.ResetPassword)
+	router.HandlerFunc(http.MethodPost, "/totp", auth(authenticating.ScopeAccount, authenticatingHandler.EnrollTOTP))
+	router.HandlerFunc(http.MethodPost, "/totp/confirm", auth(authenticating.ScopeAccount, authenticatingHandler.ConfirmTOTP))
+	router.HandlerFunc(http.MethodPut, "/pin", auth(authenticating.ScopeAccount, authenticatingHandler.SetPIN))
+	router.HandlerFunc(http.MethodDelete, "/pin", auth(authenticating.ScopeAccount, authenticatingHandler.DisablePIN))
+	router.HandlerFunc(http.MethodPost, "/token/refresh", authenticatingHandler.RefreshToken)
+	router.HandlerFunc(http.MethodPost, "/logout", auth(authenticating.ScopeAccount, authenticatingHandler.Logout))
+	router.HandlerFunc(http.MethodGet, "/.well-known/jwks.json", authenticatingHandler.JWKS)
+	router.HandlerFunc(http.MethodPost, "/oauth/token", authenticatingHandler.OAuthToken)
+	router.HandlerFunc(http.MethodPost, "/clients", auth(authenticating.ScopeClientsManage, authenticatingHandler.RegisterClient))
+	router.HandlerFunc(http.MethodPost, "/introspect", auth(authenticating.ScopeTokensIntrospect, authenticatingHandler.Introspect))
+	router.HandlerFunc(http.MethodGet, "/sessions", auth(authenticating.ScopeAccount, authenticatingHandler.Sessions))
+	router.HandlerFunc(http.MethodDelete, "/sessions", auth(authenticating.ScopeAccount, authenticatingHandler.RevokeSessions))
+	router.HandlerFunc(http.MethodDelete, "/sessions/:id", auth(authenticating.ScopeAccount, authenticatingHandler.RevokeSession))
+	router.HandlerFunc(http.MethodPost, "/transfers", auth(authenticating.ScopeAccount, idempotencyHandler.Idempotent(transferringHandler.MakeTransfer)))
+	router.HandlerFunc(http.MethodGet, "/transfers", auth(authenticating.ScopeAccount, listingHandler.GetUserTransfers))
+	// customers can only reverse transfers they received, ScopeReversalsManage granting any other
+	router.HandlerFunc(http.MethodPost, "/transfers/:id/reversal", auth(authenticating.ScopeAccount, idempotencyHandler.Idempotent(transferringHandler.ReverseTransfer)))
+	router.HandlerFunc(http.MethodPost, "/transfers/:id", onlyParam("id", "scheduled", auth(authenticating.ScopeAccount, idempotencyHandler.Idempotent(schedulingHandler.ScheduleTransfer))))
+	// only the parties of a transfer get it and its receipt, which anyone can verify
+	router.HandlerFunc(http.MethodGet, "/transfers/:id", whenParam("id", "scheduled", auth(authenticating.ScopeAccount, schedulingHandler.ListScheduledTransfers), auth(authenticating.ScopeAccount, listingHandler.GetTransfer)))
+	router.HandlerFunc(http.MethodGet, "/transfers/:id/receipt", auth(authenticating.ScopeAccount, receiptingHandler.GetReceipt))
+	router.HandlerFunc(http.MethodGet, "/receipts/verify", receiptingHandler.VerifyReceipt)
+	router.HandlerFunc(http.MethodDelete, "/transfers/scheduled/:id", auth(authenticating.ScopeAccount, schedulingHandler.CancelScheduledTransfer))
+
+	router.HandlerFunc(http.MethodPost, "/standing-orders", auth(authenticating.ScopeAccount, idempotencyHandler.Idempotent(schedulingHandler.CreateStandingOrder)))
+	router.HandlerFunc(http.MethodGet, "/standing-orders", auth(authenticating.ScopeAccount, schedulingHandler.ListStandingOrders))
+	router.HandlerFunc(http.MethodPost, "/standing-orders/:id/pause", auth(authenticating.ScopeAccount, schedulingHandler.PauseStandingOrder))
+	router.HandlerFunc(http.MethodPost, "/standing-orders/:id/resume", auth(authenticating.ScopeAccount, schedulingHandler.ResumeStandingOrder))
+	router.HandlerFunc(http.MethodDelete, "/standing-orders/:id", auth(authenticating.ScopeAccount, schedulingHandler.CancelStandingOrder))
+
+	router.HandlerFunc(http.MethodGet, "/limits", auth(authenticating.ScopeAccount, limitingHandler.GetLimits))
+	router.HandlerFunc(http.MethodPatch, "/limits", auth(authenticating.ScopeAccount, limitingHandler.LowerLimits))
 
 	return router
 }
 
+// onlyParam serves next only when the path param name equals value, responding not found otherwise.
+// httprouter does not allow a static segment where a param is already registered, as POST /transfers/scheduled
+// along with POST /transfers/:id/reversal, so the static route is registered as the param and matched here
+func onlyParam(name string, value string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if httprouter.ParamsFromContext(r.Context()).ByName(name) != value {
+			http.NotFound(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// whenParam serves next when the path param name equals value and otherwise serves otherwise, for the same reason as
+// onlyParam, as GET /transfers/scheduled along with GET /transfers/:id
+func whenParam(name string, value string, next http.HandlerFunc, otherwise http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if httprouter.ParamsFromContext(r.Context()).ByName(name) != value {
+			otherwise(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
 func SetJSONError(logger *logrus.Entry, err error, status int, w http.ResponseWriter) {
+	SetJSONErrorWithCode(logger, err, "", status, w)
+}
+
+// SetJSONErrorWithCode sets err as JSON along with code, which clients can rely on instead of the message
+func SetJSONErrorWithCode(logger *logrus.Entry, err error, code string, status int, w http.ResponseWriter) {
 	if logger == nil {
 		logger = logrus.NewEntry(log)
 	}
@@ -75,8 +213,43 @@ func SetJSONError(logger *logrus.Entry, err error, status int, w http.ResponseWr
 	response := ErrorResponse{
 		StatusCode: status,
 		Message:    message,
+		Code:       code,
 	}
 	w.Header().Set("Content-Type", DefaultContentType)
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(response)
 }
+
+// SetTransferAuthorizationError sets err, as returned by authenticating.Service AuthorizeTransfer, as JSON along with the
+// code and status every endpoint moving money out of an account responds with
+func SetTransferAuthorizationError(logger *logrus.Entry, err error, w http.ResponseWriter) {
+	switch err.Error() {
+	case authenticating.ErrPINRequired.Error():
+		SetJSONErrorWithCode(logger, err, "pin_required", http.StatusForbidden, w)
+	case authenticating.ErrInvalidPIN.Error():
+		SetJSONErrorWithCode(logger, err, "invalid_pin", http.StatusForbidden, w)
+	case authenticating.ErrPINLocked.Error():
+		SetJSONErrorWithCode(logger, err, "pin_locked", http.StatusTooManyRequests, w)
+	case authenticating.ErrTOTPRequired.Error():
+		SetJSONErrorWithCode(logger, err, "totp_required", http.StatusForbidden, w)
+	case authenticating.ErrInvalidTOTPCode.Error():
+		SetJSONErrorWithCode(logger, err, "invalid_totp_code", http.StatusForbidden, w)
+	case authenticating.ErrTOTPLocked.Error():
+		SetJSONErrorWithCode(logger, err, "totp_locked", http.StatusTooManyRequests, w)
+	default:
+		SetJSONError(logger, err, http.StatusInternalServerError, w)
+	}
+}
+
+// WithPersistenceReport returns r along with the report its handler fills in through NothingPersisted
+func WithPersistenceReport(r *http.Request) (*http.Request, *PersistenceReport) {
+	report := &PersistenceReport{}
+	return r.WithContext(context.WithValue(r.Context(), persistenceReportKey, report)), report
+}
+
+// NothingPersisted reports that serving r failed before persisting anything, so that it can be retried from scratch
+func NothingPersisted(r *http.Request) {
+	if report, ok := r.Context().Value(persistenceReportKey).(*PersistenceReport); ok {
+		report.NothingPersisted = true
+	}
+}
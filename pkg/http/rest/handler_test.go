@@ -1,14 +1,22 @@
 package rest
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/sirupsen/logrus"
 
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
 	am "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/adding"
 	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	im "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/idempotency"
+	lim "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
 	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	rm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/receipting"
+	sm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/scheduling"
 	tm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/transferring"
 )
 
@@ -20,10 +28,91 @@ func TestHandler(t *testing.T) {
 	transferringHandlerMock := tm.HandlerMock{}
 	listingHandlerMock := &lm.HandlerMock{}
 	authHandlerMock := &aum.HandlerMock{}
+	idempotencyHandlerMock := im.HandlerMock{}
+	schedulingHandlerMock := sm.HandlerMock{}
+	limitingHandlerMock := lim.HandlerMock{}
+	receiptingHandlerMock := rm.HandlerMock{}
 
-	handler := Handler(logger, addingHandlerMock, transferringHandlerMock, authHandlerMock, listingHandlerMock)
+	handler := Handler(logger, addingHandlerMock, transferringHandlerMock, authHandlerMock, listingHandlerMock, idempotencyHandlerMock, schedulingHandlerMock, limitingHandlerMock, receiptingHandlerMock)
 
 	if handler == nil {
 		t.Errorf("Expected an implementation of http.Handler, got %s", handler)
 	}
 }
+
+func TestOnlyParam(t *testing.T) {
+	tt := []struct {
+		name           string
+		param          string
+		expectedStatus int
+	}{
+		{
+			name:           "When param matches the value",
+			param:          "scheduled",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "When param does not match the value",
+			param:          "5f8f8ccb30a1cd7511c5cb72",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			next := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/transfers/"+tc.param, nil)
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tc.param}}))
+
+			onlyParam("id", "scheduled", next)(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestWhenParam(t *testing.T) {
+	tt := []struct {
+		name           string
+		param          string
+		expectedStatus int
+	}{
+		{
+			name:           "When param matches the value",
+			param:          "scheduled",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "When param does not match the value",
+			param:          "5f8f8ccb30a1cd7511c5cb72",
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			next := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}
+			otherwise := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/transfers/"+tc.param, nil)
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tc.param}}))
+
+			whenParam("id", "scheduled", next, otherwise)(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package idempotency
+
+import (
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger  *logrus.Entry
+	service idempotency.Service
+}
+
+func NewHandler(logger *logrus.Entry, service idempotency.Service) Handler {
+	return Handler{
+		logger:  logger,
+		service: service,
+	}
+}
@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+)
+
+var ErrKeyTooLong = errors.New("idempotency key must have at most 255 characters")
+
+// Idempotent makes next honor the Idempotency-Key header: the first request under a key is served by next
+// and its response stored, so retries with the same key and body get that same response without running next again.
+// Server errors are stored as well, unless next reports through rest.NothingPersisted that the request can be retried.
+// When next panics, the key is released before the panic goes on, as it would otherwise stay in progress until it
+// expired, refusing every retry
+func (h Handler) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(KeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			rest.SetJSONError(h.logger, ErrKeyTooLong, http.StatusBadRequest, w)
+			return
+		}
+
+		ctx := r.Context()
+		accountID := ctx.Value(pkg.AccountID).(string)
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		record, replay, err := h.service.Begin(ctx, accountID, key, requestHash(r, body))
+		if err != nil {
+			switch err.Error() {
+			case idempotency.ErrKeyReused.Error(), idempotency.ErrRequestInProgress.Error():
+				rest.SetJSONError(h.logger, err, http.StatusConflict, w)
+			default:
+				rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+			}
+			return
+		}
+
+		if replay {
+			if record.ContentType != "" {
+				w.Header().Set("Content-Type", record.ContentType)
+			}
+			if record.Location != "" {
+				w.Header().Set("Location", record.Location)
+			}
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(record.StatusCode)
+			_, _ = w.Write(record.Body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		r, report := rest.WithPersistenceReport(r)
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					if err := h.service.Release(ctx, record); err != nil {
+						h.logger.Errorf("Err %v when releasing idempotency key %s", err, key)
+					}
+					panic(p)
+				}
+			}()
+			next(recorder, r)
+		}()
+
+		// the key is freed for the client to retry only when nothing was committed, as retrying a server error that
+		// left a transfer behind could make it twice
+		if recorder.statusCode >= http.StatusInternalServerError && report.NothingPersisted {
+			if err = h.service.Release(ctx, record); err != nil {
+				h.logger.Errorf("Err %v when releasing idempotency key %s", err, key)
+			}
+			return
+		}
+
+		record.StatusCode = recorder.statusCode
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Location = recorder.Header().Get("Location")
+		record.Body = recorder.body.Bytes()
+		if err = h.service.Complete(ctx, record); err != nil {
+			h.logger.Errorf("Err %v when storing response of idempotency key %s", err, key)
+		}
+	}
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	_, _ = hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder writes through to the wrapped ResponseWriter while keeping a copy of what was written
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if !rr.wroteHeader {
+		rr.statusCode = statusCode
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,251 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	im "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/idempotency"
+	"github.com/sirupsen/logrus"
+)
+
+func TestIdempotent(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name               string
+		key                string
+		idempotencyService *im.MockService
+		nextStatus         int
+		nextResponse       string
+		nextLocation       string
+		nextPersistsNone   bool
+		expectedNextCalls  int
+		expectedStatus     int
+		expectedResponse   string
+		expectedLocation   string
+		expectedReplayed   bool
+		expectedCompleted  bool
+		expectedReleased   bool
+	}{
+		{
+			name:               "When no idempotency key is informed",
+			idempotencyService: &im.MockService{},
+			nextStatus:         http.StatusOK,
+			expectedNextCalls:  1,
+			expectedStatus:     http.StatusOK,
+		},
+		{
+			name:               "When the key is used for the first time",
+			key:                "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{},
+			nextStatus:         http.StatusBadRequest,
+			nextResponse:       `{"status_code":400,"message":"not enough balance to execute this operation"}`,
+			expectedNextCalls:  1,
+			expectedStatus:     http.StatusBadRequest,
+			expectedResponse:   `{"status_code":400,"message":"not enough balance to execute this operation"}`,
+			expectedCompleted:  true,
+		},
+		{
+			name:               "When the key is used for the first time to create something",
+			key:                "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{},
+			nextStatus:         http.StatusCreated,
+			nextResponse:       `{"id":"f1869a4f9a84f89sa"}`,
+			nextLocation:       "/transfers/f1869a4f9a84f89sa",
+			expectedNextCalls:  1,
+			expectedStatus:     http.StatusCreated,
+			expectedResponse:   `{"id":"f1869a4f9a84f89sa"}`,
+			expectedLocation:   "/transfers/f1869a4f9a84f89sa",
+			expectedCompleted:  true,
+		},
+		{
+			name: "When the key was already used by the same request",
+			key:  "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{
+				Replay: true,
+				Record: idempotency.Record{
+					Completed:   true,
+					StatusCode:  http.StatusOK,
+					ContentType: "application/json",
+					Body:        []byte(`{"status_code":200,"message":"stored"}`),
+				},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"status_code":200,"message":"stored"}`,
+			expectedReplayed: true,
+		},
+		{
+			name: "When the key was already used by the same request that created something",
+			key:  "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{
+				Replay: true,
+				Record: idempotency.Record{
+					Completed:   true,
+					StatusCode:  http.StatusCreated,
+					ContentType: "application/json",
+					Location:    "/transfers/f1869a4f9a84f89sa",
+					Body:        []byte(`{"id":"f1869a4f9a84f89sa"}`),
+				},
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedResponse: `{"id":"f1869a4f9a84f89sa"}`,
+			expectedLocation: "/transfers/f1869a4f9a84f89sa",
+			expectedReplayed: true,
+		},
+		{
+			name: "When the key was already used by a different request",
+			key:  "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{
+				Err: idempotency.ErrKeyReused,
+			},
+			expectedStatus:   http.StatusConflict,
+			expectedResponse: `{"status_code":409,"message":"this idempotency key was already used with a different request"}`,
+		},
+		{
+			name: "When a request with the same key is still in progress",
+			key:  "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{
+				Err: idempotency.ErrRequestInProgress,
+			},
+			expectedStatus:   http.StatusConflict,
+			expectedResponse: `{"status_code":409,"message":"a request with this idempotency key is still being processed"}`,
+		},
+		{
+			name: "When fails to reserve the key",
+			key:  "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{
+				Err: errors.New("foo"),
+			},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+		{
+			name:               "When the key is too long",
+			key:                string(bytes.Repeat([]byte("a"), 256)),
+			idempotencyService: &im.MockService{},
+			expectedStatus:     http.StatusBadRequest,
+			expectedResponse:   `{"status_code":400,"message":"idempotency key must have at most 255 characters"}`,
+		},
+		{
+			name:               "When the request fails with a server error before persisting anything",
+			key:                "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{},
+			nextStatus:         http.StatusInternalServerError,
+			nextResponse:       `{"status_code":500,"message":"foo"}`,
+			nextPersistsNone:   true,
+			expectedNextCalls:  1,
+			expectedStatus:     http.StatusInternalServerError,
+			expectedResponse:   `{"status_code":500,"message":"foo"}`,
+			expectedReleased:   true,
+		},
+		{
+			name:               "When the request fails with a server error after persisting something",
+			key:                "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0",
+			idempotencyService: &im.MockService{},
+			nextStatus:         http.StatusInternalServerError,
+			nextResponse:       `{"status_code":500,"message":"foo"}`,
+			expectedNextCalls:  1,
+			expectedStatus:     http.StatusInternalServerError,
+			expectedResponse:   `{"status_code":500,"message":"foo"}`,
+			expectedCompleted:  true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.idempotencyService)
+			reqBody := `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`
+
+			nextCalls := 0
+			next := func(w http.ResponseWriter, r *http.Request) {
+				nextCalls++
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != reqBody {
+					t.Errorf("Expected next to receive body %s; got %s", reqBody, body)
+				}
+				if tc.nextResponse != "" {
+					w.Header().Set("Content-Type", "application/json")
+				}
+				if tc.nextLocation != "" {
+					w.Header().Set("Location", tc.nextLocation)
+				}
+				if tc.nextPersistsNone {
+					rest.NothingPersisted(r)
+				}
+				w.WriteHeader(tc.nextStatus)
+				_, _ = w.Write([]byte(tc.nextResponse))
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/transfers", bytes.NewBufferString(reqBody))
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+			if tc.key != "" {
+				r.Header.Set(KeyHeader, tc.key)
+			}
+
+			handler.Idempotent(next)(w, r)
+
+			if nextCalls != tc.expectedNextCalls {
+				t.Errorf("Expected next to be called %d times; got %d", tc.expectedNextCalls, nextCalls)
+			}
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if replayed := w.Header().Get(ReplayedHeader) == "true"; replayed != tc.expectedReplayed {
+				t.Errorf("Expected replayed header to be %v; got %v", tc.expectedReplayed, replayed)
+			}
+			if completed := tc.idempotencyService.Completed != nil; completed != tc.expectedCompleted {
+				t.Errorf("Expected response to be stored %v; got %v", tc.expectedCompleted, completed)
+			}
+			if tc.expectedCompleted && string(tc.idempotencyService.Completed.Body) != tc.expectedResponse {
+				t.Errorf("Expected stored body %s; got %s", tc.expectedResponse, tc.idempotencyService.Completed.Body)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected location %s; got %s", tc.expectedLocation, location)
+			}
+			if tc.expectedCompleted && tc.idempotencyService.Completed.Location != tc.expectedLocation {
+				t.Errorf("Expected stored location %s; got %s", tc.expectedLocation, tc.idempotencyService.Completed.Location)
+			}
+			if tc.idempotencyService.Released != tc.expectedReleased {
+				t.Errorf("Expected key to be released %v; got %v", tc.expectedReleased, tc.idempotencyService.Released)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
+
+func TestIdempotent_Panic(t *testing.T) {
+	logger := logrus.NewEntry(lgr.NewDefaultLogger())
+	idempotencyService := &im.MockService{}
+	handler := NewHandler(logger, idempotencyService)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/transfers", bytes.NewBufferString(`{"amount":11.11}`))
+	r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+	r.Header.Set(KeyHeader, "d7a8fbb3-07d4-4b1a-9d2c-2f1b5ae1c1d0")
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected the panic of next to go on; got %v", p)
+		}
+		if !idempotencyService.Released || idempotencyService.Completed != nil {
+			t.Errorf("Expected key to be released and no response to be stored; got released %v, stored %v", idempotencyService.Released, idempotencyService.Completed)
+		}
+	}()
+	handler.Idempotent(next)(w, r)
+}
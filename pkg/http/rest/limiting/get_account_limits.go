@@ -0,0 +1,30 @@
+package limiting
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// GetAccountLimits answers the limits of the account in the path, whichever it is
+func (h Handler) GetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := httprouter.ParamsFromContext(ctx).ByName("id")
+
+	limits, err := h.service.GetLimits(ctx, accountID)
+	if err != nil {
+		switch err.Error() {
+		case mongodb.ErrNoAccountWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(limits)
+}
@@ -0,0 +1,74 @@
+package limiting
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetAccountLimits(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name             string
+		limitingService  *lm.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name: "When account uses the limits of its tier",
+			limitingService: &lm.MockService{Limits: limiting.AccountLimits{
+				Tier:       limiting.Premium,
+				Limits:     limiting.TierLimits[limiting.Premium],
+				TierLimits: limiting.TierLimits[limiting.Premium],
+			}},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"tier":"premium","limits":{"per_transaction":50000.00,"daily":100000.00,"monthly":500000.00,"night_time":1000.00},"tier_limits":{"per_transaction":50000.00,"daily":100000.00,"monthly":500000.00,"night_time":1000.00}}`,
+		},
+		{
+			name:             "When account doesn't exist",
+			limitingService:  &lm.MockService{Err: mongodb.ErrNoAccountWasFound},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"status_code":404,"message":"no account was found with the given filter parameters"}`,
+		},
+		{
+			name:             "When fails to retrieve limits",
+			limitingService:  &lm.MockService{Err: errors.New("foo")},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.limitingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/accounts/5f8f8ccb30a1cd7511c5cb70/limits", nil)
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "5f8f8ccb30a1cd7511c5cb70"}}))
+
+			handler.GetAccountLimits(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			if tc.limitingService.AccountID != "5f8f8ccb30a1cd7511c5cb70" {
+				t.Errorf("Expected limits of account 5f8f8ccb30a1cd7511c5cb70, got %s", tc.limitingService.AccountID)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package limiting
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+func (h Handler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+
+	limits, err := h.service.GetLimits(ctx, accountID)
+	if err != nil {
+		switch err.Error() {
+		case mongodb.ErrNoAccountWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(limits)
+}
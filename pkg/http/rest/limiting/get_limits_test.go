@@ -0,0 +1,73 @@
+package limiting
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetLimits(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	lowered := limiting.TierLimits[limiting.Standard]
+	lowered.NightTime = money.FromCents(20000)
+
+	tt := []struct {
+		name             string
+		limitingService  *lm.MockService
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name: "When account has lowered its night-time limit",
+			limitingService: &lm.MockService{Limits: limiting.AccountLimits{
+				Tier:       limiting.Standard,
+				Limits:     lowered,
+				TierLimits: limiting.TierLimits[limiting.Standard],
+			}},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"tier":"standard","limits":{"per_transaction":5000.00,"daily":10000.00,"monthly":50000.00,"night_time":200.00},"tier_limits":{"per_transaction":5000.00,"daily":10000.00,"monthly":50000.00,"night_time":1000.00}}`,
+		},
+		{
+			name:             "When account doesn't exist",
+			limitingService:  &lm.MockService{Err: mongodb.ErrNoAccountWasFound},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"status_code":404,"message":"no account was found with the given filter parameters"}`,
+		},
+		{
+			name:             "When fails to retrieve limits",
+			limitingService:  &lm.MockService{Err: errors.New("foo")},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.limitingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/limits", nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+
+			handler.GetLimits(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
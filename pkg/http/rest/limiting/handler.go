@@ -0,0 +1,18 @@
+package limiting
+
+import (
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger  *logrus.Entry
+	service limiting.Service
+}
+
+func NewHandler(logger *logrus.Entry, service limiting.Service) Handler {
+	return Handler{
+		logger:  logger,
+		service: service,
+	}
+}
@@ -0,0 +1,39 @@
+package limiting
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// LowerLimits lowers the limits present in the request body, keeping the absent ones
+func (h Handler) LowerLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+	decoder := json.NewDecoder(r.Body)
+	var limits limiting.Limits
+	if err := decoder.Decode(&limits); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	updated, err := h.service.LowerLimits(ctx, accountID, limits)
+	if err != nil {
+		switch err.Error() {
+		case limiting.ErrLimitRaise.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		case mongodb.ErrNoAccountWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(updated)
+}
@@ -0,0 +1,92 @@
+package limiting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLowerLimits(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	lowered := limiting.TierLimits[limiting.Premium]
+	lowered.Daily = money.FromCents(300000)
+
+	tt := []struct {
+		name             string
+		reqBodyJSON      string
+		limitingService  *lm.MockService
+		expectedLimits   limiting.Limits
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:        "When daily limit is lowered",
+			reqBodyJSON: `{"daily":3000}`,
+			limitingService: &lm.MockService{Limits: limiting.AccountLimits{
+				Tier:       limiting.Premium,
+				Limits:     lowered,
+				TierLimits: limiting.TierLimits[limiting.Premium],
+			}},
+			expectedLimits:   limiting.Limits{Daily: money.FromCents(300000)},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"tier":"premium","limits":{"per_transaction":50000.00,"daily":3000.00,"monthly":500000.00,"night_time":1000.00},"tier_limits":{"per_transaction":50000.00,"daily":100000.00,"monthly":500000.00,"night_time":1000.00}}`,
+		},
+		{
+			name:             "When req body cannot be deserialized as limits",
+			reqBodyJSON:      `{"daily":"3000"}`,
+			limitingService:  &lm.MockService{},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"amount must be a number with at most two decimal places"}`,
+		},
+		{
+			name:             "When a limit would be raised",
+			reqBodyJSON:      `{"night_time":5000}`,
+			limitingService:  &lm.MockService{Err: limiting.ErrLimitRaise},
+			expectedLimits:   limiting.Limits{NightTime: money.FromCents(500000)},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"limits can only be lowered, each being greater than zero and not greater than the current one"}`,
+		},
+		{
+			name:             "When fails to lower limits",
+			reqBodyJSON:      `{"monthly":1000}`,
+			limitingService:  &lm.MockService{Err: errors.New("foo")},
+			expectedLimits:   limiting.Limits{Monthly: money.FromCents(100000)},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.limitingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPatch, "/limits", bytes.NewBufferString(tc.reqBodyJSON))
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+
+			handler.LowerLimits(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			if tc.limitingService.Lowered != tc.expectedLimits {
+				t.Errorf("Expected limits %v to be lowered, got %v", tc.expectedLimits, tc.limitingService.Lowered)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package limiting
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// SetAccountLimits replaces the tier and custom limits of the account in the path, absent limits being the tier ones
+func (h Handler) SetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := httprouter.ParamsFromContext(ctx).ByName("id")
+	decoder := json.NewDecoder(r.Body)
+	var settings limiting.Settings
+	if err := decoder.Decode(&settings); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	updated, err := h.service.SetLimits(ctx, accountID, settings)
+	if err != nil {
+		switch err.Error() {
+		case limiting.ErrUnknownTier.Error(),
+			limiting.ErrLimitAboveTier.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		case mongodb.ErrNoAccountWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(updated)
+}
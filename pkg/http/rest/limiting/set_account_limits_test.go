@@ -0,0 +1,92 @@
+package limiting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetAccountLimits(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	lowered := limiting.TierLimits[limiting.Premium]
+	lowered.NightTime = money.FromCents(50000)
+
+	tt := []struct {
+		name             string
+		reqBodyJSON      string
+		limitingService  *lm.MockService
+		expectedSettings limiting.Settings
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:        "When account is raised to premium keeping a lowered night-time limit",
+			reqBodyJSON: `{"tier":"premium","limits":{"night_time":500}}`,
+			limitingService: &lm.MockService{Limits: limiting.AccountLimits{
+				Tier:       limiting.Premium,
+				Limits:     lowered,
+				TierLimits: limiting.TierLimits[limiting.Premium],
+			}},
+			expectedSettings: limiting.Settings{Tier: limiting.Premium, Custom: limiting.Limits{NightTime: money.FromCents(50000)}},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"tier":"premium","limits":{"per_transaction":50000.00,"daily":100000.00,"monthly":500000.00,"night_time":500.00},"tier_limits":{"per_transaction":50000.00,"daily":100000.00,"monthly":500000.00,"night_time":1000.00}}`,
+		},
+		{
+			name:             "When req body cannot be deserialized as settings",
+			reqBodyJSON:      `{"tier":1}`,
+			limitingService:  &lm.MockService{},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"Invalid Settings entity: expected type Tier, got number at field tier"}`,
+		},
+		{
+			name:             "When tier is unknown",
+			reqBodyJSON:      `{"tier":"gold"}`,
+			limitingService:  &lm.MockService{Err: limiting.ErrUnknownTier},
+			expectedSettings: limiting.Settings{Tier: "gold"},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"tier must be either standard or premium"}`,
+		},
+		{
+			name:             "When fails to set limits",
+			reqBodyJSON:      `{"tier":"standard"}`,
+			limitingService:  &lm.MockService{Err: errors.New("foo")},
+			expectedSettings: limiting.Settings{Tier: limiting.Standard},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.limitingService)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/accounts/5f8f8ccb30a1cd7511c5cb70/limits", bytes.NewBufferString(tc.reqBodyJSON))
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "5f8f8ccb30a1cd7511c5cb70"}}))
+
+			handler.SetAccountLimits(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			if tc.limitingService.Set != tc.expectedSettings {
+				t.Errorf("Expected settings %v to be set, got %v", tc.expectedSettings, tc.limitingService.Set)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
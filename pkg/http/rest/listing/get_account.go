@@ -0,0 +1,85 @@
+package listing
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// accountDetails is an account as its owner sees it, its CPF being masked
+type accountDetails struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	CPF       string                `json:"cpf"`
+	Balance   money.Money           `json:"balance"`
+	CreatedAt *time.Time            `json:"created_at,omitempty"`
+	Status    listing.AccountStatus `json:"status"`
+	// LockedUntil is when the lockout of locked accounts ends
+	LockedUntil *time.Time             `json:"locked_until,omitempty"`
+	Limits      limiting.AccountLimits `json:"limits"`
+}
+
+// GetAccount answers the account in the path, me standing for the one of the token. Only tokens granting
+// authenticating.ScopeAccountsRead get accounts other than their own
+func (h Handler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := httprouter.ParamsFromContext(ctx).ByName("id")
+	if id == "me" {
+		id = ctx.Value(pkg.AccountID).(string)
+	}
+	if id != ctx.Value(pkg.AccountID).(string) && !authenticating.HasScope(ctx, authenticating.ScopeAccountsRead) {
+		rest.SetJSONError(h.logger, authenticating.ErrMissingScope, http.StatusForbidden, w)
+		return
+	}
+
+	account, err := h.service.GetAccountByID(ctx, id)
+	if err != nil {
+		h.setAccountError(err, w)
+		return
+	}
+	limits, err := h.limitingService.GetLimits(ctx, id)
+	if err != nil {
+		h.setAccountError(err, w)
+		return
+	}
+	lockedUntil, err := h.authService.LockedUntil(ctx, account.CPF)
+	if err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		return
+	}
+
+	details := accountDetails{
+		ID:        account.ID,
+		Name:      account.Name,
+		CPF:       listing.MaskCPF(account.CPF),
+		Balance:   account.Balance,
+		CreatedAt: account.CreatedAt,
+		Status:    listing.AccountActive,
+		Limits:    limits,
+	}
+	if !lockedUntil.IsZero() {
+		details.Status = listing.AccountLocked
+		details.LockedUntil = &lockedUntil
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(details)
+}
+
+func (h Handler) setAccountError(err error, w http.ResponseWriter) {
+	switch err.Error() {
+	case mongodb.ErrNoAccountWasFound.Error():
+		rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+	default:
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+	}
+}
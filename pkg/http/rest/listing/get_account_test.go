@@ -0,0 +1,155 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	ls "github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lim "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetAccount(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	createdAt := time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC)
+	account := ls.Account{ID: "a6sf46af6af", Name: "Alice", CPF: "11111111030", Balance: money.FromCents(4242), CreatedAt: &createdAt}
+	limits := limiting.AccountLimits{
+		Tier:       limiting.Standard,
+		Limits:     limiting.Limits{PerTransaction: money.FromCents(50000), Daily: money.FromCents(100000), Monthly: money.FromCents(500000), NightTime: money.FromCents(20000)},
+		TierLimits: limiting.Limits{PerTransaction: money.FromCents(50000), Daily: money.FromCents(100000), Monthly: money.FromCents(500000), NightTime: money.FromCents(20000)},
+	}
+	limitsJSON := `{"tier":"standard","limits":{"per_transaction":500.00,"daily":1000.00,"monthly":5000.00,"night_time":200.00},"tier_limits":{"per_transaction":500.00,"daily":1000.00,"monthly":5000.00,"night_time":200.00}}`
+	tt := []struct {
+		name             string
+		id               string
+		scopes           []authenticating.Scope
+		service          *listing.MockService
+		limitingService  *lim.MockService
+		authService      *aum.MockService
+		expectedID       string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "When the owner asks its account",
+			id:               "a6sf46af6af",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Account: account},
+			limitingService:  &lim.MockService{Limits: limits},
+			authService:      &aum.MockService{},
+			expectedID:       "a6sf46af6af",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"id":"a6sf46af6af","name":"Alice","cpf":"***.111.110-**","balance":42.42,"created_at":"2020-10-21T10:00:00Z","status":"active","limits":` + limitsJSON + `}`,
+		},
+		{
+			name:             "When me stands for the account of the token",
+			id:               "me",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Account: account},
+			limitingService:  &lim.MockService{Limits: limits},
+			authService:      &aum.MockService{},
+			expectedID:       "a6sf46af6af",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"id":"a6sf46af6af","name":"Alice","cpf":"***.111.110-**","balance":42.42,"created_at":"2020-10-21T10:00:00Z","status":"active","limits":` + limitsJSON + `}`,
+		},
+		{
+			name:             "When the login of the account is locked out",
+			id:               "me",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Account: account},
+			limitingService:  &lim.MockService{Limits: limits},
+			authService:      &aum.MockService{Locked: createdAt.Add(time.Minute * 15)},
+			expectedID:       "a6sf46af6af",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"id":"a6sf46af6af","name":"Alice","cpf":"***.111.110-**","balance":42.42,"created_at":"2020-10-21T10:00:00Z","status":"locked","locked_until":"2020-10-21T10:15:00Z","limits":` + limitsJSON + `}`,
+		},
+		{
+			name:             "When operator asks another account",
+			id:               "5f8f8ccb30a1cd7511c5cb70",
+			scopes:           authenticating.ScopesOf(authenticating.Operator),
+			service:          &listing.MockService{Account: ls.Account{ID: "5f8f8ccb30a1cd7511c5cb70", Name: "Bob", CPF: "52998224725"}},
+			limitingService:  &lim.MockService{Limits: limits},
+			authService:      &aum.MockService{},
+			expectedID:       "5f8f8ccb30a1cd7511c5cb70",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"id":"5f8f8ccb30a1cd7511c5cb70","name":"Bob","cpf":"***.982.247-**","balance":0.00,"status":"active","limits":` + limitsJSON + `}`,
+		},
+		{
+			name:             "When customer asks another account",
+			id:               "5f8f8ccb30a1cd7511c5cb70",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Account: account},
+			limitingService:  &lim.MockService{},
+			authService:      &aum.MockService{},
+			expectedStatus:   http.StatusForbidden,
+			expectedResponse: `{"status_code":403,"message":"your credentials don't grant access to this route"}`,
+		},
+		{
+			name:             "When no account was found with the given id",
+			id:               "5f8f8ccb30a1cd7511c5cb70",
+			scopes:           authenticating.ScopesOf(authenticating.Operator),
+			service:          &listing.MockService{Err: mongodb.ErrNoAccountWasFound},
+			limitingService:  &lim.MockService{},
+			authService:      &aum.MockService{},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"status_code":404,"message":"no account was found with the given filter parameters"}`,
+		},
+		{
+			name:             "When the limits could not be retrieved",
+			id:               "me",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Account: account},
+			limitingService:  &lim.MockService{Err: errors.New("foo")},
+			authService:      &aum.MockService{},
+			expectedID:       "a6sf46af6af",
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+		{
+			name:             "When the lockout could not be told",
+			id:               "me",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Account: account},
+			limitingService:  &lim.MockService{Limits: limits},
+			authService:      &aum.MockService{Err: errors.New("bar")},
+			expectedID:       "a6sf46af6af",
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"bar"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.service, tc.limitingService, tc.authService)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%s", tc.id), nil)
+			ctx := context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tc.id}})
+			ctx = context.WithValue(ctx, pkg.AccountID, "a6sf46af6af")
+			r = r.WithContext(context.WithValue(ctx, pkg.Scopes, tc.scopes))
+
+			handler.GetAccount(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.limitingService.AccountID != tc.expectedID {
+				t.Errorf("Expected limits of account %q; got %q", tc.expectedID, tc.limitingService.AccountID)
+			}
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -2,19 +2,43 @@ package listing
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
 	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
 	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
 )
 
-func (h Handler) GetBalanceByID(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-	id := p.ByName("id")
+var ErrInvalidBalanceTime = errors.New("at must be a RFC 3339 date-time, as 2020-10-21T10:00:00Z")
+
+// GetBalanceByID answers the current account balance or, given the at query param, the one rebuilt from its ledger at that time.
+// Only tokens granting authenticating.ScopeAccountsRead get the balance of accounts other than their own
+func (h Handler) GetBalanceByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	id := httprouter.ParamsFromContext(ctx).ByName("id")
+	if id != ctx.Value(pkg.AccountID).(string) && !authenticating.HasScope(ctx, authenticating.ScopeAccountsRead) {
+		rest.SetJSONError(h.logger, authenticating.ErrMissingScope, http.StatusForbidden, w)
+		return
+	}
 
-	balance, err := h.service.GetAccountBalanceByID(ctx, id)
+	var balance money.Money
+	var err error
+	if at := r.URL.Query().Get("at"); at != "" {
+		atTime, parseErr := time.Parse(time.RFC3339, at)
+		if parseErr != nil {
+			rest.SetJSONError(h.logger, ErrInvalidBalanceTime, http.StatusBadRequest, w)
+			return
+		}
+		balance, err = h.service.GetAccountBalanceAt(ctx, id, atTime)
+	} else {
+		balance, err = h.service.GetAccountBalanceByID(ctx, id)
+	}
 	if err != nil {
 		if err.Error() == mongodb.ErrNoAccountWasFound.Error() {
 			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
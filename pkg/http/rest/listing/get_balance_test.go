@@ -1,6 +1,7 @@
 package listing
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -8,8 +9,13 @@ import (
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
 	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lim "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
 	"github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
 	"github.com/sirupsen/logrus"
 )
@@ -19,6 +25,8 @@ func TestGetAccountBalanceByID(t *testing.T) {
 	tt := []struct {
 		name             string
 		id               string
+		scopes           []authenticating.Scope
+		query            string
 		service          *listing.MockService
 		expectedStatus   int
 		expectedResponse string
@@ -26,13 +34,49 @@ func TestGetAccountBalanceByID(t *testing.T) {
 		{
 			name:             "When successfully returns",
 			id:               "a6sf46af6af",
-			service:          &listing.MockService{Balance: 42.42},
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Balance: money.FromCents(4242)},
 			expectedStatus:   http.StatusOK,
 			expectedResponse: `{"balance":42.42}`,
 		},
+		{
+			name:             "When balance is asked at a given time",
+			id:               "a6sf46af6af",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			query:            "?at=2020-10-21T10:00:00Z",
+			service:          &listing.MockService{Balance: money.FromCents(1050)},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"balance":10.50}`,
+		},
+		{
+			name:             "When the given time is not RFC 3339",
+			id:               "a6sf46af6af",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			query:            "?at=21/10/2020",
+			service:          &listing.MockService{},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"at must be a RFC 3339 date-time, as 2020-10-21T10:00:00Z"}`,
+		},
+		{
+			name:             "When operator asks the balance of another account",
+			id:               "5f8f8ccb30a1cd7511c5cb70",
+			scopes:           authenticating.ScopesOf(authenticating.Operator),
+			service:          &listing.MockService{Balance: money.FromCents(100)},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"balance":1.00}`,
+		},
+		{
+			name:             "When customer asks the balance of another account",
+			id:               "5f8f8ccb30a1cd7511c5cb70",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
+			service:          &listing.MockService{Balance: money.FromCents(100)},
+			expectedStatus:   http.StatusForbidden,
+			expectedResponse: `{"status_code":403,"message":"your credentials don't grant access to this route"}`,
+		},
 		{
 			name:             "When no account was found with the given id",
 			id:               "a6sf46af6af",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
 			service:          &listing.MockService{Err: mongodb.ErrNoAccountWasFound},
 			expectedStatus:   http.StatusNotFound,
 			expectedResponse: `{"status_code":404,"message":"no account was found with the given filter parameters"}`,
@@ -40,6 +84,7 @@ func TestGetAccountBalanceByID(t *testing.T) {
 		{
 			name:             "When unexpected errors inside the service occurs",
 			id:               "a6sf46af6af",
+			scopes:           authenticating.ScopesOf(authenticating.Customer),
 			service:          &listing.MockService{Err: errors.New("foo")},
 			expectedStatus:   http.StatusInternalServerError,
 			expectedResponse: `{"status_code":500,"message":"foo"}`,
@@ -48,15 +93,15 @@ func TestGetAccountBalanceByID(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			handler := NewHandler(logger, tc.service)
+			handler := NewHandler(logger, tc.service, &lim.MockService{}, &aum.MockService{})
 			w := httptest.NewRecorder()
-			target := fmt.Sprintf("/accounts/%s/balance", tc.id)
+			target := fmt.Sprintf("/accounts/%s/balance%s", tc.id, tc.query)
 			r := httptest.NewRequest(http.MethodGet, target, nil)
+			ctx := context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tc.id}})
+			ctx = context.WithValue(ctx, pkg.AccountID, "a6sf46af6af")
+			r = r.WithContext(context.WithValue(ctx, pkg.Scopes, tc.scopes))
 
-			handler.GetBalanceByID(w, r, httprouter.Params{{
-				Key:   "id",
-				Value: tc.id,
-			}})
+			handler.GetBalanceByID(w, r)
 
 			if w.Code != tc.expectedStatus {
 				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
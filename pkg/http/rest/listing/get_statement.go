@@ -0,0 +1,72 @@
+package listing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+var ErrInvalidStatementTime = errors.New("from and to must be RFC 3339 date-times, as 2020-10-21T10:00:00Z")
+
+// GetStatement answers the balance movements of the account of the token in the period given by the from and to
+// query params, each line along with the balance after it. Statements exported as CSV, OFX or NDJSON, as told by the
+// format query param or the Accept header, are streamed as they are read
+func (h Handler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+
+	format, err := statementFormat(r)
+	if err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	var from, to time.Time
+	for param, bound := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := r.URL.Query().Get(param); value != "" {
+			if *bound, err = time.Parse(time.RFC3339, value); err != nil {
+				rest.SetJSONError(h.logger, ErrInvalidStatementTime, http.StatusBadRequest, w)
+				return
+			}
+		}
+	}
+
+	if format != formatJSON {
+		exporter := newStatementExporter(w, format)
+		if err = h.service.StreamStatement(ctx, accountID, from, to, exporter); err != nil {
+			// once the statement has started, the response can only be cut short
+			if exporter.started {
+				h.logger.Errorf("Err %v occurred when exporting statement of account %s as %s", err, accountID, format)
+				return
+			}
+			h.setStatementError(err, w)
+		}
+		return
+	}
+
+	statement, err := h.service.GetStatement(ctx, accountID, from, to)
+	if err != nil {
+		h.setStatementError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(statement)
+}
+
+func (h Handler) setStatementError(err error, w http.ResponseWriter) {
+	switch err.Error() {
+	case listing.ErrInvalidRange.Error(), listing.ErrStatementPeriodTooLong.Error():
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+	case mongodb.ErrNoAccountWasFound.Error():
+		rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+	default:
+		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+	}
+}
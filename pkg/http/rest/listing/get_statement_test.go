@@ -0,0 +1,248 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lim "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
+	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetStatement(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	defaultClientID := "jff46as84dcsa365418"
+	from := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 10, 31, 23, 59, 59, 0, time.UTC)
+	tt := []struct {
+		name             string
+		query            string
+		listingService   *lm.MockService
+		expectedFrom     time.Time
+		expectedTo       time.Time
+		expectedResponse string
+		expectedStatus   int
+	}{
+		{
+			name:  "When successfully retrieves the statement of a period",
+			query: "?from=2020-10-01T00:00:00Z&to=2020-10-31T23:59:59Z",
+			listingService: &lm.MockService{
+				Statement: listing.Statement{
+					AccountID:      defaultClientID,
+					From:           from,
+					To:             to,
+					OpeningBalance: money.FromCents(10000),
+					ClosingBalance: money.FromCents(8500),
+					Lines: []listing.StatementLine{
+						{TransferID: "4as6g84as68gf4as", CounterpartyID: "4896as4rfa689tqwrtg", Amount: money.FromCents(-2500), Balance: money.FromCents(7500), CreatedAt: from},
+						{Amount: money.FromCents(1000), Balance: money.FromCents(8500), CreatedAt: to},
+					},
+				},
+			},
+			expectedFrom:     from,
+			expectedTo:       to,
+			expectedResponse: `{"account_id":"jff46as84dcsa365418","from":"2020-10-01T00:00:00Z","to":"2020-10-31T23:59:59Z","opening_balance":100.00,"closing_balance":85.00,"lines":[{"transfer_id":"4as6g84as68gf4as","counterparty_id":"4896as4rfa689tqwrtg","amount":-25.00,"balance":75.00,"created_at":"2020-10-01T00:00:00Z"},{"amount":10.00,"balance":85.00,"created_at":"2020-10-31T23:59:59Z"}]}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name: "When the period is left to defaults",
+			listingService: &lm.MockService{
+				Statement: listing.Statement{AccountID: defaultClientID, From: from, To: to, Lines: []listing.StatementLine{}},
+			},
+			expectedResponse: `{"account_id":"jff46as84dcsa365418","from":"2020-10-01T00:00:00Z","to":"2020-10-31T23:59:59Z","opening_balance":0.00,"closing_balance":0.00,"lines":[]}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "When a bound is malformed",
+			query:            "?to=2020-10-31",
+			listingService:   &lm.MockService{},
+			expectedResponse: `{"status_code":400,"message":"from and to must be RFC 3339 date-times, as 2020-10-21T10:00:00Z"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When the period is too long",
+			query:            "?from=2018-10-01T00:00:00Z",
+			listingService:   &lm.MockService{Err: listing.ErrStatementPeriodTooLong},
+			expectedFrom:     time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC),
+			expectedResponse: `{"status_code":400,"message":"statement period must be of at most 366 days"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When the account no longer exists",
+			listingService:   &lm.MockService{Err: mongodb.ErrNoAccountWasFound},
+			expectedResponse: `{"status_code":404,"message":"no account was found with the given filter parameters"}`,
+			expectedStatus:   http.StatusNotFound,
+		},
+		{
+			name:             "When fails to retrieve the statement",
+			listingService:   &lm.MockService{Err: errors.New("db error")},
+			expectedResponse: `{"status_code":500,"message":"db error"}`,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.listingService, &lim.MockService{}, &aum.MockService{})
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/accounts/me/statement"+tc.query, nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, defaultClientID))
+
+			handler.GetStatement(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			if !tc.listingService.StatementFrom.Equal(tc.expectedFrom) || !tc.listingService.StatementTo.Equal(tc.expectedTo) {
+				t.Errorf("Expected statement from %s to %s; got from %s to %s", tc.expectedFrom, tc.expectedTo, tc.listingService.StatementFrom, tc.listingService.StatementTo)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
+
+func TestGetStatement_Export(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	defaultClientID := "jff46as84dcsa365418"
+	from := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 10, 31, 23, 59, 59, 0, time.UTC)
+	statement := listing.Statement{
+		AccountID:      defaultClientID,
+		From:           from,
+		To:             to,
+		OpeningBalance: money.FromCents(10000),
+		ClosingBalance: money.FromCents(8500),
+		Lines: []listing.StatementLine{
+			{TransferID: "4as6g84as68gf4as", CounterpartyID: "4896as4rfa689tqwrtg", Amount: money.FromCents(-2500), Balance: money.FromCents(7500), CreatedAt: from},
+			{Amount: money.FromCents(1000), Balance: money.FromCents(8500), CreatedAt: to},
+		},
+	}
+	empty := listing.Statement{
+		AccountID:      defaultClientID,
+		From:           from,
+		To:             to,
+		OpeningBalance: money.FromCents(10000),
+		ClosingBalance: money.FromCents(10000),
+	}
+	tt := []struct {
+		name                string
+		query               string
+		accept              string
+		listingService      *lm.MockService
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        []string
+	}{
+		{
+			name:                "When exporting as CSV by the format param",
+			query:               "?format=csv",
+			listingService:      &lm.MockService{Statement: statement},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: []string{"type,created_at,transfer_id,reversal_of,counterparty_id,amount,balance\n" +
+				"opening,2020-10-01T00:00:00Z,,,,,100.00\n" +
+				"movement,2020-10-01T00:00:00Z,4as6g84as68gf4as,,4896as4rfa689tqwrtg,-25.00,75.00\n" +
+				"movement,2020-10-31T23:59:59Z,,,,10.00,85.00\n" +
+				"closing,2020-10-31T23:59:59Z,,,,,85.00\n"},
+		},
+		{
+			name:                "When exporting as CSV a period without movements",
+			query:               "?format=csv",
+			listingService:      &lm.MockService{Statement: empty},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: []string{"type,created_at,transfer_id,reversal_of,counterparty_id,amount,balance\n" +
+				"opening,2020-10-01T00:00:00Z,,,,,100.00\n" +
+				"closing,2020-10-31T23:59:59Z,,,,,100.00\n"},
+		},
+		{
+			name:                "When exporting as NDJSON by the Accept header",
+			accept:              "application/x-ndjson, application/json;q=0.9",
+			listingService:      &lm.MockService{Statement: statement},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody: []string{`{"account_id":"jff46as84dcsa365418","from":"2020-10-01T00:00:00Z","to":"2020-10-31T23:59:59Z","opening_balance":100.00}` + "\n" +
+				`{"transfer_id":"4as6g84as68gf4as","counterparty_id":"4896as4rfa689tqwrtg","amount":-25.00,"balance":75.00,"created_at":"2020-10-01T00:00:00Z"}` + "\n" +
+				`{"amount":10.00,"balance":85.00,"created_at":"2020-10-31T23:59:59Z"}` + "\n" +
+				`{"closing_balance":85.00}` + "\n"},
+		},
+		{
+			name:                "When exporting as NDJSON a period without movements",
+			query:               "?format=ndjson",
+			listingService:      &lm.MockService{Statement: empty},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody: []string{`{"account_id":"jff46as84dcsa365418","from":"2020-10-01T00:00:00Z","to":"2020-10-31T23:59:59Z","opening_balance":100.00}` + "\n" +
+				`{"closing_balance":100.00}` + "\n"},
+		},
+		{
+			name:                "When exporting as OFX",
+			query:               "?format=ofx",
+			listingService:      &lm.MockService{Statement: statement},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ofx",
+			expectedBody: []string{
+				`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`,
+				`<BANKACCTFROM><BANKID>TRANSFER</BANKID><ACCTID>jff46as84dcsa365418</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>`,
+				`<BANKTRANLIST><DTSTART>20201001000000.000[0:GMT]</DTSTART><DTEND>20201031235959.000[0:GMT]</DTEND>`,
+				`<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20201001000000.000[0:GMT]</DTPOSTED><TRNAMT>-25.00</TRNAMT><FITID>4as6g84as68gf4as</FITID><NAME>4896as4rfa689tqwrtg</NAME></STMTTRN>`,
+				`<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20201031235959.000[0:GMT]</DTPOSTED><TRNAMT>10.00</TRNAMT><FITID>ADJ1604188799000000000</FITID><MEMO>Adjustment</MEMO></STMTTRN>`,
+				`</BANKTRANLIST><LEDGERBAL><BALAMT>85.00</BALAMT><DTASOF>20201031235959.000[0:GMT]</DTASOF></LEDGERBAL></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`,
+			},
+		},
+		{
+			name:                "When the format is unknown",
+			query:               "?format=xlsx",
+			listingService:      &lm.MockService{Statement: statement},
+			expectedStatus:      http.StatusBadRequest,
+			expectedContentType: "application/json",
+			expectedBody:        []string{`{"status_code":400,"message":"format must be one of json, csv, ofx or ndjson"}` + "\n"},
+		},
+		{
+			name:                "When the export fails before it starts",
+			query:               "?format=csv",
+			listingService:      &lm.MockService{Err: mongodb.ErrNoAccountWasFound},
+			expectedStatus:      http.StatusNotFound,
+			expectedContentType: "application/json",
+			expectedBody:        []string{`{"status_code":404,"message":"no account was found with the given filter parameters"}` + "\n"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.listingService, &lim.MockService{}, &aum.MockService{})
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/accounts/me/statement"+tc.query, nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, defaultClientID))
+			r.Header.Set("Accept", tc.accept)
+
+			handler.GetStatement(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Errorf("Expected content type %s; got %s", tc.expectedContentType, contentType)
+			}
+			body := w.Body.String()
+			for _, want := range tc.expectedBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected response to contain %s; got %s", want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package listing
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// GetTransfer answers the transfer in the path along with the names of its parties, which are the only accounts
+// that get it
+func (h Handler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := httprouter.ParamsFromContext(ctx).ByName("id")
+
+	transfer, err := h.service.GetTransfer(ctx, ctx.Value(pkg.AccountID).(string), id)
+	if err != nil {
+		switch err.Error() {
+		case mongodb.ErrNoTransferWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(transfer)
+}
@@ -0,0 +1,88 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	ls "github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lim "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetTransfer(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	completedAt := time.Date(2020, 10, 21, 10, 0, 1, 0, time.UTC)
+	transfer := ls.TransferDetails{
+		Transfer: ls.Transfer{
+			ID:                   "5f8f8ccb30a1cd7511c5cb72",
+			OriginAccountID:      "a6sf46af6af",
+			DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+			Amount:               money.FromCents(1050),
+			Status:               transferstatus.Completed,
+			CreatedAt:            time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC),
+			CompletedAt:          &completedAt,
+		},
+		Origin:      ls.Party{AccountID: "a6sf46af6af", Name: "Monkey D. Luffy", CPF: "***.111.110-**"},
+		Destination: ls.Party{AccountID: "5f8f8ccb30a1cd7511c5cb70", Name: "Roronoa Zoro", CPF: "***.982.247-**"},
+	}
+	tt := []struct {
+		name             string
+		service          *listing.MockService
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:           "When a party asks the transfer",
+			service:        &listing.MockService{Transfer: transfer},
+			expectedStatus: http.StatusOK,
+			expectedResponse: `{"id":"5f8f8ccb30a1cd7511c5cb72","account_origin_id":"a6sf46af6af","account_destination_id":"5f8f8ccb30a1cd7511c5cb70",` +
+				`"amount":10.50,"status":"completed","created_at":"2020-10-21T10:00:00Z","completed_at":"2020-10-21T10:00:01Z",` +
+				`"origin":{"account_id":"a6sf46af6af","name":"Monkey D. Luffy","cpf":"***.111.110-**"},` +
+				`"destination":{"account_id":"5f8f8ccb30a1cd7511c5cb70","name":"Roronoa Zoro","cpf":"***.982.247-**"}}`,
+		},
+		{
+			name:             "When the transfer is not one of the account",
+			service:          &listing.MockService{Err: mongodb.ErrNoTransferWasFound},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"status_code":404,"message":"no transfer was found with the given filter parameters"}`,
+		},
+		{
+			name:             "When unexpected errors inside the service occurs",
+			service:          &listing.MockService{Err: errors.New("foo")},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.service, &lim.MockService{}, &aum.MockService{})
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/transfers/5f8f8ccb30a1cd7511c5cb72", nil)
+			ctx := context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "5f8f8ccb30a1cd7511c5cb72"}})
+			r = r.WithContext(context.WithValue(ctx, pkg.AccountID, "a6sf46af6af"))
+
+			handler.GetTransfer(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.service.TransferOf != "a6sf46af6af" {
+				t.Errorf("Expected transfer to be retrieved on behalf of the account of the token; got %s", tc.service.TransferOf)
+			}
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -2,22 +2,92 @@ package listing
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg"
 	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
 )
 
+var ErrInvalidTransfersTime = errors.New("from and to must be RFC 3339 date-times, as 2020-10-21T10:00:00Z")
+var ErrInvalidTransfersAmount = errors.New("min_amount and max_amount must be numbers with at most two decimal places")
+
+// GetUserTransfers answers a page of the transfers sent and received by the account of the token, filtered and
+// sorted as told by the query params
 func (h Handler) GetUserTransfers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	accountID := ctx.Value(pkg.AccountID).(string)
 
-	accountTransfers, err := h.service.GetTransfersByAccountID(ctx, accountID)
+	filter, page, err := transfersQuery(r.URL.Query())
+	if err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+
+	transferPage, err := h.service.GetTransfersByAccountID(ctx, accountID, filter, page)
 	if err != nil {
-		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		switch err.Error() {
+		case listing.ErrInvalidCursor.Error(), listing.ErrInvalidPageSize.Error(), listing.ErrInvalidRange.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
 		return
 	}
 
 	w.Header().Set("Content-Type", rest.DefaultContentType)
-	_ = json.NewEncoder(w).Encode(accountTransfers)
+	_ = json.NewEncoder(w).Encode(transferPage)
+}
+
+// transfersQuery reads the filter and page of a transfers listing from its query params
+func transfersQuery(query url.Values) (listing.TransferFilter, listing.PageRequest, error) {
+	var filter listing.TransferFilter
+	page := listing.PageRequest{Cursor: query.Get("cursor")}
+	var err error
+
+	if status := query.Get("status"); status != "" {
+		if filter.Status, err = transferstatus.Parse(status); err != nil {
+			return filter, page, err
+		}
+	}
+	if direction := query.Get("direction"); direction != "" {
+		if filter.Direction, err = listing.ParseDirection(direction); err != nil {
+			return filter, page, err
+		}
+	}
+	filter.StandingOrderID = query.Get("standing_order_id")
+	filter.CounterpartyID = query.Get("counterparty_id")
+
+	for param, bound := range map[string]*time.Time{"from": &filter.CreatedFrom, "to": &filter.CreatedUntil} {
+		if value := query.Get(param); value != "" {
+			if *bound, err = time.Parse(time.RFC3339, value); err != nil {
+				return filter, page, ErrInvalidTransfersTime
+			}
+		}
+	}
+	for param, bound := range map[string]*money.Money{"min_amount": &filter.MinAmount, "max_amount": &filter.MaxAmount} {
+		if value := query.Get(param); value != "" {
+			if *bound, err = money.Parse(value); err != nil || *bound <= 0 {
+				return filter, page, ErrInvalidTransfersAmount
+			}
+		}
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if page.Sort, err = listing.ParseSort(sort); err != nil {
+			return filter, page, err
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if page.Limit, err = strconv.Atoi(limit); err != nil || page.Limit <= 0 {
+			return filter, page, listing.ErrInvalidPageSize
+		}
+	}
+	return filter, page, nil
 }
@@ -10,8 +10,12 @@ import (
 
 	"github.com/pedroyremolo/transfer-api/pkg"
 	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lim "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
 	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,43 +26,152 @@ func TestGetUserTransfers(t *testing.T) {
 		ID:                   "4as6g84as68gf4as",
 		OriginAccountID:      defaultClientID,
 		DestinationAccountID: "4896as4rfa689tqwrtg",
-		Amount:               23.32,
+		Amount:               money.FromCents(2332),
+		Status:               transferstatus.Completed,
 		CreatedAt:            time.Time{},
 	}
 	defaultReceivedTransfer := listing.Transfer{
 		ID:                   "t4a8g496ag49ga",
 		OriginAccountID:      "4896as4rfa689tqwrtg",
 		DestinationAccountID: defaultClientID,
-		Amount:               23.32,
+		Amount:               money.FromCents(2332),
+		Status:               transferstatus.Failed,
+		FailureReason:        transferstatus.ReasonNotEnoughBalance,
 		CreatedAt:            time.Time{},
 	}
+	authHeader := http.Header{
+		"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+	}
 	tt := []struct {
 		name             string
+		query            string
 		reqHeader        http.Header
 		listingService   *lm.MockService
+		expectedFilter   listing.TransferFilter
+		expectedPage     listing.PageRequest
 		expectedResponse string
 		expectedStatus   int
 	}{
 		{
-			name: "When successfully retrieves account transfers",
-			reqHeader: http.Header{
-				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
-			},
+			name:      "When successfully retrieves account transfers",
+			reqHeader: authHeader,
 			listingService: &lm.MockService{
-				AccountTransfers: listing.AccountTransfers{
-					Sent:     []listing.Transfer{defaultSentTransfer},
-					Received: []listing.Transfer{defaultReceivedTransfer},
+				TransferPage: listing.TransferPage{
+					Transfers:  []listing.Transfer{defaultSentTransfer, defaultReceivedTransfer},
+					NextCursor: "eyJzb3J0IjoiLWNyZWF0ZWRfYXQifQ",
 				},
 				Err: nil,
 			},
-			expectedResponse: `{"sent":[{"id":"4as6g84as68gf4as","account_origin_id":"jff46as84dcsa365418","account_destination_id":"4896as4rfa689tqwrtg","amount":23.32,"created_at":"0001-01-01T00:00:00Z"}],"received":[{"id":"t4a8g496ag49ga","account_origin_id":"4896as4rfa689tqwrtg","account_destination_id":"jff46as84dcsa365418","amount":23.32,"created_at":"0001-01-01T00:00:00Z"}]}`,
+			expectedResponse: `{"transfers":[{"id":"4as6g84as68gf4as","account_origin_id":"jff46as84dcsa365418","account_destination_id":"4896as4rfa689tqwrtg","amount":23.32,"status":"completed","created_at":"0001-01-01T00:00:00Z"},{"id":"t4a8g496ag49ga","account_origin_id":"4896as4rfa689tqwrtg","account_destination_id":"jff46as84dcsa365418","amount":23.32,"status":"failed","failure_reason":"not_enough_balance","created_at":"0001-01-01T00:00:00Z"}],"next_cursor":"eyJzb3J0IjoiLWNyZWF0ZWRfYXQifQ"}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:      "When filtering account transfers by status",
+			query:     "?status=completed",
+			reqHeader: authHeader,
+			listingService: &lm.MockService{
+				TransferPage: listing.TransferPage{Transfers: []listing.Transfer{defaultSentTransfer}},
+			},
+			expectedFilter:   listing.TransferFilter{Status: transferstatus.Completed},
+			expectedResponse: `{"transfers":[{"id":"4as6g84as68gf4as","account_origin_id":"jff46as84dcsa365418","account_destination_id":"4896as4rfa689tqwrtg","amount":23.32,"status":"completed","created_at":"0001-01-01T00:00:00Z"}]}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:      "When filtering the transfers of a standing order",
+			query:     "?standing_order_id=5f8f8ccb30a1cd7511c5cb74",
+			reqHeader: authHeader,
+			listingService: &lm.MockService{
+				TransferPage: listing.TransferPage{Transfers: []listing.Transfer{}},
+			},
+			expectedFilter:   listing.TransferFilter{StandingOrderID: "5f8f8ccb30a1cd7511c5cb74"},
+			expectedResponse: `{"transfers":[]}`,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:      "When filtering, sorting and paginating account transfers",
+			query:     "?direction=sent&counterparty_id=4896as4rfa689tqwrtg&from=2020-10-01T00:00:00Z&to=2020-10-31T23:59:59Z&min_amount=10&max_amount=99.90&sort=-amount&limit=50&cursor=eyJzb3J0IjoiLWFtb3VudCJ9",
+			reqHeader: authHeader,
+			listingService: &lm.MockService{
+				TransferPage: listing.TransferPage{Transfers: []listing.Transfer{}},
+			},
+			expectedFilter: listing.TransferFilter{
+				Direction:      listing.Sent,
+				CounterpartyID: "4896as4rfa689tqwrtg",
+				CreatedFrom:    time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+				CreatedUntil:   time.Date(2020, 10, 31, 23, 59, 59, 0, time.UTC),
+				MinAmount:      money.FromCents(1000),
+				MaxAmount:      money.FromCents(9990),
+			},
+			expectedPage: listing.PageRequest{
+				Sort:   listing.Sort{Field: listing.SortByAmount},
+				Limit:  50,
+				Cursor: "eyJzb3J0IjoiLWFtb3VudCJ9",
+			},
+			expectedResponse: `{"transfers":[]}`,
 			expectedStatus:   http.StatusOK,
 		},
 		{
-			name: "When fails to retrieve account transfers",
-			reqHeader: http.Header{
-				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+			name:             "When filtering by an unknown status",
+			query:            "?status=done",
+			reqHeader:        authHeader,
+			listingService:   &lm.MockService{},
+			expectedResponse: `{"status_code":400,"message":"status must be one of pending, completed, failed or reversed"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When filtering by an unknown direction",
+			query:            "?direction=both",
+			reqHeader:        authHeader,
+			listingService:   &lm.MockService{},
+			expectedResponse: `{"status_code":400,"message":"direction must be one of sent or received"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When filtering by a malformed date",
+			query:            "?from=2020-10-01",
+			reqHeader:        authHeader,
+			listingService:   &lm.MockService{},
+			expectedResponse: `{"status_code":400,"message":"from and to must be RFC 3339 date-times, as 2020-10-21T10:00:00Z"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When filtering by a malformed amount",
+			query:            "?max_amount=10.001",
+			reqHeader:        authHeader,
+			listingService:   &lm.MockService{},
+			expectedResponse: `{"status_code":400,"message":"min_amount and max_amount must be numbers with at most two decimal places"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When sorting by an unknown field",
+			query:            "?sort=status",
+			reqHeader:        authHeader,
+			listingService:   &lm.MockService{},
+			expectedResponse: `{"status_code":400,"message":"sort must be one of created_at, -created_at, amount or -amount"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:             "When limit is not a number",
+			query:            "?limit=all",
+			reqHeader:        authHeader,
+			listingService:   &lm.MockService{},
+			expectedResponse: `{"status_code":400,"message":"limit must be between 1 and 100"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:      "When cursor is invalid",
+			query:     "?cursor=foo",
+			reqHeader: authHeader,
+			listingService: &lm.MockService{
+				Err: listing.ErrInvalidCursor,
 			},
+			expectedPage:     listing.PageRequest{Cursor: "foo"},
+			expectedResponse: `{"status_code":400,"message":"cursor is invalid or was issued for another sort"}`,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:      "When fails to retrieve account transfers",
+			reqHeader: authHeader,
 			listingService: &lm.MockService{
 				Err: errors.New("db error"),
 			},
@@ -69,9 +182,9 @@ func TestGetUserTransfers(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			handler := NewHandler(logger, tc.listingService)
+			handler := NewHandler(logger, tc.listingService, &lim.MockService{}, &aum.MockService{})
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest(http.MethodGet, "/transfers", nil)
+			r := httptest.NewRequest(http.MethodGet, "/transfers"+tc.query, nil)
 			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, defaultClientID))
 			r.Header = tc.reqHeader
 
@@ -81,6 +194,14 @@ func TestGetUserTransfers(t *testing.T) {
 				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
 			}
 
+			if tc.listingService.TransferFilter != tc.expectedFilter {
+				t.Errorf("Expected transfers filtered by %v; got %v", tc.expectedFilter, tc.listingService.TransferFilter)
+			}
+
+			if tc.listingService.PageRequest != tc.expectedPage {
+				t.Errorf("Expected transfers page %v; got %v", tc.expectedPage, tc.listingService.PageRequest)
+			}
+
 			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
 		})
 	}
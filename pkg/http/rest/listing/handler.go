@@ -1,6 +1,8 @@
 package listing
 
 import (
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
 	"github.com/pedroyremolo/transfer-api/pkg/listing"
 	"github.com/sirupsen/logrus"
 )
@@ -8,12 +10,16 @@ import (
 type Handler struct {
 	logger *logrus.Entry
 
-	service listing.Service
+	service         listing.Service
+	limitingService limiting.Service
+	authService     authenticating.Service
 }
 
-func NewHandler(logger *logrus.Entry, service listing.Service) Handler {
+func NewHandler(logger *logrus.Entry, service listing.Service, limitingService limiting.Service, authService authenticating.Service) Handler {
 	return Handler{
-		logger:  logger,
-		service: service,
+		logger:          logger,
+		service:         service,
+		limitingService: limitingService,
+		authService:     authService,
 	}
 }
@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	lim "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/limiting"
 	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
 	"github.com/sirupsen/logrus"
 )
@@ -32,7 +35,7 @@ func TestGetAccounts(t *testing.T) {
 						Name:      "Monkey D. Luffy",
 						CPF:       "11111111030",
 						Secret:    "t89awsg4189a1f9a8s1d",
-						Balance:   100000.42,
+						Balance:   money.FromCents(10000042),
 						CreatedAt: &currentTime,
 					},
 					{
@@ -40,7 +43,7 @@ func TestGetAccounts(t *testing.T) {
 						Name:      "Harry Potter",
 						CPF:       "95360976055",
 						Secret:    "4wq89fa6s19q8etg498a",
-						Balance:   40000.42,
+						Balance:   money.FromCents(4000042),
 						CreatedAt: &currentTime,
 					},
 				},
@@ -67,7 +70,7 @@ func TestGetAccounts(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			handler := NewHandler(logger, tc.service)
+			handler := NewHandler(logger, tc.service, &lim.MockService{}, &aum.MockService{})
 			w := httptest.NewRecorder()
 			target := "/accounts"
 			r := httptest.NewRequest(http.MethodGet, target, nil)
@@ -0,0 +1,310 @@
+package listing
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatOFX    = "ofx"
+	formatNDJSON = "ndjson"
+	// flushEvery is the number of lines after which an export is flushed to the client
+	flushEvery         = 100
+	filenameDateLayout = "2006-01-02"
+	// ofxBankID identifies the transfer-api as the bank of the accounts in OFX statements
+	ofxBankID      = "TRANSFER"
+	ofxCurrency    = "BRL"
+	ofxTimeLayout  = "20060102150405.000[0:GMT]"
+	ofxFileHeaders = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+)
+
+var ErrInvalidStatementFormat = errors.New("format must be one of json, csv, ofx or ndjson")
+
+// the type of the CSV rows, the balances of the period being rows of their own around the movements
+const (
+	csvOpening  = "opening"
+	csvMovement = "movement"
+	csvClosing  = "closing"
+)
+
+// ndjsonOpening is the first record of an NDJSON export, before its lines
+type ndjsonOpening struct {
+	AccountID      string      `json:"account_id"`
+	From           time.Time   `json:"from"`
+	To             time.Time   `json:"to"`
+	OpeningBalance money.Money `json:"opening_balance"`
+}
+
+// ndjsonClosing is the last record of an NDJSON export, after its lines
+type ndjsonClosing struct {
+	ClosingBalance money.Money `json:"closing_balance"`
+}
+
+// formatsByMediaType are the formats a statement is exported in by the media types of the Accept header
+var formatsByMediaType = map[string]string{
+	"application/json":     formatJSON,
+	"text/csv":             formatCSV,
+	"application/x-ofx":    formatOFX,
+	"application/x-ndjson": formatNDJSON,
+}
+
+// statementFormat reads the format of a statement from the format query param or, without it, from the first known
+// media type of the Accept header, defaulting to json
+func statementFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch format {
+		case formatJSON, formatCSV, formatOFX, formatNDJSON:
+			return format, nil
+		default:
+			return "", ErrInvalidStatementFormat
+		}
+	}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if format, ok := formatsByMediaType[mediaType]; err == nil && ok {
+			return format, nil
+		}
+	}
+	return formatJSON, nil
+}
+
+// statementExporter writes a statement to the response as it is read, the response status and headers being sent
+// only along with its opening, so that errors before it can still be answered as usual
+type statementExporter struct {
+	w       http.ResponseWriter
+	format  string
+	started bool
+	lines   int
+
+	csv    *csv.Writer
+	ndjson *json.Encoder
+	ofx    *xml.Encoder
+}
+
+func newStatementExporter(w http.ResponseWriter, format string) *statementExporter {
+	return &statementExporter{w: w, format: format}
+}
+
+func (e *statementExporter) WriteOpening(statement listing.Statement) error {
+	e.started = true
+	filename := fmt.Sprintf("statement-%s-%s.%s", statement.From.Format(filenameDateLayout), statement.To.Format(filenameDateLayout), e.format)
+	switch e.format {
+	case formatCSV:
+		e.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		e.csv = csv.NewWriter(e.w)
+		if err := e.csv.Write([]string{"type", "created_at", "transfer_id", "reversal_of", "counterparty_id", "amount", "balance"}); err != nil {
+			return err
+		}
+		return e.csv.Write([]string{csvOpening, statement.From.Format(time.RFC3339Nano), "", "", "", "", statement.OpeningBalance.String()})
+	case formatOFX:
+		e.w.Header().Set("Content-Type", "application/x-ofx")
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		if _, err := io.WriteString(e.w, ofxFileHeaders); err != nil {
+			return err
+		}
+		e.ofx = xml.NewEncoder(e.w)
+		return e.ofxOpening(statement)
+	default:
+		e.w.Header().Set("Content-Type", "application/x-ndjson")
+		e.ndjson = json.NewEncoder(e.w)
+		return e.ndjson.Encode(ndjsonOpening{
+			AccountID:      statement.AccountID,
+			From:           statement.From,
+			To:             statement.To,
+			OpeningBalance: statement.OpeningBalance,
+		})
+	}
+}
+
+func (e *statementExporter) WriteLine(line listing.StatementLine) error {
+	var err error
+	switch e.format {
+	case formatCSV:
+		err = e.csv.Write([]string{
+			csvMovement,
+			line.CreatedAt.Format(time.RFC3339Nano),
+			line.TransferID,
+			line.ReversalOf,
+			line.CounterpartyID,
+			line.Amount.String(),
+			line.Balance.String(),
+		})
+	case formatOFX:
+		err = e.ofx.Encode(ofxTransaction(line))
+	default:
+		err = e.ndjson.Encode(line)
+	}
+	if err != nil {
+		return err
+	}
+	if e.lines++; e.lines%flushEvery == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *statementExporter) WriteClosing(statement listing.Statement) error {
+	var err error
+	switch e.format {
+	case formatCSV:
+		err = e.csv.Write([]string{csvClosing, statement.To.Format(time.RFC3339Nano), "", "", "", "", statement.ClosingBalance.String()})
+	case formatOFX:
+		err = e.ofxClosing(statement)
+	default:
+		err = e.ndjson.Encode(ndjsonClosing{ClosingBalance: statement.ClosingBalance})
+	}
+	if err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+func (e *statementExporter) flush() error {
+	switch {
+	case e.csv != nil:
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	case e.ofx != nil:
+		if err := e.ofx.Flush(); err != nil {
+			return err
+		}
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// ofxStatus is the status of a successful OFX response
+type ofxStatus struct {
+	XMLName  xml.Name `xml:"STATUS"`
+	Code     int      `xml:"CODE"`
+	Severity string   `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	XMLName  xml.Name  `xml:"SIGNONMSGSRSV1"`
+	Status   ofxStatus `xml:"SONRS>STATUS"`
+	DTServer string    `xml:"SONRS>DTSERVER"`
+	Language string    `xml:"SONRS>LANGUAGE"`
+}
+
+type ofxAccount struct {
+	XMLName  xml.Name `xml:"BANKACCTFROM"`
+	BankID   string   `xml:"BANKID"`
+	AcctID   string   `xml:"ACCTID"`
+	AcctType string   `xml:"ACCTTYPE"`
+}
+
+type ofxStatementTransaction struct {
+	XMLName  xml.Name `xml:"STMTTRN"`
+	TrnType  string   `xml:"TRNTYPE"`
+	DTPosted string   `xml:"DTPOSTED"`
+	TrnAmt   string   `xml:"TRNAMT"`
+	FITID    string   `xml:"FITID"`
+	Name     string   `xml:"NAME,omitempty"`
+	Memo     string   `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	XMLName xml.Name `xml:"LEDGERBAL"`
+	BalAmt  string   `xml:"BALAMT"`
+	DTAsOf  string   `xml:"DTASOF"`
+}
+
+// ofxOpening writes the OFX elements up to the start of the transactions list, left open for the lines
+func (e *statementExporter) ofxOpening(statement listing.Statement) error {
+	success := ofxStatus{Code: 0, Severity: "INFO"}
+	if err := e.ofx.EncodeToken(xml.StartElement{Name: xml.Name{Local: "OFX"}}); err != nil {
+		return err
+	}
+	if err := e.ofx.Encode(ofxSignOn{Status: success, DTServer: ofxTime(time.Now()), Language: "POR"}); err != nil {
+		return err
+	}
+	for _, name := range []string{"BANKMSGSRSV1", "STMTTRNRS"} {
+		if err := e.ofx.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	if err := e.ofx.EncodeElement("0", xml.StartElement{Name: xml.Name{Local: "TRNUID"}}); err != nil {
+		return err
+	}
+	if err := e.ofx.Encode(success); err != nil {
+		return err
+	}
+	if err := e.ofx.EncodeToken(xml.StartElement{Name: xml.Name{Local: "STMTRS"}}); err != nil {
+		return err
+	}
+	if err := e.ofx.EncodeElement(ofxCurrency, xml.StartElement{Name: xml.Name{Local: "CURDEF"}}); err != nil {
+		return err
+	}
+	if err := e.ofx.Encode(ofxAccount{BankID: ofxBankID, AcctID: statement.AccountID, AcctType: "CHECKING"}); err != nil {
+		return err
+	}
+	if err := e.ofx.EncodeToken(xml.StartElement{Name: xml.Name{Local: "BANKTRANLIST"}}); err != nil {
+		return err
+	}
+	if err := e.ofx.EncodeElement(ofxTime(statement.From), xml.StartElement{Name: xml.Name{Local: "DTSTART"}}); err != nil {
+		return err
+	}
+	return e.ofx.EncodeElement(ofxTime(statement.To), xml.StartElement{Name: xml.Name{Local: "DTEND"}})
+}
+
+// ofxClosing closes the transactions list with the closing balance and every element left open by ofxOpening
+func (e *statementExporter) ofxClosing(statement listing.Statement) error {
+	if err := e.ofx.EncodeToken(xml.EndElement{Name: xml.Name{Local: "BANKTRANLIST"}}); err != nil {
+		return err
+	}
+	if err := e.ofx.Encode(ofxBalance{BalAmt: statement.ClosingBalance.String(), DTAsOf: ofxTime(statement.To)}); err != nil {
+		return err
+	}
+	for _, name := range []string{"STMTRS", "STMTTRNRS", "BANKMSGSRSV1", "OFX"} {
+		if err := e.ofx.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ofxTransaction is the OFX transaction of a line, identified by its transfer or, for adjustments, its time
+func ofxTransaction(line listing.StatementLine) ofxStatementTransaction {
+	t := ofxStatementTransaction{
+		TrnType:  "CREDIT",
+		DTPosted: ofxTime(line.CreatedAt),
+		TrnAmt:   line.Amount.String(),
+		FITID:    line.TransferID,
+		Name:     line.CounterpartyID,
+	}
+	if line.Amount < 0 {
+		t.TrnType = "DEBIT"
+	}
+	if t.FITID == "" {
+		t.FITID = fmt.Sprintf("ADJ%d", line.CreatedAt.UnixNano())
+		t.Memo = "Adjustment"
+	}
+	if line.ReversalOf != "" {
+		t.Memo = "Reversal of " + line.ReversalOf
+	}
+	return t
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format(ofxTimeLayout)
+}
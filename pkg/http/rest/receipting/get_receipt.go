@@ -0,0 +1,34 @@
+package receipting
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// GetReceipt issues the receipt of the transfer in the path to the account of the token, one of its parties
+func (h Handler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := httprouter.ParamsFromContext(ctx).ByName("id")
+
+	receipt, err := h.service.IssueReceipt(ctx, ctx.Value(pkg.AccountID).(string), id)
+	if err != nil {
+		switch err.Error() {
+		case mongodb.ErrNoTransferWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		case receipting.ErrTransferNotCompleted.Error():
+			rest.SetJSONError(h.logger, err, http.StatusConflict, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(receipt)
+}
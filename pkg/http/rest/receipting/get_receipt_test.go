@@ -0,0 +1,91 @@
+package receipting
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	rm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/receipting"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"github.com/sirupsen/logrus"
+)
+
+var defaultReceipt = receipting.Receipt{
+	TransferID:       "5f8f8ccb30a1cd7511c5cb72",
+	Origin:           listing.Party{AccountID: "a6sf46af6af", Name: "Monkey D. Luffy", CPF: "***.111.110-**"},
+	Destination:      listing.Party{AccountID: "5f8f8ccb30a1cd7511c5cb70", Name: "Roronoa Zoro", CPF: "***.982.247-**"},
+	Amount:           money.FromCents(1050),
+	Status:           transferstatus.Completed,
+	CreatedAt:        time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC),
+	IssuedAt:         time.Date(2020, 10, 22, 8, 30, 0, 0, time.UTC),
+	VerificationCode: "eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl",
+}
+
+func TestGetReceipt(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	tt := []struct {
+		name             string
+		service          *rm.MockService
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:           "When a party asks the receipt",
+			service:        &rm.MockService{Receipt: defaultReceipt},
+			expectedStatus: http.StatusOK,
+			expectedResponse: `{"transfer_id":"5f8f8ccb30a1cd7511c5cb72",` +
+				`"origin":{"account_id":"a6sf46af6af","name":"Monkey D. Luffy","cpf":"***.111.110-**"},` +
+				`"destination":{"account_id":"5f8f8ccb30a1cd7511c5cb70","name":"Roronoa Zoro","cpf":"***.982.247-**"},` +
+				`"amount":10.50,"status":"completed","created_at":"2020-10-21T10:00:00Z","issued_at":"2020-10-22T08:30:00Z",` +
+				`"verification_code":"eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl"}`,
+		},
+		{
+			name:             "When the transfer is not one of the account",
+			service:          &rm.MockService{Err: mongodb.ErrNoTransferWasFound},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"status_code":404,"message":"no transfer was found with the given filter parameters"}`,
+		},
+		{
+			name:             "When the transfer was not completed",
+			service:          &rm.MockService{Err: receipting.ErrTransferNotCompleted},
+			expectedStatus:   http.StatusConflict,
+			expectedResponse: `{"status_code":409,"message":"only completed transfers have receipts"}`,
+		},
+		{
+			name:             "When unexpected errors inside the service occurs",
+			service:          &rm.MockService{Err: errors.New("foo")},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.service)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/transfers/5f8f8ccb30a1cd7511c5cb72/receipt", nil)
+			ctx := context.WithValue(r.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "5f8f8ccb30a1cd7511c5cb72"}})
+			r = r.WithContext(context.WithValue(ctx, pkg.AccountID, "a6sf46af6af"))
+
+			handler.GetReceipt(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.service.AccountID != "a6sf46af6af" {
+				t.Errorf("Expected receipt to be issued to the account of the token; got %s", tc.service.AccountID)
+			}
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package receipting
+
+import (
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger  *logrus.Entry
+	service receipting.Service
+}
+
+func NewHandler(logger *logrus.Entry, service receipting.Service) Handler {
+	return Handler{
+		logger:  logger,
+		service: service,
+	}
+}
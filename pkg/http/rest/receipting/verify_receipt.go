@@ -0,0 +1,36 @@
+package receipting
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+)
+
+var ErrMissingVerificationCode = errors.New("code query param must be informed")
+
+// VerifyReceipt answers the receipt of the verification code in the code query param, being public so that anyone
+// handed a receipt can tell whether it is genuine
+func (h Handler) VerifyReceipt(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		rest.SetJSONError(h.logger, ErrMissingVerificationCode, http.StatusBadRequest, w)
+		return
+	}
+
+	receipt, err := h.service.VerifyReceipt(r.Context(), code)
+	if err != nil {
+		switch err.Error() {
+		case receipting.ErrInvalidVerificationCode.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	_ = json.NewEncoder(w).Encode(receipt)
+}
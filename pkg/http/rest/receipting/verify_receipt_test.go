@@ -0,0 +1,79 @@
+package receipting
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	rm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/receipting"
+	"github.com/sirupsen/logrus"
+)
+
+func TestVerifyReceipt(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	verified := defaultReceipt
+	verified.VerificationCode = ""
+	tt := []struct {
+		name             string
+		query            string
+		service          *rm.MockService
+		expectedCode     string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:           "When the code is genuine",
+			query:          "?code=eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl",
+			service:        &rm.MockService{Receipt: verified},
+			expectedCode:   "eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl",
+			expectedStatus: http.StatusOK,
+			expectedResponse: `{"transfer_id":"5f8f8ccb30a1cd7511c5cb72",` +
+				`"origin":{"account_id":"a6sf46af6af","name":"Monkey D. Luffy","cpf":"***.111.110-**"},` +
+				`"destination":{"account_id":"5f8f8ccb30a1cd7511c5cb70","name":"Roronoa Zoro","cpf":"***.982.247-**"},` +
+				`"amount":10.50,"status":"completed","created_at":"2020-10-21T10:00:00Z","issued_at":"2020-10-22T08:30:00Z"}`,
+		},
+		{
+			name:             "When the code is forged",
+			query:            "?code=eyJhbGciOiJIUzI1NiJ9.e30.Zm9yZ2Vk",
+			service:          &rm.MockService{Err: receipting.ErrInvalidVerificationCode},
+			expectedCode:     "eyJhbGciOiJIUzI1NiJ9.e30.Zm9yZ2Vk",
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"verification code is invalid or was not issued by us"}`,
+		},
+		{
+			name:             "When no code is informed",
+			service:          &rm.MockService{Receipt: verified},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"code query param must be informed"}`,
+		},
+		{
+			name:             "When unexpected errors inside the service occurs",
+			query:            "?code=eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl",
+			service:          &rm.MockService{Err: errors.New("foo")},
+			expectedCode:     "eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl",
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.service)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/receipts/verify"+tc.query, nil)
+
+			handler.VerifyReceipt(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.service.Code != tc.expectedCode {
+				t.Errorf("Expected code %q to be verified; got %q", tc.expectedCode, tc.service.Code)
+			}
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package scheduling
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+func (h Handler) CancelScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+	id := httprouter.ParamsFromContext(ctx).ByName("id")
+
+	if err := h.service.Cancel(ctx, accountID, id); err != nil {
+		switch err.Error() {
+		case mongodb.ErrNoScheduledTransferWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		case scheduling.ErrNotCancellable.Error():
+			rest.SetJSONError(h.logger, err, http.StatusConflict, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	sm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/scheduling"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name              string
+		schedulingService *sm.MockService
+		expectedResponse  string
+		expectedStatus    int
+	}{
+		{
+			name:              "When scheduled transfer is cancelled",
+			schedulingService: &sm.MockService{},
+			expectedStatus:    http.StatusNoContent,
+		},
+		{
+			name:              "When there's no scheduled transfer with the informed id",
+			schedulingService: &sm.MockService{Err: mongodb.ErrNoScheduledTransferWasFound},
+			expectedStatus:    http.StatusNotFound,
+			expectedResponse:  `{"status_code":404,"message":"` + mongodb.ErrNoScheduledTransferWasFound.Error() + `"}`,
+		},
+		{
+			name:              "When scheduled transfer was already run",
+			schedulingService: &sm.MockService{Err: scheduling.ErrNotCancellable},
+			expectedStatus:    http.StatusConflict,
+			expectedResponse:  `{"status_code":409,"message":"only scheduled transfers that are not being processed can be cancelled"}`,
+		},
+		{
+			name:              "When fails to cancel scheduled transfer",
+			schedulingService: &sm.MockService{Err: errors.New("foo")},
+			expectedStatus:    http.StatusInternalServerError,
+			expectedResponse:  `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.schedulingService, &aum.MockService{}, authenticating.DefaultTOTPTransferThreshold)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/transfers/scheduled/5f8f8ccb30a1cd7511c5cb72", nil)
+			ctx := context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g")
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "5f8f8ccb30a1cd7511c5cb72"}})
+			r = r.WithContext(ctx)
+
+			handler.CancelScheduledTransfer(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.schedulingService.Cancelled != "5f8f8ccb30a1cd7511c5cb72" {
+				t.Errorf("Expected scheduled transfer 5f8f8ccb30a1cd7511c5cb72 to be cancelled; got %s", tc.schedulingService.Cancelled)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	sm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCreateStandingOrder(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	endsAt := time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name              string
+		reqBodyJSON       string
+		schedulingService *sm.MockService
+		authService       *aum.MockService
+		expectedOrder     scheduling.StandingOrder
+		expectedPIN       string
+		expectedLocation  string
+		expectedResponse  string
+		expectedStatus    int
+	}{
+		{
+			name:              "When standing order is successfully created",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500,"rule":{"frequency":"monthly","day":5},"starts_at":"2030-01-01T09:00:00Z","ends_at":"2030-12-31T00:00:00Z","occurrences":7,"pin":"4821"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb74"},
+			expectedOrder: scheduling.StandingOrder{
+				OriginAccountID:      "4a6sgf4as6g",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+				Amount:               money.FromCents(50000),
+				Rule:                 scheduling.Rule{Frequency: scheduling.Monthly, Day: 5},
+				StartsAt:             time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC),
+				EndsAt:               &endsAt,
+			},
+			expectedPIN:      "4821",
+			expectedLocation: "/standing-orders/5f8f8ccb30a1cd7511c5cb74",
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:              "When pin is missing",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500,"rule":{"frequency":"weekly","day":1},"starts_at":"2030-01-01T09:00:00Z"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb74"},
+			authService:       &aum.MockService{PINErr: authenticating.ErrPINRequired},
+			expectedStatus:    http.StatusForbidden,
+			expectedResponse:  `{"status_code":403,"message":"the transaction pin is required to authorize transfers of this account","code":"pin_required"}`,
+		},
+		{
+			name:              "When pin is wrong",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500,"rule":{"frequency":"weekly","day":1},"starts_at":"2030-01-01T09:00:00Z","pin":"0000"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb74"},
+			authService:       &aum.MockService{PINErr: authenticating.ErrInvalidPIN},
+			expectedPIN:       "0000",
+			expectedStatus:    http.StatusForbidden,
+			expectedResponse:  `{"status_code":403,"message":"transaction pin doesn't match, verify it and try again","code":"invalid_pin"}`,
+		},
+		{
+			name:              "When order above the TOTP threshold carries an invalid code",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":1500,"rule":{"frequency":"weekly","day":1},"starts_at":"2030-01-01T09:00:00Z","pin":"4821","totp_code":"654321"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb74"},
+			authService:       &aum.MockService{Err: authenticating.ErrInvalidTOTPCode},
+			expectedPIN:       "4821",
+			expectedStatus:    http.StatusForbidden,
+			expectedResponse:  `{"status_code":403,"message":"two-factor authentication code is invalid or was already used","code":"invalid_totp_code"}`,
+		},
+		{
+			name:              "When req body cannot be deserialized as standing order",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500,"max_occurrences":"12"}`,
+			schedulingService: &sm.MockService{},
+			expectedStatus:    http.StatusBadRequest,
+			expectedResponse:  `{"status_code":400,"message":"Invalid StandingOrder entity: expected type int, got string at field max_occurrences"}`,
+		},
+		{
+			name:              "When cron expression is invalid",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500,"rule":{"frequency":"cron","expression":"0 9 *"}}`,
+			schedulingService: &sm.MockService{Err: scheduling.ErrInvalidCronExpression},
+			expectedStatus:    http.StatusBadRequest,
+			expectedResponse:  `{"status_code":400,"message":"` + scheduling.ErrInvalidCronExpression.Error() + `"}`,
+		},
+		{
+			name:              "When standing order would never run",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500,"rule":{"frequency":"monthly","day":5},"ends_at":"2030-01-02T00:00:00Z"}`,
+			schedulingService: &sm.MockService{Err: scheduling.ErrNoOccurrence},
+			expectedStatus:    http.StatusBadRequest,
+			expectedResponse:  `{"status_code":400,"message":"standing order would never run with the given rule, starts_at, ends_at and max_occurrences"}`,
+		},
+		{
+			name:              "When amount is not greater than zero",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":0,"rule":{"frequency":"weekly","day":1}}`,
+			schedulingService: &sm.MockService{Err: transferring.ErrNonPositiveAmount},
+			expectedStatus:    http.StatusBadRequest,
+			expectedResponse:  `{"status_code":400,"message":"amount to be transferred must be greater than zero"}`,
+		},
+		{
+			name:              "When fails to create standing order",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500,"rule":{"frequency":"weekly","day":1}}`,
+			schedulingService: &sm.MockService{Err: errors.New("foo")},
+			expectedStatus:    http.StatusInternalServerError,
+			expectedResponse:  `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.authService == nil {
+				tc.authService = &aum.MockService{}
+			}
+			handler := NewHandler(logger, tc.schedulingService, tc.authService, authenticating.DefaultTOTPTransferThreshold)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/standing-orders", bytes.NewBufferString(tc.reqBodyJSON))
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+
+			handler.CreateStandingOrder(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			rejected := w.Code == http.StatusForbidden || w.Code == http.StatusTooManyRequests
+			if rejected && tc.schedulingService.Order.OriginAccountID != "" {
+				t.Errorf("Expected no standing order to be created; got %v", tc.schedulingService.Order)
+			}
+			if tc.authService.PIN != tc.expectedPIN {
+				t.Errorf("Expected pin %q to be checked; got %q", tc.expectedPIN, tc.authService.PIN)
+			}
+			if tc.expectedStatus == http.StatusCreated {
+				got := tc.schedulingService.Order
+				if got.OriginAccountID != tc.expectedOrder.OriginAccountID ||
+					got.DestinationAccountID != tc.expectedOrder.DestinationAccountID ||
+					got.Amount != tc.expectedOrder.Amount ||
+					got.Rule != tc.expectedOrder.Rule ||
+					!got.StartsAt.Equal(tc.expectedOrder.StartsAt) ||
+					got.EndsAt == nil || !got.EndsAt.Equal(*tc.expectedOrder.EndsAt) ||
+					got.Occurrences != 0 ||
+					got.PIN != "" {
+					t.Errorf("Expected standing order %v; got %v", tc.expectedOrder, got)
+				}
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected location %s; got %s", tc.expectedLocation, location)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package scheduling
+
+import (
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger      *logrus.Entry
+	service     scheduling.Service
+	authService authenticating.Service
+	// totpThreshold is the amount above which scheduled transfers and standing orders need a TOTP code, as transfers do
+	totpThreshold money.Money
+}
+
+func NewHandler(logger *logrus.Entry, service scheduling.Service, authService authenticating.Service, totpThreshold money.Money) Handler {
+	return Handler{
+		logger:        logger,
+		service:       service,
+		authService:   authService,
+		totpThreshold: totpThreshold,
+	}
+}
@@ -0,0 +1,95 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	sm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/scheduling"
+	"github.com/sirupsen/logrus"
+)
+
+func TestListScheduledTransfers(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	scheduledFor := time.Date(2030, 10, 21, 10, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name              string
+		schedulingService *sm.MockService
+		expectedResponse  string
+		expectedStatus    int
+	}{
+		{
+			name: "When account has scheduled transfers",
+			schedulingService: &sm.MockService{Transfers: []scheduling.ScheduledTransfer{
+				{
+					ID:                   "5f8f8ccb30a1cd7511c5cb72",
+					OriginAccountID:      "4a6sgf4as6g",
+					DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+					Amount:               money.FromCents(1111),
+					ScheduledFor:         scheduledFor,
+					Status:               scheduling.Scheduled,
+					NextAttemptAt:        scheduledFor,
+					CreatedAt:            createdAt,
+				},
+				{
+					ID:                   "5f8f8ccb30a1cd7511c5cb73",
+					OriginAccountID:      "4a6sgf4as6g",
+					DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+					Amount:               money.FromCents(500),
+					ScheduledFor:         scheduledFor,
+					Status:               scheduling.Failed,
+					Attempts:             3,
+					LastError:            "not enough balance to execute this operation",
+					TransferID:           "5f8f8ccb30a1cd7511c5cb74",
+					CreatedAt:            createdAt,
+				},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedResponse: `[{"id":"5f8f8ccb30a1cd7511c5cb72","account_origin_id":"4a6sgf4as6g","account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z","status":"scheduled","attempts":0,"created_at":"2020-10-21T10:00:00Z"},` +
+				`{"id":"5f8f8ccb30a1cd7511c5cb73","account_origin_id":"4a6sgf4as6g","account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":5.00,"scheduled_for":"2030-10-21T10:00:00Z","status":"failed","attempts":3,"last_error":"not enough balance to execute this operation","transfer_id":"5f8f8ccb30a1cd7511c5cb74","created_at":"2020-10-21T10:00:00Z"}]`,
+		},
+		{
+			name:              "When account has no scheduled transfers",
+			schedulingService: &sm.MockService{Transfers: []scheduling.ScheduledTransfer{}},
+			expectedStatus:    http.StatusOK,
+			expectedResponse:  `[]`,
+		},
+		{
+			name:              "When fails to retrieve scheduled transfers",
+			schedulingService: &sm.MockService{Err: errors.New("foo")},
+			expectedStatus:    http.StatusInternalServerError,
+			expectedResponse:  `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.schedulingService, &aum.MockService{}, authenticating.DefaultTOTPTransferThreshold)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/transfers/scheduled", nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+
+			handler.ListScheduledTransfers(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	sm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/scheduling"
+	"github.com/sirupsen/logrus"
+)
+
+func TestListStandingOrders(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+	startsAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	nextRunAt := time.Date(2030, 2, 5, 9, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name              string
+		schedulingService *sm.MockService
+		expectedResponse  string
+		expectedStatus    int
+	}{
+		{
+			name: "When account has standing orders",
+			schedulingService: &sm.MockService{Orders: []scheduling.StandingOrder{
+				{
+					ID:                   "5f8f8ccb30a1cd7511c5cb74",
+					OriginAccountID:      "4a6sgf4as6g",
+					DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+					Amount:               money.FromCents(50000),
+					Rule:                 scheduling.Rule{Frequency: scheduling.Monthly, Day: 5},
+					StartsAt:             startsAt,
+					MaxOccurrences:       12,
+					Occurrences:          1,
+					NextRunAt:            &nextRunAt,
+					Status:               scheduling.OrderActive,
+					CreatedAt:            createdAt,
+				},
+			}},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `[{"id":"5f8f8ccb30a1cd7511c5cb74","account_origin_id":"4a6sgf4as6g","account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":500.00,"rule":{"frequency":"monthly","day":5},"starts_at":"2030-01-01T09:00:00Z","max_occurrences":12,"occurrences":1,"next_run_at":"2030-02-05T09:00:00Z","status":"active","created_at":"2020-10-21T10:00:00Z"}]`,
+		},
+		{
+			name:              "When fails to retrieve standing orders",
+			schedulingService: &sm.MockService{Err: errors.New("foo")},
+			expectedStatus:    http.StatusInternalServerError,
+			expectedResponse:  `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.schedulingService, &aum.MockService{}, authenticating.DefaultTOTPTransferThreshold)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/standing-orders", nil)
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+
+			handler.ListStandingOrders(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	sm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/scheduling"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPauseStandingOrder(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name              string
+		schedulingService *sm.MockService
+		expectedResponse  string
+		expectedStatus    int
+	}{
+		{
+			name:              "When standing order is paused",
+			schedulingService: &sm.MockService{},
+			expectedStatus:    http.StatusNoContent,
+		},
+		{
+			name:              "When there's no standing order with the informed id",
+			schedulingService: &sm.MockService{Err: mongodb.ErrNoStandingOrderWasFound},
+			expectedStatus:    http.StatusNotFound,
+			expectedResponse:  `{"status_code":404,"message":"no standing order was found with the given filter parameters"}`,
+		},
+		{
+			name:              "When standing order is not active",
+			schedulingService: &sm.MockService{Err: scheduling.ErrNotPausable},
+			expectedStatus:    http.StatusConflict,
+			expectedResponse:  `{"status_code":409,"message":"` + scheduling.ErrNotPausable.Error() + `"}`,
+		},
+		{
+			name:              "When fails to pause standing order",
+			schedulingService: &sm.MockService{Err: errors.New("foo")},
+			expectedStatus:    http.StatusInternalServerError,
+			expectedResponse:  `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.schedulingService, &aum.MockService{}, authenticating.DefaultTOTPTransferThreshold)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/standing-orders/5f8f8ccb30a1cd7511c5cb74/pause", nil)
+			ctx := context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g")
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "5f8f8ccb30a1cd7511c5cb74"}})
+			r = r.WithContext(ctx)
+
+			handler.PauseStandingOrder(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.schedulingService.Updated != "5f8f8ccb30a1cd7511c5cb74" {
+				t.Errorf("Expected standing order 5f8f8ccb30a1cd7511c5cb74 to be updated; got %s", tc.schedulingService.Updated)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package scheduling
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+)
+
+// ResumeStandingOrder activates the paused standing order in the path, skipping the occurrences it had while paused
+func (h Handler) ResumeStandingOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := ctx.Value(pkg.AccountID).(string)
+	id := httprouter.ParamsFromContext(ctx).ByName("id")
+
+	if err := h.service.ResumeStandingOrder(ctx, accountID, id); err != nil {
+		switch err.Error() {
+		case mongodb.ErrNoStandingOrderWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		case scheduling.ErrNotResumable.Error(),
+			scheduling.ErrStandingOrderChanged.Error():
+			rest.SetJSONError(h.logger, err, http.StatusConflict, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+)
+
+func (h Handler) ScheduleTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var body scheduling.ScheduledTransfer
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+	// only what the client books is taken from the body, the rest is up to the scheduler
+	transfer := scheduling.ScheduledTransfer{
+		OriginAccountID:      ctx.Value(pkg.AccountID).(string),
+		DestinationAccountID: body.DestinationAccountID,
+		Amount:               body.Amount,
+		ScheduledFor:         body.ScheduledFor,
+	}
+
+	// the executor makes the transfer later on without any credentials, so they are checked when it is booked
+	authorization := authenticating.TransferAuthorization{
+		AccountID: transfer.OriginAccountID,
+		Amount:    transfer.Amount,
+		PIN:       body.PIN,
+		TOTPCode:  body.TOTPCode,
+	}
+	if err := h.authService.AuthorizeTransfer(ctx, authorization, h.totpThreshold); err != nil {
+		rest.NothingPersisted(r)
+		rest.SetTransferAuthorizationError(h.logger, err, w)
+		return
+	}
+
+	id, err := h.service.Schedule(ctx, transfer)
+	if err != nil {
+		rest.NothingPersisted(r)
+		switch err.Error() {
+		case scheduling.ErrScheduledForPast.Error(),
+			transferring.ErrSameAccount.Error(),
+			transferring.ErrNonPositiveAmount.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Location", fmt.Sprintf("/transfers/scheduled/%s", id))
+	w.WriteHeader(http.StatusCreated)
+}
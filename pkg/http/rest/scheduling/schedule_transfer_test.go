@@ -0,0 +1,167 @@
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	sm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScheduleTransfer(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name              string
+		reqBodyJSON       string
+		schedulingService *sm.MockService
+		authService       *aum.MockService
+		expectedTransfer  scheduling.ScheduledTransfer
+		expectedPIN       string
+		expectedTOTPCode  string
+		expectedLocation  string
+		expectedResponse  string
+		expectedStatus    int
+	}{
+		{
+			name:              "When transfer is successfully scheduled",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z","status":"executed","pin":"4821"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			expectedTransfer: scheduling.ScheduledTransfer{
+				OriginAccountID:      "4a6sgf4as6g",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+				Amount:               money.FromCents(1111),
+				ScheduledFor:         time.Date(2030, 10, 21, 10, 0, 0, 0, time.UTC),
+			},
+			expectedPIN:      "4821",
+			expectedLocation: "/transfers/scheduled/5f8f8ccb30a1cd7511c5cb72",
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:              "When transfer above the TOTP threshold is scheduled along with a code",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":1500.00,"scheduled_for":"2030-10-21T10:00:00Z","pin":"4821","totp_code":"123456"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			expectedTransfer: scheduling.ScheduledTransfer{
+				OriginAccountID:      "4a6sgf4as6g",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+				Amount:               money.FromCents(150000),
+				ScheduledFor:         time.Date(2030, 10, 21, 10, 0, 0, 0, time.UTC),
+			},
+			expectedPIN:      "4821",
+			expectedTOTPCode: "123456",
+			expectedLocation: "/transfers/scheduled/5f8f8ccb30a1cd7511c5cb72",
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:              "When pin is missing",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			authService:       &aum.MockService{PINErr: authenticating.ErrPINRequired},
+			expectedStatus:    http.StatusForbidden,
+			expectedResponse:  `{"status_code":403,"message":"the transaction pin is required to authorize transfers of this account","code":"pin_required"}`,
+		},
+		{
+			name:              "When pin is wrong",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z","pin":"0000"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			authService:       &aum.MockService{PINErr: authenticating.ErrInvalidPIN},
+			expectedPIN:       "0000",
+			expectedStatus:    http.StatusForbidden,
+			expectedResponse:  `{"status_code":403,"message":"transaction pin doesn't match, verify it and try again","code":"invalid_pin"}`,
+		},
+		{
+			name:              "When pin is locked",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z","pin":"4821"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			authService:       &aum.MockService{PINErr: authenticating.ErrPINLocked},
+			expectedPIN:       "4821",
+			expectedStatus:    http.StatusTooManyRequests,
+			expectedResponse:  `{"status_code":429,"message":"` + authenticating.ErrPINLocked.Error() + `","code":"pin_locked"}`,
+		},
+		{
+			name:              "When transfer above the TOTP threshold lacks a code",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":1500.00,"scheduled_for":"2030-10-21T10:00:00Z","pin":"4821"}`,
+			schedulingService: &sm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			authService:       &aum.MockService{Err: authenticating.ErrTOTPRequired},
+			expectedPIN:       "4821",
+			expectedStatus:    http.StatusForbidden,
+			expectedResponse:  `{"status_code":403,"message":"a two-factor authentication code is required for this operation","code":"totp_required"}`,
+		},
+		{
+			name:              "When req body cannot be deserialized as scheduled transfer",
+			reqBodyJSON:       `{"account_destination_id":123,"amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z"}`,
+			schedulingService: &sm.MockService{},
+			expectedStatus:    http.StatusBadRequest,
+			expectedResponse:  `{"status_code":400,"message":"Invalid ScheduledTransfer entity: expected type string, got number at field account_destination_id"}`,
+		},
+		{
+			name:              "When transfer is scheduled for the past",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"scheduled_for":"2020-10-21T10:00:00Z"}`,
+			schedulingService: &sm.MockService{Err: scheduling.ErrScheduledForPast},
+			expectedStatus:    http.StatusBadRequest,
+			expectedResponse:  `{"status_code":400,"message":"scheduled_for must be a future date-time"}`,
+		},
+		{
+			name:              "When origin and destination accounts are the same",
+			reqBodyJSON:       `{"account_destination_id":"4a6sgf4as6g","amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z"}`,
+			schedulingService: &sm.MockService{Err: transferring.ErrSameAccount},
+			expectedStatus:    http.StatusBadRequest,
+			expectedResponse:  `{"status_code":400,"message":"origin and destination accounts must be different"}`,
+		},
+		{
+			name:              "When fails to schedule transfer",
+			reqBodyJSON:       `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"scheduled_for":"2030-10-21T10:00:00Z"}`,
+			schedulingService: &sm.MockService{Err: errors.New("foo")},
+			expectedStatus:    http.StatusInternalServerError,
+			expectedResponse:  `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.authService == nil {
+				tc.authService = &aum.MockService{}
+			}
+			handler := NewHandler(logger, tc.schedulingService, tc.authService, authenticating.DefaultTOTPTransferThreshold)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/transfers/scheduled", bytes.NewBufferString(tc.reqBodyJSON))
+			r = r.WithContext(context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g"))
+
+			handler.ScheduleTransfer(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			rejected := w.Code == http.StatusForbidden || w.Code == http.StatusTooManyRequests
+			if (tc.expectedStatus == http.StatusCreated || rejected) && tc.schedulingService.Transfer != tc.expectedTransfer {
+				t.Errorf("Expected scheduled transfer %v; got %v", tc.expectedTransfer, tc.schedulingService.Transfer)
+			}
+			if tc.authService.PIN != tc.expectedPIN {
+				t.Errorf("Expected pin %q to be checked; got %q", tc.expectedPIN, tc.authService.PIN)
+			}
+			if tc.authService.TOTPCode != tc.expectedTOTPCode {
+				t.Errorf("Expected TOTP code %q to be checked; got %q", tc.expectedTOTPCode, tc.authService.TOTPCode)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected location %s; got %s", tc.expectedLocation, location)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
@@ -1,33 +1,25 @@
 package transferring
 
 import (
-	"github.com/pedroyremolo/transfer-api/pkg/adding"
-	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"github.com/pedroyremolo/transfer-api/pkg/transferring"
-	"github.com/pedroyremolo/transfer-api/pkg/updating"
 	"github.com/sirupsen/logrus"
 )
 
 type Handler struct {
-	logger          *logrus.Entry
-	service         transferring.Service
-	listingService  listing.Service
-	addingService   adding.Service
-	updatingService updating.Service
+	logger      *logrus.Entry
+	service     transferring.Service
+	authService authenticating.Service
+	// totpThreshold is the amount above which transfers need a TOTP code from accounts enrolled in TOTP
+	totpThreshold money.Money
 }
 
-func NewHandler(
-	logger *logrus.Entry,
-	service transferring.Service,
-	listingService listing.Service,
-	addingService adding.Service,
-	updatingService updating.Service,
-) Handler {
+func NewHandler(logger *logrus.Entry, service transferring.Service, authService authenticating.Service, totpThreshold money.Money) Handler {
 	return Handler{
-		logger:          logger,
-		service:         service,
-		listingService:  listingService,
-		addingService:   addingService,
-		updatingService: updatingService,
+		logger:        logger,
+		service:       service,
+		authService:   authService,
+		totpThreshold: totpThreshold,
 	}
 }
@@ -2,62 +2,72 @@ package transferring
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/pedroyremolo/transfer-api/pkg"
-	"github.com/pedroyremolo/transfer-api/pkg/adding"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
 	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
 	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
-	"github.com/pedroyremolo/transfer-api/pkg/updating"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
 )
 
+// createdTransfer is the body of a transfer just made, whose id is its Location as well
+type createdTransfer struct {
+	ID string `json:"id"`
+}
+
 func (h Handler) MakeTransfer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	originAccountID := ctx.Value(pkg.AccountID).(string)
-	/* token, err := verifyTokenFromAuthHeader(r, auth, ctx)
-	if err != nil {
-		SetJSONError(err, http.StatusUnauthorized, w)
-		return
-	} */ // will be replaced by middleware
 	decoder := json.NewDecoder(r.Body)
-	var transfer adding.Transfer
+	var transfer transferring.Transfer
 	if err := decoder.Decode(&transfer); err != nil {
 		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
 		return
 	}
+	transfer.OriginAccountID = originAccountID
 
-	originBalance, err := h.listingService.GetAccountBalanceByID(ctx, originAccountID) //take account id from ctx
-	if err != nil {
-		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
-		return
+	authorization := authenticating.TransferAuthorization{
+		AccountID: originAccountID,
+		Amount:    transfer.Amount,
+		PIN:       transfer.PIN,
+		TOTPCode:  transfer.TOTPCode,
 	}
-	destinationBalance, err := h.listingService.GetAccountBalanceByID(ctx, transfer.DestinationAccountID)
-	if err != nil {
-		if err.Error() == mongodb.ErrNoAccountWasFound.Error() {
-			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
-			return
-		}
-		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+	if err := h.authService.AuthorizeTransfer(ctx, authorization, h.totpThreshold); err != nil {
+		rest.NothingPersisted(r)
+		rest.SetTransferAuthorizationError(h.logger, err, w)
 		return
 	}
 
-	var origin, destination updating.Account
-	origin.ID = originAccountID
-	destination.ID = transfer.DestinationAccountID
-	transfer.OriginAccountID = originAccountID
-
-	origin.Balance, destination.Balance, err = h.service.BalanceBetweenAccounts(originBalance, destinationBalance, transfer.Amount)
+	id, err := h.service.MakeTransfer(ctx, transfer)
 	if err != nil {
-		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
-		return
-	}
-
-	if err = h.updatingService.UpdateAccounts(ctx, origin, destination); err != nil {
-		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		if id == "" {
+			rest.NothingPersisted(r)
+		}
+		switch err.Error() {
+		case transferring.ErrNotEnoughBalance.Error(),
+			transferring.ErrSameAccount.Error(),
+			transferring.ErrNonPositiveAmount.Error(),
+			mongodb.ErrNoAccountWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		case limiting.ErrPerTransactionLimitExceeded.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "per_transaction_limit_exceeded", http.StatusBadRequest, w)
+		case limiting.ErrDailyLimitExceeded.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "daily_limit_exceeded", http.StatusBadRequest, w)
+		case limiting.ErrMonthlyLimitExceeded.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "monthly_limit_exceeded", http.StatusBadRequest, w)
+		case limiting.ErrNightTimeLimitExceeded.Error():
+			rest.SetJSONErrorWithCode(h.logger, err, "night_time_limit_exceeded", http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
 		return
 	}
 
-	if _, err = h.addingService.AddTransfer(ctx, transfer); err != nil {
-		rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
-	}
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Location", fmt.Sprintf("/transfers/%s", id))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(createdTransfer{ID: id})
 }
@@ -11,13 +11,13 @@ import (
 
 	"github.com/pedroyremolo/transfer-api/pkg"
 	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
 	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
-	am "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/adding"
 	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
-	lm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/listing"
 	tm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/transferring"
-	um "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/updating"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
 	"github.com/sirupsen/logrus"
 )
 
@@ -29,11 +29,11 @@ func TestMakeTransfer(t *testing.T) {
 		name                string
 		reqBodyJSON         string
 		reqHeader           http.Header
-		authService         *aum.MockService
-		listingService      *lm.MockService
 		transferringService *tm.MockService
-		updatingService     *um.MockService
-		addingService       *am.MockService
+		authService         *aum.MockService
+		expectedTOTPCode    string
+		expectedPIN         string
+		expectedLocation    string
 		expectedResponse    string
 		expectedStatus      int
 	}{
@@ -44,64 +44,13 @@ func TestMakeTransfer(t *testing.T) {
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
-				Err: nil,
-			},
-			listingService: &lm.MockService{
-				Balance: 22.22,
-			},
-			transferringService: &tm.MockService{},
-			updatingService:     &um.MockService{},
-			addingService: &am.MockService{
+			transferringService: &tm.MockService{
 				ID: "f1869a4f9a84f89sa",
 			},
-			expectedStatus: http.StatusOK,
+			expectedLocation: "/transfers/f1869a4f9a84f89sa",
+			expectedResponse: `{"id":"f1869a4f9a84f89sa"}`,
+			expectedStatus:   http.StatusCreated,
 		},
-		/* 		{
-		   			name:        "When no auth header is sent",
-		   			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
-		   			reqHeader: http.Header{
-		   				"Content-Type": []string{"application/json"},
-		   			},
-		   			expectedStatus:   http.StatusUnauthorized,
-		   			expectedResponse: `{"status_code":401,"message":"it seems you don't have or didn't pass valid credentials to this route"}`,
-		   		},
-		   		{
-		   			name:        "When malformed auth header is sent",
-		   			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
-		   			reqHeader: http.Header{
-		   				"Authorization": []string{"Bearerea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
-		   				"Content-Type":  []string{"application/json"},
-		   			},
-		   			expectedStatus:   http.StatusUnauthorized,
-		   			expectedResponse: `{"status_code":401,"message":"it seems you don't have or didn't pass valid credentials to this route"}`,
-		   		},
-		   		{
-		   			name:        "When auth header is not of Bearer type",
-		   			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
-		   			reqHeader: http.Header{
-		   				"Authorization": []string{"Basic ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
-		   				"Content-Type":  []string{"application/json"},
-		   			},
-		   			expectedStatus:   http.StatusUnauthorized,
-		   			expectedResponse: `{"status_code":401,"message":"it seems you don't have or didn't pass valid credentials to this route"}`,
-		   		},
-		   		{
-		   			name:        "When auth header contains an invalid token",
-		   			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
-		   			reqHeader: http.Header{
-		   				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
-		   				"Content-Type":  []string{"application/json"},
-		   			},
-		   			authService: &aum.MockService{
-		   				Err: errors.New("foo"),
-		   			},
-		   			expectedStatus:   http.StatusUnauthorized,
-		   			expectedResponse: `{"status_code":401,"message":"it seems you don't have or didn't pass valid credentials to this route"}`,
-		   		}, */ // to be excluded after middleware refactoring
 		{
 			name:        "When req body cannot be deserialized as transfer",
 			reqBodyJSON: `{"account_destination_id":123,"amount":11.11}`,
@@ -109,71 +58,43 @@ func TestMakeTransfer(t *testing.T) {
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
-				Err: nil,
-			},
-			expectedStatus:   http.StatusBadRequest,
-			expectedResponse: `{"status_code":400,"message":"Invalid Transfer entity: expected type string, got number at field account_destination_id"}`,
+			transferringService: &tm.MockService{},
+			expectedStatus:      http.StatusBadRequest,
+			expectedResponse:    `{"status_code":400,"message":"Invalid Transfer entity: expected type string, got number at field account_destination_id"}`,
 		},
 		{
-			name:        "When fails to retrieve origin account balance",
-			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
+			name:        "When amount has more than two decimal places",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.111}`,
 			reqHeader: http.Header{
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
-				Err: nil,
-			},
-			listingService: &lm.MockService{
-				CallsToFail: 1,
-				Err:         errors.New("foo"),
-			},
-			expectedStatus:   http.StatusInternalServerError,
-			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			transferringService: &tm.MockService{},
+			expectedStatus:      http.StatusBadRequest,
+			expectedResponse:    `{"status_code":400,"message":"amount must be a number with at most two decimal places"}`,
 		},
 		{
-			name:        "When fails to retrieve destination account balance",
-			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
+			name:        "When amount is not greater than zero",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":-11.11}`,
 			reqHeader: http.Header{
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
-				Err: nil,
-			},
-			listingService: &lm.MockService{
-				CallsToFail: 2,
-				Err:         errors.New("foo"),
+			transferringService: &tm.MockService{
+				Err: transferring.ErrNonPositiveAmount,
 			},
-			expectedStatus:   http.StatusInternalServerError,
-			expectedResponse: `{"status_code":500,"message":"foo"}`,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"amount to be transferred must be greater than zero"}`,
 		},
 		{
-			name:        "When there's no destination account with the informed id",
+			name:        "When there's no account with the informed id",
 			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
 			reqHeader: http.Header{
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
-				Err: nil,
-			},
-			listingService: &lm.MockService{
-				CallsToFail: 2,
-				Err:         errors.New("no account was found with the given filter parameters"),
+			transferringService: &tm.MockService{
+				Err: mongodb.ErrNoAccountWasFound,
 			},
 			expectedStatus:   http.StatusBadRequest,
 			expectedResponse: `{"status_code":400,"message":"no account was found with the given filter parameters"}`,
@@ -185,70 +106,196 @@ func TestMakeTransfer(t *testing.T) {
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
+			transferringService: &tm.MockService{
+				Err: transferring.ErrNotEnoughBalance,
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"not enough balance to execute this operation"}`,
+		},
+		{
+			name:        "When origin and destination accounts are the same",
+			reqBodyJSON: `{"account_destination_id":"4a6sgf4as6g","amount":11.11}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
+			},
+			transferringService: &tm.MockService{
+				Err: transferring.ErrSameAccount,
 			},
-			listingService: &lm.MockService{
-				Balance: 22.22,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"origin and destination accounts must be different"}`,
+		},
+		{
+			name:        "When transfer exceeds the daily limit of the origin account",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
 			},
 			transferringService: &tm.MockService{
-				Err: errors.New("not enough origin balance"),
+				Err: limiting.ErrDailyLimitExceeded,
 			},
 			expectedStatus:   http.StatusBadRequest,
-			expectedResponse: `{"status_code":400,"message":"not enough origin balance"}`,
+			expectedResponse: `{"status_code":400,"message":"amount exceeds what is left of the daily limit of the account","code":"daily_limit_exceeded"}`,
 		},
 		{
-			name:        "When fails to update accounts balances",
+			name:        "When transfer exceeds the night-time limit of the origin account",
 			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
 			reqHeader: http.Header{
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
+			transferringService: &tm.MockService{
+				Err: limiting.ErrNightTimeLimitExceeded,
 			},
-			listingService: &lm.MockService{
-				Balance: 22.22,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"status_code":400,"message":"amount exceeds what is left of the night-time limit of the account, from 20h to 6h","code":"night_time_limit_exceeded"}`,
+		},
+		{
+			name:        "When fails to execute transfer",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
 			},
-			transferringService: &tm.MockService{},
-			updatingService: &um.MockService{
+			transferringService: &tm.MockService{
 				Err: errors.New("foo"),
 			},
 			expectedResponse: `{"status_code":500,"message":"foo"}`,
 			expectedStatus:   http.StatusInternalServerError,
 		},
 		{
-			name:        "When fails to add transfers to db",
+			name:        "When transfer above the TOTP threshold carries a code",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":1500.00,"totp_code":"123456"}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
+			},
+			transferringService: &tm.MockService{
+				ID: "f1869a4f9a84f89sa",
+			},
+			authService:      &aum.MockService{},
+			expectedTOTPCode: "123456",
+			expectedLocation: "/transfers/f1869a4f9a84f89sa",
+			expectedResponse: `{"id":"f1869a4f9a84f89sa"}`,
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:        "When transfer above the TOTP threshold of an enrolled account lacks a code",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":1500.00}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
+			},
+			transferringService: &tm.MockService{},
+			authService:         &aum.MockService{Err: authenticating.ErrTOTPRequired},
+			expectedStatus:      http.StatusForbidden,
+			expectedResponse:    `{"status_code":403,"message":"a two-factor authentication code is required for this operation","code":"totp_required"}`,
+		},
+		{
+			name:        "When transfer above the TOTP threshold carries an invalid code",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":1500.00,"totp_code":"654321"}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
+			},
+			transferringService: &tm.MockService{},
+			authService:         &aum.MockService{Err: authenticating.ErrInvalidTOTPCode},
+			expectedTOTPCode:    "654321",
+			expectedStatus:      http.StatusForbidden,
+			expectedResponse:    `{"status_code":403,"message":"two-factor authentication code is invalid or was already used","code":"invalid_totp_code"}`,
+		},
+		{
+			name:        "When transfer below the TOTP threshold carries no code",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":999.99}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
+			},
+			transferringService: &tm.MockService{
+				ID: "f1869a4f9a84f89sa",
+			},
+			authService:      &aum.MockService{Err: authenticating.ErrTOTPRequired},
+			expectedLocation: "/transfers/f1869a4f9a84f89sa",
+			expectedResponse: `{"id":"f1869a4f9a84f89sa"}`,
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:        "When transfer carries the pin of the origin account",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"pin":"4821"}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
+			},
+			transferringService: &tm.MockService{
+				ID: "f1869a4f9a84f89sa",
+			},
+			authService:      &aum.MockService{},
+			expectedPIN:      "4821",
+			expectedLocation: "/transfers/f1869a4f9a84f89sa",
+			expectedResponse: `{"id":"f1869a4f9a84f89sa"}`,
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:        "When transfer lacks the pin of the origin account",
 			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11}`,
 			reqHeader: http.Header{
 				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
 				"Content-Type":  []string{"application/json"},
 			},
-			authService: &aum.MockService{
-				Token: authenticating.Token{
-					ClientID: "4f98as4f98sa496a1f",
-				},
+			transferringService: &tm.MockService{},
+			authService:         &aum.MockService{PINErr: authenticating.ErrPINRequired},
+			expectedStatus:      http.StatusForbidden,
+			expectedResponse:    `{"status_code":403,"message":"the transaction pin is required to authorize transfers of this account","code":"pin_required"}`,
+		},
+		{
+			name:        "When transfer carries a wrong pin",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"pin":"0000"}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
 			},
-			listingService: &lm.MockService{
-				Balance: 22.22,
+			transferringService: &tm.MockService{},
+			authService:         &aum.MockService{PINErr: authenticating.ErrInvalidPIN},
+			expectedPIN:         "0000",
+			expectedStatus:      http.StatusForbidden,
+			expectedResponse:    `{"status_code":403,"message":"transaction pin doesn't match, verify it and try again","code":"invalid_pin"}`,
+		},
+		{
+			name:        "When the pin of the origin account is locked",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":11.11,"pin":"4821"}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
 			},
 			transferringService: &tm.MockService{},
-			updatingService:     &um.MockService{},
-			addingService: &am.MockService{
-				Err: errors.New("foo"),
+			authService:         &aum.MockService{PINErr: authenticating.ErrPINLocked},
+			expectedPIN:         "4821",
+			expectedStatus:      http.StatusTooManyRequests,
+			expectedResponse:    `{"status_code":429,"message":"transaction pin is locked after too many wrong attempts, try again later","code":"pin_locked"}`,
+		},
+		{
+			name:        "When the TOTP of the origin account is locked",
+			reqBodyJSON: `{"account_destination_id":"5f8f8ccb30a1cd7511c5cb70","amount":1500.00,"pin":"4821","totp_code":"123456"}`,
+			reqHeader: http.Header{
+				"Authorization": []string{"Bearer ea4984da84fa8e.ae498f4a9e8f.af84a9f64a9"},
+				"Content-Type":  []string{"application/json"},
 			},
-			expectedResponse: `{"status_code":500,"message":"foo"}`,
-			expectedStatus:   http.StatusInternalServerError,
+			transferringService: &tm.MockService{},
+			authService:         &aum.MockService{Err: authenticating.ErrTOTPLocked},
+			expectedPIN:         "4821",
+			expectedTOTPCode:    "123456",
+			expectedStatus:      http.StatusTooManyRequests,
+			expectedResponse:    `{"status_code":429,"message":"two-factor authentication is locked after too many wrong codes, try again later","code":"totp_locked"}`,
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			handler := NewHandler(logger, tc.transferringService, tc.listingService, tc.addingService, tc.updatingService)
+			if tc.authService == nil {
+				tc.authService = &aum.MockService{}
+			}
+			handler := NewHandler(logger, tc.transferringService, tc.authService, authenticating.DefaultTOTPTransferThreshold)
 
 			var reqBody string
 			jsonBuffer := bytes.NewBuffer([]byte(tc.reqBodyJSON))
@@ -266,6 +313,15 @@ func TestMakeTransfer(t *testing.T) {
 			if w.Code != tc.expectedStatus {
 				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
 			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected location %s; got %s", tc.expectedLocation, location)
+			}
+			if tc.authService.TOTPCode != tc.expectedTOTPCode {
+				t.Errorf("Expected TOTP code %q to be checked; got %q", tc.expectedTOTPCode, tc.authService.TOTPCode)
+			}
+			if tc.authService.PIN != tc.expectedPIN {
+				t.Errorf("Expected pin %q to be checked; got %q", tc.expectedPIN, tc.authService.PIN)
+			}
 
 			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
 		})
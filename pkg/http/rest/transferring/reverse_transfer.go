@@ -0,0 +1,55 @@
+package transferring
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/http/rest"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+)
+
+// ReverseTransfer gives back the amount informed in the body, or all that is left when there's no body,
+// of the transfer in the path to its origin account. Only tokens granting authenticating.ScopeReversalsManage
+// reverse transfers other than the ones received by their account
+func (h Handler) ReverseTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var reversal transferring.Reversal
+	if err := json.NewDecoder(r.Body).Decode(&reversal); err != nil && err != io.EOF {
+		rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		return
+	}
+	reversal.TransferID = httprouter.ParamsFromContext(ctx).ByName("id")
+	reversal.RequesterID = ctx.Value(pkg.AccountID).(string)
+	reversal.ByOperator = authenticating.HasScope(ctx, authenticating.ScopeReversalsManage)
+
+	id, err := h.service.ReverseTransfer(ctx, reversal)
+	if err != nil {
+		// reversals are made all at once, so failing ones leave nothing behind
+		rest.NothingPersisted(r)
+		switch err.Error() {
+		case mongodb.ErrNoTransferWasFound.Error():
+			rest.SetJSONError(h.logger, err, http.StatusNotFound, w)
+		case transferring.ErrNotAllowedToReverse.Error():
+			rest.SetJSONError(h.logger, err, http.StatusForbidden, w)
+		case transferring.ErrNotReversible.Error(),
+			transferring.ErrReversalExceedsAmount.Error(),
+			transferring.ErrNotEnoughBalance.Error(),
+			transferring.ErrNonPositiveAmount.Error():
+			rest.SetJSONError(h.logger, err, http.StatusBadRequest, w)
+		default:
+			rest.SetJSONError(h.logger, err, http.StatusInternalServerError, w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", rest.DefaultContentType)
+	w.Header().Set("Location", fmt.Sprintf("/transfers/%s", id))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(createdTransfer{ID: id})
+}
@@ -0,0 +1,132 @@
+package transferring
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pedroyremolo/transfer-api/pkg"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage/mongodb"
+	"github.com/pedroyremolo/transfer-api/pkg/tests/helpers"
+	aum "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/authenticating"
+	tm "github.com/pedroyremolo/transfer-api/pkg/tests/mocks/transferring"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/sirupsen/logrus"
+)
+
+func TestReverseTransfer(t *testing.T) {
+	log := lgr.NewDefaultLogger()
+	logger := logrus.NewEntry(log)
+
+	tt := []struct {
+		name                string
+		reqBodyJSON         string
+		role                authenticating.Role
+		transferringService *tm.MockService
+		expectedReversal    transferring.Reversal
+		expectedLocation    string
+		expectedResponse    string
+		expectedStatus      int
+	}{
+		{
+			name:                "When what is left of the transfer is reversed",
+			transferringService: &tm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			expectedReversal:    transferring.Reversal{TransferID: "5f8f8ccb30a1cd7511c5cb71", RequesterID: "4a6sgf4as6g"},
+			expectedLocation:    "/transfers/5f8f8ccb30a1cd7511c5cb72",
+			expectedResponse:    `{"id":"5f8f8ccb30a1cd7511c5cb72"}`,
+			expectedStatus:      http.StatusCreated,
+		},
+		{
+			name:                "When part of the transfer is reversed",
+			reqBodyJSON:         `{"amount":5.5}`,
+			transferringService: &tm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			expectedReversal: transferring.Reversal{
+				TransferID:  "5f8f8ccb30a1cd7511c5cb71",
+				RequesterID: "4a6sgf4as6g",
+				Amount:      money.FromCents(550),
+			},
+			expectedLocation: "/transfers/5f8f8ccb30a1cd7511c5cb72",
+			expectedResponse: `{"id":"5f8f8ccb30a1cd7511c5cb72"}`,
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:                "When an admin reverses a transfer it has not received",
+			role:                authenticating.Admin,
+			transferringService: &tm.MockService{ID: "5f8f8ccb30a1cd7511c5cb72"},
+			expectedReversal: transferring.Reversal{
+				TransferID:  "5f8f8ccb30a1cd7511c5cb71",
+				RequesterID: "4a6sgf4as6g",
+				ByOperator:  true,
+			},
+			expectedLocation: "/transfers/5f8f8ccb30a1cd7511c5cb72",
+			expectedResponse: `{"id":"5f8f8ccb30a1cd7511c5cb72"}`,
+			expectedStatus:   http.StatusCreated,
+		},
+		{
+			name:                "When req body cannot be deserialized as reversal",
+			reqBodyJSON:         `{"amount":"5.5"}`,
+			transferringService: &tm.MockService{},
+			expectedStatus:      http.StatusBadRequest,
+			expectedResponse:    `{"status_code":400,"message":"amount must be a number with at most two decimal places"}`,
+		},
+		{
+			name:                "When there's no transfer with the informed id",
+			transferringService: &tm.MockService{Err: mongodb.ErrNoTransferWasFound},
+			expectedStatus:      http.StatusNotFound,
+			expectedResponse:    `{"status_code":404,"message":"no transfer was found with the given filter parameters"}`,
+		},
+		{
+			name:                "When requester has not received the transfer",
+			transferringService: &tm.MockService{Err: transferring.ErrNotAllowedToReverse},
+			expectedStatus:      http.StatusForbidden,
+			expectedResponse:    `{"status_code":403,"message":"only the account that received the transfer can reverse it"}`,
+		},
+		{
+			name:                "When amount exceeds what is left of the transfer",
+			reqBodyJSON:         `{"amount":500}`,
+			transferringService: &tm.MockService{Err: transferring.ErrReversalExceedsAmount},
+			expectedStatus:      http.StatusBadRequest,
+			expectedResponse:    `{"status_code":400,"message":"reversal amount exceeds what is left to be reversed of the transfer"}`,
+		},
+		{
+			name:                "When fails to reverse transfer",
+			transferringService: &tm.MockService{Err: errors.New("foo")},
+			expectedStatus:      http.StatusInternalServerError,
+			expectedResponse:    `{"status_code":500,"message":"foo"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(logger, tc.transferringService, &aum.MockService{}, authenticating.DefaultTOTPTransferThreshold)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/transfers/5f8f8ccb30a1cd7511c5cb71/reversal", bytes.NewBufferString(tc.reqBodyJSON))
+			ctx := context.WithValue(r.Context(), pkg.AccountID, "4a6sgf4as6g")
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "5f8f8ccb30a1cd7511c5cb71"}})
+			ctx = context.WithValue(ctx, pkg.Scopes, authenticating.ScopesOf(tc.role))
+			r = r.WithContext(ctx)
+
+			handler.ReverseTransfer(w, r)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected response status %v; got %v", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusCreated && tc.transferringService.Reversal != tc.expectedReversal {
+				t.Errorf("Expected reversal %v; got %v", tc.expectedReversal, tc.transferringService.Reversal)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Errorf("Expected location %s; got %s", tc.expectedLocation, location)
+			}
+
+			helpers.AssertResponseJSON(t, w, tc.expectedResponse)
+		})
+	}
+}
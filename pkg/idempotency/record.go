@@ -0,0 +1,19 @@
+package idempotency
+
+import "time"
+
+// Record is the outcome of a request made by an account under an idempotency key
+type Record struct {
+	Key         string
+	AccountID   string
+	RequestHash string
+	// Completed tells if the response below was already produced, being false while the first request is in progress
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	// Location is the one of responses that created something, replayed along with them
+	Location  string
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,123 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTTL is how long a key is kept, and therefore how long a request can be safely retried
+	DefaultTTL = time.Hour * 24
+	// maxBeginAttempts bounds how many times Begin reserves a key whose record keeps expiring right after it
+	// collides with it
+	maxBeginAttempts = 3
+)
+
+var ErrKeyReused = errors.New("this idempotency key was already used with a different request")
+var ErrRequestInProgress = errors.New("a request with this idempotency key is still being processed")
+
+// ErrKeyAlreadyExists must be returned by Repository.AddIdempotencyRecord when the account already holds an unexpired record of the key
+var ErrKeyAlreadyExists = errors.New("idempotency key already exists")
+
+type Service interface {
+	// Begin reserves key to the request identified by requestHash. When the key was already used by the same request,
+	// the stored record is returned with replay set to true so its response can be sent again
+	Begin(ctx context.Context, accountID string, key string, requestHash string) (record Record, replay bool, err error)
+	// Complete stores the response produced for a record returned by Begin
+	Complete(ctx context.Context, record Record) error
+	// Release frees a key reserved by Begin, allowing a retry to be processed from scratch
+	Release(ctx context.Context, record Record) error
+}
+
+type Repository interface {
+	AddIdempotencyRecord(ctx context.Context, record Record) error
+	GetIdempotencyRecord(ctx context.Context, accountID string, key string) (Record, error)
+	UpdateIdempotencyRecord(ctx context.Context, record Record) error
+	DeleteIdempotencyRecord(ctx context.Context, accountID string, key string) error
+}
+
+type service struct {
+	r   Repository
+	ttl time.Duration
+	log *logrus.Logger
+}
+
+func NewService(repository Repository) Service {
+	return &service{
+		r:   repository,
+		ttl: DefaultTTL,
+		log: lgr.NewDefaultLogger(),
+	}
+}
+
+func (s *service) Begin(ctx context.Context, accountID string, key string, requestHash string) (Record, bool, error) {
+	s.log.Infof("Reserving idempotency key %s of account %s", key, accountID)
+	now := time.Now().UTC()
+	record := Record{
+		Key:         key,
+		AccountID:   accountID,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	var existing Record
+	for attempt := 1; ; attempt++ {
+		err := s.r.AddIdempotencyRecord(ctx, record)
+		if err == nil {
+			return record, false, nil
+		}
+		if err != ErrKeyAlreadyExists {
+			s.log.Errorf("Err %v when reserving idempotency key %s", err, key)
+			return Record{}, false, err
+		}
+
+		existing, err = s.r.GetIdempotencyRecord(ctx, accountID, key)
+		// the record may expire, or be released, between colliding with it and retrieving it, the key being free
+		// to be reserved again then
+		if err == storage.ErrNoIdempotencyRecordWasFound && attempt < maxBeginAttempts {
+			s.log.Warnf("Idempotency key %s of account %s was freed meanwhile, reserving it again", key, accountID)
+			continue
+		}
+		if err != nil {
+			s.log.Errorf("Err %v when retrieving idempotency key %s", err, key)
+			return Record{}, false, err
+		}
+		break
+	}
+	if existing.RequestHash != requestHash {
+		s.log.Errorf("Idempotency key %s of account %s was reused with a different request", key, accountID)
+		return Record{}, false, ErrKeyReused
+	}
+	if !existing.Completed {
+		s.log.Errorf("Idempotency key %s of account %s is still in progress", key, accountID)
+		return Record{}, false, ErrRequestInProgress
+	}
+
+	s.log.Infof("Replaying response of idempotency key %s of account %s", key, accountID)
+	return existing, true, nil
+}
+
+func (s *service) Complete(ctx context.Context, record Record) error {
+	s.log.Infof("Storing response %d of idempotency key %s", record.StatusCode, record.Key)
+	record.Completed = true
+	if err := s.r.UpdateIdempotencyRecord(ctx, record); err != nil {
+		s.log.Errorf("Err %v when storing response of idempotency key %s", err, record.Key)
+		return err
+	}
+	return nil
+}
+
+func (s *service) Release(ctx context.Context, record Record) error {
+	s.log.Infof("Releasing idempotency key %s of account %s", record.Key, record.AccountID)
+	if err := s.r.DeleteIdempotencyRecord(ctx, record.AccountID, record.Key); err != nil {
+		s.log.Errorf("Err %v when releasing idempotency key %s", err, record.Key)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+)
+
+func TestService_Begin(t *testing.T) {
+	completed := Record{Key: "k3y", AccountID: "4f98as4f98sa496a1f", RequestHash: "h4sh", Completed: true, StatusCode: 200}
+	tt := []struct {
+		name        string
+		requestHash string
+		repository  *mockRepository
+		wantReplay  bool
+		wantErr     error
+	}{
+		{
+			name:        "When key was never used",
+			requestHash: "h4sh",
+			repository:  &mockRepository{},
+		},
+		{
+			name:        "When key was used by the same request",
+			requestHash: "h4sh",
+			repository:  &mockRepository{addErr: ErrKeyAlreadyExists, existing: completed},
+			wantReplay:  true,
+		},
+		{
+			name:        "When key was used by a different request",
+			requestHash: "0th3r",
+			repository:  &mockRepository{addErr: ErrKeyAlreadyExists, existing: completed},
+			wantErr:     ErrKeyReused,
+		},
+		{
+			name:        "When the request using the key is still in progress",
+			requestHash: "h4sh",
+			repository:  &mockRepository{addErr: ErrKeyAlreadyExists, existing: Record{RequestHash: "h4sh"}},
+			wantErr:     ErrRequestInProgress,
+		},
+		{
+			name:        "When the record of the key expires right after colliding with it",
+			requestHash: "h4sh",
+			repository:  &mockRepository{addErr: ErrKeyAlreadyExists, expiresOnGet: true},
+		},
+		{
+			name:        "When repository fails to reserve the key",
+			requestHash: "h4sh",
+			repository:  &mockRepository{addErr: errors.New("foo")},
+			wantErr:     errors.New("foo"),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(tc.repository)
+			record, replay, err := s.Begin(context.TODO(), completed.AccountID, completed.Key, tc.requestHash)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("Begin() err = %v, want %v", err, tc.wantErr)
+			}
+			if replay != tc.wantReplay {
+				t.Errorf("Begin() replay = %v, want %v", replay, tc.wantReplay)
+			}
+			if err == nil && !replay && !record.ExpiresAt.After(record.CreatedAt) {
+				t.Errorf("Expected reserved record to expire after %s, got %s", record.CreatedAt, record.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestService_Complete(t *testing.T) {
+	r := &mockRepository{}
+	s := NewService(r)
+
+	if err := s.Complete(context.TODO(), Record{Key: "k3y", StatusCode: 200}); err != nil {
+		t.Fatalf("Complete() err = %v", err)
+	}
+	if !r.updated.Completed || r.updated.StatusCode != 200 {
+		t.Errorf("Expected record to be stored as completed with its response, got %v", r.updated)
+	}
+}
+
+type mockRepository struct {
+	addErr   error
+	existing Record
+	updated  Record
+	// expiresOnGet makes the record of the key be gone when it's retrieved, freeing the key
+	expiresOnGet bool
+}
+
+func (m *mockRepository) AddIdempotencyRecord(_ context.Context, _ Record) error {
+	return m.addErr
+}
+
+func (m *mockRepository) GetIdempotencyRecord(_ context.Context, _ string, _ string) (Record, error) {
+	if m.expiresOnGet {
+		m.expiresOnGet, m.addErr = false, nil
+		return Record{}, storage.ErrNoIdempotencyRecordWasFound
+	}
+	return m.existing, nil
+}
+
+func (m *mockRepository) UpdateIdempotencyRecord(_ context.Context, record Record) error {
+	m.updated = record
+	return nil
+}
+
+func (m *mockRepository) DeleteIdempotencyRecord(_ context.Context, _ string, _ string) error {
+	return nil
+}
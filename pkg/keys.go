@@ -2,4 +2,15 @@ package pkg
 
 type ContextKey string
 
+// AccountID is the account the request was authenticated as, only set to requests bearing tokens of accounts
 var AccountID ContextKey = "account_id"
+
+// ClientID is the API client the request was authenticated as, set instead of AccountID to requests bearing tokens
+// of API clients
+var ClientID ContextKey = "client_id"
+
+// TokenID is the id of the token the request was authenticated with
+var TokenID ContextKey = "token_id"
+
+// Scopes are the scopes granted by the token the request was authenticated with
+var Scopes ContextKey = "scopes"
@@ -0,0 +1,23 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+type EntryType string
+
+const (
+	Debit  EntryType = "debit"
+	Credit EntryType = "credit"
+)
+
+type Entry struct {
+	AccountID string
+	// TransferID is empty for entries that don't come from a transfer, as opening balances and adjustments
+	TransferID string
+	Type       EntryType
+	Amount     money.Money
+	CreatedAt  time.Time
+}
@@ -0,0 +1,83 @@
+// Package ledger records every balance movement as double-entry bookkeeping: each movement is a set of debit and
+// credit entries of the same total, and an account balance is the sum of its credits minus the sum of its debits
+package ledger
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+// ExternalAccountID is the counterpart of money entering or leaving the system without a transfer,
+// as opening balances and manual adjustments, keeping those movements balanced too
+const ExternalAccountID = "external"
+
+var ErrUnbalancedEntries = errors.New("ledger entries debits and credits must have the same total")
+var ErrNonPositiveEntry = errors.New("ledger entries amount must be greater than zero")
+
+// TransferEntries debits amount from the origin account and credits it to the destination one
+func TransferEntries(transferID string, originAccountID string, destinationAccountID string, amount money.Money, at time.Time) []Entry {
+	return []Entry{
+		{AccountID: originAccountID, TransferID: transferID, Type: Debit, Amount: amount, CreatedAt: at},
+		{AccountID: destinationAccountID, TransferID: transferID, Type: Credit, Amount: amount, CreatedAt: at},
+	}
+}
+
+// OpeningEntries credits the initial balance of an account, returning no entries for an empty one
+func OpeningEntries(accountID string, balance money.Money, at time.Time) []Entry {
+	return AdjustmentEntries(accountID, 0, balance, at)
+}
+
+// AdjustmentEntries moves an account balance from one value to another against ExternalAccountID,
+// returning no entries when both are the same
+func AdjustmentEntries(accountID string, from money.Money, to money.Money, at time.Time) []Entry {
+	switch {
+	case to > from:
+		return []Entry{
+			{AccountID: ExternalAccountID, Type: Debit, Amount: to - from, CreatedAt: at},
+			{AccountID: accountID, Type: Credit, Amount: to - from, CreatedAt: at},
+		}
+	case to < from:
+		return []Entry{
+			{AccountID: accountID, Type: Debit, Amount: from - to, CreatedAt: at},
+			{AccountID: ExternalAccountID, Type: Credit, Amount: from - to, CreatedAt: at},
+		}
+	default:
+		return nil
+	}
+}
+
+// Validate checks entries are meant to be written together, having only positive amounts and balanced totals
+func Validate(entries []Entry) error {
+	var debits, credits money.Money
+	for _, e := range entries {
+		if e.Amount <= 0 {
+			return ErrNonPositiveEntry
+		}
+		switch e.Type {
+		case Debit:
+			debits += e.Amount
+		case Credit:
+			credits += e.Amount
+		}
+	}
+	if debits != credits {
+		return ErrUnbalancedEntries
+	}
+	return nil
+}
+
+// Balance sums the credits of entries minus their debits
+func Balance(entries []Entry) money.Money {
+	var balance money.Money
+	for _, e := range entries {
+		switch e.Type {
+		case Debit:
+			balance -= e.Amount
+		case Credit:
+			balance += e.Amount
+		}
+	}
+	return balance
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+func TestEntries(t *testing.T) {
+	now := time.Now().UTC()
+	tt := []struct {
+		name        string
+		entries     []Entry
+		wantEntries int
+		wantBalance map[string]money.Money
+	}{
+		{
+			name:        "When a transfer is made",
+			entries:     TransferEntries("t1", "origin", "destination", money.FromCents(4242), now),
+			wantEntries: 2,
+			wantBalance: map[string]money.Money{"origin": -4242, "destination": 4242},
+		},
+		{
+			name:        "When an account is opened with balance",
+			entries:     OpeningEntries("account", money.FromCents(10000), now),
+			wantEntries: 2,
+			wantBalance: map[string]money.Money{"account": 10000, ExternalAccountID: -10000},
+		},
+		{
+			name:        "When an account is opened without balance",
+			entries:     OpeningEntries("account", 0, now),
+			wantBalance: map[string]money.Money{"account": 0},
+		},
+		{
+			name:        "When an account balance is lowered",
+			entries:     AdjustmentEntries("account", money.FromCents(10000), money.FromCents(2500), now),
+			wantEntries: 2,
+			wantBalance: map[string]money.Money{"account": -7500, ExternalAccountID: 7500},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if len(tc.entries) != tc.wantEntries {
+				t.Fatalf("Expected %d entries, got %d", tc.wantEntries, len(tc.entries))
+			}
+			if len(tc.entries) > 0 {
+				if err := Validate(tc.entries); err != nil {
+					t.Errorf("Expected entries to be valid, got err %v", err)
+				}
+			}
+			for accountID, want := range tc.wantBalance {
+				var accountEntries []Entry
+				for _, e := range tc.entries {
+					if e.AccountID == accountID {
+						accountEntries = append(accountEntries, e)
+					}
+				}
+				if got := Balance(accountEntries); got != want {
+					t.Errorf("Expected balance %s of account %s, got %s", want, accountID, got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tt := []struct {
+		name    string
+		entries []Entry
+		wantErr error
+	}{
+		{
+			name: "When debits and credits have the same total",
+			entries: []Entry{
+				{AccountID: "a", Type: Debit, Amount: 300},
+				{AccountID: "b", Type: Credit, Amount: 100},
+				{AccountID: "c", Type: Credit, Amount: 200},
+			},
+		},
+		{
+			name: "When debits and credits have different totals",
+			entries: []Entry{
+				{AccountID: "a", Type: Debit, Amount: 300},
+				{AccountID: "b", Type: Credit, Amount: 100},
+			},
+			wantErr: ErrUnbalancedEntries,
+		},
+		{
+			name: "When an entry has no amount",
+			entries: []Entry{
+				{AccountID: "a", Type: Debit, Amount: 0},
+				{AccountID: "b", Type: Credit, Amount: 0},
+			},
+			wantErr: ErrNonPositiveEntry,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Validate(tc.entries); err != tc.wantErr {
+				t.Errorf("Validate() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
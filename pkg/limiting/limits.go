@@ -0,0 +1,108 @@
+package limiting
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+// Tier groups accounts sharing the same default limits, accounts with no tier being Standard ones
+type Tier string
+
+const (
+	Standard Tier = "standard"
+	Premium  Tier = "premium"
+)
+
+// Limits caps what an account may transfer. Daily and monthly totals are of the calendar day and month in
+// Brasília time, while NightTime caps the total of each night, from NightStartHour to NightEndHour
+type Limits struct {
+	PerTransaction money.Money `json:"per_transaction"`
+	Daily          money.Money `json:"daily"`
+	Monthly        money.Money `json:"monthly"`
+	NightTime      money.Money `json:"night_time"`
+}
+
+// AccountLimits are the limits in force for an account, being its custom ones where it has lowered them and
+// the ones of its tier elsewhere
+type AccountLimits struct {
+	Tier       Tier   `json:"tier"`
+	Limits     Limits `json:"limits"`
+	TierLimits Limits `json:"tier_limits"`
+}
+
+// Settings are the limits related data stored along with an account, zero custom limits meaning the tier ones
+type Settings struct {
+	Tier   Tier   `json:"tier"`
+	Custom Limits `json:"limits"`
+}
+
+// Spending is an outgoing transfer of an account, as far as limits are concerned
+type Spending struct {
+	Amount    money.Money
+	CreatedAt time.Time
+}
+
+const (
+	// NightStartHour and NightEndHour bound the night, in Brasília time, in which NightTime caps transfers
+	NightStartHour = 20
+	NightEndHour   = 6
+)
+
+// TierLimits are the default limits of each tier
+var TierLimits = map[Tier]Limits{
+	Standard: {
+		PerTransaction: money.FromCents(500000),
+		Daily:          money.FromCents(1000000),
+		Monthly:        money.FromCents(5000000),
+		NightTime:      money.FromCents(100000),
+	},
+	Premium: {
+		PerTransaction: money.FromCents(5000000),
+		Daily:          money.FromCents(10000000),
+		Monthly:        money.FromCents(50000000),
+		NightTime:      money.FromCents(100000),
+	},
+}
+
+// brasilia has had no daylight saving time since 2019, so a fixed zone spares the need of the tz database
+var brasilia = time.FixedZone("BRT", -3*60*60)
+
+// effective combines custom, which holds zeros where the account uses the tier limits, with tier
+func effective(tier Limits, custom Limits) Limits {
+	pick := func(tierLimit money.Money, customLimit money.Money) money.Money {
+		if customLimit > 0 && customLimit < tierLimit {
+			return customLimit
+		}
+		return tierLimit
+	}
+	return Limits{
+		PerTransaction: pick(tier.PerTransaction, custom.PerTransaction),
+		Daily:          pick(tier.Daily, custom.Daily),
+		Monthly:        pick(tier.Monthly, custom.Monthly),
+		NightTime:      pick(tier.NightTime, custom.NightTime),
+	}
+}
+
+// nightStart returns when the night at is in started, and whether at is in a night at all
+func nightStart(at time.Time) (time.Time, bool) {
+	local := at.In(brasilia)
+	switch {
+	case local.Hour() >= NightStartHour:
+		return time.Date(local.Year(), local.Month(), local.Day(), NightStartHour, 0, 0, 0, brasilia), true
+	case local.Hour() < NightEndHour:
+		return time.Date(local.Year(), local.Month(), local.Day()-1, NightStartHour, 0, 0, 0, brasilia), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func dayStart(at time.Time) time.Time {
+	local := at.In(brasilia)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, brasilia)
+}
+
+func monthStart(at time.Time) time.Time {
+	local := at.In(brasilia)
+	return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, brasilia)
+}
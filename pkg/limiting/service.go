@@ -0,0 +1,182 @@
+package limiting
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrPerTransactionLimitExceeded = errors.New("amount exceeds the per transaction limit of the account")
+var ErrDailyLimitExceeded = errors.New("amount exceeds what is left of the daily limit of the account")
+var ErrMonthlyLimitExceeded = errors.New("amount exceeds what is left of the monthly limit of the account")
+var ErrNightTimeLimitExceeded = errors.New("amount exceeds what is left of the night-time limit of the account, from 20h to 6h")
+var ErrLimitRaise = errors.New("limits can only be lowered, each being greater than zero and not greater than the current one")
+var ErrUnknownTier = errors.New("tier must be either standard or premium")
+var ErrLimitAboveTier = errors.New("limits must be either zero, meaning the one of the tier, or up to the one of the tier")
+
+type Service interface {
+	GetLimits(ctx context.Context, accountID string) (AccountLimits, error)
+	// LowerLimits lowers the limits of the account to the non zero ones of limits, keeping the others
+	LowerLimits(ctx context.Context, accountID string, limits Limits) (AccountLimits, error)
+	// SetLimits sets the tier and custom limits of the account, which unlike LowerLimits may raise them
+	SetLimits(ctx context.Context, accountID string, settings Settings) (AccountLimits, error)
+	// Check tells whether the outgoing transfers of the account, which must already include amount as a pending
+	// transfer made at at, are within its limits. Since pending transfers are counted, concurrent transfers can't
+	// get through a limit together
+	Check(ctx context.Context, accountID string, amount money.Money, at time.Time) error
+}
+
+type Repository interface {
+	GetLimitSettings(ctx context.Context, accountID string) (Settings, error)
+	SetLimitSettings(ctx context.Context, accountID string, settings Settings) error
+	// GetSpendings returns the pending or completed transfers, reversals aside, the account made since since
+	GetSpendings(ctx context.Context, accountID string, since time.Time) ([]Spending, error)
+}
+
+type service struct {
+	r   Repository
+	log *logrus.Logger
+}
+
+func NewService(repository Repository) Service {
+	return &service{
+		r:   repository,
+		log: lgr.NewDefaultLogger(),
+	}
+}
+
+func (s *service) GetLimits(ctx context.Context, accountID string) (AccountLimits, error) {
+	s.log.Infof("Retrieving limits of account %s", accountID)
+	settings, err := s.r.GetLimitSettings(ctx, accountID)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving limit settings of account %s", err, accountID)
+		return AccountLimits{}, err
+	}
+	return accountLimits(settings), nil
+}
+
+func (s *service) LowerLimits(ctx context.Context, accountID string, limits Limits) (AccountLimits, error) {
+	s.log.Infof("Lowering limits of account %s to %v", accountID, limits)
+	current, err := s.GetLimits(ctx, accountID)
+	if err != nil {
+		return AccountLimits{}, err
+	}
+
+	lower := func(currentLimit money.Money, newLimit money.Money) (money.Money, error) {
+		if newLimit == 0 {
+			return currentLimit, nil
+		}
+		if newLimit < 0 || newLimit > currentLimit {
+			return 0, ErrLimitRaise
+		}
+		return newLimit, nil
+	}
+	var custom Limits
+	if custom.PerTransaction, err = lower(current.Limits.PerTransaction, limits.PerTransaction); err != nil {
+		return AccountLimits{}, err
+	}
+	if custom.Daily, err = lower(current.Limits.Daily, limits.Daily); err != nil {
+		return AccountLimits{}, err
+	}
+	if custom.Monthly, err = lower(current.Limits.Monthly, limits.Monthly); err != nil {
+		return AccountLimits{}, err
+	}
+	if custom.NightTime, err = lower(current.Limits.NightTime, limits.NightTime); err != nil {
+		return AccountLimits{}, err
+	}
+
+	settings := Settings{Tier: current.Tier, Custom: custom}
+	if err = s.r.SetLimitSettings(ctx, accountID, settings); err != nil {
+		s.log.Errorf("Err %v when setting custom limits of account %s", err, accountID)
+		return AccountLimits{}, err
+	}
+	return accountLimits(settings), nil
+}
+
+func (s *service) SetLimits(ctx context.Context, accountID string, settings Settings) (AccountLimits, error) {
+	s.log.Infof("Setting limits of account %s to %v", accountID, settings)
+	tierLimits, ok := TierLimits[settings.Tier]
+	if !ok {
+		return AccountLimits{}, ErrUnknownTier
+	}
+	for _, limit := range []struct{ tier, custom money.Money }{
+		{tierLimits.PerTransaction, settings.Custom.PerTransaction},
+		{tierLimits.Daily, settings.Custom.Daily},
+		{tierLimits.Monthly, settings.Custom.Monthly},
+		{tierLimits.NightTime, settings.Custom.NightTime},
+	} {
+		if limit.custom < 0 || limit.custom > limit.tier {
+			return AccountLimits{}, ErrLimitAboveTier
+		}
+	}
+
+	if err := s.r.SetLimitSettings(ctx, accountID, settings); err != nil {
+		s.log.Errorf("Err %v when setting limit settings of account %s", err, accountID)
+		return AccountLimits{}, err
+	}
+	return accountLimits(settings), nil
+}
+
+func (s *service) Check(ctx context.Context, accountID string, amount money.Money, at time.Time) error {
+	limits, err := s.GetLimits(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if amount > limits.Limits.PerTransaction {
+		s.log.Errorf("Amount %s exceeds the per transaction limit %s of account %s", amount, limits.Limits.PerTransaction, accountID)
+		return ErrPerTransactionLimitExceeded
+	}
+
+	since := monthStart(at)
+	night, isNight := nightStart(at)
+	if isNight && night.Before(since) {
+		since = night
+	}
+	spendings, err := s.r.GetSpendings(ctx, accountID, since)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving spendings of account %s", err, accountID)
+		return err
+	}
+
+	var daily, monthly, nightly money.Money
+	day, month := dayStart(at), monthStart(at)
+	for _, spending := range spendings {
+		if !spending.CreatedAt.Before(month) {
+			monthly += spending.Amount
+		}
+		if !spending.CreatedAt.Before(day) {
+			daily += spending.Amount
+		}
+		if isNight && !spending.CreatedAt.Before(night) {
+			nightly += spending.Amount
+		}
+	}
+	switch {
+	case daily > limits.Limits.Daily:
+		s.log.Errorf("Account %s spent %s today, exceeding its daily limit %s", accountID, daily, limits.Limits.Daily)
+		return ErrDailyLimitExceeded
+	case monthly > limits.Limits.Monthly:
+		s.log.Errorf("Account %s spent %s this month, exceeding its monthly limit %s", accountID, monthly, limits.Limits.Monthly)
+		return ErrMonthlyLimitExceeded
+	case nightly > limits.Limits.NightTime:
+		s.log.Errorf("Account %s spent %s tonight, exceeding its night-time limit %s", accountID, nightly, limits.Limits.NightTime)
+		return ErrNightTimeLimitExceeded
+	}
+	return nil
+}
+
+func accountLimits(settings Settings) AccountLimits {
+	tier := settings.Tier
+	if _, ok := TierLimits[tier]; !ok {
+		tier = Standard
+	}
+	return AccountLimits{
+		Tier:       tier,
+		Limits:     effective(TierLimits[tier], settings.Custom),
+		TierLimits: TierLimits[tier],
+	}
+}
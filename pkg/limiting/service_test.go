@@ -0,0 +1,274 @@
+package limiting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+)
+
+func TestService_Check(t *testing.T) {
+	// 14h and 22h of October 21st in Brasília time
+	afternoon := time.Date(2020, 10, 21, 17, 0, 0, 0, time.UTC)
+	night := time.Date(2020, 10, 22, 1, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name      string
+		amount    money.Money
+		at        time.Time
+		settings  Settings
+		spendings []Spending
+		wantErr   error
+		wantSince time.Time
+	}{
+		{
+			name:   "When transfer is within every limit",
+			amount: money.FromCents(400000),
+			at:     afternoon,
+			spendings: []Spending{
+				{Amount: money.FromCents(400000), CreatedAt: afternoon},
+				{Amount: money.FromCents(500000), CreatedAt: afternoon.AddDate(0, 0, -1)},
+			},
+			wantSince: time.Date(2020, 10, 1, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "When amount exceeds the per transaction limit",
+			amount:    money.FromCents(500001),
+			at:        afternoon,
+			spendings: []Spending{{Amount: money.FromCents(500001), CreatedAt: afternoon}},
+			wantErr:   ErrPerTransactionLimitExceeded,
+		},
+		{
+			name:   "When transfers of the day exceed the daily limit",
+			amount: money.FromCents(300000),
+			at:     afternoon,
+			spendings: []Spending{
+				{Amount: money.FromCents(300000), CreatedAt: afternoon},
+				{Amount: money.FromCents(400000), CreatedAt: afternoon.Add(-time.Hour)},
+				{Amount: money.FromCents(300001), CreatedAt: time.Date(2020, 10, 21, 3, 0, 0, 0, time.UTC)},
+			},
+			wantErr: ErrDailyLimitExceeded,
+		},
+		{
+			name:   "When transfers of the month exceed the monthly limit",
+			amount: money.FromCents(100000),
+			at:     afternoon,
+			spendings: []Spending{
+				{Amount: money.FromCents(100000), CreatedAt: afternoon},
+				{Amount: money.FromCents(1000000), CreatedAt: afternoon.AddDate(0, 0, -1)},
+				{Amount: money.FromCents(1000000), CreatedAt: afternoon.AddDate(0, 0, -2)},
+				{Amount: money.FromCents(1000000), CreatedAt: afternoon.AddDate(0, 0, -3)},
+				{Amount: money.FromCents(1000000), CreatedAt: afternoon.AddDate(0, 0, -4)},
+				{Amount: money.FromCents(900001), CreatedAt: afternoon.AddDate(0, 0, -5)},
+			},
+			wantErr: ErrMonthlyLimitExceeded,
+		},
+		{
+			name:   "When transfers of the night exceed the night-time limit",
+			amount: money.FromCents(50000),
+			at:     night,
+			spendings: []Spending{
+				{Amount: money.FromCents(50000), CreatedAt: night},
+				{Amount: money.FromCents(50001), CreatedAt: time.Date(2020, 10, 21, 23, 30, 0, 0, time.UTC)},
+				{Amount: money.FromCents(300000), CreatedAt: afternoon},
+			},
+			wantErr: ErrNightTimeLimitExceeded,
+		},
+		{
+			name:   "When night started in the previous month",
+			amount: money.FromCents(10000),
+			at:     time.Date(2020, 11, 1, 5, 0, 0, 0, time.UTC),
+			spendings: []Spending{
+				{Amount: money.FromCents(10000), CreatedAt: time.Date(2020, 11, 1, 5, 0, 0, 0, time.UTC)},
+			},
+			wantSince: time.Date(2020, 10, 31, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "When account lowered its per transaction limit",
+			amount:    money.FromCents(10001),
+			at:        afternoon,
+			settings:  Settings{Tier: Premium, Custom: Limits{PerTransaction: money.FromCents(10000)}},
+			spendings: []Spending{{Amount: money.FromCents(10001), CreatedAt: afternoon}},
+			wantErr:   ErrPerTransactionLimitExceeded,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{settings: tc.settings, spendings: tc.spendings}
+			s := NewService(repository)
+			err := s.Check(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", tc.amount, tc.at)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("Check() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if !tc.wantSince.IsZero() && !repository.since.Equal(tc.wantSince) {
+				t.Errorf("Expected spendings since %s, got %s", tc.wantSince, repository.since)
+			}
+		})
+	}
+}
+
+func TestService_LowerLimits(t *testing.T) {
+	tt := []struct {
+		name       string
+		settings   Settings
+		limits     Limits
+		settingErr error
+		wantLimits Limits
+		wantErr    error
+	}{
+		{
+			name:   "When absent limits keep their current value",
+			limits: Limits{Daily: money.FromCents(200000)},
+			wantLimits: Limits{
+				PerTransaction: money.FromCents(500000),
+				Daily:          money.FromCents(200000),
+				Monthly:        money.FromCents(5000000),
+				NightTime:      money.FromCents(100000),
+			},
+		},
+		{
+			name:     "When a lowered limit is lowered again",
+			settings: Settings{Tier: Premium, Custom: Limits{NightTime: money.FromCents(50000)}},
+			limits:   Limits{NightTime: money.FromCents(20000)},
+			wantLimits: Limits{
+				PerTransaction: money.FromCents(5000000),
+				Daily:          money.FromCents(10000000),
+				Monthly:        money.FromCents(50000000),
+				NightTime:      money.FromCents(20000),
+			},
+		},
+		{
+			name:     "When a lowered limit would be raised back",
+			settings: Settings{Custom: Limits{NightTime: money.FromCents(50000)}},
+			limits:   Limits{NightTime: money.FromCents(50001)},
+			wantErr:  ErrLimitRaise,
+		},
+		{
+			name:    "When a limit is negative",
+			limits:  Limits{Monthly: money.FromCents(-1)},
+			wantErr: ErrLimitRaise,
+		},
+		{
+			name:       "When account doesn't exist",
+			limits:     Limits{Daily: money.FromCents(200000)},
+			settingErr: storage.ErrNoAccountWasFound,
+			wantErr:    storage.ErrNoAccountWasFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{settings: tc.settings, err: tc.settingErr}
+			s := NewService(repository)
+			limits, err := s.LowerLimits(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", tc.limits)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("LowerLimits() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				if repository.stored != (Settings{}) {
+					t.Errorf("Expected no custom limits to be set, got %v", repository.stored)
+				}
+				return
+			}
+			if repository.stored.Custom != tc.wantLimits || limits.Limits != tc.wantLimits {
+				t.Errorf("Expected limits %v, got %v stored and %v returned", tc.wantLimits, repository.stored.Custom, limits.Limits)
+			}
+		})
+	}
+}
+
+func TestService_SetLimits(t *testing.T) {
+	tt := []struct {
+		name       string
+		settings   Settings
+		wantLimits Limits
+		wantErr    error
+	}{
+		{
+			name:       "When account is raised to premium",
+			settings:   Settings{Tier: Premium},
+			wantLimits: TierLimits[Premium],
+		},
+		{
+			name:       "When a lowered limit is raised back up to the tier one",
+			settings:   Settings{Tier: Standard, Custom: Limits{Daily: money.FromCents(1000000)}},
+			wantLimits: TierLimits[Standard],
+		},
+		{
+			name:     "When a limit is above the one of the tier",
+			settings: Settings{Tier: Standard, Custom: Limits{Daily: money.FromCents(1000001)}},
+			wantErr:  ErrLimitAboveTier,
+		},
+		{
+			name:     "When a limit is negative",
+			settings: Settings{Tier: Standard, Custom: Limits{NightTime: money.FromCents(-1)}},
+			wantErr:  ErrLimitAboveTier,
+		},
+		{
+			name:     "When tier is unknown",
+			settings: Settings{Tier: "gold"},
+			wantErr:  ErrUnknownTier,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{settings: Settings{Custom: Limits{Daily: money.FromCents(100)}}}
+			limits, err := NewService(repository).SetLimits(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", tc.settings)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("SetLimits() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				if repository.stored != (Settings{}) {
+					t.Errorf("Expected no limits to be set, got %v", repository.stored)
+				}
+				return
+			}
+			if repository.stored != tc.settings || limits.Tier != tc.settings.Tier || limits.Limits != tc.wantLimits {
+				t.Errorf("Expected limits %v of tier %s, got %v stored and %v returned", tc.wantLimits, tc.settings.Tier, repository.stored, limits)
+			}
+		})
+	}
+}
+
+func TestService_GetLimits(t *testing.T) {
+	repository := &mockRepository{settings: Settings{Tier: "gold", Custom: Limits{Daily: money.FromCents(99999999)}}}
+	limits, err := NewService(repository).GetLimits(context.TODO(), "5f8f8ccb30a1cd7511c5cb70")
+	if err != nil {
+		t.Fatalf("GetLimits() error = %v", err)
+	}
+	if limits.Tier != Standard || limits.Limits != TierLimits[Standard] {
+		t.Errorf("Expected unknown tier and custom limits above it to fall back to standard, got %v", limits)
+	}
+
+	repository.err = errors.New("foo")
+	if _, err = NewService(repository).GetLimits(context.TODO(), "5f8f8ccb30a1cd7511c5cb70"); err == nil {
+		t.Error("Expected GetLimits() to fail along with the repository")
+	}
+}
+
+type mockRepository struct {
+	settings  Settings
+	spendings []Spending
+	stored    Settings
+	since     time.Time
+	err       error
+}
+
+func (m *mockRepository) GetLimitSettings(_ context.Context, _ string) (Settings, error) {
+	return m.settings, m.err
+}
+
+func (m *mockRepository) SetLimitSettings(_ context.Context, _ string, settings Settings) error {
+	m.stored = settings
+	return m.err
+}
+
+func (m *mockRepository) GetSpendings(_ context.Context, _ string, since time.Time) ([]Spending, error) {
+	m.since = since
+	return m.spendings, m.err
+}
@@ -1,12 +1,43 @@
 package listing
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
 
 type Account struct {
-	ID        string     `json:"id,omitempty"`
-	Name      string     `json:"name,omitempty"`
-	CPF       string     `json:"cpf,omitempty"`
-	Secret    string     `json:"-"`
-	Balance   float64    `json:"balance"`
-	CreatedAt *time.Time `json:"created_at,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	CPF    string `json:"cpf,omitempty"`
+	Secret string `json:"-"`
+	// Role is the authenticating role of the account, empty for customers
+	Role      string      `json:"role,omitempty"`
+	Balance   money.Money `json:"balance"`
+	CreatedAt *time.Time  `json:"created_at,omitempty"`
+}
+
+// AccountStatus tells whether an account can be logged into
+type AccountStatus string
+
+const (
+	AccountActive AccountStatus = "active"
+	// AccountLocked is the status of accounts whose logins are locked out after too many failed attempts
+	AccountLocked AccountStatus = "locked"
+)
+
+// MaskCPF hides the first three and the last two digits of cpf, as 111.111.110-30 is shown as ***.111.110-**.
+// Anything other than the 11 digits of a CPF is masked whole
+func MaskCPF(cpf string) string {
+	if len(cpf) != 11 {
+		return strings.Repeat("*", len(cpf))
+	}
+	for _, c := range cpf {
+		if c < '0' || c > '9' {
+			return strings.Repeat("*", len(cpf))
+		}
+	}
+	return fmt.Sprintf("***.%s.%s-**", cpf[3:6], cpf[6:9])
 }
@@ -1,6 +0,0 @@
-package listing
-
-type AccountTransfers struct {
-	Sent     []Transfer `json:"sent"`
-	Received []Transfer `json:"received"`
-}
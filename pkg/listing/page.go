@@ -0,0 +1,137 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+// SortField is the transfer field a listing is ordered by
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByAmount    SortField = "amount"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidSort = errors.New("sort must be one of created_at, -created_at, amount or -amount")
+var ErrInvalidPageSize = errors.New("limit must be between 1 and 100")
+var ErrInvalidCursor = errors.New("cursor is invalid or was issued for another sort")
+
+// Sort orders transfers by Field, newest or largest first unless Ascending, ties being broken by id
+type Sort struct {
+	Field     SortField
+	Ascending bool
+}
+
+// DefaultSort lists the newest transfers first
+var DefaultSort = Sort{Field: SortByCreatedAt}
+
+// ParseSort reads s as a sort field, descending when prefixed by a minus sign
+func ParseSort(s string) (Sort, error) {
+	sort := Sort{Field: SortField(strings.TrimPrefix(s, "-")), Ascending: !strings.HasPrefix(s, "-")}
+	switch sort.Field {
+	case SortByCreatedAt, SortByAmount:
+		return sort, nil
+	default:
+		return Sort{}, ErrInvalidSort
+	}
+}
+
+func (s Sort) String() string {
+	if s.Ascending {
+		return string(s.Field)
+	}
+	return "-" + string(s.Field)
+}
+
+// Before reports whether a comes before b in a listing ordered by s
+func (s Sort) Before(a Transfer, b Transfer) bool {
+	var cmp int
+	switch s.Field {
+	case SortByAmount:
+		cmp = compare(a.Amount < b.Amount, a.Amount > b.Amount)
+	default:
+		cmp = compare(a.CreatedAt.Before(b.CreatedAt), a.CreatedAt.After(b.CreatedAt))
+	}
+	if cmp == 0 {
+		cmp = compare(a.ID < b.ID, a.ID > b.ID)
+	}
+	if s.Ascending {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+func compare(less bool, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// PageRequest asks for up to Limit transfers, in the given Sort, following the ones the Cursor was issued after
+type PageRequest struct {
+	Sort   Sort
+	Limit  int
+	Cursor string
+}
+
+// TransferPage is a page of transfers, NextCursor being empty on the last one
+type TransferPage struct {
+	Transfers  []Transfer `json:"transfers"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// Cursor is the position of a page in a listing, as the sort keys of the transfer right before it
+type Cursor struct {
+	Sort      string      `json:"sort"`
+	ID        string      `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Amount    money.Money `json:"amount"`
+}
+
+// After reports whether t comes after the cursor in a listing ordered by sort
+func (c Cursor) After(sort Sort, t Transfer) bool {
+	return sort.Before(Transfer{ID: c.ID, CreatedAt: c.CreatedAt, Amount: c.Amount}, t)
+}
+
+// TransferQuery is a page of the transfers of an account as read from the repository, After being nil on the first one
+type TransferQuery struct {
+	Filter TransferFilter
+	Sort   Sort
+	After  *Cursor
+	Limit  int
+}
+
+// encodeCursor makes the opaque cursor to the page following t
+func encodeCursor(sort Sort, t Transfer) string {
+	b, _ := json.Marshal(Cursor{Sort: sort.String(), ID: t.ID, CreatedAt: t.CreatedAt, Amount: t.Amount})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor reads a cursor made by encodeCursor, refusing the ones issued for listings in another sort
+func decodeCursor(sort Sort, cursor string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err = json.Unmarshal(b, &c); err != nil || c.Sort != sort.String() || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -2,23 +2,50 @@ package listing
 
 import (
 	"context"
+	"time"
 
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
 	"github.com/sirupsen/logrus"
 )
 
 type Service interface {
-	GetAccountBalanceByID(ctx context.Context, id string) (float64, error)
+	GetAccountBalanceByID(ctx context.Context, id string) (money.Money, error)
+	// GetAccountBalanceAt rebuilds the balance an account had at the given time from its ledger entries, summed by
+	// the repository rather than read one by one
+	GetAccountBalanceAt(ctx context.Context, id string, at time.Time) (money.Money, error)
+	GetAccountByID(ctx context.Context, id string) (Account, error)
 	GetAccountByCPF(ctx context.Context, cpf string) (Account, error)
 	GetAccounts(ctx context.Context) ([]Account, error)
-	GetTransfersByAccountID(ctx context.Context, id string) (AccountTransfers, error)
+	// GetTransfer returns the transfer id along with its parties, as long as accountID is one of them, it being
+	// reported as not found to any other account
+	GetTransfer(ctx context.Context, accountID string, id string) (TransferDetails, error)
+	// GetTransfersByAccountID returns a page of the transfers sent and received by an account
+	GetTransfersByAccountID(ctx context.Context, id string, filter TransferFilter, page PageRequest) (TransferPage, error)
+	// GetStatement returns the balance movements of an account from from to to, zero values leaving them to defaults
+	GetStatement(ctx context.Context, id string, from time.Time, to time.Time) (Statement, error)
+	// StreamStatement writes the statement of an account to w as its entries are read. Unlike GetStatement, its
+	// period is not bound to MaxStatementPeriod on purpose: neither the opening balance nor the lines are ever held
+	// whole in memory, so a longer period only takes longer to be written
+	StreamStatement(ctx context.Context, id string, from time.Time, to time.Time, w StatementWriter) error
 }
 
 type Repository interface {
 	GetAccountByID(ctx context.Context, id string) (Account, error)
 	GetAccountByCPF(ctx context.Context, cpf string) (Account, error)
 	GetAccounts(ctx context.Context) ([]Account, error)
-	GetTransfersByKey(ctx context.Context, key string, value string) ([]Transfer, error)
+	// GetTransfersByAccountID returns up to query.Limit transfers of an account in query.Sort, following query.After
+	GetTransfersByAccountID(ctx context.Context, accountID string, query TransferQuery) ([]Transfer, error)
+	// GetLedgerBalance sums the credits minus the debits of the ledger entries of an account created up to until,
+	// inclusive, without reading the entries themselves
+	GetLedgerBalance(ctx context.Context, accountID string, until time.Time) (money.Money, error)
+	// StreamEntriesByAccountIDBetween calls fn with each ledger entry of an account created from from to until,
+	// inclusive, oldest first, stopping at the first error fn returns
+	StreamEntriesByAccountIDBetween(ctx context.Context, accountID string, from time.Time, until time.Time, fn func(ledger.Entry) error) error
+	// GetTransfersByIDs returns the transfers of the given ids, leaving out the ones not found
+	GetTransfersByIDs(ctx context.Context, ids []string) ([]Transfer, error)
 }
 
 type service struct {
@@ -30,7 +57,7 @@ func NewService(repository Repository) Service {
 	return &service{repository, lgr.NewDefaultLogger()}
 }
 
-func (s *service) GetAccountBalanceByID(ctx context.Context, id string) (float64, error) {
+func (s *service) GetAccountBalanceByID(ctx context.Context, id string) (money.Money, error) {
 	s.log.Infof("Getting account balance by id %s", id)
 	account, err := s.r.GetAccountByID(ctx, id)
 	if err != nil {
@@ -41,6 +68,20 @@ func (s *service) GetAccountBalanceByID(ctx context.Context, id string) (float64
 	return account.Balance, nil
 }
 
+func (s *service) GetAccountBalanceAt(ctx context.Context, id string, at time.Time) (money.Money, error) {
+	s.log.Infof("Rebuilding account %s balance at %s", id, at)
+	if _, err := s.r.GetAccountByID(ctx, id); err != nil {
+		s.log.Errorf("Err %v occurred when getting account", err)
+		return 0, err
+	}
+	balance, err := s.r.GetLedgerBalance(ctx, id, at)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when summing ledger entries of account %s", err, id)
+		return 0, err
+	}
+	return balance, nil
+}
+
 func (s *service) GetAccounts(ctx context.Context) ([]Account, error) {
 	s.log.Info("Retrieving all accounts")
 	accounts, err := s.r.GetAccounts(ctx)
@@ -51,6 +92,16 @@ func (s *service) GetAccounts(ctx context.Context) ([]Account, error) {
 	return accounts, nil
 }
 
+func (s *service) GetAccountByID(ctx context.Context, id string) (Account, error) {
+	s.log.Infof("Retrieving account by id %s", id)
+	account, err := s.r.GetAccountByID(ctx, id)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving account by id %s", err, id)
+		return Account{}, err
+	}
+	return account, nil
+}
+
 func (s *service) GetAccountByCPF(ctx context.Context, cpf string) (Account, error) {
 	s.log.Infof("Retrieving account by CPF %s", cpf)
 	account, err := s.r.GetAccountByCPF(ctx, cpf)
@@ -61,21 +112,168 @@ func (s *service) GetAccountByCPF(ctx context.Context, cpf string) (Account, err
 	return account, nil
 }
 
-func (s *service) GetTransfersByAccountID(ctx context.Context, id string) (AccountTransfers, error) {
-	s.log.Infof("Retrieving transfers of account %s", id)
-	var sentTransfers, receivedTransfers []Transfer
-	var err error
+func (s *service) GetTransfer(ctx context.Context, accountID string, id string) (TransferDetails, error) {
+	s.log.Infof("Retrieving transfer %s on behalf of account %s", id, accountID)
+	transfers, err := s.r.GetTransfersByIDs(ctx, []string{id})
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving transfer %s", err, id)
+		return TransferDetails{}, err
+	}
+	if len(transfers) == 0 || (transfers[0].OriginAccountID != accountID && transfers[0].DestinationAccountID != accountID) {
+		s.log.Warnf("Transfer %s was not found among the ones of account %s", id, accountID)
+		return TransferDetails{}, storage.ErrNoTransferWasFound
+	}
+
+	details := TransferDetails{Transfer: transfers[0]}
+	if details.Origin, err = s.party(ctx, details.OriginAccountID); err != nil {
+		return TransferDetails{}, err
+	}
+	if details.Destination, err = s.party(ctx, details.DestinationAccountID); err != nil {
+		return TransferDetails{}, err
+	}
+	return details, nil
+}
 
-	sentTransfers, err = s.r.GetTransfersByKey(ctx, "account_origin_id", id)
+// party returns the account id as a party of a transfer
+func (s *service) party(ctx context.Context, id string) (Party, error) {
+	account, err := s.r.GetAccountByID(ctx, id)
 	if err != nil {
-		s.log.Errorf("Err %v when retrieving sent transfers from acc %s", err, id)
-		return AccountTransfers{}, err
+		s.log.Errorf("Err %v occurred when retrieving account by id %s", err, id)
+		return Party{}, err
+	}
+	return Party{AccountID: account.ID, Name: account.Name, CPF: MaskCPF(account.CPF)}, nil
+}
+
+func (s *service) GetTransfersByAccountID(ctx context.Context, id string, filter TransferFilter, page PageRequest) (TransferPage, error) {
+	s.log.Infof("Retrieving transfers of account %s filtered by %v in page %v", id, filter, page)
+	if err := filter.validate(); err != nil {
+		return TransferPage{}, err
+	}
+	if page.Limit == 0 {
+		page.Limit = DefaultPageSize
+	}
+	if page.Limit < 0 || page.Limit > MaxPageSize {
+		return TransferPage{}, ErrInvalidPageSize
+	}
+	if page.Sort.Field == "" {
+		page.Sort = DefaultSort
+	}
+
+	// one transfer more than the page holds tells whether there's a next one
+	query := TransferQuery{Filter: filter, Sort: page.Sort, Limit: page.Limit + 1}
+	if page.Cursor != "" {
+		after, err := decodeCursor(page.Sort, page.Cursor)
+		if err != nil {
+			s.log.Errorf("Err %v when decoding cursor %s", err, page.Cursor)
+			return TransferPage{}, err
+		}
+		query.After = &after
 	}
-	receivedTransfers, err = s.r.GetTransfersByKey(ctx, "account_destination_id", id)
+
+	transfers, err := s.r.GetTransfersByAccountID(ctx, id, query)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving transfers of acc %s", err, id)
+		return TransferPage{}, err
+	}
+
+	result := TransferPage{Transfers: transfers}
+	if len(transfers) > page.Limit {
+		result.Transfers = transfers[:page.Limit]
+		result.NextCursor = encodeCursor(page.Sort, result.Transfers[page.Limit-1])
+	}
+	return result, nil
+}
+
+func (s *service) GetStatement(ctx context.Context, id string, from time.Time, to time.Time) (Statement, error) {
+	from, to, err := statementPeriod(from, to)
+	if err != nil {
+		return Statement{}, err
+	}
+	if to.Sub(from) > MaxStatementPeriod {
+		return Statement{}, ErrStatementPeriodTooLong
+	}
+
+	collector := &statementCollector{}
+	if err = s.streamStatement(ctx, id, from, to, collector); err != nil {
+		return Statement{}, err
+	}
+	return collector.statement, nil
+}
+
+func (s *service) StreamStatement(ctx context.Context, id string, from time.Time, to time.Time, w StatementWriter) error {
+	from, to, err := statementPeriod(from, to)
 	if err != nil {
-		s.log.Errorf("Err %v when retrieving received transfers from acc %s", err, id)
-		return AccountTransfers{}, err
+		return err
 	}
+	return s.streamStatement(ctx, id, from, to, w)
+}
 
-	return AccountTransfers{Sent: sentTransfers, Received: receivedTransfers}, nil
+func (s *service) streamStatement(ctx context.Context, id string, from time.Time, to time.Time, w StatementWriter) error {
+	s.log.Infof("Retrieving statement of account %s from %s to %s", id, from, to)
+	opening, err := s.GetAccountBalanceAt(ctx, id, from.Add(-time.Nanosecond))
+	if err != nil {
+		return err
+	}
+	statement := Statement{AccountID: id, From: from, To: to, OpeningBalance: opening, ClosingBalance: opening}
+	if err = w.WriteOpening(statement); err != nil {
+		return err
+	}
+
+	// entries are turned into lines a batch at a time, so that their transfers are retrieved together while the
+	// statement is never held whole in memory
+	batch := make([]ledger.Entry, 0, statementBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		transfers, err := s.transfersOf(ctx, batch)
+		if err != nil {
+			s.log.Errorf("Err %v occurred when retrieving transfers of the statement of account %s", err, id)
+			return err
+		}
+		for _, line := range statementLines(id, statement.ClosingBalance, batch, transfers) {
+			if err = w.WriteLine(line); err != nil {
+				return err
+			}
+			statement.ClosingBalance = line.Balance
+		}
+		batch = batch[:0]
+		return nil
+	}
+	err = s.r.StreamEntriesByAccountIDBetween(ctx, id, from, to, func(e ledger.Entry) error {
+		if batch = append(batch, e); len(batch) == statementBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		s.log.Errorf("Err %v occurred when streaming statement of account %s", err, id)
+		return err
+	}
+	return w.WriteClosing(statement)
+}
+
+// transfersOf returns the transfers entries came from by their ids
+func (s *service) transfersOf(ctx context.Context, entries []ledger.Entry) (map[string]Transfer, error) {
+	var ids []string
+	for _, e := range entries {
+		if e.TransferID != "" {
+			ids = append(ids, e.TransferID)
+		}
+	}
+	transfers := make(map[string]Transfer, len(ids))
+	if len(ids) == 0 {
+		return transfers, nil
+	}
+	found, err := s.r.GetTransfersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range found {
+		transfers[t.ID] = t
+	}
+	return transfers, nil
 }
@@ -3,9 +3,15 @@ package listing
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
 )
 
 func TestService_GetAccountBalanceByID(t *testing.T) {
@@ -18,7 +24,7 @@ func TestService_GetAccountBalanceByID(t *testing.T) {
 			name: "When runs smoothly",
 			id:   "4d6as4d6a84d6as4wq4",
 			repository: &mockListingRepository{
-				expectedAccount: Account{Balance: 42.42},
+				expectedAccount: Account{Balance: money.FromCents(4242)},
 				expectedError:   nil,
 			},
 		},
@@ -39,7 +45,60 @@ func TestService_GetAccountBalanceByID(t *testing.T) {
 				t.Errorf("Expected err %s; got %s", tc.repository.expectedError, err)
 			}
 			if balance != tc.repository.expectedAccount.Balance {
-				t.Errorf("Expected balance %.2f; got %.2f", tc.repository.expectedAccount.Balance, balance)
+				t.Errorf("Expected balance %s; got %s", tc.repository.expectedAccount.Balance, balance)
+			}
+		})
+	}
+}
+
+func TestService_GetAccountBalanceAt(t *testing.T) {
+	at := time.Now().UTC()
+	tt := []struct {
+		name        string
+		id          string
+		repository  *mockListingRepository
+		wantBalance money.Money
+		wantErr     error
+	}{
+		{
+			name: "When entries are found",
+			id:   "4d6as4d6a84d6as4wq4",
+			repository: &mockListingRepository{
+				expectedAccount: Account{Balance: money.FromCents(100)},
+				expectedEntries: []ledger.Entry{
+					{AccountID: "4d6as4d6a84d6as4wq4", Type: ledger.Credit, Amount: money.FromCents(5000)},
+					{AccountID: "4d6as4d6a84d6as4wq4", Type: ledger.Debit, Amount: money.FromCents(1258)},
+				},
+			},
+			wantBalance: money.FromCents(3742),
+		},
+		{
+			name:        "When account had no entries yet",
+			id:          "4d6as4d6a84d6as4wq4",
+			repository:  &mockListingRepository{},
+			wantBalance: 0,
+		},
+		{
+			name: "When can't find an account with the given ID",
+			repository: &mockListingRepository{
+				expectedError: errors.New("couldn't find the informed account"),
+			},
+			wantErr: errors.New("couldn't find the informed account"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(tc.repository)
+			balance, err := s.GetAccountBalanceAt(context.TODO(), tc.id, at)
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Errorf("Expected err %v; got %v", tc.wantErr, err)
+			}
+			if balance != tc.wantBalance {
+				t.Errorf("Expected balance %s; got %s", tc.wantBalance, balance)
+			}
+			if tc.wantErr == nil && !tc.repository.balanceUntil.Equal(at) {
+				t.Errorf("Expected ledger summed until %s; got %s", at, tc.repository.balanceUntil)
 			}
 		})
 	}
@@ -60,7 +119,7 @@ func TestService_GetAccounts(t *testing.T) {
 						Name:      "Monkey D. Luffy",
 						CPF:       "11111111030",
 						Secret:    "onepiece42",
-						Balance:   100000.00,
+						Balance:   money.FromCents(10000000),
 						CreatedAt: &currentTime,
 					},
 					{
@@ -68,7 +127,7 @@ func TestService_GetAccounts(t *testing.T) {
 						Name:      "Harry Potter",
 						CPF:       "95360976055",
 						Secret:    "rh934h@",
-						Balance:   40000.00,
+						Balance:   money.FromCents(4000000),
 						CreatedAt: &currentTime,
 					},
 				},
@@ -118,7 +177,7 @@ func TestService_GetAccountByCPF(t *testing.T) {
 					Name:    "Monkey D. Luffy",
 					CPF:     "11111111030",
 					Secret:  "onepiece42",
-					Balance: 100000.00,
+					Balance: money.FromCents(10000000),
 				},
 			},
 		},
@@ -144,82 +203,484 @@ func TestService_GetAccountByCPF(t *testing.T) {
 	}
 }
 
+func TestService_GetAccountByID(t *testing.T) {
+	tt := []struct {
+		name       string
+		id         string
+		repository *mockListingRepository
+	}{
+		{
+			name: "When runs smoothly",
+			id:   "g4a68vf6a4g96ws84g",
+			repository: &mockListingRepository{
+				expectedAccount: Account{
+					ID:      "g4a68vf6a4g96ws84g",
+					Name:    "Monkey D. Luffy",
+					CPF:     "11111111030",
+					Balance: money.FromCents(10000000),
+				},
+			},
+		},
+		{
+			name: "When can't find an account with the given id",
+			id:   "g4a68vf6a4g96ws84g",
+			repository: &mockListingRepository{
+				expectedError: errors.New("couldn't find the informed account"),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(tc.repository)
+			account, err := s.GetAccountByID(context.TODO(), tc.id)
+			if err != tc.repository.expectedError {
+				t.Errorf("Expected err %s; got %s", tc.repository.expectedError, err)
+			}
+			if account.ID != tc.repository.expectedAccount.ID {
+				t.Errorf("Expected id %s; got %s", tc.repository.expectedAccount.ID, account.ID)
+			}
+		})
+	}
+}
+
+func TestService_GetTransfer(t *testing.T) {
+	transfer := Transfer{
+		ID:                   "5f8f8ccb30a1cd7511c5cb72",
+		OriginAccountID:      "g4a68vf6a4g96ws84g",
+		DestinationAccountID: "a6sf46af6af",
+		Amount:               money.FromCents(1050),
+		Status:               transferstatus.Completed,
+	}
+	accounts := map[string]Account{
+		"g4a68vf6a4g96ws84g": {ID: "g4a68vf6a4g96ws84g", Name: "Monkey D. Luffy", CPF: "11111111030"},
+		"a6sf46af6af":        {ID: "a6sf46af6af", Name: "Roronoa Zoro", CPF: "52998224725"},
+	}
+	tt := []struct {
+		name       string
+		accountID  string
+		repository *mockListingRepository
+		want       TransferDetails
+		wantErr    error
+	}{
+		{
+			name:       "When the origin asks the transfer",
+			accountID:  "g4a68vf6a4g96ws84g",
+			repository: &mockListingRepository{expectedTransfers: []Transfer{transfer}, accountsByID: accounts},
+			want: TransferDetails{
+				Transfer:    transfer,
+				Origin:      Party{AccountID: "g4a68vf6a4g96ws84g", Name: "Monkey D. Luffy", CPF: "***.111.110-**"},
+				Destination: Party{AccountID: "a6sf46af6af", Name: "Roronoa Zoro", CPF: "***.982.247-**"},
+			},
+		},
+		{
+			name:       "When the destination asks the transfer",
+			accountID:  "a6sf46af6af",
+			repository: &mockListingRepository{expectedTransfers: []Transfer{transfer}, accountsByID: accounts},
+			want: TransferDetails{
+				Transfer:    transfer,
+				Origin:      Party{AccountID: "g4a68vf6a4g96ws84g", Name: "Monkey D. Luffy", CPF: "***.111.110-**"},
+				Destination: Party{AccountID: "a6sf46af6af", Name: "Roronoa Zoro", CPF: "***.982.247-**"},
+			},
+		},
+		{
+			name:       "When another account asks the transfer",
+			accountID:  "5f8f8ccb30a1cd7511c5cb70",
+			repository: &mockListingRepository{expectedTransfers: []Transfer{transfer}, accountsByID: accounts},
+			wantErr:    storage.ErrNoTransferWasFound,
+		},
+		{
+			name:       "When the transfer does not exist",
+			accountID:  "g4a68vf6a4g96ws84g",
+			repository: &mockListingRepository{accountsByID: accounts},
+			wantErr:    storage.ErrNoTransferWasFound,
+		},
+		{
+			name:       "When a party could not be retrieved",
+			accountID:  "g4a68vf6a4g96ws84g",
+			repository: &mockListingRepository{expectedTransfers: []Transfer{transfer}, accountsByID: map[string]Account{"g4a68vf6a4g96ws84g": accounts["g4a68vf6a4g96ws84g"]}},
+			wantErr:    storage.ErrNoAccountWasFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(tc.repository)
+			got, err := s.GetTransfer(context.TODO(), tc.accountID, transfer.ID)
+			if err != tc.wantErr {
+				t.Fatalf("Expected err %v; got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected transfer %+v; got %+v", tc.want, got)
+			}
+			if !reflect.DeepEqual(tc.repository.transferIDs, []string{transfer.ID}) {
+				t.Errorf("Expected only transfer %s to be looked up; got %v", transfer.ID, tc.repository.transferIDs)
+			}
+		})
+	}
+}
+
 func TestService_GetAccountTransfersByID(t *testing.T) {
 	accId := "wr896q4c3ar46"
 	transfers := []Transfer{{
 		ID:                   "6f5a4f56a",
 		OriginAccountID:      accId,
 		DestinationAccountID: "r4wq861a65f8qr6",
-		Amount:               56.23,
-		CreatedAt:            time.Time{},
+		Amount:               money.FromCents(5623),
+		CreatedAt:            time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC),
 	}, {
 		ID:                   "9w8qe74981q",
-		OriginAccountID:      accId,
-		DestinationAccountID: "r9849a8c96a8w6",
-		Amount:               23.23,
-		CreatedAt:            time.Time{},
+		OriginAccountID:      "r9849a8c96a8w6",
+		DestinationAccountID: accId,
+		Amount:               money.FromCents(2323),
+		CreatedAt:            time.Date(2020, 10, 20, 10, 0, 0, 0, time.UTC),
 	}}
+	amountSort := Sort{Field: SortByAmount, Ascending: true}
 	tt := []struct {
 		name       string
-		id         string
+		filter     TransferFilter
+		page       PageRequest
 		repository *mockListingRepository
-		want       AccountTransfers
-		wantErr    bool
+		wantQuery  TransferQuery
+		want       TransferPage
+		wantErr    error
 	}{
 		{
-			name: "When successfully retrieve account transfers",
-			id:   accId,
+			name:       "When successfully retrieve the last page of account transfers",
+			repository: &mockListingRepository{expectedTransfers: transfers},
+			wantQuery:  TransferQuery{Sort: DefaultSort, Limit: DefaultPageSize + 1},
+			want:       TransferPage{Transfers: transfers},
+		},
+		{
+			name:       "When there are more transfers than the page holds",
+			filter:     TransferFilter{Status: transferstatus.Completed, Direction: Sent},
+			page:       PageRequest{Sort: amountSort, Limit: 1},
+			repository: &mockListingRepository{expectedTransfers: transfers},
+			wantQuery:  TransferQuery{Filter: TransferFilter{Status: transferstatus.Completed, Direction: Sent}, Sort: amountSort, Limit: 2},
+			want:       TransferPage{Transfers: transfers[:1], NextCursor: encodeCursor(amountSort, transfers[0])},
+		},
+		{
+			name:       "When retrieving the page following a cursor",
+			page:       PageRequest{Sort: amountSort, Limit: 1, Cursor: encodeCursor(amountSort, transfers[0])},
+			repository: &mockListingRepository{expectedTransfers: transfers[1:]},
+			wantQuery: TransferQuery{Sort: amountSort, Limit: 2, After: &Cursor{
+				Sort:      "amount",
+				ID:        transfers[0].ID,
+				CreatedAt: transfers[0].CreatedAt,
+				Amount:    transfers[0].Amount,
+			}},
+			want: TransferPage{Transfers: transfers[1:]},
+		},
+		{
+			name:       "When cursor was issued for another sort",
+			page:       PageRequest{Cursor: encodeCursor(amountSort, transfers[0])},
+			repository: &mockListingRepository{},
+			wantErr:    ErrInvalidCursor,
+		},
+		{
+			name:       "When cursor is malformed",
+			page:       PageRequest{Cursor: "not-a-cursor"},
+			repository: &mockListingRepository{},
+			wantErr:    ErrInvalidCursor,
+		},
+		{
+			name:       "When page is larger than the maximum",
+			page:       PageRequest{Limit: MaxPageSize + 1},
+			repository: &mockListingRepository{},
+			wantErr:    ErrInvalidPageSize,
+		},
+		{
+			name:       "When amount range ends before it starts",
+			filter:     TransferFilter{MinAmount: money.FromCents(200), MaxAmount: money.FromCents(100)},
+			repository: &mockListingRepository{},
+			wantErr:    ErrInvalidRange,
+		},
+		{
+			name:       "When date range ends before it starts",
+			filter:     TransferFilter{CreatedFrom: transfers[0].CreatedAt, CreatedUntil: transfers[1].CreatedAt},
+			repository: &mockListingRepository{},
+			wantErr:    ErrInvalidRange,
+		},
+		{
+			name:       "When an error occurs when retrieving transfers",
+			repository: &mockListingRepository{expectedError: errors.New("foo")},
+			wantErr:    errors.New("foo"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(tc.repository)
+			got, err := s.GetTransfersByAccountID(context.TODO(), accId, tc.filter, tc.page)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("GetTransfersByAccountID() err = %v; want err %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected transfer page %v, got %v", tc.want, got)
+			}
+			if tc.wantErr == nil && !reflect.DeepEqual(tc.repository.transfersQuery, tc.wantQuery) {
+				t.Errorf("Expected transfers queried by %v, got %v", tc.wantQuery, tc.repository.transfersQuery)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tt := []struct {
+		sort    string
+		want    Sort
+		wantErr error
+	}{
+		{sort: "created_at", want: Sort{Field: SortByCreatedAt, Ascending: true}},
+		{sort: "-created_at", want: Sort{Field: SortByCreatedAt}},
+		{sort: "amount", want: Sort{Field: SortByAmount, Ascending: true}},
+		{sort: "-amount", want: Sort{Field: SortByAmount}},
+		{sort: "status", wantErr: ErrInvalidSort},
+	}
+	for _, tc := range tt {
+		t.Run(tc.sort, func(t *testing.T) {
+			got, err := ParseSort(tc.sort)
+			if err != tc.wantErr {
+				t.Fatalf("ParseSort() err = %v; want %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Expected sort %v, got %v", tc.want, got)
+			}
+			if err == nil && got.String() != tc.sort {
+				t.Errorf("Expected sort to be formatted as %s, got %s", tc.sort, got)
+			}
+		})
+	}
+}
+
+func TestMaskCPF(t *testing.T) {
+	tt := []struct {
+		cpf  string
+		want string
+	}{
+		{cpf: "11111111030", want: "***.111.110-**"},
+		{cpf: "52998224725", want: "***.982.247-**"},
+		{cpf: "5299822472", want: "**********"},
+		{cpf: "529.982.247-25", want: "**************"},
+		{cpf: "", want: ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.cpf, func(t *testing.T) {
+			if got := MaskCPF(tc.cpf); got != tc.want {
+				t.Errorf("Expected cpf to be masked as %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestService_GetStatement(t *testing.T) {
+	accId := "wr896q4c3ar46"
+	from := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 10, 31, 23, 59, 59, 0, time.UTC)
+	transfers := []Transfer{
+		{ID: "6f5a4f56a", OriginAccountID: accId, DestinationAccountID: "r4wq861a65f8qr6", Amount: money.FromCents(3000)},
+		{ID: "9w8qe74981q", OriginAccountID: "r4wq861a65f8qr6", DestinationAccountID: accId, Amount: money.FromCents(500), ReversalOf: "6f5a4f56a"},
+	}
+	periodEntries := []ledger.Entry{
+		{AccountID: accId, TransferID: "6f5a4f56a", Type: ledger.Debit, Amount: money.FromCents(3000), CreatedAt: from.Add(time.Hour)},
+		{AccountID: accId, TransferID: "9w8qe74981q", Type: ledger.Credit, Amount: money.FromCents(500), CreatedAt: from.Add(time.Hour * 2)},
+		{AccountID: accId, Type: ledger.Credit, Amount: money.FromCents(1000), CreatedAt: from.Add(time.Hour * 3)},
+	}
+	openingEntries := []ledger.Entry{{AccountID: accId, Type: ledger.Credit, Amount: money.FromCents(10000), CreatedAt: from.Add(-time.Hour)}}
+	tt := []struct {
+		name       string
+		from       time.Time
+		to         time.Time
+		repository *mockListingRepository
+		want       Statement
+		wantErr    error
+	}{
+		{
+			name: "When the period has balance movements",
+			from: from,
+			to:   to,
 			repository: &mockListingRepository{
+				expectedEntries:   openingEntries,
+				periodEntries:     periodEntries,
 				expectedTransfers: transfers,
 			},
-			want: AccountTransfers{
-				Sent:     transfers,
-				Received: transfers,
+			want: Statement{
+				AccountID:      accId,
+				From:           from,
+				To:             to,
+				OpeningBalance: money.FromCents(10000),
+				ClosingBalance: money.FromCents(8500),
+				Lines: []StatementLine{
+					{TransferID: "6f5a4f56a", CounterpartyID: "r4wq861a65f8qr6", Amount: money.FromCents(-3000), Balance: money.FromCents(7000), CreatedAt: from.Add(time.Hour)},
+					{TransferID: "9w8qe74981q", ReversalOf: "6f5a4f56a", CounterpartyID: "r4wq861a65f8qr6", Amount: money.FromCents(500), Balance: money.FromCents(7500), CreatedAt: from.Add(time.Hour * 2)},
+					{Amount: money.FromCents(1000), Balance: money.FromCents(8500), CreatedAt: from.Add(time.Hour * 3)},
+				},
 			},
 		},
 		{
-			name: "When an error occurs when retrieving sent transfers",
-			id:   accId,
-			repository: &mockListingRepository{
-				expectedError: errors.New("foo"),
-				callsToFail:   1,
+			name:       "When the period has no balance movements",
+			from:       from,
+			to:         to,
+			repository: &mockListingRepository{expectedEntries: openingEntries},
+			want: Statement{
+				AccountID:      accId,
+				From:           from,
+				To:             to,
+				OpeningBalance: money.FromCents(10000),
+				ClosingBalance: money.FromCents(10000),
+				Lines:          []StatementLine{},
 			},
-			want:    AccountTransfers{},
-			wantErr: true,
 		},
 		{
-			name: "When an error occurs when retrieving received transfers",
-			id:   accId,
-			repository: &mockListingRepository{
-				expectedError: errors.New("foo"),
-				callsToFail:   2,
-			},
-			want:    AccountTransfers{},
-			wantErr: true,
+			name:       "When the period ends before it starts",
+			from:       to,
+			to:         from,
+			repository: &mockListingRepository{},
+			wantErr:    ErrInvalidRange,
+		},
+		{
+			name:       "When the period is too long",
+			from:       from.AddDate(-2, 0, 0),
+			to:         to,
+			repository: &mockListingRepository{},
+			wantErr:    ErrStatementPeriodTooLong,
+		},
+		{
+			name:       "When can't find an account with the given ID",
+			from:       from,
+			to:         to,
+			repository: &mockListingRepository{expectedError: errors.New("couldn't find the informed account")},
+			wantErr:    errors.New("couldn't find the informed account"),
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			s := NewService(tc.repository)
-			got, err := s.GetTransfersByAccountID(context.TODO(), tc.id)
-
-			if err != nil && !tc.wantErr {
-				t.Errorf("GetTransfersByAccountID() err = %v; want err %v", err, tc.wantErr)
+			got, err := s.GetStatement(context.TODO(), accId, tc.from, tc.to)
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("GetStatement() err = %v; want err %v", err, tc.wantErr)
 			}
-
 			if !reflect.DeepEqual(got, tc.want) {
-				t.Errorf("Expected account tranfers %v, got %v", tc.want, got)
+				t.Errorf("Expected statement %v, got %v", tc.want, got)
+			}
+			if tc.wantErr == nil && (!tc.repository.entriesFrom.Equal(tc.from) || !tc.repository.entriesUntil.Equal(tc.to)) {
+				t.Errorf("Expected entries from %s to %s; got from %s to %s", tc.from, tc.to, tc.repository.entriesFrom, tc.repository.entriesUntil)
 			}
 		})
 	}
 }
 
+func TestService_StreamStatement(t *testing.T) {
+	accId := "wr896q4c3ar46"
+	from := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	periodEntries := make([]ledger.Entry, 0, statementBatchSize*2+50)
+	for i := 0; i < cap(periodEntries); i++ {
+		periodEntries = append(periodEntries, ledger.Entry{
+			AccountID:  accId,
+			TransferID: fmt.Sprintf("transfer%d", i),
+			Type:       ledger.Credit,
+			Amount:     money.FromCents(1),
+			CreatedAt:  from.Add(time.Minute * time.Duration(i)),
+		})
+	}
+	tt := []struct {
+		name            string
+		writer          *mockStatementWriter
+		wantLines       int
+		wantLookups     int
+		wantClosing     money.Money
+		wantErr         error
+		wantClosingCall bool
+	}{
+		{
+			name:            "When streaming every line in batches",
+			writer:          &mockStatementWriter{},
+			wantLines:       len(periodEntries),
+			wantLookups:     3,
+			wantClosing:     money.FromCents(10000 + int64(len(periodEntries))),
+			wantClosingCall: true,
+		},
+		{
+			name:        "When the writer fails halfway",
+			writer:      &mockStatementWriter{failAfter: statementBatchSize + 10, err: errors.New("broken pipe")},
+			wantLines:   statementBatchSize + 10,
+			wantLookups: 2,
+			wantErr:     errors.New("broken pipe"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockListingRepository{
+				expectedEntries: []ledger.Entry{{AccountID: accId, Type: ledger.Credit, Amount: money.FromCents(10000)}},
+				periodEntries:   periodEntries,
+			}
+			s := NewService(repository)
+			// exports are not bound to MaxStatementPeriod, unlike GetStatement
+			to := from.Add(MaxStatementPeriod * 2)
+			err := s.StreamStatement(context.TODO(), accId, from, to, tc.writer)
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("StreamStatement() err = %v; want err %v", err, tc.wantErr)
+			}
+			if want := from.Add(-time.Nanosecond); !repository.balanceUntil.Equal(want) {
+				t.Errorf("Expected opening balance summed up to %s; got %s", want, repository.balanceUntil)
+			}
+			if len(tc.writer.lines) != tc.wantLines || repository.transferLookups != tc.wantLookups {
+				t.Errorf("Expected %d lines from %d transfer lookups; got %d from %d", tc.wantLines, tc.wantLookups, len(tc.writer.lines), repository.transferLookups)
+			}
+			if tc.writer.opening.OpeningBalance != money.FromCents(10000) {
+				t.Errorf("Expected opening balance %s; got %s", money.FromCents(10000), tc.writer.opening.OpeningBalance)
+			}
+			if tc.writer.closed != tc.wantClosingCall || tc.writer.closing.ClosingBalance != tc.wantClosing {
+				t.Errorf("Expected closing balance %s; got %s", tc.wantClosing, tc.writer.closing.ClosingBalance)
+			}
+		})
+	}
+}
+
+type mockStatementWriter struct {
+	opening   Statement
+	lines     []StatementLine
+	closing   Statement
+	closed    bool
+	failAfter int
+	err       error
+}
+
+func (m *mockStatementWriter) WriteOpening(statement Statement) error {
+	m.opening = statement
+	return nil
+}
+
+func (m *mockStatementWriter) WriteLine(line StatementLine) error {
+	if m.err != nil && len(m.lines) == m.failAfter {
+		return m.err
+	}
+	m.lines = append(m.lines, line)
+	return nil
+}
+
+func (m *mockStatementWriter) WriteClosing(statement Statement) error {
+	m.closing = statement
+	m.closed = true
+	return nil
+}
+
 type mockListingRepository struct {
 	expectedAccounts  []Account
 	expectedAccount   Account
+	accountsByID      map[string]Account
 	expectedTransfers []Transfer
-	callsToFail       int
+	transfersQuery    TransferQuery
+	expectedEntries   []ledger.Entry
+	entriesUntil      time.Time
+	balanceUntil      time.Time
+	periodEntries     []ledger.Entry
+	entriesFrom       time.Time
+	transferIDs       []string
+	transferLookups   int
 	expectedError     error
 }
 
@@ -227,7 +688,14 @@ func (m *mockListingRepository) GetAccounts(_ context.Context) ([]Account, error
 	return m.expectedAccounts, m.expectedError
 }
 
-func (m *mockListingRepository) GetAccountByID(_ context.Context, _ string) (Account, error) {
+func (m *mockListingRepository) GetAccountByID(_ context.Context, id string) (Account, error) {
+	if m.accountsByID != nil {
+		account, ok := m.accountsByID[id]
+		if !ok {
+			return Account{}, storage.ErrNoAccountWasFound
+		}
+		return account, m.expectedError
+	}
 	return m.expectedAccount, m.expectedError
 }
 
@@ -235,11 +703,32 @@ func (m *mockListingRepository) GetAccountByCPF(_ context.Context, _ string) (Ac
 	return m.expectedAccount, m.expectedError
 }
 
-func (m *mockListingRepository) GetTransfersByKey(_ context.Context, _ string, _ string) ([]Transfer, error) {
-	var err error
-	m.callsToFail--
-	if m.callsToFail <= 0 {
-		err = m.expectedError
+func (m *mockListingRepository) GetTransfersByAccountID(_ context.Context, _ string, query TransferQuery) ([]Transfer, error) {
+	m.transfersQuery = query
+	return m.expectedTransfers, m.expectedError
+}
+
+func (m *mockListingRepository) GetLedgerBalance(_ context.Context, _ string, until time.Time) (money.Money, error) {
+	m.balanceUntil = until
+	return ledger.Balance(m.expectedEntries), m.expectedError
+}
+
+func (m *mockListingRepository) StreamEntriesByAccountIDBetween(_ context.Context, _ string, from time.Time, until time.Time, fn func(ledger.Entry) error) error {
+	m.entriesFrom = from
+	m.entriesUntil = until
+	if m.expectedError != nil {
+		return m.expectedError
 	}
-	return m.expectedTransfers, err
+	for _, e := range m.periodEntries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockListingRepository) GetTransfersByIDs(_ context.Context, ids []string) ([]Transfer, error) {
+	m.transferIDs = ids
+	m.transferLookups++
+	return m.expectedTransfers, m.expectedError
 }
@@ -0,0 +1,111 @@
+package listing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+const (
+	statementBatchSize = 100
+	// DefaultStatementPeriod is the period of statements whose start isn't given, ending at the given end or now
+	DefaultStatementPeriod = time.Hour * 24 * 30
+	MaxStatementPeriod     = time.Hour * 24 * 366
+)
+
+var ErrStatementPeriodTooLong = errors.New("statement period must be of at most 366 days")
+
+// Statement is the timeline of the balance movements of an account in a period, from and to included
+type Statement struct {
+	AccountID      string          `json:"account_id"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance money.Money     `json:"opening_balance"`
+	ClosingBalance money.Money     `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+}
+
+// StatementLine is a balance movement of an account, the ones not coming from a transfer, as adjustments, having
+// neither TransferID nor CounterpartyID
+type StatementLine struct {
+	TransferID string `json:"transfer_id,omitempty"`
+	// ReversalOf is the transfer a reversal gives back
+	ReversalOf     string `json:"reversal_of,omitempty"`
+	CounterpartyID string `json:"counterparty_id,omitempty"`
+	// Amount is negative for debits
+	Amount money.Money `json:"amount"`
+	// Balance is the one of the account right after the movement
+	Balance   money.Money `json:"balance"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// StatementWriter receives a statement as it is read: its opening first, then each line, oldest first, and at last
+// its closing balance. Lines are never set on the statements it receives
+type StatementWriter interface {
+	WriteOpening(statement Statement) error
+	WriteLine(line StatementLine) error
+	WriteClosing(statement Statement) error
+}
+
+// statementCollector keeps a whole statement in memory
+type statementCollector struct {
+	statement Statement
+}
+
+func (c *statementCollector) WriteOpening(statement Statement) error {
+	c.statement = statement
+	c.statement.Lines = make([]StatementLine, 0)
+	return nil
+}
+
+func (c *statementCollector) WriteLine(line StatementLine) error {
+	c.statement.Lines = append(c.statement.Lines, line)
+	return nil
+}
+
+func (c *statementCollector) WriteClosing(statement Statement) error {
+	c.statement.ClosingBalance = statement.ClosingBalance
+	return nil
+}
+
+// statementPeriod fills in the defaults of a statement period and validates it
+func statementPeriod(from time.Time, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-DefaultStatementPeriod)
+	}
+	// mongodb keeps times to the millisecond, so the opening balance and the lines split the entries the same way
+	// in every repository only when the period bounds are truncated to it as well
+	from, to = from.Truncate(time.Millisecond), to.Truncate(time.Millisecond)
+	if to.Before(from) {
+		return from, to, ErrInvalidRange
+	}
+	return from, to, nil
+}
+
+// statementLines turns the entries of accountID, oldest first, into lines with the running balance from opening
+func statementLines(accountID string, opening money.Money, entries []ledger.Entry, transfers map[string]Transfer) []StatementLine {
+	lines := make([]StatementLine, 0, len(entries))
+	balance := opening
+	for _, e := range entries {
+		amount := e.Amount
+		if e.Type == ledger.Debit {
+			amount = -amount
+		}
+		balance += amount
+		line := StatementLine{TransferID: e.TransferID, Amount: amount, Balance: balance, CreatedAt: e.CreatedAt}
+		if t, ok := transfers[e.TransferID]; ok {
+			line.ReversalOf = t.ReversalOf
+			line.CounterpartyID = t.OriginAccountID
+			if t.OriginAccountID == accountID {
+				line.CounterpartyID = t.DestinationAccountID
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -1,11 +1,86 @@
 package listing
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
 
 type Transfer struct {
-	ID                   string    `json:"id"`
-	OriginAccountID      string    `json:"account_origin_id"`
-	DestinationAccountID string    `json:"account_destination_id"`
-	Amount               float64   `json:"amount"`
-	CreatedAt            time.Time `json:"created_at"`
+	ID                   string                `json:"id"`
+	OriginAccountID      string                `json:"account_origin_id"`
+	DestinationAccountID string                `json:"account_destination_id"`
+	Amount               money.Money           `json:"amount"`
+	Status               transferstatus.Status `json:"status"`
+	FailureReason        transferstatus.Reason `json:"failure_reason,omitempty"`
+	ReversedAmount       money.Money           `json:"reversed_amount,omitempty"`
+	ReversalOf           string                `json:"reversal_of,omitempty"`
+	StandingOrderID      string                `json:"standing_order_id,omitempty"`
+	CreatedAt            time.Time             `json:"created_at"`
+	CompletedAt          *time.Time            `json:"completed_at,omitempty"`
+	FailedAt             *time.Time            `json:"failed_at,omitempty"`
+	ReversedAt           *time.Time            `json:"reversed_at,omitempty"`
+}
+
+// Party is an account a transfer was sent from or to, as shown to the other party
+type Party struct {
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+	// CPF is masked through MaskCPF
+	CPF string `json:"cpf"`
+}
+
+// TransferDetails is a transfer along with the accounts it was sent from and to
+type TransferDetails struct {
+	Transfer
+	Origin      Party `json:"origin"`
+	Destination Party `json:"destination"`
+}
+
+// Direction tells whether a transfer was sent or received by the account listing it
+type Direction string
+
+const (
+	Sent     Direction = "sent"
+	Received Direction = "received"
+)
+
+var ErrInvalidDirection = errors.New("direction must be one of sent or received")
+var ErrInvalidRange = errors.New("ranges must not end before they start")
+
+// ParseDirection validates s as one of the known directions
+func ParseDirection(s string) (Direction, error) {
+	direction := Direction(s)
+	switch direction {
+	case Sent, Received:
+		return direction, nil
+	default:
+		return "", ErrInvalidDirection
+	}
+}
+
+// TransferFilter narrows the transfers retrieved, its zero value matching every transfer.
+// Zero bounds leave their side of a range open, the others being inclusive
+type TransferFilter struct {
+	Status          transferstatus.Status
+	StandingOrderID string
+	Direction       Direction
+	// CounterpartyID is the other account of the transfers
+	CounterpartyID string
+	CreatedFrom    time.Time
+	CreatedUntil   time.Time
+	MinAmount      money.Money
+	MaxAmount      money.Money
+}
+
+func (f TransferFilter) validate() error {
+	if !f.CreatedFrom.IsZero() && !f.CreatedUntil.IsZero() && f.CreatedUntil.Before(f.CreatedFrom) {
+		return ErrInvalidRange
+	}
+	if f.MinAmount != 0 && f.MaxAmount != 0 && f.MaxAmount < f.MinAmount {
+		return ErrInvalidRange
+	}
+	return nil
 }
@@ -0,0 +1,92 @@
+// Package money provides an exact representation of monetary values, free of floating point drift
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is a monetary value held as an integer amount of cents, the currency minor unit, so that
+// adding, subtracting and comparing amounts is always exact
+type Money int64
+
+const centsPerUnit = 100
+
+var ErrInvalidAmount = errors.New("amount must be a number with at most two decimal places")
+
+// FromCents returns the Money worth the given amount of cents
+func FromCents(cents int64) Money {
+	return Money(cents)
+}
+
+// Parse reads a decimal string such as "42", "-0.5" or "1234.56" as Money, rejecting anything
+// that can't be represented in cents without rounding
+func Parse(s string) (Money, error) {
+	digits := strings.TrimPrefix(s, "-")
+	negative := len(digits) < len(s)
+
+	units, cents := digits, ""
+	if i := strings.IndexByte(digits, '.'); i != -1 {
+		units, cents = digits[:i], strings.TrimRight(digits[i+1:], "0")
+		if i == len(digits)-1 {
+			return 0, ErrInvalidAmount
+		}
+	}
+	if units == "" || len(cents) > 2 || !isDigits(units) || !isDigits(cents) {
+		return 0, ErrInvalidAmount
+	}
+
+	cents = (cents + "00")[:2]
+	amount, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money(amount), nil
+}
+
+// Cents returns the amount of cents m is worth
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// String formats m as a decimal number with exactly two decimal places, like "-1234.05"
+func (m Money) String() string {
+	sign, cents := "", int64(m)
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/centsPerUnit, cents%centsPerUnit)
+}
+
+// MarshalJSON Marshaler implementation that writes m as a JSON number with two decimal places
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON Unmarshaler implementation that reads a JSON number without going through float64
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		name    string
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{name: "When amount has no decimals", input: "42", want: 4200},
+		{name: "When amount has one decimal", input: "42.5", want: 4250},
+		{name: "When amount has two decimals", input: "42.42", want: 4242},
+		{name: "When amount has trailing zeros beyond cents", input: "42.4200", want: 4242},
+		{name: "When amount is negative", input: "-0.05", want: -5},
+		{name: "When amount is zero", input: "0", want: 0},
+		{name: "When amount has more than two decimals", input: "42.421", wantErr: true},
+		{name: "When amount uses exponent notation", input: "4.2e1", wantErr: true},
+		{name: "When amount has no units", input: ".42", wantErr: true},
+		{name: "When amount ends with a dot", input: "42.", wantErr: true},
+		{name: "When amount is empty", input: "", wantErr: true},
+		{name: "When amount is not a number", input: `"abc"`, wantErr: true},
+		{name: "When amount overflows", input: "99999999999999999999", wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %d cents, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tt := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{name: "When money is positive", money: 4242, want: "42.42"},
+		{name: "When money has less than a unit", money: 5, want: "0.05"},
+		{name: "When money is negative", money: -105, want: "-1.05"},
+		{name: "When money is a round amount", money: 10000, want: "100.00"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.money.String(); got != tc.want {
+				t.Errorf("String() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var payload struct {
+		Amount Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount":112.51}`), &payload); err != nil {
+		t.Fatalf("Unmarshal() err = %v", err)
+	}
+	if payload.Amount != FromCents(11251) {
+		t.Errorf("Expected 11251 cents, got %d", payload.Amount)
+	}
+
+	payload.Amount += FromCents(1)
+	b, _ := json.Marshal(payload)
+	if string(b) != `{"amount":112.52}` {
+		t.Errorf("Expected {\"amount\":112.52}, got %s", b)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":0.001}`), &payload); err == nil {
+		t.Error("Expected amounts with more than two decimals to be rejected")
+	}
+}
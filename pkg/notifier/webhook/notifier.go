@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/sirupsen/logrus"
+)
+
+// PasswordResetEvent is the event of the notices of password reset
+const PasswordResetEvent = "password_reset"
+
+// Notifier POSTs notices as JSON to a URL, leaving to the service behind it how to deliver them, be it by e-mail,
+// SMS or push
+type Notifier struct {
+	url    string
+	client *http.Client
+	log    *logrus.Logger
+}
+
+// message is the body POSTed to the webhook
+type message struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+func NewNotifier(url string) *Notifier {
+	return &Notifier{
+		url:    url,
+		client: &http.Client{Timeout: time.Second * 10},
+		log:    lgr.NewDefaultLogger(),
+	}
+}
+
+func (n *Notifier) NotifyPasswordReset(ctx context.Context, notice authenticating.PasswordResetNotice) error {
+	n.log.Infof("Notifying password reset of account %s to webhook", notice.AccountID)
+	return n.post(ctx, message{Event: PasswordResetEvent, Data: notice})
+}
+
+func (n *Notifier) post(ctx context.Context, m message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := n.client.Do(req)
+	if err != nil {
+		n.log.Errorf("Unexpected err %v when posting %s event to webhook", err, m.Event)
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		n.log.Errorf("Webhook answered %d to %s event", res.StatusCode, m.Event)
+		return fmt.Errorf("webhook answered %d to %s event", res.StatusCode, m.Event)
+	}
+	return nil
+}
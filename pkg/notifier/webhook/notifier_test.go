@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+)
+
+func TestNotifier_NotifyPasswordReset(t *testing.T) {
+	notice := authenticating.PasswordResetNotice{
+		Recipient: authenticating.Recipient{AccountID: "5f8b1c2d3e4f5a6b7c8d9e0f", Name: "Alice", CPF: "12345678900"},
+		Token:     "resetToken",
+		ExpiresAt: time.Date(2020, 10, 25, 12, 0, 0, 0, time.UTC),
+	}
+	tt := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "When the webhook accepts the notice",
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "When the webhook fails",
+			statusCode: http.StatusBadGateway,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got struct {
+				Event string                             `json:"event"`
+				Data  authenticating.PasswordResetNotice `json:"data"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("Expected a JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("Unexpected err %v decoding the body", err)
+				}
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer server.Close()
+
+			err := NewNotifier(server.URL).NotifyPasswordReset(context.Background(), notice)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NotifyPasswordReset() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got.Event != PasswordResetEvent {
+				t.Errorf("Expected event %s, got %s", PasswordResetEvent, got.Event)
+			}
+			if got.Data.Token != notice.Token || got.Data.AccountID != notice.AccountID || !got.Data.ExpiresAt.Equal(notice.ExpiresAt) {
+				t.Errorf("Expected notice %v, got %v", notice, got.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package receipting
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
+
+// Receipt attests a transfer as it was when the receipt was issued
+type Receipt struct {
+	TransferID  string                `json:"transfer_id"`
+	ReversalOf  string                `json:"reversal_of,omitempty"`
+	Origin      listing.Party         `json:"origin"`
+	Destination listing.Party         `json:"destination"`
+	Amount      money.Money           `json:"amount"`
+	Status      transferstatus.Status `json:"status"`
+	CreatedAt   time.Time             `json:"created_at"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+	IssuedAt    time.Time             `json:"issued_at"`
+	// VerificationCode is the receipt signed by the Signer, through which third parties verify it
+	VerificationCode string `json:"verification_code,omitempty"`
+}
+
+// Signer signs receipts with a key only the app holds, so that they can't be forged
+type Signer interface {
+	// SignReceipt signs receipt, but its VerificationCode, into a code holding it
+	SignReceipt(receipt Receipt) (string, error)
+	// VerifyReceipt returns the receipt held by code, failing when it wasn't signed by SignReceipt
+	VerifyReceipt(code string) (Receipt, error)
+}
@@ -0,0 +1,77 @@
+package receipting
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrTransferNotCompleted = errors.New("only completed transfers have receipts")
+var ErrInvalidVerificationCode = errors.New("verification code is invalid or was not issued by us")
+
+type Service interface {
+	// IssueReceipt issues the receipt of the transfer id to accountID, which must be one of its parties
+	IssueReceipt(ctx context.Context, accountID string, id string) (Receipt, error)
+	// VerifyReceipt returns the receipt of code, ErrInvalidVerificationCode being returned when it was forged or
+	// tampered with
+	VerifyReceipt(ctx context.Context, code string) (Receipt, error)
+}
+
+type service struct {
+	lister listing.Service
+	signer Signer
+	log    *logrus.Logger
+}
+
+func NewService(lister listing.Service, signer Signer) Service {
+	return &service{
+		lister: lister,
+		signer: signer,
+		log:    lgr.NewDefaultLogger(),
+	}
+}
+
+func (s *service) IssueReceipt(ctx context.Context, accountID string, id string) (Receipt, error) {
+	s.log.Infof("Issuing receipt of transfer %s to account %s", id, accountID)
+	transfer, err := s.lister.GetTransfer(ctx, accountID, id)
+	if err != nil {
+		return Receipt{}, err
+	}
+	// reversed transfers were completed before being reversed, their receipts attesting so
+	if transfer.Status != transferstatus.Completed && transfer.Status != transferstatus.Reversed {
+		s.log.Warnf("Refusing receipt of transfer %s in status %s", id, transfer.Status)
+		return Receipt{}, ErrTransferNotCompleted
+	}
+
+	receipt := Receipt{
+		TransferID:  transfer.ID,
+		ReversalOf:  transfer.ReversalOf,
+		Origin:      transfer.Origin,
+		Destination: transfer.Destination,
+		Amount:      transfer.Amount,
+		Status:      transfer.Status,
+		CreatedAt:   transfer.CreatedAt,
+		CompletedAt: transfer.CompletedAt,
+		IssuedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	if receipt.VerificationCode, err = s.signer.SignReceipt(receipt); err != nil {
+		s.log.Errorf("Err %v when signing receipt of transfer %s", err, id)
+		return Receipt{}, err
+	}
+	return receipt, nil
+}
+
+func (s *service) VerifyReceipt(_ context.Context, code string) (Receipt, error) {
+	s.log.Info("Verifying receipt")
+	receipt, err := s.signer.VerifyReceipt(code)
+	if err != nil {
+		s.log.Warnf("Err %v when verifying receipt code %s", err, code)
+		return Receipt{}, ErrInvalidVerificationCode
+	}
+	return receipt, nil
+}
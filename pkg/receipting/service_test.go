@@ -0,0 +1,169 @@
+package receipting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
+
+func TestService_IssueReceipt(t *testing.T) {
+	completedAt := time.Date(2020, 10, 21, 10, 0, 1, 0, time.UTC)
+	transfer := listing.TransferDetails{
+		Transfer: listing.Transfer{
+			ID:                   "5f8f8ccb30a1cd7511c5cb72",
+			OriginAccountID:      "g4a68vf6a4g96ws84g",
+			DestinationAccountID: "a6sf46af6af",
+			Amount:               money.FromCents(1050),
+			Status:               transferstatus.Completed,
+			CreatedAt:            time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC),
+			CompletedAt:          &completedAt,
+		},
+		Origin:      listing.Party{AccountID: "g4a68vf6a4g96ws84g", Name: "Monkey D. Luffy", CPF: "***.111.110-**"},
+		Destination: listing.Party{AccountID: "a6sf46af6af", Name: "Roronoa Zoro", CPF: "***.982.247-**"},
+	}
+	reversed := transfer
+	reversed.Status = transferstatus.Reversed
+	pending := transfer
+	pending.Status = transferstatus.Pending
+	failed := transfer
+	failed.Status = transferstatus.Failed
+	tt := []struct {
+		name    string
+		lister  *mockLister
+		signer  *mockSigner
+		wantErr error
+	}{
+		{
+			name:   "When the transfer was completed",
+			lister: &mockLister{transfer: transfer},
+			signer: &mockSigner{code: "eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl"},
+		},
+		{
+			name:   "When the transfer was reversed after being completed",
+			lister: &mockLister{transfer: reversed},
+			signer: &mockSigner{code: "eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl"},
+		},
+		{
+			name:    "When the transfer is still pending",
+			lister:  &mockLister{transfer: pending},
+			signer:  &mockSigner{},
+			wantErr: ErrTransferNotCompleted,
+		},
+		{
+			name:    "When the transfer has failed",
+			lister:  &mockLister{transfer: failed},
+			signer:  &mockSigner{},
+			wantErr: ErrTransferNotCompleted,
+		},
+		{
+			name:    "When the transfer is not one of the account",
+			lister:  &mockLister{err: storage.ErrNoTransferWasFound},
+			signer:  &mockSigner{},
+			wantErr: storage.ErrNoTransferWasFound,
+		},
+		{
+			name:    "When the receipt could not be signed",
+			lister:  &mockLister{transfer: transfer},
+			signer:  &mockSigner{err: errors.New("signing failed")},
+			wantErr: errors.New("signing failed"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(tc.lister, tc.signer)
+			receipt, err := s.IssueReceipt(context.TODO(), "g4a68vf6a4g96ws84g", transfer.ID)
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("IssueReceipt() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if tc.lister.accountID != "g4a68vf6a4g96ws84g" {
+				t.Errorf("Expected transfer to be retrieved on behalf of the account; got %s", tc.lister.accountID)
+			}
+			if tc.wantErr != nil {
+				if tc.wantErr == ErrTransferNotCompleted && tc.signer.signed != nil {
+					t.Errorf("Expected no receipt to be signed; got %+v", *tc.signer.signed)
+				}
+				return
+			}
+			if receipt.VerificationCode != tc.signer.code {
+				t.Errorf("Expected verification code %s; got %s", tc.signer.code, receipt.VerificationCode)
+			}
+			if receipt.TransferID != transfer.ID || receipt.Amount != transfer.Amount || receipt.Status != tc.lister.transfer.Status ||
+				receipt.Origin != transfer.Origin || receipt.Destination != transfer.Destination {
+				t.Errorf("Expected receipt of transfer %+v; got %+v", tc.lister.transfer, receipt)
+			}
+			if receipt.IssuedAt.IsZero() {
+				t.Error("Expected receipt to have its issuing time")
+			}
+			if tc.signer.signed.VerificationCode != "" || tc.signer.signed.IssuedAt != receipt.IssuedAt {
+				t.Errorf("Expected the receipt but its code to be signed; got %+v", *tc.signer.signed)
+			}
+		})
+	}
+}
+
+func TestService_VerifyReceipt(t *testing.T) {
+	receipt := Receipt{TransferID: "5f8f8ccb30a1cd7511c5cb72", Amount: money.FromCents(1050), Status: transferstatus.Completed}
+	tt := []struct {
+		name    string
+		signer  *mockSigner
+		wantErr error
+	}{
+		{
+			name:   "When the code was signed by us",
+			signer: &mockSigner{receipt: receipt},
+		},
+		{
+			name:    "When the code was forged",
+			signer:  &mockSigner{err: errors.New("signature is invalid")},
+			wantErr: ErrInvalidVerificationCode,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(&mockLister{}, tc.signer)
+			got, err := s.VerifyReceipt(context.TODO(), "eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl")
+			if err != tc.wantErr {
+				t.Fatalf("VerifyReceipt() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && got != receipt {
+				t.Errorf("Expected receipt %+v; got %+v", receipt, got)
+			}
+		})
+	}
+}
+
+type mockLister struct {
+	listing.Service
+	transfer  listing.TransferDetails
+	accountID string
+	err       error
+}
+
+func (m *mockLister) GetTransfer(_ context.Context, accountID string, _ string) (listing.TransferDetails, error) {
+	m.accountID = accountID
+	return m.transfer, m.err
+}
+
+type mockSigner struct {
+	code    string
+	signed  *Receipt
+	receipt Receipt
+	err     error
+}
+
+func (m *mockSigner) SignReceipt(receipt Receipt) (string, error) {
+	m.signed = &receipt
+	return m.code, m.err
+}
+
+func (m *mockSigner) VerifyReceipt(_ string) (Receipt, error) {
+	return m.receipt, m.err
+}
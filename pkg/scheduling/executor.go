@@ -0,0 +1,52 @@
+package scheduling
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultInterval is how often the Executor looks for due transfers when no other interval is given
+const DefaultInterval = time.Second * 30
+
+// Executor runs the due scheduled transfers in background. Any number of server replicas may run one,
+// since each transfer is claimed by a single executor before it is run
+type Executor struct {
+	service  Service
+	interval time.Duration
+	log      *logrus.Logger
+}
+
+func NewExecutor(service Service, interval time.Duration) *Executor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Executor{
+		service:  service,
+		interval: interval,
+		log:      lgr.NewDefaultLogger(),
+	}
+}
+
+// Run blocks running due transfers at every interval until ctx is done
+func (e *Executor) Run(ctx context.Context) {
+	e.log.Infof("Running scheduled transfers every %s", e.interval)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		if run, err := e.service.RunDue(ctx); err != nil {
+			e.log.Errorf("Err %v when running due scheduled transfers", err)
+		} else if run > 0 {
+			e.log.Infof("%d due scheduled transfers were run", run)
+		}
+
+		select {
+		case <-ctx.Done():
+			e.log.Info("Stopping scheduled transfers executor")
+			return
+		case <-ticker.C:
+		}
+	}
+}
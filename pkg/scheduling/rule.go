@@ -0,0 +1,201 @@
+package scheduling
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	// Weekly rules run on the ISO weekday Day, 1 being monday and 7 sunday
+	Weekly Frequency = "weekly"
+	// Monthly rules run on the day Day of the month, or on its last day when the month is shorter than that
+	Monthly Frequency = "monthly"
+	// Cron rules run whenever their Expression matches
+	Cron Frequency = "cron"
+)
+
+var ErrInvalidFrequency = errors.New("frequency must be one of weekly, monthly or cron")
+var ErrInvalidWeekday = errors.New("day of a weekly rule must be between 1, monday, and 7, sunday")
+var ErrInvalidDayOfMonth = errors.New("day of a monthly rule must be between 1 and 31")
+var ErrInvalidCronExpression = errors.New("expression of a cron rule must have the five fields minute, hour, day of month, month and day of week, each being *, a number, a range or a list of them, optionally with a step")
+
+// cronHorizon bounds how far ahead a cron expression is looked up, so the ones that never match, as 0 0 30 2 *, end
+const cronHorizon = 5
+
+// Rule tells when the occurrences of a standing order are. Weekly and monthly occurrences are at the
+// time of the day the standing order starts, while cron ones are at the time their expression tells.
+// Every time is in UTC, with minutes as the finest resolution
+type Rule struct {
+	Frequency  Frequency `json:"frequency"`
+	Day        int       `json:"day,omitempty"`
+	Expression string    `json:"expression,omitempty"`
+}
+
+func (r Rule) Validate() error {
+	switch r.Frequency {
+	case Weekly:
+		if r.Day < 1 || r.Day > 7 {
+			return ErrInvalidWeekday
+		}
+	case Monthly:
+		if r.Day < 1 || r.Day > 31 {
+			return ErrInvalidDayOfMonth
+		}
+	case Cron:
+		if _, err := parseCron(r.Expression); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidFrequency
+	}
+	return nil
+}
+
+// next returns the first occurrence of a valid r strictly after after, taking the time of the day of
+// weekly and monthly rules from at. It returns the zero time when there's no such occurrence
+func (r Rule) next(after time.Time, at time.Time) time.Time {
+	after = after.UTC()
+	at = at.UTC()
+	switch r.Frequency {
+	case Weekly:
+		candidate := time.Date(after.Year(), after.Month(), after.Day(), at.Hour(), at.Minute(), 0, 0, time.UTC)
+		for !candidate.After(after) || isoWeekday(candidate) != r.Day {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+		return candidate
+	case Monthly:
+		for i := 0; ; i++ {
+			firstDay := time.Date(after.Year(), after.Month()+time.Month(i), 1, 0, 0, 0, 0, time.UTC)
+			day := r.Day
+			if lastDay := firstDay.AddDate(0, 1, -1).Day(); day > lastDay {
+				day = lastDay
+			}
+			candidate := time.Date(firstDay.Year(), firstDay.Month(), day, at.Hour(), at.Minute(), 0, 0, time.UTC)
+			if candidate.After(after) {
+				return candidate
+			}
+		}
+	case Cron:
+		schedule, err := parseCron(r.Expression)
+		if err != nil {
+			return time.Time{}
+		}
+		return schedule.next(after)
+	}
+	return time.Time{}
+}
+
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// cronSchedule holds a bit for every value each field of a cron expression matches
+type cronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// as in cron, when both day fields are restricted a day matches when any of them does
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+func parseCron(expression string) (cronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return cronSchedule{}, ErrInvalidCronExpression
+	}
+	var schedule cronSchedule
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.dayOfMonth, err = parseCronField(fields[2], 1, 31); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return cronSchedule{}, err
+	}
+	if schedule.dayOfWeek, err = parseCronField(fields[4], 0, 7); err != nil {
+		return cronSchedule{}, err
+	}
+	// both 0 and 7 are sunday
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+	schedule.anyDayOfMonth = strings.HasPrefix(fields[2], "*")
+	schedule.anyDayOfWeek = strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+// parseCronField parses a comma separated list of *, n or n-m, each optionally followed by /step
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, ErrInvalidCronExpression
+			}
+			part = part[:i]
+		}
+
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, ErrInvalidCronExpression
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, ErrInvalidCronExpression
+				}
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, ErrInvalidCronExpression
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c cronSchedule) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	horizon := t.AddDate(cronHorizon, 0, 0)
+	for t.Before(horizon) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c cronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := c.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := c.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
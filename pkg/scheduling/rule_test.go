@@ -0,0 +1,96 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRule_Validate(t *testing.T) {
+	tt := []struct {
+		name    string
+		rule    Rule
+		wantErr error
+	}{
+		{name: "When rule is weekly on sunday", rule: Rule{Frequency: Weekly, Day: 7}},
+		{name: "When weekly rule has no weekday", rule: Rule{Frequency: Weekly}, wantErr: ErrInvalidWeekday},
+		{name: "When rule is monthly on the last day", rule: Rule{Frequency: Monthly, Day: 31}},
+		{name: "When monthly rule day is out of range", rule: Rule{Frequency: Monthly, Day: 32}, wantErr: ErrInvalidDayOfMonth},
+		{name: "When rule is cron with lists, ranges and steps", rule: Rule{Frequency: Cron, Expression: "0,30 9-18/3 1-15 */2 1-5"}},
+		{name: "When cron expression misses fields", rule: Rule{Frequency: Cron, Expression: "0 9 * *"}, wantErr: ErrInvalidCronExpression},
+		{name: "When cron expression is out of range", rule: Rule{Frequency: Cron, Expression: "60 9 * * *"}, wantErr: ErrInvalidCronExpression},
+		{name: "When cron expression has a reversed range", rule: Rule{Frequency: Cron, Expression: "0 18-9 * * *"}, wantErr: ErrInvalidCronExpression},
+		{name: "When frequency is unknown", rule: Rule{Frequency: "daily"}, wantErr: ErrInvalidFrequency},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.rule.Validate(); err != tc.wantErr {
+				t.Errorf("Validate() err = %v; want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRule_next(t *testing.T) {
+	at := time.Date(2020, time.October, 21, 9, 30, 0, 0, time.UTC)
+	tt := []struct {
+		name  string
+		rule  Rule
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "When weekly rule is at a later weekday",
+			rule:  Rule{Frequency: Weekly, Day: 5},
+			after: at,
+			want:  time.Date(2020, time.October, 23, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "When weekly rule is at the same weekday",
+			rule:  Rule{Frequency: Weekly, Day: 3},
+			after: at,
+			want:  time.Date(2020, time.October, 28, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "When monthly rule is later in the month",
+			rule:  Rule{Frequency: Monthly, Day: 25},
+			after: at,
+			want:  time.Date(2020, time.October, 25, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "When monthly rule day is missing in the month",
+			rule:  Rule{Frequency: Monthly, Day: 31},
+			after: time.Date(2021, time.January, 31, 9, 30, 0, 0, time.UTC),
+			want:  time.Date(2021, time.February, 28, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "When cron rule has a step",
+			rule:  Rule{Frequency: Cron, Expression: "*/15 10 * * *"},
+			after: at,
+			want:  time.Date(2020, time.October, 21, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "When cron rule restricts both days",
+			rule:  Rule{Frequency: Cron, Expression: "0 8 1 * 5"},
+			after: at,
+			want:  time.Date(2020, time.October, 23, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "When cron rule is on sunday as 7",
+			rule:  Rule{Frequency: Cron, Expression: "0 8 * 12 7"},
+			after: at,
+			want:  time.Date(2020, time.December, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "When cron rule never matches",
+			rule:  Rule{Frequency: Cron, Expression: "0 0 30 2 *"},
+			after: at,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.rule.next(tc.after, at); !got.Equal(tc.want) {
+				t.Errorf("next() = %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package scheduling
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+type Status string
+
+const (
+	// Scheduled transfers wait for their time, or for their next attempt after a failed one
+	Scheduled Status = "scheduled"
+	// Processing transfers were claimed by an executor, which is running them
+	Processing Status = "processing"
+	Executed   Status = "executed"
+	// Failed transfers ran out of attempts or had their execution interrupted
+	Failed    Status = "failed"
+	Cancelled Status = "cancelled"
+)
+
+// ScheduledTransfer is a transfer booked to be made at a future time
+type ScheduledTransfer struct {
+	ID                   string      `json:"id"`
+	OriginAccountID      string      `json:"account_origin_id"`
+	DestinationAccountID string      `json:"account_destination_id"`
+	Amount               money.Money `json:"amount"`
+	ScheduledFor         time.Time   `json:"scheduled_for"`
+	Status               Status      `json:"status"`
+	Attempts             int         `json:"attempts"`
+	LastError            string      `json:"last_error,omitempty"`
+	// TransferID is the transfer made by the last attempt, if it got to be recorded
+	TransferID string `json:"transfer_id,omitempty"`
+	// StandingOrderID is the standing order this transfer is an occurrence of, if any
+	StandingOrderID string    `json:"standing_order_id,omitempty"`
+	NextAttemptAt   time.Time `json:"-"`
+	// LockedUntil is when the claim of a processing transfer expires
+	LockedUntil time.Time `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	// TOTPCode authorizes scheduling a transfer above the threshold as it does a transfer, never being stored
+	TOTPCode string `json:"totp_code,omitempty"`
+	// PIN authorizes scheduling a transfer as it does a transfer, never being stored
+	PIN string `json:"pin,omitempty"`
+}
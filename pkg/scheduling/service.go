@@ -0,0 +1,361 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxAttempts bounds how many times a scheduled transfer is run before it is considered failed
+	MaxAttempts = 3
+	// RetryDelay is multiplied by the attempts already made to delay the next one
+	RetryDelay = time.Minute * 5
+	// ClaimLease is how long an executor holds a claimed transfer, which is more than enough to run it
+	ClaimLease = time.Minute
+)
+
+var ErrScheduledForPast = errors.New("scheduled_for must be a future date-time")
+var ErrNotCancellable = errors.New("only scheduled transfers that are not being processed can be cancelled")
+
+// ErrNotProcessing is returned by Repository.FinishScheduledTransfer when the claim of the transfer was lost
+var ErrNotProcessing = errors.New("scheduled transfer is no longer being processed")
+
+// ErrNoScheduledTransferDue must be returned by Repository.ClaimDueScheduledTransfer when there's nothing to run
+var ErrNoScheduledTransferDue = errors.New("no scheduled transfer is due")
+
+// ErrInterrupted is recorded on transfers whose claim expired, since the outcome of their execution is unknown
+var ErrInterrupted = errors.New("execution was interrupted, check the account transfers before scheduling it again")
+
+var ErrStartsInPast = errors.New("starts_at must be a future date-time")
+var ErrEndsBeforeStart = errors.New("ends_at must be after starts_at")
+var ErrNegativeOccurrences = errors.New("max_occurrences must not be negative")
+var ErrNoOccurrence = errors.New("standing order would never run with the given rule, starts_at, ends_at and max_occurrences")
+var ErrNotPausable = errors.New("only active standing orders can be paused")
+var ErrNotResumable = errors.New("only paused standing orders can be resumed")
+var ErrStandingOrderOver = errors.New("standing order is already finished or cancelled")
+
+// ErrStandingOrderChanged is returned by Repository.UpdateStandingOrder when the order was changed while it was updated
+var ErrStandingOrderChanged = errors.New("standing order was changed meanwhile, try again")
+
+// ErrOccurrenceAlreadyScheduled must be returned by Repository.AddScheduledTransfer when there's already a transfer
+// scheduled for the same standing order and time
+var ErrOccurrenceAlreadyScheduled = errors.New("occurrence of the standing order was already scheduled")
+
+type Service interface {
+	Schedule(ctx context.Context, transfer ScheduledTransfer) (string, error)
+	GetScheduledTransfersByAccountID(ctx context.Context, accountID string) ([]ScheduledTransfer, error)
+	Cancel(ctx context.Context, accountID string, id string) error
+	// RunDue schedules the occurrences of standing orders due up to now and then makes every transfer due up to now,
+	// returning how many were run
+	RunDue(ctx context.Context) (int, error)
+	CreateStandingOrder(ctx context.Context, order StandingOrder) (string, error)
+	GetStandingOrdersByAccountID(ctx context.Context, accountID string) ([]StandingOrder, error)
+	PauseStandingOrder(ctx context.Context, accountID string, id string) error
+	// ResumeStandingOrder activates a paused standing order again, skipping the occurrences it had while paused
+	ResumeStandingOrder(ctx context.Context, accountID string, id string) error
+	CancelStandingOrder(ctx context.Context, accountID string, id string) error
+}
+
+type Repository interface {
+	AddScheduledTransfer(ctx context.Context, transfer ScheduledTransfer) (string, error)
+	GetScheduledTransfersByAccountID(ctx context.Context, accountID string) ([]ScheduledTransfer, error)
+	// CancelScheduledTransfer cancels the transfer id of origin accountID, failing with ErrNotCancellable
+	// if it is not in the Scheduled status
+	CancelScheduledTransfer(ctx context.Context, accountID string, id string) error
+	// ClaimDueScheduledTransfer atomically moves the earliest Scheduled transfer whose next attempt is due at now to
+	// Processing, counting the attempt and locking it until lockedUntil, so no other executor can claim it
+	ClaimDueScheduledTransfer(ctx context.Context, now time.Time, lockedUntil time.Time) (ScheduledTransfer, error)
+	// FinishScheduledTransfer records the outcome of a claimed transfer, as long as it is still Processing
+	FinishScheduledTransfer(ctx context.Context, transfer ScheduledTransfer) error
+	// FailExpiredScheduledTransfers moves to Failed, with ErrInterrupted, the Processing transfers locked until before now
+	FailExpiredScheduledTransfers(ctx context.Context, now time.Time) (int, error)
+	AddStandingOrder(ctx context.Context, order StandingOrder) (string, error)
+	GetStandingOrdersByAccountID(ctx context.Context, accountID string) ([]StandingOrder, error)
+	// GetDueStandingOrders returns the active standing orders whose next run is at or before now
+	GetDueStandingOrders(ctx context.Context, now time.Time) ([]StandingOrder, error)
+	// UpdateStandingOrder hands the standing order id of origin accountID to updateFn and stores what it returns,
+	// failing with ErrStandingOrderChanged if the order was changed by someone else in between
+	UpdateStandingOrder(ctx context.Context, accountID string, id string, updateFn StandingOrderFunc) (StandingOrder, error)
+}
+
+type service struct {
+	r          Repository
+	transferor transferring.Service
+	log        *logrus.Logger
+	now        func() time.Time
+}
+
+func NewService(repository Repository, transferor transferring.Service) Service {
+	return &service{
+		r:          repository,
+		transferor: transferor,
+		log:        lgr.NewDefaultLogger(),
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+	}
+}
+
+func (s *service) Schedule(ctx context.Context, transfer ScheduledTransfer) (string, error) {
+	s.log.Infof("Scheduling transfer %v", transfer)
+	now := s.now()
+	if transfer.Amount <= 0 {
+		s.log.Errorf("Scheduled transfer %v has a non positive amount", transfer)
+		return "", transferring.ErrNonPositiveAmount
+	}
+	if transfer.OriginAccountID == transfer.DestinationAccountID {
+		s.log.Errorf("Scheduled transfer %v has the same origin and destination", transfer)
+		return "", transferring.ErrSameAccount
+	}
+	if !transfer.ScheduledFor.After(now) {
+		s.log.Errorf("Scheduled transfer %v is not scheduled for the future", transfer)
+		return "", ErrScheduledForPast
+	}
+
+	transfer.ScheduledFor = transfer.ScheduledFor.UTC()
+	transfer.Status = Scheduled
+	transfer.Attempts = 0
+	transfer.NextAttemptAt = transfer.ScheduledFor
+	transfer.CreatedAt = now
+	id, err := s.r.AddScheduledTransfer(ctx, transfer)
+	if err != nil {
+		s.log.Errorf("Err %v when adding scheduled transfer %v", err, transfer)
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *service) GetScheduledTransfersByAccountID(ctx context.Context, accountID string) ([]ScheduledTransfer, error) {
+	s.log.Infof("Retrieving scheduled transfers of account %s", accountID)
+	transfers, err := s.r.GetScheduledTransfersByAccountID(ctx, accountID)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving scheduled transfers of account %s", err, accountID)
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (s *service) Cancel(ctx context.Context, accountID string, id string) error {
+	s.log.Infof("Cancelling scheduled transfer %s of account %s", id, accountID)
+	if err := s.r.CancelScheduledTransfer(ctx, accountID, id); err != nil {
+		s.log.Errorf("Err %v when cancelling scheduled transfer %s", err, id)
+		return err
+	}
+	return nil
+}
+
+func (s *service) RunDue(ctx context.Context) (int, error) {
+	now := s.now()
+	s.scheduleDueOccurrences(ctx, now)
+
+	interrupted, err := s.r.FailExpiredScheduledTransfers(ctx, now)
+	if err != nil {
+		s.log.Errorf("Err %v when failing interrupted scheduled transfers", err)
+		return 0, err
+	}
+	if interrupted > 0 {
+		s.log.Warnf("%d scheduled transfers had their execution interrupted and were failed", interrupted)
+	}
+
+	run := 0
+	for {
+		// the transfers run before took their time, so each claim is leased from the moment it is made
+		now = s.now()
+		transfer, err := s.r.ClaimDueScheduledTransfer(ctx, now, now.Add(ClaimLease))
+		if err == ErrNoScheduledTransferDue {
+			return run, nil
+		}
+		if err != nil {
+			s.log.Errorf("Err %v when claiming due scheduled transfers", err)
+			return run, err
+		}
+		run++
+		if err = s.r.FinishScheduledTransfer(ctx, s.execute(ctx, transfer)); err != nil {
+			s.log.Errorf("Err %v when recording the outcome of scheduled transfer %s", err, transfer.ID)
+		}
+	}
+}
+
+// execute runs a claimed transfer through transferring.Service, returning it with the outcome to be recorded
+func (s *service) execute(ctx context.Context, transfer ScheduledTransfer) ScheduledTransfer {
+	s.log.Infof("Running attempt %d of scheduled transfer %s", transfer.Attempts, transfer.ID)
+	id, err := s.transferor.MakeTransfer(ctx, transferring.Transfer{
+		OriginAccountID:      transfer.OriginAccountID,
+		DestinationAccountID: transfer.DestinationAccountID,
+		Amount:               transfer.Amount,
+		StandingOrderID:      transfer.StandingOrderID,
+	})
+	transfer.TransferID = id
+	if err == nil {
+		s.log.Infof("Scheduled transfer %s executed as transfer %s", transfer.ID, id)
+		transfer.Status = Executed
+		transfer.LastError = ""
+		return transfer
+	}
+
+	transfer.LastError = err.Error()
+	if transfer.Attempts >= MaxAttempts {
+		s.log.Errorf("Scheduled transfer %s failed its last attempt due to err %v", transfer.ID, err)
+		transfer.Status = Failed
+		return transfer
+	}
+	s.log.Warnf("Scheduled transfer %s failed attempt %d due to err %v, it will be retried", transfer.ID, transfer.Attempts, err)
+	transfer.Status = Scheduled
+	transfer.NextAttemptAt = s.now().Add(RetryDelay * time.Duration(transfer.Attempts))
+	return transfer
+}
+
+func (s *service) CreateStandingOrder(ctx context.Context, order StandingOrder) (string, error) {
+	s.log.Infof("Creating standing order %v", order)
+	now := s.now()
+	if order.Amount <= 0 {
+		s.log.Errorf("Standing order %v has a non positive amount", order)
+		return "", transferring.ErrNonPositiveAmount
+	}
+	if order.OriginAccountID == order.DestinationAccountID {
+		s.log.Errorf("Standing order %v has the same origin and destination", order)
+		return "", transferring.ErrSameAccount
+	}
+	if err := order.Rule.Validate(); err != nil {
+		s.log.Errorf("Standing order %v has an invalid rule due to err %v", order, err)
+		return "", err
+	}
+	if order.MaxOccurrences < 0 {
+		s.log.Errorf("Standing order %v has a negative max occurrences", order)
+		return "", ErrNegativeOccurrences
+	}
+	if order.StartsAt.IsZero() {
+		order.StartsAt = now
+	}
+	if order.StartsAt.Before(now) {
+		s.log.Errorf("Standing order %v starts in the past", order)
+		return "", ErrStartsInPast
+	}
+	order.StartsAt = order.StartsAt.UTC()
+	if order.EndsAt != nil {
+		if !order.EndsAt.After(order.StartsAt) {
+			s.log.Errorf("Standing order %v ends before it starts", order)
+			return "", ErrEndsBeforeStart
+		}
+		endsAt := order.EndsAt.UTC()
+		order.EndsAt = &endsAt
+	}
+
+	order.Status = OrderActive
+	order.Occurrences = 0
+	order.CreatedAt = now
+	// the first occurrence may be at the very start
+	order.scheduleNext(order.StartsAt.Add(-time.Nanosecond))
+	if order.Status == OrderFinished {
+		s.log.Errorf("Standing order %v has no occurrence", order)
+		return "", ErrNoOccurrence
+	}
+
+	id, err := s.r.AddStandingOrder(ctx, order)
+	if err != nil {
+		s.log.Errorf("Err %v when adding standing order %v", err, order)
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *service) GetStandingOrdersByAccountID(ctx context.Context, accountID string) ([]StandingOrder, error) {
+	s.log.Infof("Retrieving standing orders of account %s", accountID)
+	orders, err := s.r.GetStandingOrdersByAccountID(ctx, accountID)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving standing orders of account %s", err, accountID)
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (s *service) PauseStandingOrder(ctx context.Context, accountID string, id string) error {
+	s.log.Infof("Pausing standing order %s of account %s", id, accountID)
+	_, err := s.r.UpdateStandingOrder(ctx, accountID, id, func(order StandingOrder) (StandingOrder, error) {
+		if order.Status != OrderActive {
+			return order, ErrNotPausable
+		}
+		order.Status = OrderPaused
+		return order, nil
+	})
+	if err != nil {
+		s.log.Errorf("Err %v when pausing standing order %s", err, id)
+		return err
+	}
+	return nil
+}
+
+func (s *service) ResumeStandingOrder(ctx context.Context, accountID string, id string) error {
+	s.log.Infof("Resuming standing order %s of account %s", id, accountID)
+	_, err := s.r.UpdateStandingOrder(ctx, accountID, id, func(order StandingOrder) (StandingOrder, error) {
+		if order.Status != OrderPaused {
+			return order, ErrNotResumable
+		}
+		order.Status = OrderActive
+		order.scheduleNext(s.now())
+		return order, nil
+	})
+	if err != nil {
+		s.log.Errorf("Err %v when resuming standing order %s", err, id)
+		return err
+	}
+	return nil
+}
+
+func (s *service) CancelStandingOrder(ctx context.Context, accountID string, id string) error {
+	s.log.Infof("Cancelling standing order %s of account %s", id, accountID)
+	_, err := s.r.UpdateStandingOrder(ctx, accountID, id, func(order StandingOrder) (StandingOrder, error) {
+		if order.Status != OrderActive && order.Status != OrderPaused {
+			return order, ErrStandingOrderOver
+		}
+		order.Status = OrderCancelled
+		order.NextRunAt = nil
+		return order, nil
+	})
+	if err != nil {
+		s.log.Errorf("Err %v when cancelling standing order %s", err, id)
+		return err
+	}
+	return nil
+}
+
+// scheduleDueOccurrences schedules every occurrence due up to now of the active standing orders, catching up
+// the ones missed while no executor was running
+func (s *service) scheduleDueOccurrences(ctx context.Context, now time.Time) {
+	orders, err := s.r.GetDueStandingOrders(ctx, now)
+	if err != nil {
+		s.log.Errorf("Err %v when retrieving due standing orders", err)
+		return
+	}
+	for _, order := range orders {
+		for order.Status == OrderActive && order.NextRunAt != nil && !order.NextRunAt.After(now) {
+			if order, err = s.scheduleOccurrence(ctx, order, now); err != nil {
+				s.log.Warnf("Err %v when scheduling occurrence of standing order %s", err, order.ID)
+				break
+			}
+		}
+	}
+}
+
+// scheduleOccurrence schedules the occurrence of order at its next run and then moves it to the following one.
+// Another executor may be doing the same, so the occurrence is scheduled only once and only one of them moves it
+func (s *service) scheduleOccurrence(ctx context.Context, order StandingOrder, now time.Time) (StandingOrder, error) {
+	occurrence := order.occurrence(now)
+	s.log.Infof("Scheduling occurrence %d of standing order %s for %s", order.Occurrences+1, order.ID, occurrence.ScheduledFor)
+	if _, err := s.r.AddScheduledTransfer(ctx, occurrence); err != nil && err != ErrOccurrenceAlreadyScheduled {
+		return order, err
+	}
+	return s.r.UpdateStandingOrder(ctx, order.OriginAccountID, order.ID, func(stored StandingOrder) (StandingOrder, error) {
+		if stored.Status != OrderActive || stored.NextRunAt == nil || !stored.NextRunAt.Equal(occurrence.ScheduledFor) {
+			return stored, ErrStandingOrderChanged
+		}
+		stored.Occurrences++
+		stored.scheduleNext(*stored.NextRunAt)
+		return stored, nil
+	})
+}
@@ -0,0 +1,489 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+)
+
+func TestService_Schedule(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	tt := []struct {
+		name     string
+		transfer ScheduledTransfer
+		repoErr  error
+		wantErr  error
+	}{
+		{
+			name: "When transfer is scheduled successfully",
+			transfer: ScheduledTransfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+				ScheduledFor:         future,
+			},
+		},
+		{
+			name: "When transfer is scheduled for the past",
+			transfer: ScheduledTransfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+				ScheduledFor:         time.Now().Add(-time.Minute),
+			},
+			wantErr: ErrScheduledForPast,
+		},
+		{
+			name: "When amount is not greater than zero",
+			transfer: ScheduledTransfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				ScheduledFor:         future,
+			},
+			wantErr: transferring.ErrNonPositiveAmount,
+		},
+		{
+			name: "When origin and destination are the same account",
+			transfer: ScheduledTransfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+				Amount:               money.FromCents(1111),
+				ScheduledFor:         future,
+			},
+			wantErr: transferring.ErrSameAccount,
+		},
+		{
+			name: "When repository fails to add the transfer",
+			transfer: ScheduledTransfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+				ScheduledFor:         future,
+			},
+			repoErr: errors.New("foo"),
+			wantErr: errors.New("foo"),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &mockRepository{err: tc.repoErr}
+			s := NewService(r, &mockTransferor{})
+			_, err := s.Schedule(context.TODO(), tc.transfer)
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("Schedule() err = %v; want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if r.added.Status != Scheduled || !r.added.NextAttemptAt.Equal(future.UTC()) || r.added.CreatedAt.IsZero() {
+				t.Errorf("Expected a scheduled transfer whose next attempt is at %s, got %v", future.UTC(), r.added)
+			}
+		})
+	}
+}
+
+func TestService_RunDue(t *testing.T) {
+	tt := []struct {
+		name           string
+		attempts       int
+		transferErr    error
+		wantStatus     Status
+		wantLastError  string
+		wantNextDelay  time.Duration
+		wantTransferID string
+	}{
+		{
+			name:           "When the due transfer is executed",
+			attempts:       1,
+			wantStatus:     Executed,
+			wantTransferID: "5f8f8ccb30a1cd7511c5cb72",
+		},
+		{
+			name:           "When the due transfer fails and has attempts left",
+			attempts:       1,
+			transferErr:    transferring.ErrNotEnoughBalance,
+			wantStatus:     Scheduled,
+			wantLastError:  transferring.ErrNotEnoughBalance.Error(),
+			wantNextDelay:  RetryDelay,
+			wantTransferID: "5f8f8ccb30a1cd7511c5cb72",
+		},
+		{
+			name:           "When the due transfer fails its last attempt",
+			attempts:       MaxAttempts,
+			transferErr:    transferring.ErrNotEnoughBalance,
+			wantStatus:     Failed,
+			wantLastError:  transferring.ErrNotEnoughBalance.Error(),
+			wantTransferID: "5f8f8ccb30a1cd7511c5cb72",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &mockRepository{due: []ScheduledTransfer{{
+				ID:                   "5f8f8ccb30a1cd7511c5cb73",
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+				Status:               Processing,
+				Attempts:             tc.attempts,
+			}}}
+			transferor := &mockTransferor{id: "5f8f8ccb30a1cd7511c5cb72", err: tc.transferErr}
+			s := NewService(r, transferor)
+
+			run, err := s.RunDue(context.TODO())
+			if err != nil || run != 1 {
+				t.Fatalf("RunDue() = %d, %v; want 1 transfer run", run, err)
+			}
+			if len(transferor.transfers) != 1 || transferor.transfers[0].Amount != money.FromCents(1111) {
+				t.Errorf("Expected the due transfer to be made once, got %v", transferor.transfers)
+			}
+			finished := r.finished
+			if finished.Status != tc.wantStatus || finished.LastError != tc.wantLastError || finished.TransferID != tc.wantTransferID {
+				t.Errorf("Expected %s transfer with last err %q, got %v", tc.wantStatus, tc.wantLastError, finished)
+			}
+			if tc.wantNextDelay > 0 && finished.NextAttemptAt.Before(time.Now().Add(tc.wantNextDelay-time.Minute)) {
+				t.Errorf("Expected next attempt delayed by %s, got %s", tc.wantNextDelay, finished.NextAttemptAt)
+			}
+		})
+	}
+}
+
+func TestService_RunDueLeases(t *testing.T) {
+	clock := time.Date(2020, 10, 20, 9, 0, 0, 0, time.UTC)
+	r := &mockRepository{}
+	for i := 0; i < 3; i++ {
+		r.due = append(r.due, ScheduledTransfer{
+			ID:                   fmt.Sprintf("5f8f8ccb30a1cd7511c5cb7%d", i),
+			OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+			DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+			Amount:               money.FromCents(1111),
+			Status:               Processing,
+			Attempts:             1,
+		})
+	}
+	// every transfer outlasts a whole lease, as a slow one would
+	transferor := &mockTransferor{id: "5f8f8ccb30a1cd7511c5cb72", clock: &clock, took: ClaimLease + time.Second}
+	s := &service{r: r, transferor: transferor, log: lgr.NewDefaultLogger(), now: func() time.Time { return clock }}
+
+	run, err := s.RunDue(context.TODO())
+	if err != nil || run != 3 {
+		t.Fatalf("RunDue() = %d, %v; want 3 transfers run", run, err)
+	}
+	// the last claim is the one finding nothing due
+	if len(r.leases) != 4 {
+		t.Fatalf("Expected 4 claims; got %d", len(r.leases))
+	}
+	start := time.Date(2020, 10, 20, 9, 0, 0, 0, time.UTC)
+	for i, lease := range r.leases {
+		claimedAt := start.Add((ClaimLease + time.Second) * time.Duration(i))
+		if !r.claimedAt[i].Equal(claimedAt) || !lease.Equal(claimedAt.Add(ClaimLease)) {
+			t.Errorf("Expected claim %d at %s leased until %s; got at %s until %s", i, claimedAt, claimedAt.Add(ClaimLease), r.claimedAt[i], lease)
+		}
+	}
+}
+
+func TestService_CreateStandingOrder(t *testing.T) {
+	now := time.Now().UTC()
+	startsAt := time.Date(now.Year()+1, time.January, 10, 9, 0, 0, 0, time.UTC)
+	endsAt := time.Date(now.Year()+1, time.December, 31, 0, 0, 0, 0, time.UTC)
+	firstWeekEnd := startsAt.AddDate(0, 0, 7)
+	tt := []struct {
+		name          string
+		order         StandingOrder
+		repoErr       error
+		wantErr       error
+		wantNextRunAt time.Time
+	}{
+		{
+			name: "When a monthly standing order is created",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(50000),
+				Rule:                 Rule{Frequency: Monthly, Day: 5},
+				StartsAt:             startsAt,
+				EndsAt:               &endsAt,
+			},
+			wantNextRunAt: time.Date(now.Year()+1, time.February, 5, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "When the first occurrence is at the start",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(50000),
+				Rule:                 Rule{Frequency: Cron, Expression: "0 9 * * *"},
+				StartsAt:             startsAt,
+				MaxOccurrences:       3,
+			},
+			wantNextRunAt: startsAt,
+		},
+		{
+			name: "When rule is invalid",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(50000),
+				Rule:                 Rule{Frequency: Weekly, Day: 8},
+				StartsAt:             startsAt,
+			},
+			wantErr: ErrInvalidWeekday,
+		},
+		{
+			name: "When it starts in the past",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(50000),
+				Rule:                 Rule{Frequency: Monthly, Day: 5},
+				StartsAt:             now.Add(-time.Hour),
+			},
+			wantErr: ErrStartsInPast,
+		},
+		{
+			name: "When it ends before it starts",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(50000),
+				Rule:                 Rule{Frequency: Monthly, Day: 5},
+				StartsAt:             endsAt,
+				EndsAt:               &startsAt,
+			},
+			wantErr: ErrEndsBeforeStart,
+		},
+		{
+			name: "When it ends before its first occurrence",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(50000),
+				Rule:                 Rule{Frequency: Monthly, Day: 5},
+				StartsAt:             startsAt,
+				EndsAt:               &firstWeekEnd,
+			},
+			wantErr: ErrNoOccurrence,
+		},
+		{
+			name: "When amount is not greater than zero",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Rule:                 Rule{Frequency: Monthly, Day: 5},
+				StartsAt:             startsAt,
+			},
+			wantErr: transferring.ErrNonPositiveAmount,
+		},
+		{
+			name: "When repository fails to add the standing order",
+			order: StandingOrder{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(50000),
+				Rule:                 Rule{Frequency: Monthly, Day: 5},
+				StartsAt:             startsAt,
+			},
+			repoErr: errors.New("foo"),
+			wantErr: errors.New("foo"),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &mockRepository{err: tc.repoErr}
+			s := NewService(r, &mockTransferor{})
+			_, err := s.CreateStandingOrder(context.TODO(), tc.order)
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("CreateStandingOrder() err = %v; want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if r.addedOrder.Status != OrderActive || r.addedOrder.NextRunAt == nil || !r.addedOrder.NextRunAt.Equal(tc.wantNextRunAt) {
+				t.Errorf("Expected an active standing order whose next run is at %s, got %v", tc.wantNextRunAt, r.addedOrder)
+			}
+		})
+	}
+}
+
+func TestService_UpdateStandingOrderStatus(t *testing.T) {
+	nextRunAt := time.Now().UTC().Add(-time.Hour * 24 * 60)
+	tt := []struct {
+		name       string
+		status     OrderStatus
+		update     func(s Service) error
+		wantErr    error
+		wantStatus OrderStatus
+	}{
+		{
+			name:   "When an active standing order is paused",
+			status: OrderActive,
+			update: func(s Service) error {
+				return s.PauseStandingOrder(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", "5f8f8ccb30a1cd7511c5cb74")
+			},
+			wantStatus: OrderPaused,
+		},
+		{
+			name:   "When a paused standing order is paused",
+			status: OrderPaused,
+			update: func(s Service) error {
+				return s.PauseStandingOrder(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", "5f8f8ccb30a1cd7511c5cb74")
+			},
+			wantErr:    ErrNotPausable,
+			wantStatus: OrderPaused,
+		},
+		{
+			name:   "When a paused standing order is resumed",
+			status: OrderPaused,
+			update: func(s Service) error {
+				return s.ResumeStandingOrder(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", "5f8f8ccb30a1cd7511c5cb74")
+			},
+			wantStatus: OrderActive,
+		},
+		{
+			name:   "When a finished standing order is resumed",
+			status: OrderFinished,
+			update: func(s Service) error {
+				return s.ResumeStandingOrder(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", "5f8f8ccb30a1cd7511c5cb74")
+			},
+			wantErr:    ErrNotResumable,
+			wantStatus: OrderFinished,
+		},
+		{
+			name:   "When a paused standing order is cancelled",
+			status: OrderPaused,
+			update: func(s Service) error {
+				return s.CancelStandingOrder(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", "5f8f8ccb30a1cd7511c5cb74")
+			},
+			wantStatus: OrderCancelled,
+		},
+		{
+			name:   "When a cancelled standing order is cancelled",
+			status: OrderCancelled,
+			update: func(s Service) error {
+				return s.CancelStandingOrder(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", "5f8f8ccb30a1cd7511c5cb74")
+			},
+			wantErr:    ErrStandingOrderOver,
+			wantStatus: OrderCancelled,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &mockRepository{order: StandingOrder{
+				ID:        "5f8f8ccb30a1cd7511c5cb74",
+				Rule:      Rule{Frequency: Weekly, Day: 1},
+				StartsAt:  nextRunAt,
+				NextRunAt: &nextRunAt,
+				Status:    tc.status,
+			}}
+			s := NewService(r, &mockTransferor{})
+			if err := tc.update(s); err != tc.wantErr {
+				t.Fatalf("Expected err %v, got %v", tc.wantErr, err)
+			}
+			if r.order.Status != tc.wantStatus {
+				t.Errorf("Expected standing order %s, got %s", tc.wantStatus, r.order.Status)
+			}
+			// occurrences missed while paused are skipped
+			if tc.wantStatus == OrderActive && (r.order.NextRunAt == nil || r.order.NextRunAt.Before(time.Now())) {
+				t.Errorf("Expected resumed standing order to run next in the future, got %v", r.order.NextRunAt)
+			}
+		})
+	}
+}
+
+type mockRepository struct {
+	added      ScheduledTransfer
+	due        []ScheduledTransfer
+	claimedAt  []time.Time
+	leases     []time.Time
+	finished   ScheduledTransfer
+	addedOrder StandingOrder
+	order      StandingOrder
+	err        error
+}
+
+func (m *mockRepository) AddScheduledTransfer(_ context.Context, transfer ScheduledTransfer) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.added = transfer
+	return "5f8f8ccb30a1cd7511c5cb73", nil
+}
+
+func (m *mockRepository) GetScheduledTransfersByAccountID(_ context.Context, _ string) ([]ScheduledTransfer, error) {
+	return m.due, m.err
+}
+
+func (m *mockRepository) CancelScheduledTransfer(_ context.Context, _ string, _ string) error {
+	return m.err
+}
+
+func (m *mockRepository) ClaimDueScheduledTransfer(_ context.Context, now time.Time, lockedUntil time.Time) (ScheduledTransfer, error) {
+	m.claimedAt = append(m.claimedAt, now)
+	m.leases = append(m.leases, lockedUntil)
+	if len(m.due) == 0 {
+		return ScheduledTransfer{}, ErrNoScheduledTransferDue
+	}
+	transfer := m.due[0]
+	m.due = m.due[1:]
+	return transfer, nil
+}
+
+func (m *mockRepository) FinishScheduledTransfer(_ context.Context, transfer ScheduledTransfer) error {
+	m.finished = transfer
+	return nil
+}
+
+func (m *mockRepository) FailExpiredScheduledTransfers(_ context.Context, _ time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *mockRepository) AddStandingOrder(_ context.Context, order StandingOrder) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.addedOrder = order
+	return "5f8f8ccb30a1cd7511c5cb74", nil
+}
+
+func (m *mockRepository) GetStandingOrdersByAccountID(_ context.Context, _ string) ([]StandingOrder, error) {
+	return []StandingOrder{m.order}, m.err
+}
+
+func (m *mockRepository) GetDueStandingOrders(_ context.Context, _ time.Time) ([]StandingOrder, error) {
+	return nil, m.err
+}
+
+func (m *mockRepository) UpdateStandingOrder(_ context.Context, _ string, _ string, updateFn StandingOrderFunc) (StandingOrder, error) {
+	if m.err != nil {
+		return StandingOrder{}, m.err
+	}
+	updated, err := updateFn(m.order)
+	if err != nil {
+		return m.order, err
+	}
+	m.order = updated
+	return updated, nil
+}
+
+type mockTransferor struct {
+	transferring.Service
+	id        string
+	transfers []transferring.Transfer
+	err       error
+	// clock, when set, is moved forward by took on every transfer
+	clock *time.Time
+	took  time.Duration
+}
+
+func (m *mockTransferor) MakeTransfer(_ context.Context, transfer transferring.Transfer) (string, error) {
+	if m.clock != nil {
+		*m.clock = m.clock.Add(m.took)
+	}
+	m.transfers = append(m.transfers, transfer)
+	return m.id, m.err
+}
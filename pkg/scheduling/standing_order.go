@@ -0,0 +1,71 @@
+package scheduling
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+type OrderStatus string
+
+const (
+	// OrderActive standing orders have their occurrences scheduled as they become due
+	OrderActive OrderStatus = "active"
+	// OrderPaused standing orders skip every occurrence until they are resumed
+	OrderPaused OrderStatus = "paused"
+	// OrderFinished standing orders reached their end date or occurrences count
+	OrderFinished  OrderStatus = "finished"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+// StandingOrder is a transfer repeated by a Rule, from StartsAt until EndsAt or until MaxOccurrences were
+// scheduled, whichever comes first. Each occurrence is scheduled as a ScheduledTransfer linked to it
+type StandingOrder struct {
+	ID                   string      `json:"id"`
+	OriginAccountID      string      `json:"account_origin_id"`
+	DestinationAccountID string      `json:"account_destination_id"`
+	Amount               money.Money `json:"amount"`
+	Rule                 Rule        `json:"rule"`
+	StartsAt             time.Time   `json:"starts_at"`
+	EndsAt               *time.Time  `json:"ends_at,omitempty"`
+	MaxOccurrences       int         `json:"max_occurrences,omitempty"`
+	Occurrences          int         `json:"occurrences"`
+	// NextRunAt is the next occurrence to be scheduled, missing once the order is over
+	NextRunAt *time.Time  `json:"next_run_at,omitempty"`
+	Status    OrderStatus `json:"status"`
+	CreatedAt time.Time   `json:"created_at"`
+	// TOTPCode authorizes setting up an order above the threshold as it does a transfer, never being stored
+	TOTPCode string `json:"totp_code,omitempty"`
+	// PIN authorizes setting up an order as it does a transfer, never being stored
+	PIN string `json:"pin,omitempty"`
+}
+
+// StandingOrderFunc changes the stored standing order it is given, returning how it must be stored
+type StandingOrderFunc func(order StandingOrder) (StandingOrder, error)
+
+// scheduleNext moves NextRunAt to the first occurrence after after, finishing o when there's none left
+func (o *StandingOrder) scheduleNext(after time.Time) {
+	next := o.Rule.next(after, o.StartsAt)
+	if next.IsZero() ||
+		(o.EndsAt != nil && next.After(*o.EndsAt)) ||
+		(o.MaxOccurrences > 0 && o.Occurrences >= o.MaxOccurrences) {
+		o.NextRunAt = nil
+		o.Status = OrderFinished
+		return
+	}
+	o.NextRunAt = &next
+}
+
+// occurrence is the transfer scheduled for the occurrence of o at NextRunAt
+func (o StandingOrder) occurrence(now time.Time) ScheduledTransfer {
+	return ScheduledTransfer{
+		OriginAccountID:      o.OriginAccountID,
+		DestinationAccountID: o.DestinationAccountID,
+		Amount:               o.Amount,
+		ScheduledFor:         *o.NextRunAt,
+		Status:               Scheduled,
+		NextAttemptAt:        *o.NextRunAt,
+		StandingOrderID:      o.ID,
+		CreatedAt:            now,
+	}
+}
@@ -0,0 +1,19 @@
+// Package storage holds what is shared among every storage implementation of the domain repositories
+package storage
+
+import "errors"
+
+var ErrCPFAlreadyExists = errors.New("this cpf could not be inserted in our DB")
+var ErrNoAccountWasFound = errors.New("no account was found with the given filter parameters")
+var ErrNoTokenWasFound = errors.New("no token was found with the given filter parameters")
+var ErrNoIdempotencyRecordWasFound = errors.New("no idempotency record was found with the given filter parameters")
+var ErrNoTransferWasFound = errors.New("no transfer was found with the given filter parameters")
+var ErrNoScheduledTransferWasFound = errors.New("no scheduled transfer was found with the given filter parameters")
+var ErrNoStandingOrderWasFound = errors.New("no standing order was found with the given filter parameters")
+var ErrNoLoginAttemptsWereFound = errors.New("no login attempts were found with the given filter parameters")
+var ErrNoRefreshTokenWasFound = errors.New("no refresh token was found with the given filter parameters")
+var ErrNoTwoFactorWasFound = errors.New("no two-factor authentication was found with the given filter parameters")
+var ErrNoLoginChallengeWasFound = errors.New("no login challenge was found with the given filter parameters")
+var ErrNoPasswordResetWasFound = errors.New("no password reset was found with the given filter parameters")
+var ErrNoTransactionPINWasFound = errors.New("no transaction pin was found with the given filter parameters")
+var ErrNoAPIClientWasFound = errors.New("no api client was found with the given filter parameters")
@@ -0,0 +1,22 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+type Account struct {
+	ID      string
+	Name    string
+	CPF     string
+	Secret  string
+	Role    authenticating.Role
+	Balance money.Money
+	Tier    limiting.Tier
+	// Limits holds the limits the account lowered, zero meaning the one of its tier
+	Limits    limiting.Limits
+	CreatedAt time.Time
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/pedroyremolo/transfer-api/pkg/adding"
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Storage) AddAccount(_ context.Context, account adding.Account) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding account %v to memory repo", account)
+	if _, ok := s.accountsByCPF[string(account.CPF)]; ok {
+		s.log.Errorf("CPF %s already exists in our repo", account.CPF)
+		return "", ErrCPFAlreadyExists
+	}
+
+	memAccount := Account{
+		ID:        primitive.NewObjectID().Hex(),
+		Name:      string(account.Name),
+		CPF:       string(account.CPF),
+		Secret:    string(account.Secret),
+		Balance:   money.Money(account.Balance),
+		CreatedAt: account.CreatedAt,
+	}
+	if err := s.addEntries(ledger.OpeningEntries(memAccount.ID, memAccount.Balance, memAccount.CreatedAt)); err != nil {
+		return "", err
+	}
+	s.accounts = append(s.accounts, memAccount)
+	s.accountsByID[memAccount.ID] = len(s.accounts) - 1
+	s.accountsByCPF[memAccount.CPF] = len(s.accounts) - 1
+	return memAccount.ID, nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/adding"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+func TestStorage_AddAccount(t *testing.T) {
+	tt := []struct {
+		name     string
+		existing []string
+		cpf      string
+		wantErr  error
+	}{
+		{
+			name: "When account is added successfully",
+			cpf:  "11111111030",
+		},
+		{
+			name:     "When cpf already exists",
+			existing: []string{"11111111030"},
+			cpf:      "11111111030",
+			wantErr:  ErrCPFAlreadyExists,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewStorage()
+			for _, cpf := range tc.existing {
+				addAccount(t, s, cpf, 0)
+			}
+
+			id, err := s.AddAccount(context.TODO(), adding.Account{Name: "Gopher", CPF: "11111111030", Balance: 4242})
+			if err != tc.wantErr {
+				t.Fatalf("AddAccount() err = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+
+			account, err := s.GetAccountByID(context.TODO(), id)
+			if err != nil {
+				t.Fatalf("Expected account %s to be stored, got err %v", id, err)
+			}
+			if account.CPF != tc.cpf || account.Balance != money.FromCents(4242) {
+				t.Errorf("Expected stored account of cpf %s and balance 42.42, got %v", tc.cpf, account)
+			}
+		})
+	}
+}
+
+func TestStorage_AddAccount_Concurrently(t *testing.T) {
+	s := NewStorage()
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.AddAccount(context.TODO(), adding.Account{Name: "Gopher", CPF: "11111111030"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var added int
+	for err := range errs {
+		if err == nil {
+			added++
+		}
+	}
+	if added != 1 {
+		t.Errorf("Expected only one account of the same cpf to be added, got %d", added)
+	}
+}
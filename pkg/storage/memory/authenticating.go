@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Storage) AddToken(_ context.Context, token authenticating.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding token %v to memory repo", token)
+	s.purgeExpiredTokens(time.Now().UTC())
+	s.tokens[*token.ID] = token
+	return nil
+}
+
+func (s *Storage) GetTokenByID(_ context.Context, id primitive.ObjectID) (authenticating.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving token %v of memory repo", id)
+	token, ok := s.tokens[id]
+	if !ok {
+		s.log.Errorf("No token was found for id %s", id)
+		return authenticating.Token{}, ErrNoTokenWasFound
+	}
+	return token, nil
+}
+
+func (s *Storage) DeleteToken(_ context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Deleting token %v of memory repo", id)
+	if _, ok := s.tokens[id]; !ok {
+		s.log.Errorf("No token was found for id %s", id)
+		return ErrNoTokenWasFound
+	}
+	delete(s.tokens, id)
+	return nil
+}
+
+func (s *Storage) ListClientTokens(_ context.Context, clientID string) ([]authenticating.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Listing tokens of client %s of memory repo", clientID)
+	now := time.Now().UTC()
+	var tokens []authenticating.Token
+	for _, token := range s.tokens {
+		if token.ClientID == clientID && (token.ExpiresAt.IsZero() || token.ExpiresAt.After(now)) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (s *Storage) AddRefreshToken(_ context.Context, refreshToken authenticating.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding refresh token of family %s to memory repo", refreshToken.FamilyID)
+	s.refreshTokens[refreshToken.Hash] = refreshToken
+	return nil
+}
+
+func (s *Storage) UseRefreshToken(_ context.Context, hash string, now time.Time) (authenticating.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Info("Using refresh token of memory repo")
+	refreshToken, ok := s.refreshTokens[hash]
+	if !ok {
+		s.log.Error("No refresh token was found for the given hash")
+		return authenticating.RefreshToken{}, ErrNoRefreshTokenWasFound
+	}
+	if refreshToken.UsedAt == nil {
+		used := refreshToken
+		used.UsedAt = &now
+		s.refreshTokens[hash] = used
+	}
+	return refreshToken, nil
+}
+
+func (s *Storage) RevokeTokenFamily(_ context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Revoking token family %s of memory repo", familyID)
+	for id, token := range s.tokens {
+		if token.FamilyID == familyID {
+			delete(s.tokens, id)
+		}
+	}
+	for hash, refreshToken := range s.refreshTokens {
+		if refreshToken.FamilyID == familyID {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetLoginAttempts(_ context.Context, key string) (authenticating.LoginAttempts, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving login attempts of %s of memory repo", key)
+	attempts, ok := s.loginAttempts[key]
+	if !ok || !attempts.ExpiresAt.After(time.Now().UTC()) {
+		s.log.Errorf("No login attempts were found for %s", key)
+		return authenticating.LoginAttempts{}, ErrNoLoginAttemptsWereFound
+	}
+	return attempts, nil
+}
+
+func (s *Storage) AddFailedLogin(_ context.Context, key string, at time.Time, expiresAt time.Time) (authenticating.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding failed login attempt of %s to memory repo", key)
+	s.purgeExpiredLoginAttempts(at)
+	attempts, ok := s.loginAttempts[key]
+	if !ok || !attempts.ExpiresAt.After(at) {
+		attempts = authenticating.LoginAttempts{Key: key}
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	if expiresAt.After(attempts.ExpiresAt) {
+		attempts.ExpiresAt = expiresAt
+	}
+	s.loginAttempts[key] = attempts
+	return attempts, nil
+}
+
+func (s *Storage) LockLogin(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Locking login of %s until %s in memory repo", key, until)
+	attempts := s.loginAttempts[key]
+	attempts.Key = key
+	attempts.LockedUntil = &until
+	if until.After(attempts.ExpiresAt) {
+		attempts.ExpiresAt = until
+	}
+	s.loginAttempts[key] = attempts
+	return nil
+}
+
+func (s *Storage) ResetLoginAttempts(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Resetting login attempts of %s of memory repo", key)
+	delete(s.loginAttempts, key)
+	return nil
+}
+
+func (s *Storage) AddSecurityEvent(_ context.Context, event authenticating.SecurityEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding security event %v to memory repo", event)
+	s.securityEvents = append(s.securityEvents, event)
+	return nil
+}
+
+func (s *Storage) SetTwoFactor(_ context.Context, twoFactor authenticating.TwoFactor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Setting two-factor of account %s in memory repo", twoFactor.AccountID)
+	twoFactor.RecoveryCodes = append([]string(nil), twoFactor.RecoveryCodes...)
+	s.twoFactors[twoFactor.AccountID] = twoFactor
+	return nil
+}
+
+func (s *Storage) GetTwoFactor(_ context.Context, accountID string) (authenticating.TwoFactor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving two-factor of account %s of memory repo", accountID)
+	twoFactor, ok := s.twoFactors[accountID]
+	if !ok {
+		s.log.Errorf("No two-factor was found for account %s", accountID)
+		return authenticating.TwoFactor{}, ErrNoTwoFactorWasFound
+	}
+	twoFactor.RecoveryCodes = append([]string(nil), twoFactor.RecoveryCodes...)
+	return twoFactor, nil
+}
+
+func (s *Storage) UseTOTPStep(_ context.Context, accountID string, step int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Using TOTP step %d of account %s of memory repo", step, accountID)
+	twoFactor, ok := s.twoFactors[accountID]
+	if !ok {
+		s.log.Errorf("No two-factor was found for account %s", accountID)
+		return false, ErrNoTwoFactorWasFound
+	}
+	if step <= twoFactor.LastUsedStep {
+		return false, nil
+	}
+	twoFactor.LastUsedStep = step
+	s.twoFactors[accountID] = twoFactor
+	return true, nil
+}
+
+func (s *Storage) UseRecoveryCode(_ context.Context, accountID string, digest string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Using recovery code of account %s of memory repo", accountID)
+	twoFactor, ok := s.twoFactors[accountID]
+	if !ok {
+		s.log.Errorf("No two-factor was found for account %s", accountID)
+		return false, ErrNoTwoFactorWasFound
+	}
+	for i, recoveryCode := range twoFactor.RecoveryCodes {
+		if recoveryCode == digest {
+			twoFactor.RecoveryCodes = append(twoFactor.RecoveryCodes[:i:i], twoFactor.RecoveryCodes[i+1:]...)
+			s.twoFactors[accountID] = twoFactor
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Storage) AddLoginChallenge(_ context.Context, challenge authenticating.LoginChallenge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding login challenge of %s to memory repo", challenge.ClientID)
+	s.purgeExpiredLoginChallenges(time.Now().UTC())
+	s.loginChallenges[challenge.Hash] = challenge
+	return nil
+}
+
+func (s *Storage) GetLoginChallenge(_ context.Context, hash string) (authenticating.LoginChallenge, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Info("Retrieving login challenge of memory repo")
+	challenge, ok := s.loginChallenges[hash]
+	if !ok || !challenge.ExpiresAt.After(time.Now().UTC()) {
+		s.log.Error("No login challenge was found for the given hash")
+		return authenticating.LoginChallenge{}, ErrNoLoginChallengeWasFound
+	}
+	return challenge, nil
+}
+
+func (s *Storage) DeleteLoginChallenge(_ context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Info("Deleting login challenge of memory repo")
+	if _, ok := s.loginChallenges[hash]; !ok {
+		s.log.Error("No login challenge was found for the given hash")
+		return ErrNoLoginChallengeWasFound
+	}
+	delete(s.loginChallenges, hash)
+	return nil
+}
+
+func (s *Storage) SetAccountSecret(_ context.Context, accountID string, secretDigest string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Setting secret of account %s in memory repo", accountID)
+	account, err := s.accountByID(accountID)
+	if err != nil {
+		return err
+	}
+	account.Secret = secretDigest
+	return nil
+}
+
+func (s *Storage) RevokeClientTokens(_ context.Context, clientID string, exceptFamilyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Revoking tokens of client %s but family %s of memory repo", clientID, exceptFamilyID)
+	for id, token := range s.tokens {
+		if token.ClientID == clientID && (exceptFamilyID == "" || token.FamilyID != exceptFamilyID) {
+			delete(s.tokens, id)
+		}
+	}
+	for hash, refreshToken := range s.refreshTokens {
+		if refreshToken.ClientID == clientID && (exceptFamilyID == "" || refreshToken.FamilyID != exceptFamilyID) {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) AddPasswordReset(_ context.Context, reset authenticating.PasswordReset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding password reset of account %s to memory repo", reset.AccountID)
+	for hash, other := range s.passwordResets {
+		if other.AccountID == reset.AccountID || !other.ExpiresAt.After(time.Now().UTC()) {
+			delete(s.passwordResets, hash)
+		}
+	}
+	s.passwordResets[reset.Hash] = reset
+	return nil
+}
+
+func (s *Storage) GetPasswordReset(_ context.Context, hash string) (authenticating.PasswordReset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Info("Retrieving password reset of memory repo")
+	reset, ok := s.passwordResets[hash]
+	if !ok || !reset.ExpiresAt.After(time.Now().UTC()) {
+		s.log.Error("No password reset was found for the given hash")
+		return authenticating.PasswordReset{}, ErrNoPasswordResetWasFound
+	}
+	return reset, nil
+}
+
+func (s *Storage) DeletePasswordReset(_ context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Info("Deleting password reset of memory repo")
+	if _, ok := s.passwordResets[hash]; !ok {
+		s.log.Error("No password reset was found for the given hash")
+		return ErrNoPasswordResetWasFound
+	}
+	delete(s.passwordResets, hash)
+	return nil
+}
+
+func (s *Storage) SetTransactionPIN(_ context.Context, pin authenticating.TransactionPIN) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Setting transaction pin of account %s in memory repo", pin.AccountID)
+	s.transactionPINs[pin.AccountID] = pin
+	return nil
+}
+
+func (s *Storage) GetTransactionPIN(_ context.Context, accountID string) (authenticating.TransactionPIN, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving transaction pin of account %s of memory repo", accountID)
+	pin, ok := s.transactionPINs[accountID]
+	if !ok {
+		s.log.Infof("No transaction pin was found for account %s", accountID)
+		return authenticating.TransactionPIN{}, ErrNoTransactionPINWasFound
+	}
+	return pin, nil
+}
+
+func (s *Storage) DeleteTransactionPIN(_ context.Context, accountID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Deleting transaction pin of account %s of memory repo", accountID)
+	if _, ok := s.transactionPINs[accountID]; !ok {
+		s.log.Errorf("No transaction pin was found for account %s", accountID)
+		return ErrNoTransactionPINWasFound
+	}
+	delete(s.transactionPINs, accountID)
+	return nil
+}
+
+func (s *Storage) AddAPIClient(_ context.Context, client authenticating.APIClient) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding API client %s to memory repo", client.ID)
+	client.Scopes = append([]authenticating.Scope(nil), client.Scopes...)
+	s.apiClients[client.ID] = client
+	return nil
+}
+
+func (s *Storage) GetAPIClient(_ context.Context, id string) (authenticating.APIClient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving API client %s of memory repo", id)
+	client, ok := s.apiClients[id]
+	if !ok {
+		s.log.Errorf("No API client was found with id %s", id)
+		return authenticating.APIClient{}, ErrNoAPIClientWasFound
+	}
+	client.Scopes = append([]authenticating.Scope(nil), client.Scopes...)
+	return client, nil
+}
+
+// purgeExpiredTokens must be called with the lock held, playing the role of the TTL indexes of the mongodb storage
+func (s *Storage) purgeExpiredTokens(now time.Time) {
+	for id, token := range s.tokens {
+		if !token.ExpiresAt.IsZero() && !token.ExpiresAt.After(now) {
+			delete(s.tokens, id)
+		}
+	}
+	for hash, refreshToken := range s.refreshTokens {
+		if !refreshToken.ExpiresAt.After(now) {
+			delete(s.refreshTokens, hash)
+		}
+	}
+}
+
+// purgeExpiredLoginAttempts must be called with the lock held, playing the role of the TTL index of the mongodb storage
+func (s *Storage) purgeExpiredLoginAttempts(now time.Time) {
+	for key, attempts := range s.loginAttempts {
+		if !attempts.ExpiresAt.After(now) {
+			delete(s.loginAttempts, key)
+		}
+	}
+}
+
+// purgeExpiredLoginChallenges must be called with the lock held, playing the role of the TTL index of the mongodb storage
+func (s *Storage) purgeExpiredLoginChallenges(now time.Time) {
+	for hash, challenge := range s.loginChallenges {
+		if !challenge.ExpiresAt.After(now) {
+			delete(s.loginChallenges, hash)
+		}
+	}
+}
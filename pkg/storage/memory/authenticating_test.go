@@ -0,0 +1,372 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/adding"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/gatekeeper/jwt"
+	"github.com/pedroyremolo/transfer-api/pkg/hasher/argon2"
+	"github.com/pedroyremolo/transfer-api/pkg/notifier/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testHasherParams keep hashing cheap, as tests don't need it to be slow
+var testHasherParams = argon2.Params{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+func TestStorage_GetTokenByID(t *testing.T) {
+	s := NewStorage()
+	oid := primitive.NewObjectID()
+	token := authenticating.Token{ID: &oid, ClientID: "4sfa9684fsa698", Digest: "foo.bar.baz"}
+	if err := s.AddToken(context.TODO(), token); err != nil {
+		t.Fatalf("AddToken() err = %v", err)
+	}
+
+	tt := []struct {
+		name    string
+		id      primitive.ObjectID
+		wantErr error
+	}{
+		{name: "When token was added", id: oid},
+		{name: "When token was never added", id: primitive.NewObjectID(), wantErr: ErrNoTokenWasFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.GetTokenByID(context.TODO(), tc.id)
+			if err != tc.wantErr {
+				t.Fatalf("GetTokenByID() err = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && got.Digest != token.Digest {
+				t.Errorf("Expected token %v, got %v", token, got)
+			}
+		})
+	}
+}
+
+// login signs a token to a new session of clientID through authenticator
+func login(t *testing.T, authenticator authenticating.Service, clientID string) authenticating.Token {
+	t.Helper()
+	digest, _ := bcrypt.GenerateFromPassword([]byte(`"65416949"`), bcrypt.MinCost)
+	token, err := authenticator.Sign(context.TODO(), authenticating.Login{Secret: "65416949"}, string(digest), clientID, authenticating.Customer)
+	if err != nil {
+		t.Fatalf("Could not sign token to %s: %v", clientID, err)
+	}
+	return token
+}
+
+func TestStorage_UseRefreshToken(t *testing.T) {
+	s := NewStorage()
+	authenticator := authenticating.NewService(s, jwt.NewGatekeeper("testSecret", "test"), logging.NewNotifier(), argon2.NewHasher(testHasherParams))
+	first := login(t, authenticator, "4sfa9684fsa698")
+	other := login(t, authenticator, "4sfa9684fsa698")
+
+	second, err := authenticator.Refresh(context.TODO(), first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() err = %v", err)
+	}
+	if second.FamilyID != first.FamilyID {
+		t.Errorf("Expected refreshed token to be of family %s, got %s", first.FamilyID, second.FamilyID)
+	}
+	if _, err = authenticator.Verify(context.TODO(), first.Digest); err != nil {
+		t.Errorf("Expected tokens to remain valid until their refresh token is reused, got %v", err)
+	}
+
+	if _, err = authenticator.Refresh(context.TODO(), first.RefreshToken); err != authenticating.ErrRefreshTokenReused {
+		t.Fatalf("Expected reuse to be detected, got %v", err)
+	}
+	for _, token := range []authenticating.Token{first, second} {
+		if _, err = authenticator.Verify(context.TODO(), token.Digest); err != ErrNoTokenWasFound {
+			t.Errorf("Expected token %s to be revoked along with its family, got %v", token.ID.Hex(), err)
+		}
+	}
+	if _, err = authenticator.Refresh(context.TODO(), second.RefreshToken); err != authenticating.ErrInvalidRefreshToken {
+		t.Errorf("Expected refresh tokens to be revoked along with their family, got %v", err)
+	}
+	if _, err = authenticator.Verify(context.TODO(), other.Digest); err != nil {
+		t.Errorf("Expected tokens of other sessions to remain valid, got %v", err)
+	}
+}
+
+func TestStorage_RevokeTokenFamily(t *testing.T) {
+	s := NewStorage()
+	authenticator := authenticating.NewService(s, jwt.NewGatekeeper("testSecret", "test"), logging.NewNotifier(), argon2.NewHasher(testHasherParams))
+	token := login(t, authenticator, "4sfa9684fsa698")
+
+	if err := authenticator.Logout(context.TODO(), *token.ID); err != nil {
+		t.Fatalf("Logout() err = %v", err)
+	}
+	if _, err := authenticator.Verify(context.TODO(), token.Digest); err != ErrNoTokenWasFound {
+		t.Errorf("Expected token to be revoked, got %v", err)
+	}
+	if _, err := authenticator.Refresh(context.TODO(), token.RefreshToken); err != authenticating.ErrInvalidRefreshToken {
+		t.Errorf("Expected refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestStorage_AddToken_PurgesExpiredTokens(t *testing.T) {
+	s := NewStorage()
+	expiredID, validID := primitive.NewObjectID(), primitive.NewObjectID()
+	_ = s.AddToken(context.TODO(), authenticating.Token{ID: &expiredID, ExpiresAt: time.Now().UTC().Add(-time.Second)})
+	_ = s.AddToken(context.TODO(), authenticating.Token{ID: &validID, ExpiresAt: time.Now().UTC().Add(time.Hour)})
+
+	if _, err := s.GetTokenByID(context.TODO(), expiredID); err != ErrNoTokenWasFound {
+		t.Errorf("Expected expired token to be purged, got %v", err)
+	}
+	if _, err := s.GetTokenByID(context.TODO(), validID); err != nil {
+		t.Errorf("Expected valid token to be kept, got %v", err)
+	}
+}
+
+func TestStorage_AddFailedLogin(t *testing.T) {
+	s := NewStorage()
+	now := time.Now().UTC()
+	key := "cpf:11111111030"
+
+	if _, err := s.GetLoginAttempts(context.TODO(), key); err != ErrNoLoginAttemptsWereFound {
+		t.Fatalf("Expected err %v before any failure, got %v", ErrNoLoginAttemptsWereFound, err)
+	}
+	for i := 1; i <= 2; i++ {
+		attempts, err := s.AddFailedLogin(context.TODO(), key, now, now.Add(authenticating.FailureWindow))
+		if err != nil {
+			t.Fatalf("AddFailedLogin() err = %v", err)
+		}
+		if attempts.Failures != i {
+			t.Errorf("Expected %d failures, got %d", i, attempts.Failures)
+		}
+	}
+
+	until := now.Add(time.Hour)
+	if err := s.LockLogin(context.TODO(), key, until); err != nil {
+		t.Fatalf("LockLogin() err = %v", err)
+	}
+	attempts, err := s.GetLoginAttempts(context.TODO(), key)
+	if err != nil {
+		t.Fatalf("GetLoginAttempts() err = %v", err)
+	}
+	if attempts.LockedUntil == nil || !attempts.LockedUntil.Equal(until) || !attempts.ExpiresAt.Equal(until) {
+		t.Errorf("Expected attempts to be locked and kept until %s, got %v", until, attempts)
+	}
+
+	// failures after the attempts expired start counting again
+	later := until.Add(time.Second)
+	attempts, err = s.AddFailedLogin(context.TODO(), key, later, later.Add(authenticating.FailureWindow))
+	if err != nil {
+		t.Fatalf("AddFailedLogin() err = %v", err)
+	}
+	if attempts.Failures != 1 || attempts.LockedUntil != nil {
+		t.Errorf("Expected expired attempts to be forgotten, got %v", attempts)
+	}
+
+	if err = s.ResetLoginAttempts(context.TODO(), key); err != nil {
+		t.Fatalf("ResetLoginAttempts() err = %v", err)
+	}
+	if _, err = s.GetLoginAttempts(context.TODO(), key); err != ErrNoLoginAttemptsWereFound {
+		t.Errorf("Expected err %v after reset, got %v", ErrNoLoginAttemptsWereFound, err)
+	}
+}
+
+func TestStorage_UseTOTPStep(t *testing.T) {
+	s := NewStorage()
+	twoFactor := authenticating.TwoFactor{AccountID: "4sfa9684fsa698", Secret: "JBSWY3DPEHPK3PXP", LastUsedStep: 10, RecoveryCodes: []string{"foo", "bar"}}
+	if err := s.SetTwoFactor(context.TODO(), twoFactor); err != nil {
+		t.Fatalf("SetTwoFactor() err = %v", err)
+	}
+
+	for _, tc := range []struct {
+		step int64
+		want bool
+	}{{step: 10}, {step: 9}, {step: 11, want: true}, {step: 11}} {
+		used, err := s.UseTOTPStep(context.TODO(), twoFactor.AccountID, tc.step)
+		if err != nil || used != tc.want {
+			t.Errorf("Expected step %d to be used %v; got %v, %v", tc.step, tc.want, used, err)
+		}
+	}
+	for _, tc := range []struct {
+		digest string
+		want   bool
+	}{{digest: "foo", want: true}, {digest: "foo"}, {digest: "baz"}} {
+		used, err := s.UseRecoveryCode(context.TODO(), twoFactor.AccountID, tc.digest)
+		if err != nil || used != tc.want {
+			t.Errorf("Expected recovery code %s to be used %v; got %v, %v", tc.digest, tc.want, used, err)
+		}
+	}
+
+	got, err := s.GetTwoFactor(context.TODO(), twoFactor.AccountID)
+	if err != nil {
+		t.Fatalf("GetTwoFactor() err = %v", err)
+	}
+	if got.LastUsedStep != 11 || len(got.RecoveryCodes) != 1 || got.RecoveryCodes[0] != "bar" {
+		t.Errorf("Expected step 11 and recovery code bar to be left; got %v", got)
+	}
+	if _, err = s.UseTOTPStep(context.TODO(), "a6sf46af6af", 12); err != ErrNoTwoFactorWasFound {
+		t.Errorf("Expected err %v for an account never enrolled; got %v", ErrNoTwoFactorWasFound, err)
+	}
+}
+
+func TestStorage_GetLoginChallenge(t *testing.T) {
+	s := NewStorage()
+	now := time.Now().UTC()
+	challenges := []authenticating.LoginChallenge{
+		{Hash: "live", ClientID: "4sfa9684fsa698", ExpiresAt: now.Add(authenticating.LoginChallengeTTL)},
+		{Hash: "expired", ClientID: "4sfa9684fsa698", ExpiresAt: now.Add(-time.Second)},
+	}
+	for _, challenge := range challenges {
+		if err := s.AddLoginChallenge(context.TODO(), challenge); err != nil {
+			t.Fatalf("AddLoginChallenge() err = %v", err)
+		}
+	}
+
+	if _, err := s.GetLoginChallenge(context.TODO(), "live"); err != nil {
+		t.Errorf("Expected live challenge to be found; got %v", err)
+	}
+	if _, err := s.GetLoginChallenge(context.TODO(), "expired"); err != ErrNoLoginChallengeWasFound {
+		t.Errorf("Expected err %v for an expired challenge; got %v", ErrNoLoginChallengeWasFound, err)
+	}
+	if err := s.DeleteLoginChallenge(context.TODO(), "live"); err != nil {
+		t.Fatalf("DeleteLoginChallenge() err = %v", err)
+	}
+	if err := s.DeleteLoginChallenge(context.TODO(), "live"); err != ErrNoLoginChallengeWasFound {
+		t.Errorf("Expected err %v deleting twice; got %v", ErrNoLoginChallengeWasFound, err)
+	}
+}
+
+func TestStorage_RevokeClientTokens(t *testing.T) {
+	s := NewStorage()
+	authenticator := authenticating.NewService(s, jwt.NewGatekeeper("testSecret", "test"), logging.NewNotifier(), argon2.NewHasher(testHasherParams))
+	current := login(t, authenticator, "4sfa9684fsa698")
+	other := login(t, authenticator, "4sfa9684fsa698")
+	stranger := login(t, authenticator, "9a8f7s6f5a4s3f")
+
+	if err := s.RevokeClientTokens(context.TODO(), "4sfa9684fsa698", current.FamilyID); err != nil {
+		t.Fatalf("RevokeClientTokens() err = %v", err)
+	}
+	for _, token := range []authenticating.Token{current, stranger} {
+		if _, err := authenticator.Verify(context.TODO(), token.Digest); err != nil {
+			t.Errorf("Expected token %s to be kept, got %v", token.ID.Hex(), err)
+		}
+	}
+	if _, err := authenticator.Verify(context.TODO(), other.Digest); err != ErrNoTokenWasFound {
+		t.Errorf("Expected other session to be revoked, got %v", err)
+	}
+	if _, err := authenticator.Refresh(context.TODO(), other.RefreshToken); err != authenticating.ErrInvalidRefreshToken {
+		t.Errorf("Expected refresh token of the other session to be revoked, got %v", err)
+	}
+
+	if err := s.RevokeClientTokens(context.TODO(), "4sfa9684fsa698", ""); err != nil {
+		t.Fatalf("RevokeClientTokens() err = %v", err)
+	}
+	if _, err := authenticator.Verify(context.TODO(), current.Digest); err != ErrNoTokenWasFound {
+		t.Errorf("Expected every session to be revoked, got %v", err)
+	}
+}
+
+func TestStorage_SetAccountSecret(t *testing.T) {
+	s := NewStorage()
+	id, _ := s.AddAccount(context.TODO(), adding.Account{Name: "Gopher", CPF: "11111111030"})
+
+	if err := s.SetAccountSecret(context.TODO(), id, "new"); err != nil {
+		t.Fatalf("SetAccountSecret() err = %v", err)
+	}
+	account, _ := s.GetAccountByID(context.TODO(), id)
+	if account.Secret != "new" {
+		t.Errorf("Expected secret new, got %s", account.Secret)
+	}
+	if err := s.SetAccountSecret(context.TODO(), primitive.NewObjectID().Hex(), "new"); err != ErrNoAccountWasFound {
+		t.Errorf("Expected err %v for an unknown account, got %v", ErrNoAccountWasFound, err)
+	}
+}
+
+func TestStorage_GetPasswordReset(t *testing.T) {
+	s := NewStorage()
+	now := time.Now().UTC()
+	resets := []authenticating.PasswordReset{
+		{Hash: "expired", AccountID: "4sfa9684fsa698", ExpiresAt: now.Add(-time.Second)},
+		{Hash: "first", AccountID: "4sfa9684fsa698", ExpiresAt: now.Add(authenticating.PasswordResetTTL)},
+		{Hash: "second", AccountID: "4sfa9684fsa698", ExpiresAt: now.Add(authenticating.PasswordResetTTL)},
+	}
+	for _, reset := range resets {
+		if err := s.AddPasswordReset(context.TODO(), reset); err != nil {
+			t.Fatalf("AddPasswordReset() err = %v", err)
+		}
+	}
+
+	for _, hash := range []string{"expired", "first"} {
+		if _, err := s.GetPasswordReset(context.TODO(), hash); err != ErrNoPasswordResetWasFound {
+			t.Errorf("Expected err %v for reset %s, got %v", ErrNoPasswordResetWasFound, hash, err)
+		}
+	}
+	if _, err := s.GetPasswordReset(context.TODO(), "second"); err != nil {
+		t.Errorf("Expected latest reset to be found, got %v", err)
+	}
+	if err := s.DeletePasswordReset(context.TODO(), "second"); err != nil {
+		t.Fatalf("DeletePasswordReset() err = %v", err)
+	}
+	if err := s.DeletePasswordReset(context.TODO(), "second"); err != ErrNoPasswordResetWasFound {
+		t.Errorf("Expected err %v deleting twice, got %v", ErrNoPasswordResetWasFound, err)
+	}
+}
+
+func TestStorage_SetTransactionPIN(t *testing.T) {
+	s := NewStorage()
+	accountID := "4sfa9684fsa698"
+	for _, digest := range []string{"first", "second"} {
+		if err := s.SetTransactionPIN(context.TODO(), authenticating.TransactionPIN{AccountID: accountID, Digest: digest}); err != nil {
+			t.Fatalf("SetTransactionPIN() err = %v", err)
+		}
+	}
+
+	pin, err := s.GetTransactionPIN(context.TODO(), accountID)
+	if err != nil || pin.Digest != "second" {
+		t.Fatalf("Expected pin to be replaced, got %v, %v", pin, err)
+	}
+	if err = s.DeleteTransactionPIN(context.TODO(), accountID); err != nil {
+		t.Fatalf("DeleteTransactionPIN() err = %v", err)
+	}
+	if _, err = s.GetTransactionPIN(context.TODO(), accountID); err != ErrNoTransactionPINWasFound {
+		t.Errorf("Expected err %v once deleted, got %v", ErrNoTransactionPINWasFound, err)
+	}
+	if err = s.DeleteTransactionPIN(context.TODO(), accountID); err != ErrNoTransactionPINWasFound {
+		t.Errorf("Expected err %v deleting twice, got %v", ErrNoTransactionPINWasFound, err)
+	}
+}
+
+func TestStorage_GetAPIClient(t *testing.T) {
+	s := NewStorage()
+	client := authenticating.APIClient{
+		ID:     "5f8b1c2d3e4f5a6b7c8d9e0f",
+		Name:   "batch",
+		Scopes: []authenticating.Scope{authenticating.ScopeAccountsRead},
+	}
+	if err := s.AddAPIClient(context.TODO(), client); err != nil {
+		t.Fatalf("AddAPIClient() err = %v", err)
+	}
+	client.Scopes[0] = authenticating.ScopeLimitsManage
+
+	got, err := s.GetAPIClient(context.TODO(), client.ID)
+	if err != nil || got.Scopes[0] != authenticating.ScopeAccountsRead {
+		t.Fatalf("Expected client to be stored apart from the caller, got %v, %v", got, err)
+	}
+	if _, err = s.GetAPIClient(context.TODO(), "unknown"); err != ErrNoAPIClientWasFound {
+		t.Errorf("Expected err %v for an unknown client, got %v", ErrNoAPIClientWasFound, err)
+	}
+}
+
+func TestStorage_ListClientTokens(t *testing.T) {
+	s := NewStorage()
+	now := time.Now().UTC()
+	active, expired, others := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	s.tokens[active] = authenticating.Token{ID: &active, ClientID: "4sfa9684fsa698", ExpiresAt: now.Add(time.Minute)}
+	s.tokens[expired] = authenticating.Token{ID: &expired, ClientID: "4sfa9684fsa698", ExpiresAt: now.Add(-time.Minute)}
+	s.tokens[others] = authenticating.Token{ID: &others, ClientID: "5f8f8ccb30a1cd7511c5cb70", ExpiresAt: now.Add(time.Minute)}
+
+	tokens, err := s.ListClientTokens(context.TODO(), "4sfa9684fsa698")
+	if err != nil {
+		t.Fatalf("ListClientTokens() err = %v", err)
+	}
+	if len(tokens) != 1 || *tokens[0].ID != active {
+		t.Errorf("Expected only the unexpired token of the client, got %v", tokens)
+	}
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+)
+
+func (s *Storage) AddIdempotencyRecord(_ context.Context, record idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding idempotency key %s to memory repo", record.Key)
+	id := idempotencyRecordID(record.AccountID, record.Key)
+	if existing, ok := s.idempotencyRecords[id]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		s.log.Errorf("Idempotency key %s of account %s already exists", record.Key, record.AccountID)
+		return idempotency.ErrKeyAlreadyExists
+	}
+	s.idempotencyRecords[id] = record
+	return nil
+}
+
+func (s *Storage) GetIdempotencyRecord(_ context.Context, accountID string, key string) (idempotency.Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving idempotency key %s of account %s of memory repo", key, accountID)
+	record, ok := s.idempotencyRecords[idempotencyRecordID(accountID, key)]
+	if !ok || !record.ExpiresAt.After(time.Now().UTC()) {
+		s.log.Errorf("No idempotency key %s was found for account %s", key, accountID)
+		return idempotency.Record{}, ErrNoIdempotencyRecordWasFound
+	}
+	return record, nil
+}
+
+func (s *Storage) UpdateIdempotencyRecord(_ context.Context, record idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Updating idempotency key %s of memory repo", record.Key)
+	id := idempotencyRecordID(record.AccountID, record.Key)
+	existing, ok := s.idempotencyRecords[id]
+	if !ok {
+		s.log.Errorf("No idempotency key %s was found for account %s", record.Key, record.AccountID)
+		return ErrNoIdempotencyRecordWasFound
+	}
+	existing.Completed = record.Completed
+	existing.StatusCode = record.StatusCode
+	existing.ContentType = record.ContentType
+	existing.Location = record.Location
+	existing.Body = record.Body
+	s.idempotencyRecords[id] = existing
+	return nil
+}
+
+func (s *Storage) DeleteIdempotencyRecord(_ context.Context, accountID string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Deleting idempotency key %s of account %s of memory repo", key, accountID)
+	delete(s.idempotencyRecords, idempotencyRecordID(accountID, key))
+	return nil
+}
+
+func idempotencyRecordID(accountID string, key string) string {
+	return accountID + "/" + key
+}
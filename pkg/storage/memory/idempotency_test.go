@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+)
+
+func TestStorage_AddIdempotencyRecord(t *testing.T) {
+	now := time.Now().UTC()
+	tt := []struct {
+		name     string
+		existing *idempotency.Record
+		wantErr  error
+	}{
+		{
+			name: "When key was never used",
+		},
+		{
+			name:     "When key is in use",
+			existing: &idempotency.Record{AccountID: "4f98as4f98sa496a1f", Key: "k3y", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+			wantErr:  idempotency.ErrKeyAlreadyExists,
+		},
+		{
+			name:     "When key was used but already expired",
+			existing: &idempotency.Record{AccountID: "4f98as4f98sa496a1f", Key: "k3y", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Second)},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewStorage()
+			if tc.existing != nil {
+				_ = s.AddIdempotencyRecord(context.TODO(), *tc.existing)
+			}
+
+			record := idempotency.Record{AccountID: "4f98as4f98sa496a1f", Key: "k3y", RequestHash: "h4sh", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+			if err := s.AddIdempotencyRecord(context.TODO(), record); err != tc.wantErr {
+				t.Fatalf("AddIdempotencyRecord() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestStorage_GetIdempotencyRecord(t *testing.T) {
+	s := NewStorage()
+	now := time.Now().UTC()
+	record := idempotency.Record{AccountID: "4f98as4f98sa496a1f", Key: "k3y", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	_ = s.AddIdempotencyRecord(context.TODO(), record)
+	record.Completed, record.StatusCode, record.Location = true, 201, "/transfers/f1869a4f9a84f89sa"
+	_ = s.UpdateIdempotencyRecord(context.TODO(), record)
+
+	got, err := s.GetIdempotencyRecord(context.TODO(), record.AccountID, record.Key)
+	if err != nil {
+		t.Fatalf("GetIdempotencyRecord() err = %v", err)
+	}
+	if !got.Completed || got.StatusCode != 201 || got.Location != record.Location {
+		t.Errorf("Expected completed record with status 201 and its location, got %v", got)
+	}
+
+	if _, err = s.GetIdempotencyRecord(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", record.Key); err != ErrNoIdempotencyRecordWasFound {
+		t.Errorf("Expected keys to be scoped by account, got err %v", err)
+	}
+
+	_ = s.DeleteIdempotencyRecord(context.TODO(), record.AccountID, record.Key)
+	if _, err = s.GetIdempotencyRecord(context.TODO(), record.AccountID, record.Key); err != ErrNoIdempotencyRecordWasFound {
+		t.Errorf("Expected deleted record not to be found, got err %v", err)
+	}
+}
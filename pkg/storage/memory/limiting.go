@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
+
+func (s *Storage) GetLimitSettings(_ context.Context, accountID string) (limiting.Settings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving limit settings of account %s of memory repo", accountID)
+	account, err := s.accountByID(accountID)
+	if err != nil {
+		return limiting.Settings{}, err
+	}
+	return limiting.Settings{Tier: account.Tier, Custom: account.Limits}, nil
+}
+
+func (s *Storage) SetLimitSettings(_ context.Context, accountID string, settings limiting.Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Setting limit settings %v of account %s in memory repo", settings, accountID)
+	account, err := s.accountByID(accountID)
+	if err != nil {
+		return err
+	}
+	account.Tier = settings.Tier
+	account.Limits = settings.Custom
+	return nil
+}
+
+func (s *Storage) GetSpendings(_ context.Context, accountID string, since time.Time) ([]limiting.Spending, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving spendings of account %s since %s of memory repo", accountID, since)
+	spendings := make([]limiting.Spending, 0)
+	for _, t := range s.transfers {
+		if t.OriginAccountID != accountID || t.ReversalOf != "" || t.Status == transferstatus.Failed || t.CreatedAt.Before(since) {
+			continue
+		}
+		spendings = append(spendings, limiting.Spending{Amount: t.Amount, CreatedAt: t.CreatedAt})
+	}
+	return spendings, nil
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+)
+
+func TestStorage_GetSpendings(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	since := time.Now().UTC().Add(-time.Minute)
+	transferor := transferring.NewService(s, limiting.NewService(s))
+
+	id := executeTransfer(t, s, origin, destination, money.FromCents(600))
+	if _, err := transferor.MakeTransfer(context.TODO(), transferring.Transfer{
+		OriginAccountID:      origin,
+		DestinationAccountID: destination,
+		Amount:               money.FromCents(500),
+	}); err != transferring.ErrNotEnoughBalance {
+		t.Fatalf("Expected transfer to fail with %v, got %v", transferring.ErrNotEnoughBalance, err)
+	}
+	if _, err := transferor.ReverseTransfer(context.TODO(), transferring.Reversal{TransferID: id, RequesterID: destination}); err != nil {
+		t.Fatalf("Could not reverse transfer %s: %v", id, err)
+	}
+
+	spendings, err := s.GetSpendings(context.TODO(), origin, since)
+	if err != nil {
+		t.Fatalf("GetSpendings() err = %v", err)
+	}
+	if len(spendings) != 1 || spendings[0].Amount != money.FromCents(600) {
+		t.Errorf("Expected only the reversed transfer of 6.00 to be a spending, got %v", spendings)
+	}
+
+	spendings, _ = s.GetSpendings(context.TODO(), destination, since)
+	if len(spendings) != 0 {
+		t.Errorf("Expected reversals not to be spendings, got %v", spendings)
+	}
+}
+
+func TestStorage_MakeTransfer_WithinLimitsConcurrently(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	limiter := limiting.NewService(s)
+	transferor := transferring.NewService(s, limiter)
+
+	// lowering the daily limit down to the night-time one makes the test alike whatever time it runs
+	if _, err := limiter.LowerLimits(context.TODO(), origin, limiting.Limits{Daily: money.FromCents(100000)}); err != nil {
+		t.Fatalf("Could not lower limits of account %s: %v", origin, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = transferor.MakeTransfer(context.TODO(), transferring.Transfer{
+				OriginAccountID:      origin,
+				DestinationAccountID: destination,
+				Amount:               money.FromCents(40000),
+			})
+		}()
+	}
+	wg.Wait()
+
+	account, _ := s.GetAccountByID(context.TODO(), origin)
+	if spent := money.FromCents(1000000) - account.Balance; spent > money.FromCents(100000) {
+		t.Errorf("Expected at most 1000.00 to be transferred, got %s", spent)
+	}
+}
+
+func TestStorage_SetLimitSettings(t *testing.T) {
+	s := NewStorage()
+	id := addAccount(t, s, "11111111030", money.FromCents(1000))
+	settings := limiting.Settings{Tier: limiting.Premium, Custom: limiting.Limits{NightTime: money.FromCents(50000)}}
+
+	if err := s.SetLimitSettings(context.TODO(), id, settings); err != nil {
+		t.Fatalf("SetLimitSettings() err = %v", err)
+	}
+	if got, _ := s.GetLimitSettings(context.TODO(), id); got != settings {
+		t.Errorf("Expected settings %v, got %v", settings, got)
+	}
+	if err := s.SetLimitSettings(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", settings); err != ErrNoAccountWasFound {
+		t.Errorf("Expected err %v when setting limits of an unknown account, got %v", ErrNoAccountWasFound, err)
+	}
+}
@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+func (s *Storage) GetAccountByID(_ context.Context, id string) (listing.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving account %v of memory repo", id)
+	account, err := s.accountByID(id)
+	if err != nil {
+		return listing.Account{}, err
+	}
+	return toListingAccount(*account), nil
+}
+
+func (s *Storage) GetAccountByCPF(_ context.Context, cpf string) (listing.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving account of cpf %v of memory repo", cpf)
+	i, ok := s.accountsByCPF[cpf]
+	if !ok {
+		s.log.Errorf("No account was found with cpf %s", cpf)
+		return listing.Account{}, ErrNoAccountWasFound
+	}
+	return toListingAccount(s.accounts[i]), nil
+}
+
+func (s *Storage) GetAccounts(_ context.Context) ([]listing.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Info("Retrieving all accounts of memory repo")
+	accounts := make([]listing.Account, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		accounts = append(accounts, toListingAccount(a))
+	}
+	return accounts, nil
+}
+
+func (s *Storage) GetTransfersByAccountID(_ context.Context, accountID string, query listing.TransferQuery) ([]listing.Transfer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving transfers of account %s by %v of memory repo", accountID, query)
+	transfers := make([]listing.Transfer, 0)
+	for _, t := range s.transfers {
+		if !transferMatches(accountID, query.Filter, t) {
+			continue
+		}
+		transfer := toListingTransfer(t)
+		if query.After != nil && !query.After.After(query.Sort, transfer) {
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		return query.Sort.Before(transfers[i], transfers[j])
+	})
+	if len(transfers) > query.Limit {
+		transfers = transfers[:query.Limit]
+	}
+	return transfers, nil
+}
+
+func transferMatches(accountID string, filter listing.TransferFilter, t Transfer) bool {
+	var counterparty string
+	switch {
+	case t.OriginAccountID == accountID && filter.Direction != listing.Received:
+		counterparty = t.DestinationAccountID
+	case t.DestinationAccountID == accountID && filter.Direction != listing.Sent:
+		counterparty = t.OriginAccountID
+	default:
+		return false
+	}
+	switch {
+	case filter.CounterpartyID != "" && counterparty != filter.CounterpartyID,
+		filter.Status != "" && t.Status != filter.Status,
+		filter.StandingOrderID != "" && t.StandingOrderID != filter.StandingOrderID,
+		!filter.CreatedFrom.IsZero() && t.CreatedAt.Before(filter.CreatedFrom),
+		!filter.CreatedUntil.IsZero() && t.CreatedAt.After(filter.CreatedUntil),
+		filter.MinAmount != 0 && t.Amount < filter.MinAmount,
+		filter.MaxAmount != 0 && t.Amount > filter.MaxAmount:
+		return false
+	}
+	return true
+}
+
+func (s *Storage) GetLedgerBalance(_ context.Context, accountID string, until time.Time) (money.Money, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Summing ledger entries of account %s until %s of memory repo", accountID, until)
+	entries := make([]ledger.Entry, 0)
+	for _, e := range s.entries {
+		if e.AccountID == accountID && !e.CreatedAt.After(until) {
+			entries = append(entries, e)
+		}
+	}
+	return ledger.Balance(entries), nil
+}
+
+func (s *Storage) StreamEntriesByAccountIDBetween(_ context.Context, accountID string, from time.Time, until time.Time, fn func(ledger.Entry) error) error {
+	s.mu.RLock()
+	s.log.Infof("Streaming ledger entries of account %s from %s until %s of memory repo", accountID, from, until)
+	entries := make([]ledger.Entry, 0)
+	for _, e := range s.entries {
+		if e.AccountID == accountID && !e.CreatedAt.Before(from) && !e.CreatedAt.After(until) {
+			entries = append(entries, e)
+		}
+	}
+	// fn is called without the lock, as it may be as slow as the client it writes to
+	s.mu.RUnlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetTransfersByIDs(_ context.Context, ids []string) ([]listing.Transfer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving transfers %v of memory repo", ids)
+	transfers := make([]listing.Transfer, 0, len(ids))
+	for _, id := range ids {
+		if i, ok := s.transfersByID[id]; ok {
+			transfers = append(transfers, toListingTransfer(s.transfers[i]))
+		}
+	}
+	return transfers, nil
+}
+
+func toListingAccount(a Account) listing.Account {
+	createdAt := a.CreatedAt
+	return listing.Account{
+		ID:        a.ID,
+		Name:      a.Name,
+		CPF:       a.CPF,
+		Secret:    a.Secret,
+		Role:      string(a.Role),
+		Balance:   a.Balance,
+		CreatedAt: &createdAt,
+	}
+}
+
+func toListingTransfer(t Transfer) listing.Transfer {
+	return listing.Transfer{
+		ID:                   t.ID,
+		OriginAccountID:      t.OriginAccountID,
+		DestinationAccountID: t.DestinationAccountID,
+		Amount:               t.Amount,
+		Status:               t.Status,
+		FailureReason:        t.FailureReason,
+		ReversedAmount:       t.ReversedAmount,
+		ReversalOf:           t.ReversalOf,
+		StandingOrderID:      t.StandingOrderID,
+		CreatedAt:            t.CreatedAt,
+		CompletedAt:          t.CompletedAt,
+		FailedAt:             t.FailedAt,
+		ReversedAt:           t.ReversedAt,
+	}
+}
@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
+
+func TestStorage_GetAccountByCPF(t *testing.T) {
+	s := NewStorage()
+	id := addAccount(t, s, "11111111030", money.FromCents(1000))
+
+	tt := []struct {
+		name    string
+		cpf     string
+		wantID  string
+		wantErr error
+	}{
+		{
+			name:   "When there's an account with the given cpf",
+			cpf:    "11111111030",
+			wantID: id,
+		},
+		{
+			name:    "When there's no account with the given cpf",
+			cpf:     "95360976055",
+			wantErr: ErrNoAccountWasFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			account, err := s.GetAccountByCPF(context.TODO(), tc.cpf)
+			if err != tc.wantErr {
+				t.Fatalf("GetAccountByCPF() err = %v, want %v", err, tc.wantErr)
+			}
+			if account.ID != tc.wantID {
+				t.Errorf("Expected account %s, got %s", tc.wantID, account.ID)
+			}
+		})
+	}
+}
+
+func TestStorage_GetAccounts(t *testing.T) {
+	s := NewStorage()
+	first := addAccount(t, s, "11111111030", money.FromCents(1000))
+	second := addAccount(t, s, "95360976055", money.FromCents(2000))
+
+	accounts, err := s.GetAccounts(context.TODO())
+	if err != nil {
+		t.Fatalf("GetAccounts() err = %v", err)
+	}
+	if len(accounts) != 2 || accounts[0].ID != first || accounts[1].ID != second {
+		t.Errorf("Expected accounts %s and %s in insertion order, got %v", first, second, accounts)
+	}
+}
+
+func TestStorage_GetTransfersByAccountID(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(2000))
+	third := addAccount(t, s, "52998224725", money.FromCents(0))
+	first := executeTransfer(t, s, origin, destination, money.FromCents(500))
+	failed, _ := transferring.NewService(s, limiting.NewService(s)).MakeTransfer(context.TODO(), transferring.Transfer{
+		OriginAccountID:      origin,
+		DestinationAccountID: destination,
+		Amount:               money.FromCents(100000),
+	})
+	cutoff := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+	received := executeTransfer(t, s, destination, origin, money.FromCents(50))
+	last := executeTransfer(t, s, origin, third, money.FromCents(200))
+
+	tt := []struct {
+		name    string
+		filter  listing.TransferFilter
+		sort    listing.Sort
+		after   *listing.Cursor
+		limit   int
+		wantIDs []string
+	}{
+		{name: "When retrieving every transfer, newest first", wantIDs: []string{last, received, failed, first}},
+		{name: "When retrieving sent transfers", filter: listing.TransferFilter{Direction: listing.Sent}, wantIDs: []string{last, failed, first}},
+		{name: "When retrieving received transfers", filter: listing.TransferFilter{Direction: listing.Received}, wantIDs: []string{received}},
+		{name: "When retrieving transfers with a counterparty", filter: listing.TransferFilter{CounterpartyID: destination}, wantIDs: []string{received, failed, first}},
+		{
+			name:    "When retrieving transfers sent to a counterparty",
+			filter:  listing.TransferFilter{Direction: listing.Sent, CounterpartyID: destination},
+			wantIDs: []string{failed, first},
+		},
+		{name: "When retrieving failed transfers", filter: listing.TransferFilter{Status: transferstatus.Failed}, wantIDs: []string{failed}},
+		{name: "When retrieving transfers created from a time", filter: listing.TransferFilter{CreatedFrom: cutoff}, wantIDs: []string{last, received}},
+		{name: "When retrieving transfers created until a time", filter: listing.TransferFilter{CreatedUntil: cutoff}, wantIDs: []string{failed, first}},
+		{
+			name:    "When retrieving transfers within an amount range",
+			filter:  listing.TransferFilter{MinAmount: money.FromCents(100), MaxAmount: money.FromCents(500)},
+			wantIDs: []string{last, first},
+		},
+		{name: "When sorting by the smallest amount", sort: listing.Sort{Field: listing.SortByAmount, Ascending: true}, wantIDs: []string{received, last, first, failed}},
+		{name: "When limiting the transfers", limit: 2, wantIDs: []string{last, received}},
+		{
+			name:    "When retrieving the transfers after a cursor",
+			sort:    listing.Sort{Field: listing.SortByAmount},
+			after:   &listing.Cursor{ID: last, Amount: money.FromCents(200)},
+			wantIDs: []string{received},
+		},
+		{name: "When account has no transfers", filter: listing.TransferFilter{Direction: listing.Received, CounterpartyID: third}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			query := listing.TransferQuery{Filter: tc.filter, Sort: tc.sort, After: tc.after, Limit: tc.limit}
+			if query.Sort.Field == "" {
+				query.Sort = listing.DefaultSort
+			}
+			if query.Limit == 0 {
+				query.Limit = listing.MaxPageSize
+			}
+			transfers, err := s.GetTransfersByAccountID(context.TODO(), origin, query)
+			if err != nil {
+				t.Fatalf("GetTransfersByAccountID() err = %v", err)
+			}
+			ids := make([]string, 0, len(transfers))
+			for _, transfer := range transfers {
+				ids = append(ids, transfer.ID)
+			}
+			if len(ids) != len(tc.wantIDs) || (len(ids) > 0 && !reflect.DeepEqual(ids, tc.wantIDs)) {
+				t.Errorf("Expected transfers %v, got %v", tc.wantIDs, ids)
+			}
+		})
+	}
+}
+
+func TestStorage_GetLedgerBalance(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(2000))
+	executeTransfer(t, s, origin, destination, money.FromCents(300))
+	cutoff := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+	executeTransfer(t, s, destination, origin, money.FromCents(50))
+
+	tt := []struct {
+		name        string
+		id          string
+		until       time.Time
+		wantBalance money.Money
+	}{
+		{
+			name:        "When rebuilding the current balance",
+			id:          origin,
+			until:       time.Now().UTC(),
+			wantBalance: money.FromCents(750),
+		},
+		{
+			name:        "When rebuilding a past balance",
+			id:          destination,
+			until:       cutoff,
+			wantBalance: money.FromCents(2300),
+		},
+		{
+			name:        "When account has no entries",
+			id:          "5f8f8ccb30a1cd7511c5cb70",
+			until:       time.Now().UTC(),
+			wantBalance: 0,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			balance, err := s.GetLedgerBalance(context.TODO(), tc.id, tc.until)
+			if err != nil {
+				t.Fatalf("GetLedgerBalance() err = %v", err)
+			}
+			if balance != tc.wantBalance {
+				t.Errorf("Expected balance %s, got %s", tc.wantBalance, balance)
+			}
+		})
+	}
+
+	account, _ := s.GetAccountByID(context.TODO(), origin)
+	balance, _ := s.GetLedgerBalance(context.TODO(), origin, time.Now().UTC())
+	if balance != account.Balance {
+		t.Errorf("Expected cached balance %s to match ledger balance %s", account.Balance, balance)
+	}
+}
+
+// sentTransfers returns the transfers sent by accountID in the order they were made
+func sentTransfers(s *Storage, accountID string, filter listing.TransferFilter) []listing.Transfer {
+	filter.Direction = listing.Sent
+	transfers, _ := s.GetTransfersByAccountID(context.TODO(), accountID, listing.TransferQuery{
+		Filter: filter,
+		Sort:   listing.Sort{Field: listing.SortByCreatedAt, Ascending: true},
+		Limit:  listing.MaxPageSize,
+	})
+	return transfers
+}
+
+func TestStorage_StreamEntriesByAccountIDBetween(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(2000))
+	from := time.Now().UTC()
+	sent := executeTransfer(t, s, origin, destination, money.FromCents(300))
+	received := executeTransfer(t, s, destination, origin, money.FromCents(50))
+	until := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+	executeTransfer(t, s, origin, destination, money.FromCents(10))
+
+	var entries []ledger.Entry
+	err := s.StreamEntriesByAccountIDBetween(context.TODO(), origin, from, until, func(e ledger.Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamEntriesByAccountIDBetween() err = %v", err)
+	}
+	if len(entries) != 2 || entries[0].TransferID != sent || entries[1].TransferID != received {
+		t.Errorf("Expected the entries of transfers %s and %s, oldest first, got %v", sent, received, entries)
+	}
+}
+
+func TestStorage_GetTransfersByIDs(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(2000))
+	first := executeTransfer(t, s, origin, destination, money.FromCents(300))
+	executeTransfer(t, s, origin, destination, money.FromCents(200))
+	last := executeTransfer(t, s, destination, origin, money.FromCents(50))
+
+	transfers, err := s.GetTransfersByIDs(context.TODO(), []string{first, last, "5f8f8ccb30a1cd7511c5cb70"})
+	if err != nil {
+		t.Fatalf("GetTransfersByIDs() err = %v", err)
+	}
+	if len(transfers) != 2 || transfers[0].ID != first || transfers[1].ID != last {
+		t.Errorf("Expected transfers %s and %s, got %v", first, last, transfers)
+	}
+}
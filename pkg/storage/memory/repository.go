@@ -0,0 +1,121 @@
+// Package memory implements every domain repository over in-process maps, being meant for local
+// development, demos and end-to-end tests that shouldn't depend on a MongoDB instance
+package memory
+
+import (
+	"sync"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Storage struct {
+	mu sync.RWMutex
+
+	accounts     []Account
+	accountsByID map[string]int
+	// accountsByCPF plays the role of the unique cpf index of the mongodb storage
+	accountsByCPF map[string]int
+	transfers     []Transfer
+	transfersByID map[string]int
+	entries       []ledger.Entry
+	tokens        map[primitive.ObjectID]authenticating.Token
+	// refreshTokens is keyed by the hash of each refresh token
+	refreshTokens map[string]authenticating.RefreshToken
+	// loginAttempts is keyed by the cpf, ip or pin key of the attempts
+	loginAttempts  map[string]authenticating.LoginAttempts
+	securityEvents []authenticating.SecurityEvent
+	twoFactors     map[string]authenticating.TwoFactor
+	// loginChallenges is keyed by the hash of each login challenge
+	loginChallenges map[string]authenticating.LoginChallenge
+	// passwordResets is keyed by the hash of each reset token
+	passwordResets map[string]authenticating.PasswordReset
+	// transactionPINs is keyed by account id
+	transactionPINs map[string]authenticating.TransactionPIN
+	apiClients      map[string]authenticating.APIClient
+	// idempotencyRecords is keyed by account id and idempotency key, as built by idempotencyRecordID
+	idempotencyRecords map[string]idempotency.Record
+	scheduledTransfers []scheduling.ScheduledTransfer
+	standingOrders     []scheduling.StandingOrder
+
+	log *logrus.Logger
+}
+
+var ErrCPFAlreadyExists = storage.ErrCPFAlreadyExists
+var ErrNoAccountWasFound = storage.ErrNoAccountWasFound
+var ErrNoTokenWasFound = storage.ErrNoTokenWasFound
+var ErrNoRefreshTokenWasFound = storage.ErrNoRefreshTokenWasFound
+var ErrNoLoginAttemptsWereFound = storage.ErrNoLoginAttemptsWereFound
+var ErrNoTwoFactorWasFound = storage.ErrNoTwoFactorWasFound
+var ErrNoLoginChallengeWasFound = storage.ErrNoLoginChallengeWasFound
+var ErrNoPasswordResetWasFound = storage.ErrNoPasswordResetWasFound
+var ErrNoTransactionPINWasFound = storage.ErrNoTransactionPINWasFound
+var ErrNoAPIClientWasFound = storage.ErrNoAPIClientWasFound
+var ErrNoIdempotencyRecordWasFound = storage.ErrNoIdempotencyRecordWasFound
+var ErrNoTransferWasFound = storage.ErrNoTransferWasFound
+var ErrNoScheduledTransferWasFound = storage.ErrNoScheduledTransferWasFound
+var ErrNoStandingOrderWasFound = storage.ErrNoStandingOrderWasFound
+
+func NewStorage() *Storage {
+	return &Storage{
+		accountsByID:       make(map[string]int),
+		accountsByCPF:      make(map[string]int),
+		transfersByID:      make(map[string]int),
+		tokens:             make(map[primitive.ObjectID]authenticating.Token),
+		refreshTokens:      make(map[string]authenticating.RefreshToken),
+		loginAttempts:      make(map[string]authenticating.LoginAttempts),
+		twoFactors:         make(map[string]authenticating.TwoFactor),
+		loginChallenges:    make(map[string]authenticating.LoginChallenge),
+		passwordResets:     make(map[string]authenticating.PasswordReset),
+		transactionPINs:    make(map[string]authenticating.TransactionPIN),
+		apiClients:         make(map[string]authenticating.APIClient),
+		idempotencyRecords: make(map[string]idempotency.Record),
+		log:                lgr.NewDefaultLogger(),
+	}
+}
+
+// accountByID must be called with the lock held
+func (s *Storage) accountByID(id string) (*Account, error) {
+	i, ok := s.accountsByID[id]
+	if !ok {
+		s.log.Errorf("No account was found with id %s", id)
+		return nil, ErrNoAccountWasFound
+	}
+	return &s.accounts[i], nil
+}
+
+// transferByID must be called with the lock held
+func (s *Storage) transferByID(id string) (*Transfer, error) {
+	i, ok := s.transfersByID[id]
+	if !ok {
+		s.log.Errorf("No transfer was found with id %s", id)
+		return nil, ErrNoTransferWasFound
+	}
+	return &s.transfers[i], nil
+}
+
+// addTransfer must be called with the lock held
+func (s *Storage) addTransfer(transfer Transfer) {
+	s.transfers = append(s.transfers, transfer)
+	s.transfersByID[transfer.ID] = len(s.transfers) - 1
+}
+
+// addEntries must be called with the lock held and before any other write of the operation,
+// so unbalanced entries leave the storage untouched
+func (s *Storage) addEntries(entries []ledger.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := ledger.Validate(entries); err != nil {
+		s.log.Errorf("Err %v when adding ledger entries %v", err, entries)
+		return err
+	}
+	s.entries = append(s.entries, entries...)
+	return nil
+}
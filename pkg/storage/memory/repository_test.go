@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/adding"
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/pedroyremolo/transfer-api/pkg/updating"
+)
+
+var (
+	_ adding.Repository         = (*Storage)(nil)
+	_ listing.Repository        = (*Storage)(nil)
+	_ updating.Repository       = (*Storage)(nil)
+	_ authenticating.Repository = (*Storage)(nil)
+	_ idempotency.Repository    = (*Storage)(nil)
+	_ limiting.Repository       = (*Storage)(nil)
+	_ scheduling.Repository     = (*Storage)(nil)
+	_ transferring.Repository   = (*Storage)(nil)
+)
+
+func addAccount(t *testing.T, s *Storage, cpf string, balance money.Money) string {
+	t.Helper()
+	var account adding.Account
+	accountJSON := fmt.Sprintf(`{"name":"Gopher","cpf":"%s","balance":%s}`, cpf, balance)
+	if err := json.Unmarshal([]byte(accountJSON), &account); err != nil {
+		t.Fatalf("Could not unmarshal account %s: %v", accountJSON, err)
+	}
+	id, err := s.AddAccount(context.TODO(), account)
+	if err != nil {
+		t.Fatalf("Could not add account of cpf %s: %v", cpf, err)
+	}
+	return id
+}
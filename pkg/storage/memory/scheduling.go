@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Storage) AddScheduledTransfer(_ context.Context, transfer scheduling.ScheduledTransfer) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding scheduled transfer %v to memory repo", transfer)
+	if transfer.StandingOrderID != "" {
+		for _, t := range s.scheduledTransfers {
+			if t.StandingOrderID == transfer.StandingOrderID && t.ScheduledFor.Equal(transfer.ScheduledFor) {
+				s.log.Errorf("Occurrence of standing order %s at %s was already scheduled as %s", t.StandingOrderID, t.ScheduledFor, t.ID)
+				return "", scheduling.ErrOccurrenceAlreadyScheduled
+			}
+		}
+	}
+	transfer.ID = primitive.NewObjectID().Hex()
+	s.scheduledTransfers = append(s.scheduledTransfers, transfer)
+	return transfer.ID, nil
+}
+
+func (s *Storage) GetScheduledTransfersByAccountID(_ context.Context, accountID string) ([]scheduling.ScheduledTransfer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving scheduled transfers of account %s of memory repo", accountID)
+	transfers := make([]scheduling.ScheduledTransfer, 0)
+	for _, t := range s.scheduledTransfers {
+		if t.OriginAccountID == accountID {
+			transfers = append(transfers, t)
+		}
+	}
+	return transfers, nil
+}
+
+func (s *Storage) CancelScheduledTransfer(_ context.Context, accountID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Cancelling scheduled transfer %s of account %s of memory repo", id, accountID)
+	for i := range s.scheduledTransfers {
+		t := &s.scheduledTransfers[i]
+		if t.ID != id || t.OriginAccountID != accountID {
+			continue
+		}
+		if t.Status != scheduling.Scheduled {
+			s.log.Errorf("Scheduled transfer %s can't be cancelled from status %s", id, t.Status)
+			return scheduling.ErrNotCancellable
+		}
+		t.Status = scheduling.Cancelled
+		return nil
+	}
+	s.log.Errorf("No scheduled transfer was found with id %s", id)
+	return ErrNoScheduledTransferWasFound
+}
+
+func (s *Storage) ClaimDueScheduledTransfer(_ context.Context, now time.Time, lockedUntil time.Time) (scheduling.ScheduledTransfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due *scheduling.ScheduledTransfer
+	for i := range s.scheduledTransfers {
+		t := &s.scheduledTransfers[i]
+		if t.Status != scheduling.Scheduled || t.NextAttemptAt.After(now) {
+			continue
+		}
+		if due == nil || t.NextAttemptAt.Before(due.NextAttemptAt) {
+			due = t
+		}
+	}
+	if due == nil {
+		return scheduling.ScheduledTransfer{}, scheduling.ErrNoScheduledTransferDue
+	}
+
+	s.log.Infof("Claiming scheduled transfer %s of memory repo until %s", due.ID, lockedUntil)
+	due.Status = scheduling.Processing
+	due.Attempts++
+	due.LockedUntil = lockedUntil
+	return *due, nil
+}
+
+func (s *Storage) FinishScheduledTransfer(_ context.Context, transfer scheduling.ScheduledTransfer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Finishing scheduled transfer %s of memory repo as %s", transfer.ID, transfer.Status)
+	for i := range s.scheduledTransfers {
+		t := &s.scheduledTransfers[i]
+		if t.ID != transfer.ID {
+			continue
+		}
+		if t.Status != scheduling.Processing {
+			s.log.Errorf("Scheduled transfer %s is no longer processing, it is %s", t.ID, t.Status)
+			return scheduling.ErrNotProcessing
+		}
+		t.Status = transfer.Status
+		t.LastError = transfer.LastError
+		t.TransferID = transfer.TransferID
+		t.NextAttemptAt = transfer.NextAttemptAt
+		t.LockedUntil = time.Time{}
+		return nil
+	}
+	s.log.Errorf("No scheduled transfer was found with id %s", transfer.ID)
+	return ErrNoScheduledTransferWasFound
+}
+
+func (s *Storage) FailExpiredScheduledTransfers(_ context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := 0
+	for i := range s.scheduledTransfers {
+		t := &s.scheduledTransfers[i]
+		if t.Status == scheduling.Processing && t.LockedUntil.Before(now) {
+			s.log.Errorf("Claim of scheduled transfer %s expired at %s", t.ID, t.LockedUntil)
+			t.Status = scheduling.Failed
+			t.LastError = scheduling.ErrInterrupted.Error()
+			t.LockedUntil = time.Time{}
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+func (s *Storage) AddStandingOrder(_ context.Context, order scheduling.StandingOrder) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding standing order %v to memory repo", order)
+	order.ID = primitive.NewObjectID().Hex()
+	s.standingOrders = append(s.standingOrders, order)
+	return order.ID, nil
+}
+
+func (s *Storage) GetStandingOrdersByAccountID(_ context.Context, accountID string) ([]scheduling.StandingOrder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.log.Infof("Retrieving standing orders of account %s of memory repo", accountID)
+	orders := make([]scheduling.StandingOrder, 0)
+	for _, o := range s.standingOrders {
+		if o.OriginAccountID == accountID {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+func (s *Storage) GetDueStandingOrders(_ context.Context, now time.Time) ([]scheduling.StandingOrder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]scheduling.StandingOrder, 0)
+	for _, o := range s.standingOrders {
+		if o.Status == scheduling.OrderActive && o.NextRunAt != nil && !o.NextRunAt.After(now) {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+func (s *Storage) UpdateStandingOrder(_ context.Context, accountID string, id string, updateFn scheduling.StandingOrderFunc) (scheduling.StandingOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Updating standing order %s of account %s of memory repo", id, accountID)
+	for i := range s.standingOrders {
+		o := &s.standingOrders[i]
+		if o.ID != id || o.OriginAccountID != accountID {
+			continue
+		}
+		updated, err := updateFn(*o)
+		if err != nil {
+			s.log.Errorf("Standing order %s was not updated due to err %v", id, err)
+			return *o, err
+		}
+		*o = updated
+		return updated, nil
+	}
+	s.log.Errorf("No standing order was found with id %s", id)
+	return scheduling.StandingOrder{}, ErrNoStandingOrderWasFound
+}
@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+)
+
+func addScheduledTransfer(t *testing.T, s *Storage, origin string, destination string, at time.Time) string {
+	t.Helper()
+	id, err := s.AddScheduledTransfer(context.TODO(), scheduling.ScheduledTransfer{
+		OriginAccountID:      origin,
+		DestinationAccountID: destination,
+		Amount:               money.FromCents(100),
+		ScheduledFor:         at,
+		Status:               scheduling.Scheduled,
+		NextAttemptAt:        at,
+	})
+	if err != nil {
+		t.Fatalf("Could not schedule transfer from %s to %s: %v", origin, destination, err)
+	}
+	return id
+}
+
+func TestStorage_CancelScheduledTransfer(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	id := addScheduledTransfer(t, s, origin, destination, time.Now().Add(time.Hour))
+
+	tt := []struct {
+		name      string
+		accountID string
+		wantErr   error
+	}{
+		{name: "When another account cancels the transfer", accountID: destination, wantErr: ErrNoScheduledTransferWasFound},
+		{name: "When origin account cancels the transfer", accountID: origin},
+		{name: "When the transfer was already cancelled", accountID: origin, wantErr: scheduling.ErrNotCancellable},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.CancelScheduledTransfer(context.TODO(), tc.accountID, id); err != tc.wantErr {
+				t.Errorf("CancelScheduledTransfer() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	if _, err := s.ClaimDueScheduledTransfer(context.TODO(), time.Now().Add(time.Hour*2), time.Now()); err != scheduling.ErrNoScheduledTransferDue {
+		t.Errorf("Expected cancelled transfer not to be claimed, got err %v", err)
+	}
+}
+
+func TestStorage_RunDueScheduledTransfers(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	due := addScheduledTransfer(t, s, origin, destination, time.Now().Add(-time.Minute))
+	notDue := addScheduledTransfer(t, s, origin, destination, time.Now().Add(time.Hour))
+	scheduler := scheduling.NewService(s, transferring.NewService(s, limiting.NewService(s)))
+
+	// executors of several replicas race for the same due transfer
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run, err := scheduler.RunDue(context.TODO())
+			if err != nil {
+				t.Errorf("RunDue() err = %v", err)
+			}
+			mu.Lock()
+			total += run
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if total != 1 {
+		t.Errorf("Expected the due transfer to be run once, got %d runs", total)
+	}
+	originAccount, _ := s.GetAccountByID(context.TODO(), origin)
+	if originAccount.Balance != money.FromCents(900) {
+		t.Errorf("Expected origin balance 9.00, got %s", originAccount.Balance)
+	}
+	transfers, _ := s.GetScheduledTransfersByAccountID(context.TODO(), origin)
+	for _, transfer := range transfers {
+		switch transfer.ID {
+		case due:
+			if transfer.Status != scheduling.Executed || transfer.Attempts != 1 || transfer.TransferID == "" {
+				t.Errorf("Expected due transfer executed at its first attempt, got %v", transfer)
+			}
+		case notDue:
+			if transfer.Status != scheduling.Scheduled || transfer.Attempts != 0 {
+				t.Errorf("Expected transfer not due to be kept scheduled, got %v", transfer)
+			}
+		}
+	}
+}
+
+func TestStorage_FailExpiredScheduledTransfers(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	id := addScheduledTransfer(t, s, origin, destination, time.Now().Add(-time.Minute))
+
+	claimed, err := s.ClaimDueScheduledTransfer(context.TODO(), time.Now(), time.Now().Add(-time.Second))
+	if err != nil || claimed.ID != id || claimed.Status != scheduling.Processing {
+		t.Fatalf("ClaimDueScheduledTransfer() = %v, %v; want transfer %s processing", claimed, err, id)
+	}
+	failed, err := s.FailExpiredScheduledTransfers(context.TODO(), time.Now())
+	if err != nil || failed != 1 {
+		t.Fatalf("FailExpiredScheduledTransfers() = %d, %v; want 1 failed", failed, err)
+	}
+	claimed.Status = scheduling.Executed
+	if err = s.FinishScheduledTransfer(context.TODO(), claimed); err != scheduling.ErrNotProcessing {
+		t.Errorf("Expected err %v finishing an interrupted transfer, got %v", scheduling.ErrNotProcessing, err)
+	}
+}
+
+func TestStorage_RunDueStandingOrders(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	nextRunAt := time.Now().UTC().Add(-time.Minute)
+	id, err := s.AddStandingOrder(context.TODO(), scheduling.StandingOrder{
+		OriginAccountID:      origin,
+		DestinationAccountID: destination,
+		Amount:               money.FromCents(100),
+		Rule:                 scheduling.Rule{Frequency: scheduling.Cron, Expression: "* * * * *"},
+		StartsAt:             nextRunAt,
+		MaxOccurrences:       1,
+		NextRunAt:            &nextRunAt,
+		Status:               scheduling.OrderActive,
+	})
+	if err != nil {
+		t.Fatalf("Could not add standing order: %v", err)
+	}
+	scheduler := scheduling.NewService(s, transferring.NewService(s, limiting.NewService(s)))
+
+	// executors of several replicas race for the same due occurrence
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := scheduler.RunDue(context.TODO()); err != nil {
+				t.Errorf("RunDue() err = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	occurrences, _ := s.GetScheduledTransfersByAccountID(context.TODO(), origin)
+	if len(occurrences) != 1 || occurrences[0].StandingOrderID != id || occurrences[0].Status != scheduling.Executed {
+		t.Errorf("Expected a single executed occurrence of standing order %s, got %v", id, occurrences)
+	}
+	transfers := sentTransfers(s, origin, listing.TransferFilter{StandingOrderID: id})
+	if len(transfers) != 1 || transfers[0].StandingOrderID != id {
+		t.Errorf("Expected a single transfer of standing order %s, got %v", id, transfers)
+	}
+	orders, _ := s.GetStandingOrdersByAccountID(context.TODO(), origin)
+	if len(orders) != 1 || orders[0].Status != scheduling.OrderFinished || orders[0].Occurrences != 1 || orders[0].NextRunAt != nil {
+		t.Errorf("Expected standing order finished after its only occurrence, got %v", orders)
+	}
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
+
+type Transfer struct {
+	ID                   string
+	OriginAccountID      string
+	DestinationAccountID string
+	Amount               money.Money
+	Status               transferstatus.Status
+	FailureReason        transferstatus.Reason
+	ReversedAmount       money.Money
+	ReversalOf           string
+	StandingOrderID      string
+	CreatedAt            time.Time
+	CompletedAt          *time.Time
+	FailedAt             *time.Time
+	ReversedAt           *time.Time
+}
+
+// transition must be called with the lock held, moving t to status next if its current one allows it
+func (t *Transfer) transition(next transferstatus.Status, at time.Time) error {
+	if !t.Status.CanTransitionTo(next) {
+		return transferstatus.ErrInvalidTransition
+	}
+	t.Status = next
+	switch next {
+	case transferstatus.Completed:
+		t.CompletedAt = &at
+	case transferstatus.Failed:
+		t.FailedAt = &at
+	case transferstatus.Reversed:
+		t.ReversedAt = &at
+	}
+	return nil
+}
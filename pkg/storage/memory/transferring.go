@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Storage) AddPendingTransfer(_ context.Context, transfer transferring.Transfer) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Adding pending transfer %v to memory repo", transfer)
+	memTransfer := Transfer{
+		ID:                   primitive.NewObjectID().Hex(),
+		OriginAccountID:      transfer.OriginAccountID,
+		DestinationAccountID: transfer.DestinationAccountID,
+		Amount:               transfer.Amount,
+		Status:               transferstatus.Pending,
+		StandingOrderID:      transfer.StandingOrderID,
+		CreatedAt:            transfer.CreatedAt,
+	}
+	s.addTransfer(memTransfer)
+	return memTransfer.ID, nil
+}
+
+func (s *Storage) ExecuteTransfer(_ context.Context, id string, transfer transferring.Transfer, balanceFn transferring.BalanceFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Executing transfer %s over memory repo", id)
+	memTransfer, err := s.transferByID(id)
+	if err != nil {
+		return err
+	}
+	if !memTransfer.Status.CanTransitionTo(transferstatus.Completed) {
+		s.log.Errorf("Transfer %s can't be completed from status %s", id, memTransfer.Status)
+		return transferstatus.ErrInvalidTransition
+	}
+	origin, err := s.accountByID(transfer.OriginAccountID)
+	if err != nil {
+		return err
+	}
+	destination, err := s.accountByID(transfer.DestinationAccountID)
+	if err != nil {
+		return err
+	}
+
+	newOriginBalance, newDestinationBalance, err := balanceFn(origin.Balance, destination.Balance)
+	if err != nil {
+		s.log.Errorf("Transfer %s was not committed due to err %v", id, err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	entries := ledger.TransferEntries(id, transfer.OriginAccountID, transfer.DestinationAccountID, transfer.Amount, now)
+	if err = s.addEntries(entries); err != nil {
+		return err
+	}
+	origin.Balance = newOriginBalance
+	destination.Balance = newDestinationBalance
+	return memTransfer.transition(transferstatus.Completed, now)
+}
+
+func (s *Storage) FailTransfer(_ context.Context, id string, reason transferstatus.Reason) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Failing transfer %s of memory repo due to %s", id, reason)
+	memTransfer, err := s.transferByID(id)
+	if err != nil {
+		return err
+	}
+	if err = memTransfer.transition(transferstatus.Failed, time.Now().UTC()); err != nil {
+		s.log.Errorf("Transfer %s can't fail from status %s", id, memTransfer.Status)
+		return err
+	}
+	memTransfer.FailureReason = reason
+	return nil
+}
+
+func (s *Storage) ExecuteReversal(_ context.Context, id string, reversalFn transferring.ReversalFunc) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Executing reversal of transfer %s over memory repo", id)
+	original, err := s.transferByID(id)
+	if err != nil {
+		return "", err
+	}
+	compensating, balanceFn, err := reversalFn(transferring.StoredTransfer{
+		Transfer: transferring.Transfer{
+			OriginAccountID:      original.OriginAccountID,
+			DestinationAccountID: original.DestinationAccountID,
+			Amount:               original.Amount,
+			CreatedAt:            original.CreatedAt,
+		},
+		ID:             original.ID,
+		Status:         original.Status,
+		ReversedAmount: original.ReversedAmount,
+		ReversalOf:     original.ReversalOf,
+	})
+	if err != nil {
+		s.log.Errorf("Reversal of transfer %s was refused due to err %v", id, err)
+		return "", err
+	}
+
+	origin, err := s.accountByID(compensating.OriginAccountID)
+	if err != nil {
+		return "", err
+	}
+	destination, err := s.accountByID(compensating.DestinationAccountID)
+	if err != nil {
+		return "", err
+	}
+	newOriginBalance, newDestinationBalance, err := balanceFn(origin.Balance, destination.Balance)
+	if err != nil {
+		s.log.Errorf("Reversal of transfer %s was not committed due to err %v", id, err)
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	reversedAmount := original.ReversedAmount + compensating.Amount
+	if reversedAmount == original.Amount && !original.Status.CanTransitionTo(transferstatus.Reversed) {
+		return "", transferstatus.ErrInvalidTransition
+	}
+	memReversal := Transfer{
+		ID:                   primitive.NewObjectID().Hex(),
+		OriginAccountID:      compensating.OriginAccountID,
+		DestinationAccountID: compensating.DestinationAccountID,
+		Amount:               compensating.Amount,
+		Status:               transferstatus.Completed,
+		ReversalOf:           original.ID,
+		CreatedAt:            compensating.CreatedAt,
+		CompletedAt:          &now,
+	}
+	entries := ledger.TransferEntries(memReversal.ID, memReversal.OriginAccountID, memReversal.DestinationAccountID, memReversal.Amount, now)
+	if err = s.addEntries(entries); err != nil {
+		return "", err
+	}
+
+	origin.Balance = newOriginBalance
+	destination.Balance = newDestinationBalance
+	original.ReversedAmount = reversedAmount
+	if reversedAmount == original.Amount {
+		_ = original.transition(transferstatus.Reversed, now)
+	}
+	// original points into s.transfers, so it is only safe to use before the compensating transfer is appended
+	s.addTransfer(memReversal)
+	return memReversal.ID, nil
+}
@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
+
+func executeTransfer(t *testing.T, s *Storage, origin string, destination string, amount money.Money) string {
+	t.Helper()
+	id, err := transferring.NewService(s, limiting.NewService(s)).MakeTransfer(context.TODO(), transferring.Transfer{
+		OriginAccountID:      origin,
+		DestinationAccountID: destination,
+		Amount:               amount,
+	})
+	if err != nil {
+		t.Fatalf("Could not transfer %s from %s to %s: %v", amount, origin, destination, err)
+	}
+	return id
+}
+
+func TestStorage_ExecuteTransfer(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(2000))
+
+	tt := []struct {
+		name                   string
+		destination            string
+		balanceErr             error
+		wantErr                error
+		wantOriginBalance      money.Money
+		wantDestinationBalance money.Money
+		wantTransfers          int
+	}{
+		{
+			name:                   "When transfer is committed",
+			destination:            destination,
+			wantOriginBalance:      money.FromCents(700),
+			wantDestinationBalance: money.FromCents(2300),
+			wantTransfers:          1,
+		},
+		{
+			name:                   "When balance calculation fails nothing is committed",
+			destination:            destination,
+			balanceErr:             transferring.ErrNotEnoughBalance,
+			wantErr:                transferring.ErrNotEnoughBalance,
+			wantOriginBalance:      money.FromCents(700),
+			wantDestinationBalance: money.FromCents(2300),
+			wantTransfers:          1,
+		},
+		{
+			name:                   "When destination doesn't exist nothing is committed",
+			destination:            "5f8f8ccb30a1cd7511c5cb70",
+			wantErr:                ErrNoAccountWasFound,
+			wantOriginBalance:      money.FromCents(700),
+			wantDestinationBalance: money.FromCents(2300),
+			wantTransfers:          1,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			transfer := transferring.Transfer{OriginAccountID: origin, DestinationAccountID: tc.destination, Amount: money.FromCents(300)}
+			id, err := s.AddPendingTransfer(context.TODO(), transfer)
+			if err != nil {
+				t.Fatalf("AddPendingTransfer() err = %v", err)
+			}
+			err = s.ExecuteTransfer(context.TODO(), id, transfer, func(o money.Money, d money.Money) (money.Money, money.Money, error) {
+				return o - transfer.Amount, d + transfer.Amount, tc.balanceErr
+			})
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("ExecuteTransfer() err = %v, want %v", err, tc.wantErr)
+			}
+
+			originAccount, _ := s.GetAccountByID(context.TODO(), origin)
+			destinationAccount, _ := s.GetAccountByID(context.TODO(), destination)
+			if originAccount.Balance != tc.wantOriginBalance || destinationAccount.Balance != tc.wantDestinationBalance {
+				t.Errorf(
+					"Expected balances %s and %s, got %s and %s",
+					tc.wantOriginBalance, tc.wantDestinationBalance, originAccount.Balance, destinationAccount.Balance,
+				)
+			}
+			completed := sentTransfers(s, origin, listing.TransferFilter{Status: transferstatus.Completed})
+			if len(completed) != tc.wantTransfers {
+				t.Errorf("Expected %d completed transfers, got %d", tc.wantTransfers, len(completed))
+			}
+		})
+	}
+}
+
+func TestStorage_TransferTransitions(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(2000))
+	transfer := transferring.Transfer{OriginAccountID: origin, DestinationAccountID: destination, Amount: money.FromCents(300)}
+	balanceFn := func(o money.Money, d money.Money) (money.Money, money.Money, error) {
+		return o - transfer.Amount, d + transfer.Amount, nil
+	}
+
+	failed, _ := s.AddPendingTransfer(context.TODO(), transfer)
+	if err := s.FailTransfer(context.TODO(), failed, transferstatus.ReasonNotEnoughBalance); err != nil {
+		t.Fatalf("FailTransfer() err = %v", err)
+	}
+	if err := s.ExecuteTransfer(context.TODO(), failed, transfer, balanceFn); err != transferstatus.ErrInvalidTransition {
+		t.Errorf("Expected err %v when executing a failed transfer, got %v", transferstatus.ErrInvalidTransition, err)
+	}
+
+	completed, _ := s.AddPendingTransfer(context.TODO(), transfer)
+	if err := s.ExecuteTransfer(context.TODO(), completed, transfer, balanceFn); err != nil {
+		t.Fatalf("ExecuteTransfer() err = %v", err)
+	}
+	if err := s.FailTransfer(context.TODO(), completed, transferstatus.ReasonStorageError); err != transferstatus.ErrInvalidTransition {
+		t.Errorf("Expected err %v when failing a completed transfer, got %v", transferstatus.ErrInvalidTransition, err)
+	}
+	if err := s.FailTransfer(context.TODO(), "5f8f8ccb30a1cd7511c5cb70", transferstatus.ReasonStorageError); err != ErrNoTransferWasFound {
+		t.Errorf("Expected err %v when failing an unknown transfer, got %v", ErrNoTransferWasFound, err)
+	}
+
+	sent := sentTransfers(s, origin, listing.TransferFilter{})
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 transfers, got %v", sent)
+	}
+	if sent[0].Status != transferstatus.Failed || sent[0].FailureReason != transferstatus.ReasonNotEnoughBalance || sent[0].FailedAt == nil {
+		t.Errorf("Expected first transfer to have failed due to not enough balance, got %v", sent[0])
+	}
+	if sent[1].Status != transferstatus.Completed || sent[1].CompletedAt == nil {
+		t.Errorf("Expected second transfer to be completed, got %v", sent[1])
+	}
+}
+
+func TestStorage_ExecuteReversal(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	id := executeTransfer(t, s, origin, destination, money.FromCents(600))
+	transferor := transferring.NewService(s, limiting.NewService(s))
+
+	tt := []struct {
+		name                   string
+		amount                 money.Money
+		wantErr                error
+		wantOriginBalance      money.Money
+		wantDestinationBalance money.Money
+		wantStatus             transferstatus.Status
+		wantReversedAmount     money.Money
+	}{
+		{
+			name:                   "When part of the transfer is reversed",
+			amount:                 money.FromCents(200),
+			wantOriginBalance:      money.FromCents(600),
+			wantDestinationBalance: money.FromCents(400),
+			wantStatus:             transferstatus.Completed,
+			wantReversedAmount:     money.FromCents(200),
+		},
+		{
+			name:                   "When more than what is left is reversed nothing is committed",
+			amount:                 money.FromCents(401),
+			wantErr:                transferring.ErrReversalExceedsAmount,
+			wantOriginBalance:      money.FromCents(600),
+			wantDestinationBalance: money.FromCents(400),
+			wantStatus:             transferstatus.Completed,
+			wantReversedAmount:     money.FromCents(200),
+		},
+		{
+			name:                   "When what is left of the transfer is reversed",
+			wantOriginBalance:      money.FromCents(1000),
+			wantDestinationBalance: 0,
+			wantStatus:             transferstatus.Reversed,
+			wantReversedAmount:     money.FromCents(600),
+		},
+		{
+			name:                   "When the transfer was already reversed",
+			wantErr:                transferring.ErrNotReversible,
+			wantOriginBalance:      money.FromCents(1000),
+			wantDestinationBalance: 0,
+			wantStatus:             transferstatus.Reversed,
+			wantReversedAmount:     money.FromCents(600),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := transferor.ReverseTransfer(context.TODO(), transferring.Reversal{
+				TransferID:  id,
+				RequesterID: destination,
+				Amount:      tc.amount,
+			})
+			if err != tc.wantErr {
+				t.Fatalf("ReverseTransfer() err = %v, want %v", err, tc.wantErr)
+			}
+
+			originAccount, _ := s.GetAccountByID(context.TODO(), origin)
+			destinationAccount, _ := s.GetAccountByID(context.TODO(), destination)
+			if originAccount.Balance != tc.wantOriginBalance || destinationAccount.Balance != tc.wantDestinationBalance {
+				t.Errorf(
+					"Expected balances %s and %s, got %s and %s",
+					tc.wantOriginBalance, tc.wantDestinationBalance, originAccount.Balance, destinationAccount.Balance,
+				)
+			}
+			sent := sentTransfers(s, origin, listing.TransferFilter{})
+			if sent[0].Status != tc.wantStatus || sent[0].ReversedAmount != tc.wantReversedAmount {
+				t.Errorf("Expected transfer %s with %s reversed, got %v", tc.wantStatus, tc.wantReversedAmount, sent[0])
+			}
+		})
+	}
+
+	reversals := sentTransfers(s, destination, listing.TransferFilter{})
+	if len(reversals) != 2 || reversals[0].ReversalOf != id || reversals[1].ReversalOf != id {
+		t.Errorf("Expected 2 reversals of transfer %s, got %v", id, reversals)
+	}
+}
+
+func TestStorage_ExecuteTransfer_Concurrently(t *testing.T) {
+	s := NewStorage()
+	origin := addAccount(t, s, "11111111030", money.FromCents(1000))
+	destination := addAccount(t, s, "95360976055", money.FromCents(0))
+	transferor := transferring.NewService(s, limiting.NewService(s))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = transferor.MakeTransfer(context.TODO(), transferring.Transfer{
+				OriginAccountID:      origin,
+				DestinationAccountID: destination,
+				Amount:               money.FromCents(100),
+			})
+		}()
+	}
+	wg.Wait()
+
+	originAccount, _ := s.GetAccountByID(context.TODO(), origin)
+	destinationAccount, _ := s.GetAccountByID(context.TODO(), destination)
+	if originAccount.Balance != 0 || destinationAccount.Balance != money.FromCents(1000) {
+		t.Errorf("Expected balances 0.00 and 10.00, got %s and %s", originAccount.Balance, destinationAccount.Balance)
+	}
+}
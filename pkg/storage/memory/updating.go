@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/updating"
+)
+
+func (s *Storage) UpdateAccounts(_ context.Context, accounts []updating.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log.Infof("Updating %v accounts of memory repo", len(accounts))
+	// every account is looked up before any write, so the update is applied to all or none of them
+	targets := make([]*Account, 0, len(accounts))
+	for _, account := range accounts {
+		target, err := s.accountByID(account.ID)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+	// balances are set through adjustment entries so they can still be rebuilt from the ledger
+	var entries []ledger.Entry
+	now := time.Now().UTC()
+	for i, target := range targets {
+		entries = append(entries, ledger.AdjustmentEntries(target.ID, target.Balance, accounts[i].Balance, now)...)
+	}
+	if err := s.addEntries(entries); err != nil {
+		return err
+	}
+	for i, target := range targets {
+		target.Balance = accounts[i].Balance
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/updating"
+)
+
+func TestStorage_UpdateAccounts(t *testing.T) {
+	s := NewStorage()
+	first := addAccount(t, s, "11111111030", money.FromCents(1000))
+	second := addAccount(t, s, "95360976055", money.FromCents(2000))
+
+	tt := []struct {
+		name         string
+		accounts     []updating.Account
+		wantErr      error
+		wantBalances []money.Money
+	}{
+		{
+			name:         "When every account exists",
+			accounts:     []updating.Account{{ID: first, Balance: money.FromCents(500)}, {ID: second, Balance: money.FromCents(2500)}},
+			wantBalances: []money.Money{money.FromCents(500), money.FromCents(2500)},
+		},
+		{
+			name:         "When one of the accounts doesn't exist nothing is updated",
+			accounts:     []updating.Account{{ID: first, Balance: money.FromCents(100)}, {ID: "5f8f8ccb30a1cd7511c5cb70", Balance: money.FromCents(100)}},
+			wantErr:      ErrNoAccountWasFound,
+			wantBalances: []money.Money{money.FromCents(500), money.FromCents(2500)},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.UpdateAccounts(context.TODO(), tc.accounts); err != tc.wantErr {
+				t.Fatalf("UpdateAccounts() err = %v, want %v", err, tc.wantErr)
+			}
+			for i, id := range []string{first, second} {
+				account, _ := s.GetAccountByID(context.TODO(), id)
+				if account.Balance != tc.wantBalances[i] {
+					t.Errorf("Expected account %s balance %s, got %s", id, tc.wantBalances[i], account.Balance)
+				}
+			}
+		})
+	}
+}
@@ -7,10 +7,21 @@ import (
 )
 
 type Account struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	Name      string             `bson:"name"`
-	CPF       string             `bson:"cpf"`
-	Secret    string             `bson:"secret"`
-	Balance   float64            `bson:"balance"`
-	CreatedAt time.Time          `bson:"created_at"`
+	ID        primitive.ObjectID   `bson:"_id"`
+	Name      string               `bson:"name"`
+	CPF       string               `bson:"cpf"`
+	Secret    string               `bson:"secret"`
+	Role      string               `bson:"role,omitempty"`
+	Balance   primitive.Decimal128 `bson:"balance"`
+	Tier      string               `bson:"tier,omitempty"`
+	Limits    *Limits              `bson:"limits,omitempty"`
+	CreatedAt time.Time            `bson:"created_at"`
+}
+
+// Limits are the limits an account lowered, missing from accounts that use the ones of their tier
+type Limits struct {
+	PerTransaction primitive.Decimal128 `bson:"per_transaction"`
+	Daily          primitive.Decimal128 `bson:"daily"`
+	Monthly        primitive.Decimal128 `bson:"monthly"`
+	NightTime      primitive.Decimal128 `bson:"night_time"`
 }
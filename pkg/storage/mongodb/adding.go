@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg/adding"
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (s *Storage) AddAccount(ctx context.Context, account adding.Account) (string, error) {
@@ -19,38 +22,25 @@ func (s *Storage) AddAccount(ctx context.Context, account adding.Account) (strin
 		Name:      string(account.Name),
 		CPF:       string(account.CPF),
 		Secret:    string(account.Secret),
-		Balance:   float64(account.Balance),
+		Balance:   decimalFromMoney(money.Money(account.Balance)),
 		CreatedAt: account.CreatedAt,
 	}
 
-	oid, err := collection.InsertOne(insertionCtx, dbAccount)
+	_, err := s.withTransaction(insertionCtx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if _, insertErr := collection.InsertOne(sessCtx, dbAccount); insertErr != nil {
+			if mongo.IsDuplicateKeyError(insertErr) {
+				s.log.Errorf("CPF %s already exists in our repo", dbAccount.CPF)
+				return nil, ErrCPFAlreadyExists
+			}
+			// the original err keeps the labels that let transient ones be retried by the transaction
+			s.log.Errorf("Unexpected err %v when adding account to mongodb repo coll %s", insertErr, collection.Name())
+			return nil, insertErr
+		}
+		entries := ledger.OpeningEntries(dbAccount.ID.Hex(), money.Money(account.Balance), account.CreatedAt)
+		return nil, s.insertEntries(sessCtx, entries)
+	})
 	if err != nil {
-		s.log.Errorf("CPF %s already exists in our repo", dbAccount.CPF)
-		return "", ErrCPFAlreadyExists
-	}
-	return oid.InsertedID.(primitive.ObjectID).Hex(), err
-}
-
-func (s *Storage) AddTransfer(ctx context.Context, transfer adding.Transfer) (string, error) {
-	collection := s.client.Database(databaseName).Collection(transfersCollection)
-	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
-	defer cancel()
-
-	s.log.Infof("Adding transfer %v to mongodb repo coll %s", transfer, collection.Name())
-	originOID, _ := primitive.ObjectIDFromHex(transfer.OriginAccountID)
-	transferOID, _ := primitive.ObjectIDFromHex(transfer.DestinationAccountID)
-	dbTransfer := Transfer{
-		ID:                   primitive.NewObjectID(),
-		OriginAccountID:      originOID,
-		DestinationAccountID: transferOID,
-		Amount:               transfer.Amount,
-		CreatedAt:            transfer.CreatedAt,
-	}
-
-	oid, err := collection.InsertOne(insertionCtx, dbTransfer)
-	if err != nil {
-		s.log.Errorf("Unexpected err when adding transfer %s of origin account %s", dbTransfer.ID, dbTransfer.OriginAccountID)
 		return "", err
 	}
-	return oid.InsertedID.(primitive.ObjectID).Hex(), err
+	return dbAccount.ID.Hex(), nil
 }
@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func (s *Storage) AddToken(ctx context.Context, token authenticating.Token) error {
@@ -41,3 +42,506 @@ func (s *Storage) GetTokenByID(ctx context.Context, id primitive.ObjectID) (auth
 	}
 	return token, nil
 }
+
+func (s *Storage) DeleteToken(ctx context.Context, id primitive.ObjectID) error {
+	collection := s.client.Database(databaseName).Collection(tokensCollection)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Deleting token %v of mongodb repo coll %s", id, collection.Name())
+	result, err := collection.DeleteOne(deleteCtx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when deleting token %s", err, id.Hex())
+		return err
+	}
+	if result.DeletedCount == 0 {
+		s.log.Errorf("No token was found for id %s", id)
+		return ErrNoTokenWasFound
+	}
+	return nil
+}
+
+func (s *Storage) ListClientTokens(ctx context.Context, clientID string) ([]authenticating.Token, error) {
+	collection := s.client.Database(databaseName).Collection(tokensCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Listing tokens of client %s of mongodb repo coll %s", clientID, collection.Name())
+	// the TTL index takes a while to delete expired tokens, so they're filtered out meanwhile
+	filter := bson.D{
+		{Key: "client_id", Value: clientID},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now().UTC()}}},
+	}
+	cursor, err := collection.Find(queryCtx, filter)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when listing tokens of client %s", err, clientID)
+		return nil, err
+	}
+	var tokens []authenticating.Token
+	if err = cursor.All(queryCtx, &tokens); err != nil {
+		s.log.Errorf("Unexpected err %v when decoding tokens of client %s", err, clientID)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *Storage) AddRefreshToken(ctx context.Context, refreshToken authenticating.RefreshToken) error {
+	collection := s.client.Database(databaseName).Collection(refreshTokensCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding refresh token of family %s to mongodb repo coll %s", refreshToken.FamilyID, collection.Name())
+	_, err := collection.InsertOne(insertionCtx, refreshToken)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v occurred when adding refresh token of family %s", err, refreshToken.FamilyID)
+	}
+	return err
+}
+
+func (s *Storage) UseRefreshToken(ctx context.Context, hash string, now time.Time) (authenticating.RefreshToken, error) {
+	collection := s.client.Database(databaseName).Collection(refreshTokensCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Using refresh token of mongodb repo coll %s", collection.Name())
+	var refreshToken authenticating.RefreshToken
+	// only the first use sets used_at, any later one finding it already set
+	err := collection.FindOneAndUpdate(
+		updateCtx,
+		bson.D{{Key: "_id", Value: hash}, {Key: "used_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: now}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&refreshToken)
+	if err == nil {
+		return refreshToken, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		s.log.Errorf("Unexpected err %v when using refresh token", err)
+		return authenticating.RefreshToken{}, err
+	}
+
+	if err = collection.FindOne(updateCtx, bson.D{{Key: "_id", Value: hash}}).Decode(&refreshToken); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Error("No refresh token was found for the given hash")
+			return authenticating.RefreshToken{}, ErrNoRefreshTokenWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving refresh token", err)
+		return authenticating.RefreshToken{}, err
+	}
+	return refreshToken, nil
+}
+
+func (s *Storage) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	db := s.client.Database(databaseName)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Revoking token family %s of mongodb repo colls %s and %s", familyID, tokensCollection, refreshTokensCollection)
+	filter := bson.D{{Key: "family_id", Value: familyID}}
+	if _, err := db.Collection(tokensCollection).DeleteMany(deleteCtx, filter); err != nil {
+		s.log.Errorf("Unexpected err %v when revoking tokens of family %s", err, familyID)
+		return err
+	}
+	if _, err := db.Collection(refreshTokensCollection).DeleteMany(deleteCtx, filter); err != nil {
+		s.log.Errorf("Unexpected err %v when revoking refresh tokens of family %s", err, familyID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetLoginAttempts(ctx context.Context, key string) (authenticating.LoginAttempts, error) {
+	collection := s.client.Database(databaseName).Collection(loginAttemptsCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving login attempts of %s of mongodb repo coll %s", key, collection.Name())
+	var attempts authenticating.LoginAttempts
+	// the TTL monitor runs once a minute, so expired attempts may still be around
+	filter := bson.D{{Key: "_id", Value: key}, {Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now().UTC()}}}}
+	if err := collection.FindOne(queryCtx, filter).Decode(&attempts); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Errorf("No login attempts were found for %s", key)
+			return authenticating.LoginAttempts{}, ErrNoLoginAttemptsWereFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving login attempts of %s", err, key)
+		return authenticating.LoginAttempts{}, err
+	}
+	return attempts, nil
+}
+
+func (s *Storage) AddFailedLogin(ctx context.Context, key string, at time.Time, expiresAt time.Time) (authenticating.LoginAttempts, error) {
+	collection := s.client.Database(databaseName).Collection(loginAttemptsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding failed login attempt of %s to mongodb repo coll %s", key, collection.Name())
+	expired := bson.D{{Key: "_id", Value: key}, {Key: "expires_at", Value: bson.D{{Key: "$lte", Value: at}}}}
+	if _, err := collection.DeleteOne(updateCtx, expired); err != nil {
+		s.log.Errorf("Unexpected err %v when deleting expired login attempts of %s", err, key)
+		return authenticating.LoginAttempts{}, err
+	}
+
+	var attempts authenticating.LoginAttempts
+	err := collection.FindOneAndUpdate(
+		updateCtx,
+		bson.D{{Key: "_id", Value: key}},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "failures", Value: 1}}},
+			{Key: "$set", Value: bson.D{{Key: "last_failure_at", Value: at}}},
+			{Key: "$max", Value: bson.D{{Key: "expires_at", Value: expiresAt}}},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempts)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when adding failed login attempt of %s", err, key)
+		return authenticating.LoginAttempts{}, err
+	}
+	return attempts, nil
+}
+
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	collection := s.client.Database(databaseName).Collection(loginAttemptsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Locking login of %s until %s in mongodb repo coll %s", key, until, collection.Name())
+	_, err := collection.UpdateOne(
+		updateCtx,
+		bson.D{{Key: "_id", Value: key}},
+		bson.D{
+			{Key: "$set", Value: bson.D{{Key: "locked_until", Value: until}}},
+			{Key: "$max", Value: bson.D{{Key: "expires_at", Value: until}}},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when locking login of %s", err, key)
+	}
+	return err
+}
+
+func (s *Storage) ResetLoginAttempts(ctx context.Context, key string) error {
+	collection := s.client.Database(databaseName).Collection(loginAttemptsCollection)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Resetting login attempts of %s of mongodb repo coll %s", key, collection.Name())
+	if _, err := collection.DeleteOne(deleteCtx, bson.D{{Key: "_id", Value: key}}); err != nil {
+		s.log.Errorf("Unexpected err %v when resetting login attempts of %s", err, key)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) AddSecurityEvent(ctx context.Context, event authenticating.SecurityEvent) error {
+	collection := s.client.Database(databaseName).Collection(securityEventsCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding security event %v to mongodb repo coll %s", event, collection.Name())
+	if _, err := collection.InsertOne(insertionCtx, event); err != nil {
+		s.log.Errorf("Unexpected err %v occurred when adding security event %v", err, event)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) SetTwoFactor(ctx context.Context, twoFactor authenticating.TwoFactor) error {
+	collection := s.client.Database(databaseName).Collection(twoFactorsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Setting two-factor of account %s in mongodb repo coll %s", twoFactor.AccountID, collection.Name())
+	filter := bson.D{{Key: "_id", Value: twoFactor.AccountID}}
+	if _, err := collection.ReplaceOne(updateCtx, filter, twoFactor, options.Replace().SetUpsert(true)); err != nil {
+		s.log.Errorf("Unexpected err %v when setting two-factor of account %s", err, twoFactor.AccountID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetTwoFactor(ctx context.Context, accountID string) (authenticating.TwoFactor, error) {
+	collection := s.client.Database(databaseName).Collection(twoFactorsCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving two-factor of account %s of mongodb repo coll %s", accountID, collection.Name())
+	var twoFactor authenticating.TwoFactor
+	if err := collection.FindOne(queryCtx, bson.D{{Key: "_id", Value: accountID}}).Decode(&twoFactor); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Errorf("No two-factor was found for account %s", accountID)
+			return authenticating.TwoFactor{}, ErrNoTwoFactorWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving two-factor of account %s", err, accountID)
+		return authenticating.TwoFactor{}, err
+	}
+	return twoFactor, nil
+}
+
+func (s *Storage) UseTOTPStep(ctx context.Context, accountID string, step int64) (bool, error) {
+	collection := s.client.Database(databaseName).Collection(twoFactorsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Using TOTP step %d of account %s of mongodb repo coll %s", step, accountID, collection.Name())
+	// matching only earlier steps makes concurrent uses of the same code race for a single update
+	filter := bson.D{{Key: "_id", Value: accountID}, {Key: "last_used_step", Value: bson.D{{Key: "$lt", Value: step}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_step", Value: step}}}}
+	result, err := collection.UpdateOne(updateCtx, filter, update)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when using TOTP step of account %s", err, accountID)
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (s *Storage) UseRecoveryCode(ctx context.Context, accountID string, digest string) (bool, error) {
+	collection := s.client.Database(databaseName).Collection(twoFactorsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Using recovery code of account %s of mongodb repo coll %s", accountID, collection.Name())
+	filter := bson.D{{Key: "_id", Value: accountID}, {Key: "recovery_codes", Value: digest}}
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: "recovery_codes", Value: digest}}}}
+	result, err := collection.UpdateOne(updateCtx, filter, update)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when using recovery code of account %s", err, accountID)
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (s *Storage) AddLoginChallenge(ctx context.Context, challenge authenticating.LoginChallenge) error {
+	collection := s.client.Database(databaseName).Collection(loginChallengesCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding login challenge of %s to mongodb repo coll %s", challenge.ClientID, collection.Name())
+	if _, err := collection.InsertOne(insertionCtx, challenge); err != nil {
+		s.log.Errorf("Unexpected err %v occurred when adding login challenge of %s", err, challenge.ClientID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetLoginChallenge(ctx context.Context, hash string) (authenticating.LoginChallenge, error) {
+	collection := s.client.Database(databaseName).Collection(loginChallengesCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving login challenge of mongodb repo coll %s", collection.Name())
+	var challenge authenticating.LoginChallenge
+	// the TTL monitor runs once a minute, so expired challenges may still be around
+	filter := bson.D{{Key: "_id", Value: hash}, {Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now().UTC()}}}}
+	if err := collection.FindOne(queryCtx, filter).Decode(&challenge); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Error("No login challenge was found for the given hash")
+			return authenticating.LoginChallenge{}, ErrNoLoginChallengeWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving login challenge", err)
+		return authenticating.LoginChallenge{}, err
+	}
+	return challenge, nil
+}
+
+func (s *Storage) DeleteLoginChallenge(ctx context.Context, hash string) error {
+	collection := s.client.Database(databaseName).Collection(loginChallengesCollection)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Deleting login challenge of mongodb repo coll %s", collection.Name())
+	result, err := collection.DeleteOne(deleteCtx, bson.D{{Key: "_id", Value: hash}})
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when deleting login challenge", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		s.log.Error("No login challenge was found for the given hash")
+		return ErrNoLoginChallengeWasFound
+	}
+	return nil
+}
+
+func (s *Storage) SetAccountSecret(ctx context.Context, accountID string, secretDigest string) error {
+	collection := s.client.Database(databaseName).Collection(accountsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Setting secret of account %s in mongodb repo coll %s", accountID, collection.Name())
+	oid, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", accountID)
+		return ErrNoAccountWasFound
+	}
+	result, err := collection.UpdateOne(
+		updateCtx,
+		bson.D{{Key: "_id", Value: oid}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "secret", Value: secretDigest}}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when setting secret of account %s", err, accountID)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		s.log.Errorf("No account was found with id %s", accountID)
+		return ErrNoAccountWasFound
+	}
+	return nil
+}
+
+func (s *Storage) RevokeClientTokens(ctx context.Context, clientID string, exceptFamilyID string) error {
+	db := s.client.Database(databaseName)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Revoking tokens of client %s but family %s of mongodb repo colls %s and %s", clientID, exceptFamilyID, tokensCollection, refreshTokensCollection)
+	filter := bson.D{{Key: "client_id", Value: clientID}}
+	if exceptFamilyID != "" {
+		filter = append(filter, bson.E{Key: "family_id", Value: bson.D{{Key: "$ne", Value: exceptFamilyID}}})
+	}
+	if _, err := db.Collection(tokensCollection).DeleteMany(deleteCtx, filter); err != nil {
+		s.log.Errorf("Unexpected err %v when revoking tokens of client %s", err, clientID)
+		return err
+	}
+	if _, err := db.Collection(refreshTokensCollection).DeleteMany(deleteCtx, filter); err != nil {
+		s.log.Errorf("Unexpected err %v when revoking refresh tokens of client %s", err, clientID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) AddPasswordReset(ctx context.Context, reset authenticating.PasswordReset) error {
+	collection := s.client.Database(databaseName).Collection(passwordResetsCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding password reset of account %s to mongodb repo coll %s", reset.AccountID, collection.Name())
+	if _, err := collection.DeleteMany(insertionCtx, bson.D{{Key: "account_id", Value: reset.AccountID}}); err != nil {
+		s.log.Errorf("Unexpected err %v when deleting earlier password resets of account %s", err, reset.AccountID)
+		return err
+	}
+	if _, err := collection.InsertOne(insertionCtx, reset); err != nil {
+		s.log.Errorf("Unexpected err %v occurred when adding password reset of account %s", err, reset.AccountID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetPasswordReset(ctx context.Context, hash string) (authenticating.PasswordReset, error) {
+	collection := s.client.Database(databaseName).Collection(passwordResetsCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving password reset of mongodb repo coll %s", collection.Name())
+	var reset authenticating.PasswordReset
+	// the TTL monitor runs once a minute, so expired resets may still be around
+	filter := bson.D{{Key: "_id", Value: hash}, {Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now().UTC()}}}}
+	if err := collection.FindOne(queryCtx, filter).Decode(&reset); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Error("No password reset was found for the given hash")
+			return authenticating.PasswordReset{}, ErrNoPasswordResetWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving password reset", err)
+		return authenticating.PasswordReset{}, err
+	}
+	return reset, nil
+}
+
+func (s *Storage) DeletePasswordReset(ctx context.Context, hash string) error {
+	collection := s.client.Database(databaseName).Collection(passwordResetsCollection)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Deleting password reset of mongodb repo coll %s", collection.Name())
+	result, err := collection.DeleteOne(deleteCtx, bson.D{{Key: "_id", Value: hash}})
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when deleting password reset", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		s.log.Error("No password reset was found for the given hash")
+		return ErrNoPasswordResetWasFound
+	}
+	return nil
+}
+
+func (s *Storage) SetTransactionPIN(ctx context.Context, pin authenticating.TransactionPIN) error {
+	collection := s.client.Database(databaseName).Collection(transactionPINsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Setting transaction pin of account %s in mongodb repo coll %s", pin.AccountID, collection.Name())
+	filter := bson.D{{Key: "_id", Value: pin.AccountID}}
+	if _, err := collection.ReplaceOne(updateCtx, filter, pin, options.Replace().SetUpsert(true)); err != nil {
+		s.log.Errorf("Unexpected err %v when setting transaction pin of account %s", err, pin.AccountID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetTransactionPIN(ctx context.Context, accountID string) (authenticating.TransactionPIN, error) {
+	collection := s.client.Database(databaseName).Collection(transactionPINsCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving transaction pin of account %s of mongodb repo coll %s", accountID, collection.Name())
+	var pin authenticating.TransactionPIN
+	if err := collection.FindOne(queryCtx, bson.D{{Key: "_id", Value: accountID}}).Decode(&pin); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Infof("No transaction pin was found for account %s", accountID)
+			return authenticating.TransactionPIN{}, ErrNoTransactionPINWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving transaction pin of account %s", err, accountID)
+		return authenticating.TransactionPIN{}, err
+	}
+	return pin, nil
+}
+
+func (s *Storage) DeleteTransactionPIN(ctx context.Context, accountID string) error {
+	collection := s.client.Database(databaseName).Collection(transactionPINsCollection)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Deleting transaction pin of account %s of mongodb repo coll %s", accountID, collection.Name())
+	result, err := collection.DeleteOne(deleteCtx, bson.D{{Key: "_id", Value: accountID}})
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when deleting transaction pin of account %s", err, accountID)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		s.log.Errorf("No transaction pin was found for account %s", accountID)
+		return ErrNoTransactionPINWasFound
+	}
+	return nil
+}
+
+func (s *Storage) AddAPIClient(ctx context.Context, client authenticating.APIClient) error {
+	collection := s.client.Database(databaseName).Collection(apiClientsCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding API client %s to mongodb repo coll %s", client.ID, collection.Name())
+	if _, err := collection.InsertOne(insertionCtx, client); err != nil {
+		s.log.Errorf("Unexpected err %v occurred when adding API client %s", err, client.ID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetAPIClient(ctx context.Context, id string) (authenticating.APIClient, error) {
+	collection := s.client.Database(databaseName).Collection(apiClientsCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving API client %s of mongodb repo coll %s", id, collection.Name())
+	var client authenticating.APIClient
+	if err := collection.FindOne(queryCtx, bson.D{{Key: "_id", Value: id}}).Decode(&client); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Errorf("No API client was found with id %s", id)
+			return authenticating.APIClient{}, ErrNoAPIClientWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving API client %s", err, id)
+		return authenticating.APIClient{}, err
+	}
+	return client, nil
+}
@@ -0,0 +1,17 @@
+package mongodb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Entry struct {
+	ID primitive.ObjectID `bson:"_id"`
+	// AccountID is kept as a string since the ledger external account is not an ObjectID
+	AccountID  string               `bson:"account_id"`
+	TransferID string               `bson:"transfer_id,omitempty"`
+	Type       string               `bson:"type"`
+	Amount     primitive.Decimal128 `bson:"amount"`
+	CreatedAt  time.Time            `bson:"created_at"`
+}
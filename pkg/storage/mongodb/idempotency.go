@@ -0,0 +1,122 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *Storage) AddIdempotencyRecord(ctx context.Context, record idempotency.Record) error {
+	collection := s.client.Database(databaseName).Collection(idempotencyKeysCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding idempotency key %s to mongodb repo coll %s", record.Key, collection.Name())
+	// the TTL monitor runs only from time to time, so a key past its expiry may still be there
+	_, err := collection.DeleteOne(insertionCtx, bson.D{
+		{Key: "account_id", Value: record.AccountID},
+		{Key: "key", Value: record.Key},
+		{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: record.CreatedAt}}},
+	})
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when removing expired idempotency key %s", err, record.Key)
+		return err
+	}
+
+	_, err = collection.InsertOne(insertionCtx, IdempotencyRecord{
+		ID:          primitive.NewObjectID(),
+		Key:         record.Key,
+		AccountID:   record.AccountID,
+		RequestHash: record.RequestHash,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			s.log.Errorf("Idempotency key %s of account %s already exists", record.Key, record.AccountID)
+			return idempotency.ErrKeyAlreadyExists
+		}
+		s.log.Errorf("Unexpected err %v when adding idempotency key %s", err, record.Key)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetIdempotencyRecord(ctx context.Context, accountID string, key string) (idempotency.Record, error) {
+	collection := s.client.Database(databaseName).Collection(idempotencyKeysCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving idempotency key %s of account %s of mongodb repo coll %s", key, accountID, collection.Name())
+	var record IdempotencyRecord
+	result := collection.FindOne(queryCtx, bson.D{
+		{Key: "account_id", Value: accountID},
+		{Key: "key", Value: key},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now().UTC()}}},
+	})
+	if err := result.Decode(&record); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Errorf("No idempotency key %s was found for account %s", key, accountID)
+			return idempotency.Record{}, ErrNoIdempotencyRecordWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving idempotency key %s", err, key)
+		return idempotency.Record{}, err
+	}
+	return idempotency.Record{
+		Key:         record.Key,
+		AccountID:   record.AccountID,
+		RequestHash: record.RequestHash,
+		Completed:   record.Completed,
+		StatusCode:  record.StatusCode,
+		ContentType: record.ContentType,
+		Location:    record.Location,
+		Body:        record.Body,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+	}, nil
+}
+
+func (s *Storage) UpdateIdempotencyRecord(ctx context.Context, record idempotency.Record) error {
+	collection := s.client.Database(databaseName).Collection(idempotencyKeysCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Updating idempotency key %s of mongodb repo coll %s", record.Key, collection.Name())
+	result, err := collection.UpdateOne(
+		updateCtx,
+		bson.D{{Key: "account_id", Value: record.AccountID}, {Key: "key", Value: record.Key}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "completed", Value: record.Completed},
+			{Key: "status_code", Value: record.StatusCode},
+			{Key: "content_type", Value: record.ContentType},
+			{Key: "location", Value: record.Location},
+			{Key: "body", Value: record.Body},
+		}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when updating idempotency key %s", err, record.Key)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		s.log.Errorf("No idempotency key %s was found for account %s", record.Key, record.AccountID)
+		return ErrNoIdempotencyRecordWasFound
+	}
+	return nil
+}
+
+func (s *Storage) DeleteIdempotencyRecord(ctx context.Context, accountID string, key string) error {
+	collection := s.client.Database(databaseName).Collection(idempotencyKeysCollection)
+	deleteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Deleting idempotency key %s of account %s of mongodb repo coll %s", key, accountID, collection.Name())
+	if _, err := collection.DeleteOne(deleteCtx, bson.D{{Key: "account_id", Value: accountID}, {Key: "key", Value: key}}); err != nil {
+		s.log.Errorf("Unexpected err %v when deleting idempotency key %s", err, key)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package mongodb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Key         string             `bson:"key"`
+	AccountID   string             `bson:"account_id"`
+	RequestHash string             `bson:"request_hash"`
+	Completed   bool               `bson:"completed"`
+	StatusCode  int                `bson:"status_code"`
+	ContentType string             `bson:"content_type"`
+	Location    string             `bson:"location,omitempty"`
+	Body        []byte             `bson:"body"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	ExpiresAt   time.Time          `bson:"expires_at"`
+}
@@ -0,0 +1,339 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ledgerBackfill names the migration that writes the ledger entries of what was stored before the ledger existed
+	ledgerBackfill = "ledger_backfill"
+	// ledgerBackfillTimeout bounds how long the migration runs, after which its lock may be taken over
+	ledgerBackfillTimeout = time.Minute * 30
+
+	migrationRunning = "running"
+	migrationDone    = "done"
+)
+
+// insertEntries is meant to run inside the transaction that changes the balances the entries account for
+func (s *Storage) insertEntries(ctx context.Context, entries []ledger.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := ledger.Validate(entries); err != nil {
+		s.log.Errorf("Err %v when adding ledger entries %v", err, entries)
+		return err
+	}
+
+	collection := s.client.Database(databaseName).Collection(ledgerEntriesCollection)
+	docs := make([]interface{}, 0, len(entries))
+	for _, e := range entries {
+		docs = append(docs, Entry{
+			ID:         primitive.NewObjectID(),
+			AccountID:  e.AccountID,
+			TransferID: e.TransferID,
+			Type:       string(e.Type),
+			Amount:     decimalFromMoney(e.Amount),
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		s.log.Errorf("Unexpected err %v when adding ledger entries to mongodb repo coll %s", err, collection.Name())
+		return err
+	}
+	return nil
+}
+
+// ledgerBalance sums, within the database, the credits minus the debits of the ledger entries of accountID created
+// up to until, so that balances are rebuilt without reading the whole history of the account
+func (s *Storage) ledgerBalance(ctx context.Context, accountID string, until time.Time) (money.Money, error) {
+	collection := s.client.Database(databaseName).Collection(ledgerEntriesCollection)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "account_id", Value: accountID},
+			{Key: "created_at", Value: bson.D{{Key: "$lte", Value: until}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "balance", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$type", string(ledger.Credit)}}},
+				"$amount",
+				bson.D{{Key: "$multiply", Value: bson.A{"$amount", -1}}},
+			}}}}}},
+		}}},
+	}
+	cur, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when summing ledger entries of account %s", err, accountID)
+		return 0, err
+	}
+	defer func() {
+		if closeErr := cur.Close(ctx); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
+	// accounts without entries yet get no group at all
+	if !cur.Next(ctx) {
+		return 0, cur.Err()
+	}
+	var result struct {
+		Balance primitive.Decimal128 `bson:"balance"`
+	}
+	if err = cur.Decode(&result); err != nil {
+		s.log.Errorf("Err %v occurred when decoding ledger balance of account %s", err, accountID)
+		return 0, err
+	}
+	return moneyFromDecimal(result.Balance)
+}
+
+// BackfillLedger writes, once ever, the ledger entries of the accounts and transfers stored before balances were
+// recorded as ledger entries: completed transfers without entries get their transfer entries as of when they were
+// made, and then every account whose entries don't add up to its balance gets an opening entry of the difference as
+// of its creation.
+//
+// The migration is locked by its record in migrationsCollection, inserted as running before anything is written,
+// so that servers started together don't run it twice. The record is marked done once it succeeds and dropped when
+// it fails, so the next start runs it again, while a running record older than ledgerBackfillTimeout is taken
+// over, as its server died before finishing it
+func (s *Storage) BackfillLedger(ctx context.Context) error {
+	migrations := s.client.Database(databaseName).Collection(migrationsCollection)
+	// the whole history is read, so it's given far longer than a query
+	migrationCtx, cancel := context.WithTimeout(ctx, ledgerBackfillTimeout)
+	defer cancel()
+
+	startedAt, err := s.lockMigration(migrationCtx, ledgerBackfill)
+	if err != nil || startedAt.IsZero() {
+		return err
+	}
+
+	s.log.Infof("Running migration %s", ledgerBackfill)
+	transfers, err := s.backfillTransferEntries(migrationCtx, startedAt)
+	if err != nil {
+		s.unlockMigration(migrationCtx, ledgerBackfill, startedAt)
+		return err
+	}
+	accounts, err := s.backfillOpeningEntries(migrationCtx)
+	if err != nil {
+		s.unlockMigration(migrationCtx, ledgerBackfill, startedAt)
+		return err
+	}
+	s.log.Infof("Migration %s wrote the entries of %d transfers and %d opening balances", ledgerBackfill, transfers, accounts)
+
+	_, err = migrations.UpdateOne(
+		migrationCtx,
+		bson.D{{Key: "_id", Value: ledgerBackfill}, {Key: "started_at", Value: startedAt}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: migrationDone},
+			{Key: "done_at", Value: time.Now().UTC()},
+		}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when recording migration %s as done", err, ledgerBackfill)
+		return err
+	}
+	return nil
+}
+
+// lockMigration records migration as running, returning when it started, or the zero time when it's either done or
+// being run by another server, as its record tells
+func (s *Storage) lockMigration(ctx context.Context, migration string) (time.Time, error) {
+	migrations := s.client.Database(databaseName).Collection(migrationsCollection)
+	startedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+	_, err := migrations.InsertOne(ctx, bson.D{
+		{Key: "_id", Value: migration},
+		{Key: "status", Value: migrationRunning},
+		{Key: "started_at", Value: startedAt},
+	})
+	if err == nil {
+		return startedAt, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		s.log.Errorf("Unexpected err %v when locking migration %s", err, migration)
+		return time.Time{}, err
+	}
+
+	// records of migrations done before they were locked have no status at all
+	result, err := migrations.UpdateOne(
+		ctx,
+		bson.D{
+			{Key: "_id", Value: migration},
+			{Key: "status", Value: migrationRunning},
+			{Key: "started_at", Value: bson.D{{Key: "$lt", Value: startedAt.Add(-ledgerBackfillTimeout)}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "started_at", Value: startedAt}}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when taking over migration %s", err, migration)
+		return time.Time{}, err
+	}
+	if result.MatchedCount == 0 {
+		s.log.Infof("Migration %s is either done or being run by another server", migration)
+		return time.Time{}, nil
+	}
+	s.log.Warnf("Taking over migration %s, left running by a server that didn't finish it", migration)
+	return startedAt, nil
+}
+
+// unlockMigration drops the running record of migration, as long as it's still the one started at startedAt
+func (s *Storage) unlockMigration(ctx context.Context, migration string, startedAt time.Time) {
+	migrations := s.client.Database(databaseName).Collection(migrationsCollection)
+	filter := bson.D{
+		{Key: "_id", Value: migration},
+		{Key: "status", Value: migrationRunning},
+		{Key: "started_at", Value: startedAt},
+	}
+	if _, err := migrations.DeleteOne(ctx, filter); err != nil {
+		s.log.Errorf("Unexpected err %v when unlocking migration %s", err, migration)
+	}
+}
+
+// backfillTransferEntries writes the entries of every transfer completed or reversed before startedAt that has none,
+// returning how many transfers got them. Transfers stored before statuses existed have none and are completed ones.
+// Each transfer is read again, checked for entries and given them in a transaction that also touches it, so that
+// the transfers completed meanwhile, which get their own entries, conflict with it instead of getting them twice
+func (s *Storage) backfillTransferEntries(ctx context.Context, startedAt time.Time) (int, error) {
+	transfers := s.client.Database(databaseName).Collection(transfersCollection)
+	filter := bson.D{
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{
+			string(transferstatus.Completed), string(transferstatus.Reversed), nil,
+		}}}},
+		{Key: "completed_at", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gte", Value: startedAt}}}}},
+	}
+	cur, err := transfers.Find(ctx, filter, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving transfers to backfill", err)
+		return 0, err
+	}
+	defer func() {
+		if closeErr := cur.Close(ctx); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
+	backfilled := 0
+	for cur.Next(ctx) {
+		var listed struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err = cur.Decode(&listed); err != nil {
+			s.log.Errorf("Err %v occurred when decoding transfer to backfill", err)
+			return backfilled, err
+		}
+		wrote, err := s.withTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+			return s.backfillTransfer(sessCtx, transfers, listed.ID, startedAt)
+		})
+		if err != nil {
+			s.log.Errorf("Err %v occurred when backfilling entries of transfer %s", err, listed.ID.Hex())
+			return backfilled, err
+		}
+		if wrote.(bool) {
+			backfilled++
+		}
+	}
+	return backfilled, cur.Err()
+}
+
+// backfillTransfer writes the entries of the transfer oid, telling whether it needed them, and is meant to run
+// inside a transaction
+func (s *Storage) backfillTransfer(sessCtx mongo.SessionContext, transfers *mongo.Collection, oid primitive.ObjectID, startedAt time.Time) (bool, error) {
+	var t Transfer
+	if err := transfers.FindOne(sessCtx, bson.D{{Key: "_id", Value: oid}}).Decode(&t); err != nil {
+		return false, err
+	}
+	if t.Status != "" && t.Status != string(transferstatus.Completed) && t.Status != string(transferstatus.Reversed) {
+		return false, nil
+	}
+	if t.CompletedAt != nil && !t.CompletedAt.Before(startedAt) {
+		return false, nil
+	}
+	recorded, err := s.client.Database(databaseName).Collection(ledgerEntriesCollection).
+		CountDocuments(sessCtx, bson.D{{Key: "transfer_id", Value: oid.Hex()}})
+	if err != nil || recorded > 0 {
+		return false, err
+	}
+
+	// touching the transfer is what makes a concurrent change to it conflict
+	_, err = transfers.UpdateOne(sessCtx, bson.D{{Key: "_id", Value: oid}}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "ledger_backfilled_at", Value: startedAt},
+	}}})
+	if err != nil {
+		return false, err
+	}
+	amount, err := moneyFromDecimal(t.Amount)
+	if err != nil {
+		return false, err
+	}
+	at := t.CreatedAt
+	if t.CompletedAt != nil {
+		at = *t.CompletedAt
+	}
+	entries := ledger.TransferEntries(oid.Hex(), t.OriginAccountID.Hex(), t.DestinationAccountID.Hex(), amount, at)
+	return true, s.insertEntries(sessCtx, entries)
+}
+
+// backfillOpeningEntries writes an opening entry for every account whose entries don't add up to its balance,
+// returning how many got one. Each account is balanced in a transaction that also touches it, so that a transfer
+// made meanwhile conflicts with it instead of being taken for part of the opening balance
+func (s *Storage) backfillOpeningEntries(ctx context.Context) (int, error) {
+	accounts := s.client.Database(databaseName).Collection(accountsCollection)
+	cur, err := accounts.Find(ctx, bson.D{})
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving accounts to backfill", err)
+		return 0, err
+	}
+	defer func() {
+		if closeErr := cur.Close(ctx); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
+	backfilled := 0
+	for cur.Next(ctx) {
+		var listed Account
+		if err = cur.Decode(&listed); err != nil {
+			s.log.Errorf("Err %v occurred when decoding account to backfill", err)
+			return backfilled, err
+		}
+		opened, err := s.withTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+			account, findErr := s.findAccountByOID(sessCtx, accounts, listed.ID)
+			if findErr != nil {
+				return false, findErr
+			}
+			balance, convErr := moneyFromDecimal(account.Balance)
+			if convErr != nil {
+				return false, convErr
+			}
+			recorded, sumErr := s.ledgerBalance(sessCtx, account.ID.Hex(), time.Now().UTC())
+			if sumErr != nil {
+				return false, sumErr
+			}
+			entries := ledger.AdjustmentEntries(account.ID.Hex(), recorded, balance, account.CreatedAt)
+			if len(entries) == 0 {
+				return false, nil
+			}
+			// rewriting the balance as Decimal128 is what makes concurrent transfers of the account conflict
+			if updtErr := s.setBalance(sessCtx, accounts, account.ID, balance); updtErr != nil {
+				return false, updtErr
+			}
+			return true, s.insertEntries(sessCtx, entries)
+		})
+		if err != nil {
+			s.log.Errorf("Err %v occurred when backfilling opening entries of account %s", err, listed.ID.Hex())
+			return backfilled, err
+		}
+		if opened.(bool) {
+			backfilled++
+		}
+	}
+	return backfilled, cur.Err()
+}
@@ -0,0 +1,123 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Storage) GetLimitSettings(ctx context.Context, accountID string) (limiting.Settings, error) {
+	collection := s.client.Database(databaseName).Collection(accountsCollection)
+	queryContext, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Retrieving limit settings of account %s of mongodb repo coll %s", accountID, collection.Name())
+	oid, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", accountID)
+		return limiting.Settings{}, ErrNoAccountWasFound
+	}
+	account, err := s.findAccountByOID(queryContext, collection, oid)
+	if err != nil {
+		return limiting.Settings{}, err
+	}
+
+	settings := limiting.Settings{Tier: limiting.Tier(account.Tier)}
+	if account.Limits == nil {
+		return settings, nil
+	}
+	for _, limit := range []struct {
+		stored primitive.Decimal128
+		custom *money.Money
+	}{
+		{account.Limits.PerTransaction, &settings.Custom.PerTransaction},
+		{account.Limits.Daily, &settings.Custom.Daily},
+		{account.Limits.Monthly, &settings.Custom.Monthly},
+		{account.Limits.NightTime, &settings.Custom.NightTime},
+	} {
+		if *limit.custom, err = moneyFromDecimal(limit.stored); err != nil {
+			s.log.Errorf("Err %v when converting limits of account %s", err, accountID)
+			return limiting.Settings{}, err
+		}
+	}
+	return settings, nil
+}
+
+func (s *Storage) SetLimitSettings(ctx context.Context, accountID string, settings limiting.Settings) error {
+	collection := s.client.Database(databaseName).Collection(accountsCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Setting limit settings %v of account %s in mongodb repo coll %s", settings, accountID, collection.Name())
+	oid, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", accountID)
+		return ErrNoAccountWasFound
+	}
+	result, err := collection.UpdateOne(
+		updateCtx,
+		bson.D{{Key: "_id", Value: oid}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "tier", Value: string(settings.Tier)},
+			{Key: "limits", Value: Limits{
+				PerTransaction: decimalFromMoney(settings.Custom.PerTransaction),
+				Daily:          decimalFromMoney(settings.Custom.Daily),
+				Monthly:        decimalFromMoney(settings.Custom.Monthly),
+				NightTime:      decimalFromMoney(settings.Custom.NightTime),
+			}},
+		}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when setting limit settings of account %s", err, accountID)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		s.log.Errorf("No account was found with id %s", accountID)
+		return ErrNoAccountWasFound
+	}
+	return nil
+}
+
+func (s *Storage) GetSpendings(ctx context.Context, accountID string, since time.Time) ([]limiting.Spending, error) {
+	queryContext, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	s.log.Infof("Retrieving spendings of account %s since %s of mongodb repo coll %s", accountID, since, transfersCollection)
+	spendings := make([]limiting.Spending, 0)
+	oid, _ := primitive.ObjectIDFromHex(accountID)
+	cur, err := s.client.Database(databaseName).Collection(transfersCollection).Find(queryContext, bson.D{
+		{Key: "account_origin_id", Value: oid},
+		{Key: "created_at", Value: bson.D{{Key: "$gte", Value: since}}},
+		{Key: "status", Value: bson.D{{Key: "$ne", Value: string(transferstatus.Failed)}}},
+		{Key: "reversal_of", Value: bson.D{{Key: "$exists", Value: false}}},
+	})
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving spendings of account %s", err, accountID)
+		return spendings, err
+	}
+	defer func() {
+		if closeErr := cur.Close(queryContext); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
+	for cur.Next(queryContext) {
+		var t Transfer
+		if err = cur.Decode(&t); err != nil {
+			s.log.Errorf("Err %v occurred when decoding transfer from mongo repo", err)
+			return nil, err
+		}
+		amount, convErr := moneyFromDecimal(t.Amount)
+		if convErr != nil {
+			s.log.Errorf("Err %v occurred when converting transfer %s from mongo repo", convErr, t.ID.Hex())
+			return nil, convErr
+		}
+		spendings = append(spendings, limiting.Spending{Amount: amount, CreatedAt: t.CreatedAt})
+	}
+	return spendings, cur.Err()
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"time"
 
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
 	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func (s *Storage) GetAccountByID(ctx context.Context, id string) (listing.Account, error) {
@@ -31,14 +35,7 @@ func (s *Storage) GetAccountByID(ctx context.Context, id string) (listing.Accoun
 		s.log.Errorf("Unexpected err %v when retrieving account %s", err, id)
 		return listing.Account{}, err
 	}
-	return listing.Account{
-		ID:        account.ID.Hex(),
-		Name:      account.Name,
-		CPF:       account.CPF,
-		Secret:    account.Secret,
-		Balance:   account.Balance,
-		CreatedAt: &account.CreatedAt,
-	}, nil
+	return toListingAccount(account)
 }
 
 func (s *Storage) GetAccountByCPF(ctx context.Context, cpf string) (listing.Account, error) {
@@ -57,14 +54,7 @@ func (s *Storage) GetAccountByCPF(ctx context.Context, cpf string) (listing.Acco
 		s.log.Errorf("Unexpected err %v when retrieving account of cpf %s", err, cpf)
 		return listing.Account{}, err
 	}
-	return listing.Account{
-		ID:        account.ID.Hex(),
-		Name:      account.Name,
-		CPF:       account.CPF,
-		Secret:    account.Secret,
-		Balance:   account.Balance,
-		CreatedAt: &account.CreatedAt,
-	}, nil
+	return toListingAccount(account)
 }
 
 func (s *Storage) GetAccounts(ctx context.Context) ([]listing.Account, error) {
@@ -94,38 +84,210 @@ func (s *Storage) GetAccounts(ctx context.Context) ([]listing.Account, error) {
 			continue
 		}
 
-		accounts = append(accounts, listing.Account{
-			ID:        a.ID.Hex(),
-			Name:      a.Name,
-			CPF:       a.CPF,
-			Secret:    a.Secret,
-			Balance:   a.Balance,
-			CreatedAt: &a.CreatedAt,
-		})
+		account, convErr := toListingAccount(a)
+		if convErr != nil {
+			s.log.Errorf("Err %v occurred when converting account %s from mongo repo", convErr, a.ID.Hex())
+			continue
+		}
+		accounts = append(accounts, account)
 	}
 
 	return accounts, nil
 }
 
-func (s *Storage) GetTransfersByKey(ctx context.Context, transferKey string, transferValue string) ([]listing.Transfer, error) {
-	queryContext, cancel := context.WithTimeout(ctx, time.Second*100)
+func (s *Storage) GetTransfersByAccountID(ctx context.Context, accountID string, query listing.TransferQuery) ([]listing.Transfer, error) {
+	collection := s.client.Database(databaseName).Collection(transfersCollection)
+	queryContext, cancel := context.WithTimeout(ctx, time.Second*15)
 	defer cancel()
 
-	s.log.Infof("Retrieving transfers by %s with transferValue %s of mongodb repo coll %s", transferKey, transferValue, transfersCollection)
-	transfers := make([]listing.Transfer, 0)
-	oid, _ := primitive.ObjectIDFromHex(transferValue)
-	cur, err := s.client.Database(databaseName).Collection(transfersCollection).Find(ctx, bson.D{{Key: transferKey, Value: oid}})
+	s.log.Infof("Retrieving transfers of account %s by %v of mongodb repo coll %s", accountID, query, collection.Name())
+	transfers := make([]listing.Transfer, 0, query.Limit)
+	oid, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", accountID)
+		return transfers, nil
+	}
+	order := -1
+	if query.Sort.Ascending {
+		order = 1
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: string(query.Sort.Field), Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(int64(query.Limit))
+	cur, err := collection.Find(queryContext, transfersQuery(oid, query), opts)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving transfers of account %s", err, accountID)
+		return transfers, err
+	}
 	defer func() {
-		err = cur.Close(queryContext)
-		if err != nil {
-			s.log.Panicf("Err %v occurred when closing cursor", err)
-			panic(err)
+		if closeErr := cur.Close(queryContext); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
+	for cur.Next(queryContext) {
+		var t Transfer
+		if err = cur.Decode(&t); err != nil {
+			s.log.Errorf("Err %v occurred when decoding transfer from mongo repo", err)
+			continue
+		}
+
+		transfer, convErr := toListingTransfer(t)
+		if convErr != nil {
+			s.log.Errorf("Err %v occurred when converting transfer %s from mongo repo", convErr, t.ID.Hex())
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+	if err = cur.Err(); err != nil {
+		s.log.Errorf("Err %v occurred when iterating transfers of account %s", err, accountID)
+		return transfers, err
+	}
+	return transfers, nil
+}
+
+// transfersQuery filters the transfers of accountID, both sides of a transfer being served by their own indexes
+func transfersQuery(accountID primitive.ObjectID, query listing.TransferQuery) bson.D {
+	filter := query.Filter
+	sent := bson.D{{Key: "account_origin_id", Value: accountID}}
+	received := bson.D{{Key: "account_destination_id", Value: accountID}}
+	if filter.CounterpartyID != "" {
+		counterpartyOID, _ := primitive.ObjectIDFromHex(filter.CounterpartyID)
+		sent = append(sent, bson.E{Key: "account_destination_id", Value: counterpartyOID})
+		received = append(received, bson.E{Key: "account_origin_id", Value: counterpartyOID})
+	}
+
+	var q bson.D
+	switch filter.Direction {
+	case listing.Sent:
+		q = sent
+	case listing.Received:
+		q = received
+	default:
+		q = bson.D{{Key: "$or", Value: bson.A{sent, received}}}
+	}
+	if filter.Status != "" {
+		q = append(q, bson.E{Key: "status", Value: statusFilter(filter.Status)})
+	}
+	if filter.StandingOrderID != "" {
+		standingOrderOID, _ := primitive.ObjectIDFromHex(filter.StandingOrderID)
+		q = append(q, bson.E{Key: "standing_order_id", Value: standingOrderOID})
+	}
+	if createdAt := rangeFilter(filter.CreatedFrom, filter.CreatedUntil, !filter.CreatedFrom.IsZero(), !filter.CreatedUntil.IsZero()); createdAt != nil {
+		q = append(q, bson.E{Key: "created_at", Value: createdAt})
+	}
+	if amount := rangeFilter(decimalFromMoney(filter.MinAmount), decimalFromMoney(filter.MaxAmount), filter.MinAmount != 0, filter.MaxAmount != 0); amount != nil {
+		q = append(q, bson.E{Key: "amount", Value: amount})
+	}
+	if query.After != nil {
+		q = append(q, bson.E{Key: "$and", Value: bson.A{afterFilter(query.Sort, *query.After)}})
+	}
+	return q
+}
+
+// rangeFilter bounds a field by min and max, inclusive, leaving out the ones not set
+func rangeFilter(min interface{}, max interface{}, hasMin bool, hasMax bool) bson.D {
+	var r bson.D
+	if hasMin {
+		r = append(r, bson.E{Key: "$gte", Value: min})
+	}
+	if hasMax {
+		r = append(r, bson.E{Key: "$lte", Value: max})
+	}
+	return r
+}
+
+// afterFilter matches the transfers following the cursor in the given sort, the id breaking ties of the sort field
+func afterFilter(sort listing.Sort, after listing.Cursor) bson.D {
+	op := "$lt"
+	if sort.Ascending {
+		op = "$gt"
+	}
+	var value interface{} = after.CreatedAt
+	if sort.Field == listing.SortByAmount {
+		value = decimalFromMoney(after.Amount)
+	}
+	id, _ := primitive.ObjectIDFromHex(after.ID)
+	field := string(sort.Field)
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: field, Value: bson.D{{Key: op, Value: value}}}},
+		bson.D{{Key: field, Value: value}, {Key: "_id", Value: bson.D{{Key: op, Value: id}}}},
+	}}}
+}
+
+func (s *Storage) GetLedgerBalance(ctx context.Context, accountID string, until time.Time) (money.Money, error) {
+	queryContext, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	s.log.Infof("Summing ledger entries of account %s until %s of mongodb repo coll %s", accountID, until, ledgerEntriesCollection)
+	return s.ledgerBalance(queryContext, accountID, until)
+}
+
+func (s *Storage) StreamEntriesByAccountIDBetween(ctx context.Context, accountID string, from time.Time, until time.Time, fn func(ledger.Entry) error) error {
+	collection := s.client.Database(databaseName).Collection(ledgerEntriesCollection)
+	// the cursor is read as fast as the client takes the statement, which for long periods is far slower than a query
+	queryContext, cancel := context.WithTimeout(ctx, time.Minute*10)
+	defer cancel()
+
+	s.log.Infof("Streaming ledger entries of account %s from %s until %s of mongodb repo coll %s", accountID, from, until, collection.Name())
+	filter := bson.D{
+		{Key: "account_id", Value: accountID},
+		{Key: "created_at", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lte", Value: until}}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetBatchSize(500)
+	cur, err := collection.Find(queryContext, filter, opts)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving ledger entries of account %s", err, accountID)
+		return err
+	}
+	defer func() {
+		if closeErr := cur.Close(queryContext); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
 		}
 	}()
+
+	for cur.Next(queryContext) {
+		e, err := s.decodeEntry(cur)
+		if err != nil {
+			return err
+		}
+		if err = fn(e); err != nil {
+			return err
+		}
+	}
+	if err = cur.Err(); err != nil {
+		s.log.Errorf("Err %v occurred when iterating ledger entries of account %s", err, accountID)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetTransfersByIDs(ctx context.Context, ids []string) ([]listing.Transfer, error) {
+	collection := s.client.Database(databaseName).Collection(transfersCollection)
+	queryContext, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	s.log.Infof("Retrieving transfers %v of mongodb repo coll %s", ids, collection.Name())
+	transfers := make([]listing.Transfer, 0, len(ids))
+	oids := make(bson.A, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	cur, err := collection.Find(queryContext, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: oids}}}})
 	if err != nil {
-		s.log.Errorf("Err %v occurred when retrieving transfers by %s with transferValue %s", err, transferKey, transferValue)
+		s.log.Errorf("Err %v occurred when retrieving transfers %v", err, ids)
 		return transfers, err
 	}
+	defer func() {
+		if closeErr := cur.Close(queryContext); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
 	for cur.Next(queryContext) {
 		var t Transfer
 		if err = cur.Decode(&t); err != nil {
@@ -133,13 +295,84 @@ func (s *Storage) GetTransfersByKey(ctx context.Context, transferKey string, tra
 			continue
 		}
 
-		transfers = append(transfers, listing.Transfer{
-			ID:                   t.ID.Hex(),
-			OriginAccountID:      t.OriginAccountID.Hex(),
-			DestinationAccountID: t.DestinationAccountID.Hex(),
-			Amount:               t.Amount,
-			CreatedAt:            t.CreatedAt,
-		})
+		transfer, convErr := toListingTransfer(t)
+		if convErr != nil {
+			s.log.Errorf("Err %v occurred when converting transfer %s from mongo repo", convErr, t.ID.Hex())
+			continue
+		}
+		transfers = append(transfers, transfer)
 	}
-	return transfers, nil
+	return transfers, cur.Err()
+}
+
+// decodeEntry reads the ledger entry the cursor is at
+func (s *Storage) decodeEntry(cur *mongo.Cursor) (ledger.Entry, error) {
+	var e Entry
+	if err := cur.Decode(&e); err != nil {
+		s.log.Errorf("Err %v occurred when decoding ledger entry from mongo repo", err)
+		return ledger.Entry{}, err
+	}
+	amount, err := moneyFromDecimal(e.Amount)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when converting ledger entry %s from mongo repo", err, e.ID.Hex())
+		return ledger.Entry{}, err
+	}
+	return ledger.Entry{
+		AccountID:  e.AccountID,
+		TransferID: e.TransferID,
+		Type:       ledger.EntryType(e.Type),
+		Amount:     amount,
+		CreatedAt:  e.CreatedAt,
+	}, nil
+}
+
+func toListingAccount(account Account) (listing.Account, error) {
+	balance, err := moneyFromDecimal(account.Balance)
+	if err != nil {
+		return listing.Account{}, err
+	}
+	return listing.Account{
+		ID:        account.ID.Hex(),
+		Name:      account.Name,
+		CPF:       account.CPF,
+		Secret:    account.Secret,
+		Role:      account.Role,
+		Balance:   balance,
+		CreatedAt: &account.CreatedAt,
+	}, nil
+}
+
+func toListingTransfer(t Transfer) (listing.Transfer, error) {
+	amount, err := moneyFromDecimal(t.Amount)
+	if err != nil {
+		return listing.Transfer{}, err
+	}
+	transfer := listing.Transfer{
+		ID:                   t.ID.Hex(),
+		OriginAccountID:      t.OriginAccountID.Hex(),
+		DestinationAccountID: t.DestinationAccountID.Hex(),
+		Amount:               amount,
+		Status:               transferstatus.Status(t.Status),
+		FailureReason:        transferstatus.Reason(t.FailureReason),
+		CreatedAt:            t.CreatedAt,
+		CompletedAt:          t.CompletedAt,
+		FailedAt:             t.FailedAt,
+		ReversedAt:           t.ReversedAt,
+	}
+	if t.ReversedAmount != nil {
+		if transfer.ReversedAmount, err = moneyFromDecimal(*t.ReversedAmount); err != nil {
+			return listing.Transfer{}, err
+		}
+	}
+	if t.ReversalOf != nil {
+		transfer.ReversalOf = t.ReversalOf.Hex()
+	}
+	if t.StandingOrderID != nil {
+		transfer.StandingOrderID = t.StandingOrderID.Hex()
+	}
+	if transfer.Status == "" {
+		transfer.Status = transferstatus.Completed
+		transfer.CompletedAt = &transfer.CreatedAt
+	}
+	return transfer, nil
 }
@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// centsExponent is the Decimal128 exponent used to persist money, keeping exactly two decimal places
+const centsExponent = -2
+
+var ten = big.NewInt(10)
+
+func decimalFromMoney(m money.Money) primitive.Decimal128 {
+	d, _ := primitive.ParseDecimal128FromBigInt(big.NewInt(m.Cents()), centsExponent)
+	return d
+}
+
+// moneyFromDecimal reads a Decimal128 as money, failing if it holds fractions of a cent
+func moneyFromDecimal(d primitive.Decimal128) (money.Money, error) {
+	cents, exp, err := d.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	for ; exp > centsExponent; exp-- {
+		cents.Mul(cents, ten)
+	}
+	for remainder := new(big.Int); exp < centsExponent; exp++ {
+		if cents.QuoRem(cents, ten, remainder); remainder.Sign() != 0 {
+			return 0, money.ErrInvalidAmount
+		}
+	}
+	if !cents.IsInt64() {
+		return 0, money.ErrInvalidAmount
+	}
+	return money.FromCents(cents.Int64()), nil
+}
+
+// registry decodes Decimal128 fields from doubles too, as money was stored before it was stored as Decimal128, so
+// that accounts, transfers and limits stored back then are still read, their amounts becoming Decimal128 as soon as
+// they are written again
+var registry = bson.NewRegistryBuilder().
+	RegisterTypeDecoder(reflect.TypeOf(primitive.Decimal128{}), bsoncodec.ValueDecoderFunc(decodeDecimal)).
+	Build()
+
+// decodeDecimal decodes a double as the Decimal128 of its nearest cent, leaving any other type to the default decoder
+func decodeDecimal(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if vr.Type() != bsontype.Double {
+		return bsoncodec.DefaultValueDecoders{}.Decimal128DecodeValue(dc, vr, val)
+	}
+	f, err := vr.ReadDouble()
+	if err != nil {
+		return err
+	}
+	d, err := primitive.ParseDecimal128(strconv.FormatFloat(f, 'f', -centsExponent, 64))
+	if err != nil {
+		return err
+	}
+	val.Set(reflect.ValueOf(d))
+	return nil
+}
@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRegistry_DecodesDoubles(t *testing.T) {
+	id := primitive.NewObjectID()
+	storedAccount, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "name", Value: "Dorothy"},
+		{Key: "cpf", Value: "11111111030"},
+		{Key: "balance", Value: 0.1 + 0.2},
+		{Key: "limits", Value: bson.D{
+			{Key: "per_transaction", Value: 500.0},
+			{Key: "daily", Value: 1000.5},
+			{Key: "monthly", Value: 20000.0},
+			{Key: "night_time", Value: 100.0},
+		}},
+		{Key: "created_at", Value: time.Now().UTC()},
+	})
+	if err != nil {
+		t.Fatalf("Could not marshal account: %v", err)
+	}
+	var dbAccount Account
+	if err = bson.UnmarshalWithRegistry(registry, storedAccount, &dbAccount); err != nil {
+		t.Fatalf("Expected account stored with doubles to be decoded; got %v", err)
+	}
+	account, err := toListingAccount(dbAccount)
+	if err != nil {
+		t.Fatalf("toListingAccount() err = %v", err)
+	}
+	if account.Balance != money.FromCents(30) {
+		t.Errorf("Expected balance of 0.30; got %v", account.Balance)
+	}
+	if limits := dbAccount.Limits; limits == nil || limits.Daily.String() != "1000.50" {
+		t.Errorf("Expected daily limit of 1000.50; got %v", limits)
+	}
+
+	storedTransfer, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "account_origin_id", Value: id},
+		{Key: "account_destination_id", Value: primitive.NewObjectID()},
+		{Key: "amount", Value: 11.11},
+		{Key: "status", Value: "reversed"},
+		{Key: "reversed_amount", Value: 5.0},
+		{Key: "created_at", Value: time.Now().UTC()},
+	})
+	if err != nil {
+		t.Fatalf("Could not marshal transfer: %v", err)
+	}
+	var dbTransfer Transfer
+	if err = bson.UnmarshalWithRegistry(registry, storedTransfer, &dbTransfer); err != nil {
+		t.Fatalf("Expected transfer stored with doubles to be decoded; got %v", err)
+	}
+	transfer, err := toStoredTransfer(dbTransfer)
+	if err != nil {
+		t.Fatalf("toStoredTransfer() err = %v", err)
+	}
+	if transfer.Amount != money.FromCents(1111) || transfer.ReversedAmount != money.FromCents(500) {
+		t.Errorf("Expected amount of 11.11 with 5.00 reversed; got %v", transfer)
+	}
+
+	var stillDecimal Transfer
+	storedTransfer, _ = bson.Marshal(bson.D{{Key: "amount", Value: decimalFromMoney(money.FromCents(1111))}})
+	if err = bson.UnmarshalWithRegistry(registry, storedTransfer, &stillDecimal); err != nil || stillDecimal.Amount.String() != "11.11" {
+		t.Errorf("Expected Decimal128 to be decoded as ever; got %v, %v", stillDecimal.Amount, err)
+	}
+}
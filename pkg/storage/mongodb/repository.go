@@ -2,12 +2,12 @@ package mongodb
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,14 +20,39 @@ type Storage struct {
 }
 
 const (
-	accountsCollection  = "accounts"
-	tokensCollection    = "tokens"
-	transfersCollection = "transfers"
+	accountsCollection           = "accounts"
+	tokensCollection             = "tokens"
+	refreshTokensCollection      = "refresh_tokens"
+	loginAttemptsCollection      = "login_attempts"
+	securityEventsCollection     = "security_events"
+	twoFactorsCollection         = "two_factors"
+	loginChallengesCollection    = "login_challenges"
+	passwordResetsCollection     = "password_resets"
+	transactionPINsCollection    = "transaction_pins"
+	apiClientsCollection         = "api_clients"
+	transfersCollection          = "transfers"
+	idempotencyKeysCollection    = "idempotency_keys"
+	ledgerEntriesCollection      = "ledger_entries"
+	scheduledTransfersCollection = "scheduled_transfers"
+	standingOrdersCollection     = "standing_orders"
+	// migrationsCollection records the one-off migrations that were run on the database, by name
+	migrationsCollection = "migrations"
 )
 
-var ErrCPFAlreadyExists = errors.New("this cpf could not be inserted in our DB")
-var ErrNoAccountWasFound = errors.New("no account was found with the given filter parameters")
-var ErrNoTokenWasFound = errors.New("no token was found with the given filter parameters")
+var ErrCPFAlreadyExists = storage.ErrCPFAlreadyExists
+var ErrNoAccountWasFound = storage.ErrNoAccountWasFound
+var ErrNoTokenWasFound = storage.ErrNoTokenWasFound
+var ErrNoRefreshTokenWasFound = storage.ErrNoRefreshTokenWasFound
+var ErrNoLoginAttemptsWereFound = storage.ErrNoLoginAttemptsWereFound
+var ErrNoTwoFactorWasFound = storage.ErrNoTwoFactorWasFound
+var ErrNoLoginChallengeWasFound = storage.ErrNoLoginChallengeWasFound
+var ErrNoPasswordResetWasFound = storage.ErrNoPasswordResetWasFound
+var ErrNoTransactionPINWasFound = storage.ErrNoTransactionPINWasFound
+var ErrNoAPIClientWasFound = storage.ErrNoAPIClientWasFound
+var ErrNoIdempotencyRecordWasFound = storage.ErrNoIdempotencyRecordWasFound
+var ErrNoTransferWasFound = storage.ErrNoTransferWasFound
+var ErrNoScheduledTransferWasFound = storage.ErrNoScheduledTransferWasFound
+var ErrNoStandingOrderWasFound = storage.ErrNoStandingOrderWasFound
 
 var (
 	databaseName = os.Getenv("APP_DOCUMENT_DB_NAME")
@@ -42,6 +67,112 @@ var (
 				Options: options.Index().SetUnique(true),
 			},
 		},
+		tokensCollection: {
+			{
+				Keys:    bson.M{"expires_at": 1},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+			{
+				Keys: bson.M{"family_id": 1},
+			},
+			{
+				Keys: bson.M{"client_id": 1},
+			},
+		},
+		refreshTokensCollection: {
+			{
+				// used refresh tokens are kept until they expire, so that their reuse is detected
+				Keys:    bson.M{"expires_at": 1},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+			{
+				Keys: bson.M{"family_id": 1},
+			},
+		},
+		loginAttemptsCollection: {
+			{
+				Keys:    bson.M{"expires_at": 1},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		},
+		loginChallengesCollection: {
+			{
+				Keys:    bson.M{"expires_at": 1},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		},
+		passwordResetsCollection: {
+			{
+				Keys:    bson.M{"expires_at": 1},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+			{
+				Keys: bson.M{"account_id": 1},
+			},
+		},
+		securityEventsCollection: {
+			{
+				Keys: bson.D{{Key: "key", Value: 1}, {Key: "created_at", Value: 1}},
+			},
+		},
+		transfersCollection: {
+			{
+				// serves transfer listings of either side sorted by created_at or amount, the id breaking ties of
+				// cursors, as well as the sums of sent amounts checked against limits
+				Keys: bson.D{{Key: "account_origin_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			},
+			{
+				Keys: bson.D{{Key: "account_destination_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			},
+			{
+				Keys: bson.D{{Key: "account_origin_id", Value: 1}, {Key: "amount", Value: 1}, {Key: "_id", Value: 1}},
+			},
+			{
+				Keys: bson.D{{Key: "account_destination_id", Value: 1}, {Key: "amount", Value: 1}, {Key: "_id", Value: 1}},
+			},
+		},
+		ledgerEntriesCollection: {
+			{
+				// the id orders entries created at the same time in statements
+				Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			},
+			{
+				Keys: bson.D{{Key: "transfer_id", Value: 1}},
+			},
+		},
+		scheduledTransfersCollection: {
+			{
+				Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			},
+			{
+				Keys: bson.D{{Key: "account_origin_id", Value: 1}, {Key: "scheduled_for", Value: 1}},
+			},
+			{
+				// each occurrence of a standing order is scheduled once, however many executors try to
+				Keys: bson.D{{Key: "standing_order_id", Value: 1}, {Key: "scheduled_for", Value: 1}},
+				Options: options.Index().
+					SetUnique(true).
+					SetPartialFilterExpression(bson.D{{Key: "standing_order_id", Value: bson.D{{Key: "$exists", Value: true}}}}),
+			},
+		},
+		standingOrdersCollection: {
+			{
+				Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_run_at", Value: 1}},
+			},
+			{
+				Keys: bson.M{"account_origin_id": 1},
+			},
+		},
+		idempotencyKeysCollection: {
+			{
+				Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "key", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bson.M{"expires_at": 1},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		},
 	}
 )
 
@@ -51,7 +182,7 @@ func NewStorageFromEnv() (*Storage, error) {
 	s := new(Storage)
 
 	uri := fmt.Sprintf("mongodb://%s:%s@%s:%s", username, password, host, port)
-	clientOptions := options.Client().ApplyURI(uri)
+	clientOptions := options.Client().ApplyURI(uri).SetRegistry(registry)
 	s.client, err = mongo.NewClient(clientOptions)
 	s.log = lgr.NewDefaultLogger()
 	if err != nil {
@@ -80,6 +211,18 @@ func (s *Storage) Disconnect(ctx context.Context) {
 	}
 }
 
+// withTransaction runs fn inside a session transaction, committing its writes only if fn succeeds
+func (s *Storage) withTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) (interface{}, error)) (interface{}, error) {
+	session, err := s.client.StartSession()
+	if err != nil {
+		s.log.Errorf("Err %v occurred when starting a mongodb session", err)
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	return session.WithTransaction(ctx, fn)
+}
+
 func (s *Storage) CreateIndexes(ctx context.Context) {
 	db := s.client.Database(databaseName)
 	indexCtx, cancel := context.WithTimeout(ctx, time.Second*15)
@@ -0,0 +1,44 @@
+package mongodb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScheduledTransfer struct {
+	ID                   primitive.ObjectID   `bson:"_id"`
+	OriginAccountID      primitive.ObjectID   `bson:"account_origin_id"`
+	DestinationAccountID primitive.ObjectID   `bson:"account_destination_id"`
+	Amount               primitive.Decimal128 `bson:"amount"`
+	ScheduledFor         time.Time            `bson:"scheduled_for"`
+	Status               string               `bson:"status"`
+	Attempts             int                  `bson:"attempts"`
+	LastError            string               `bson:"last_error,omitempty"`
+	TransferID           string               `bson:"transfer_id,omitempty"`
+	StandingOrderID      *primitive.ObjectID  `bson:"standing_order_id,omitempty"`
+	NextAttemptAt        time.Time            `bson:"next_attempt_at"`
+	LockedUntil          time.Time            `bson:"locked_until"`
+	CreatedAt            time.Time            `bson:"created_at"`
+}
+
+type StandingOrder struct {
+	ID                   primitive.ObjectID   `bson:"_id"`
+	OriginAccountID      primitive.ObjectID   `bson:"account_origin_id"`
+	DestinationAccountID primitive.ObjectID   `bson:"account_destination_id"`
+	Amount               primitive.Decimal128 `bson:"amount"`
+	Rule                 Rule                 `bson:"rule"`
+	StartsAt             time.Time            `bson:"starts_at"`
+	EndsAt               *time.Time           `bson:"ends_at,omitempty"`
+	MaxOccurrences       int                  `bson:"max_occurrences"`
+	Occurrences          int                  `bson:"occurrences"`
+	NextRunAt            *time.Time           `bson:"next_run_at"`
+	Status               string               `bson:"status"`
+	CreatedAt            time.Time            `bson:"created_at"`
+}
+
+type Rule struct {
+	Frequency  string `bson:"frequency"`
+	Day        int    `bson:"day,omitempty"`
+	Expression string `bson:"expression,omitempty"`
+}
@@ -0,0 +1,427 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *Storage) AddScheduledTransfer(ctx context.Context, transfer scheduling.ScheduledTransfer) (string, error) {
+	collection := s.client.Database(databaseName).Collection(scheduledTransfersCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding scheduled transfer %v to mongodb repo coll %s", transfer, collection.Name())
+	originOID, err := primitive.ObjectIDFromHex(transfer.OriginAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", transfer.OriginAccountID)
+		return "", ErrNoAccountWasFound
+	}
+	destinationOID, err := primitive.ObjectIDFromHex(transfer.DestinationAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", transfer.DestinationAccountID)
+		return "", ErrNoAccountWasFound
+	}
+
+	var standingOrderOID *primitive.ObjectID
+	if transfer.StandingOrderID != "" {
+		oid, err := primitive.ObjectIDFromHex(transfer.StandingOrderID)
+		if err != nil {
+			s.log.Errorf("Err when serializing id %s to ObjectID", transfer.StandingOrderID)
+			return "", ErrNoStandingOrderWasFound
+		}
+		standingOrderOID = &oid
+	}
+
+	dbTransfer := ScheduledTransfer{
+		ID:                   primitive.NewObjectID(),
+		OriginAccountID:      originOID,
+		DestinationAccountID: destinationOID,
+		Amount:               decimalFromMoney(transfer.Amount),
+		ScheduledFor:         transfer.ScheduledFor,
+		Status:               string(transfer.Status),
+		NextAttemptAt:        transfer.NextAttemptAt,
+		StandingOrderID:      standingOrderOID,
+		CreatedAt:            transfer.CreatedAt,
+	}
+	if _, err = collection.InsertOne(insertionCtx, dbTransfer); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			s.log.Errorf("Occurrence of standing order %s at %s was already scheduled", transfer.StandingOrderID, transfer.ScheduledFor)
+			return "", scheduling.ErrOccurrenceAlreadyScheduled
+		}
+		s.log.Errorf("Unexpected err %v when adding scheduled transfer %s", err, dbTransfer.ID.Hex())
+		return "", err
+	}
+	return dbTransfer.ID.Hex(), nil
+}
+
+func (s *Storage) GetScheduledTransfersByAccountID(ctx context.Context, accountID string) ([]scheduling.ScheduledTransfer, error) {
+	collection := s.client.Database(databaseName).Collection(scheduledTransfersCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	s.log.Infof("Retrieving scheduled transfers of account %s of mongodb repo coll %s", accountID, collection.Name())
+	transfers := make([]scheduling.ScheduledTransfer, 0)
+	oid, _ := primitive.ObjectIDFromHex(accountID)
+	findOptions := options.Find().SetSort(bson.D{{Key: "scheduled_for", Value: 1}})
+	cur, err := collection.Find(queryCtx, bson.D{{Key: "account_origin_id", Value: oid}}, findOptions)
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving scheduled transfers of account %s", err, accountID)
+		return transfers, err
+	}
+	defer func() {
+		if closeErr := cur.Close(queryCtx); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
+	for cur.Next(queryCtx) {
+		var t ScheduledTransfer
+		if err = cur.Decode(&t); err != nil {
+			s.log.Errorf("Err %v occurred when decoding scheduled transfer from mongo repo", err)
+			continue
+		}
+		transfer, convErr := toScheduledTransfer(t)
+		if convErr != nil {
+			s.log.Errorf("Err %v occurred when converting scheduled transfer %s from mongo repo", convErr, t.ID.Hex())
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, nil
+}
+
+func (s *Storage) CancelScheduledTransfer(ctx context.Context, accountID string, id string) error {
+	collection := s.client.Database(databaseName).Collection(scheduledTransfersCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Cancelling scheduled transfer %s of account %s of mongodb repo coll %s", id, accountID, collection.Name())
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", id)
+		return ErrNoScheduledTransferWasFound
+	}
+	accountOID, _ := primitive.ObjectIDFromHex(accountID)
+	filter := bson.D{{Key: "_id", Value: oid}, {Key: "account_origin_id", Value: accountOID}}
+
+	result, err := collection.UpdateOne(
+		updateCtx,
+		append(filter, bson.E{Key: "status", Value: string(scheduling.Scheduled)}),
+		bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: string(scheduling.Cancelled)}}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when cancelling scheduled transfer %s", err, id)
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := collection.CountDocuments(updateCtx, filter)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when retrieving scheduled transfer %s", err, id)
+		return err
+	}
+	if count == 0 {
+		s.log.Errorf("No scheduled transfer was found with id %s", id)
+		return ErrNoScheduledTransferWasFound
+	}
+	s.log.Errorf("Scheduled transfer %s is no longer scheduled", id)
+	return scheduling.ErrNotCancellable
+}
+
+func (s *Storage) ClaimDueScheduledTransfer(ctx context.Context, now time.Time, lockedUntil time.Time) (scheduling.ScheduledTransfer, error) {
+	collection := s.client.Database(databaseName).Collection(scheduledTransfersCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	// a single document update is atomic, so among concurrent executors only one gets to claim each transfer
+	var t ScheduledTransfer
+	err := collection.FindOneAndUpdate(
+		updateCtx,
+		bson.D{
+			{Key: "status", Value: string(scheduling.Scheduled)},
+			{Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: now}}},
+		},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: string(scheduling.Processing)},
+				{Key: "locked_until", Value: lockedUntil},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return scheduling.ScheduledTransfer{}, scheduling.ErrNoScheduledTransferDue
+		}
+		s.log.Errorf("Unexpected err %v when claiming due scheduled transfers of mongodb repo coll %s", err, collection.Name())
+		return scheduling.ScheduledTransfer{}, err
+	}
+
+	s.log.Infof("Claimed scheduled transfer %s of mongodb repo coll %s until %s", t.ID.Hex(), collection.Name(), lockedUntil)
+	return toScheduledTransfer(t)
+}
+
+func (s *Storage) FinishScheduledTransfer(ctx context.Context, transfer scheduling.ScheduledTransfer) error {
+	collection := s.client.Database(databaseName).Collection(scheduledTransfersCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Finishing scheduled transfer %s of mongodb repo coll %s as %s", transfer.ID, collection.Name(), transfer.Status)
+	oid, err := primitive.ObjectIDFromHex(transfer.ID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", transfer.ID)
+		return ErrNoScheduledTransferWasFound
+	}
+	result, err := collection.UpdateOne(
+		updateCtx,
+		bson.D{{Key: "_id", Value: oid}, {Key: "status", Value: string(scheduling.Processing)}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: string(transfer.Status)},
+			{Key: "last_error", Value: transfer.LastError},
+			{Key: "transfer_id", Value: transfer.TransferID},
+			{Key: "next_attempt_at", Value: transfer.NextAttemptAt},
+			{Key: "locked_until", Value: time.Time{}},
+		}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when finishing scheduled transfer %s", err, transfer.ID)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		s.log.Errorf("Scheduled transfer %s is no longer processing", transfer.ID)
+		return scheduling.ErrNotProcessing
+	}
+	return nil
+}
+
+func (s *Storage) FailExpiredScheduledTransfers(ctx context.Context, now time.Time) (int, error) {
+	collection := s.client.Database(databaseName).Collection(scheduledTransfersCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	result, err := collection.UpdateMany(
+		updateCtx,
+		bson.D{
+			{Key: "status", Value: string(scheduling.Processing)},
+			{Key: "locked_until", Value: bson.D{{Key: "$lt", Value: now}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: string(scheduling.Failed)},
+			{Key: "last_error", Value: scheduling.ErrInterrupted.Error()},
+			{Key: "locked_until", Value: time.Time{}},
+		}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when failing expired scheduled transfers of mongodb repo coll %s", err, collection.Name())
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+func toScheduledTransfer(t ScheduledTransfer) (scheduling.ScheduledTransfer, error) {
+	amount, err := moneyFromDecimal(t.Amount)
+	if err != nil {
+		return scheduling.ScheduledTransfer{}, err
+	}
+	transfer := scheduling.ScheduledTransfer{
+		ID:                   t.ID.Hex(),
+		OriginAccountID:      t.OriginAccountID.Hex(),
+		DestinationAccountID: t.DestinationAccountID.Hex(),
+		Amount:               amount,
+		ScheduledFor:         t.ScheduledFor,
+		Status:               scheduling.Status(t.Status),
+		Attempts:             t.Attempts,
+		LastError:            t.LastError,
+		TransferID:           t.TransferID,
+		NextAttemptAt:        t.NextAttemptAt,
+		LockedUntil:          t.LockedUntil,
+		CreatedAt:            t.CreatedAt,
+	}
+	if t.StandingOrderID != nil {
+		transfer.StandingOrderID = t.StandingOrderID.Hex()
+	}
+	return transfer, nil
+}
+
+func (s *Storage) AddStandingOrder(ctx context.Context, order scheduling.StandingOrder) (string, error) {
+	collection := s.client.Database(databaseName).Collection(standingOrdersCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding standing order %v to mongodb repo coll %s", order, collection.Name())
+	originOID, err := primitive.ObjectIDFromHex(order.OriginAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", order.OriginAccountID)
+		return "", ErrNoAccountWasFound
+	}
+	destinationOID, err := primitive.ObjectIDFromHex(order.DestinationAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", order.DestinationAccountID)
+		return "", ErrNoAccountWasFound
+	}
+
+	dbOrder := StandingOrder{
+		ID:                   primitive.NewObjectID(),
+		OriginAccountID:      originOID,
+		DestinationAccountID: destinationOID,
+		Amount:               decimalFromMoney(order.Amount),
+		Rule: Rule{
+			Frequency:  string(order.Rule.Frequency),
+			Day:        order.Rule.Day,
+			Expression: order.Rule.Expression,
+		},
+		StartsAt:       order.StartsAt,
+		EndsAt:         order.EndsAt,
+		MaxOccurrences: order.MaxOccurrences,
+		Occurrences:    order.Occurrences,
+		NextRunAt:      order.NextRunAt,
+		Status:         string(order.Status),
+		CreatedAt:      order.CreatedAt,
+	}
+	if _, err = collection.InsertOne(insertionCtx, dbOrder); err != nil {
+		s.log.Errorf("Unexpected err %v when adding standing order %s", err, dbOrder.ID.Hex())
+		return "", err
+	}
+	return dbOrder.ID.Hex(), nil
+}
+
+func (s *Storage) GetStandingOrdersByAccountID(ctx context.Context, accountID string) ([]scheduling.StandingOrder, error) {
+	s.log.Infof("Retrieving standing orders of account %s of mongodb repo coll %s", accountID, standingOrdersCollection)
+	oid, _ := primitive.ObjectIDFromHex(accountID)
+	return s.findStandingOrders(ctx, bson.D{{Key: "account_origin_id", Value: oid}})
+}
+
+func (s *Storage) GetDueStandingOrders(ctx context.Context, now time.Time) ([]scheduling.StandingOrder, error) {
+	return s.findStandingOrders(ctx, bson.D{
+		{Key: "status", Value: string(scheduling.OrderActive)},
+		{Key: "next_run_at", Value: bson.D{{Key: "$lte", Value: now}}},
+	})
+}
+
+func (s *Storage) UpdateStandingOrder(ctx context.Context, accountID string, id string, updateFn scheduling.StandingOrderFunc) (scheduling.StandingOrder, error) {
+	collection := s.client.Database(databaseName).Collection(standingOrdersCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Updating standing order %s of account %s of mongodb repo coll %s", id, accountID, collection.Name())
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", id)
+		return scheduling.StandingOrder{}, ErrNoStandingOrderWasFound
+	}
+	accountOID, _ := primitive.ObjectIDFromHex(accountID)
+
+	var dbOrder StandingOrder
+	err = collection.FindOne(updateCtx, bson.D{{Key: "_id", Value: oid}, {Key: "account_origin_id", Value: accountOID}}).Decode(&dbOrder)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Errorf("No standing order was found with id %s", id)
+			return scheduling.StandingOrder{}, ErrNoStandingOrderWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving standing order %s", err, id)
+		return scheduling.StandingOrder{}, err
+	}
+	stored, err := toStandingOrder(dbOrder)
+	if err != nil {
+		return scheduling.StandingOrder{}, err
+	}
+	updated, err := updateFn(stored)
+	if err != nil {
+		s.log.Errorf("Standing order %s was not updated due to err %v", id, err)
+		return stored, err
+	}
+
+	// the update only applies over the very state updateFn was given
+	result, err := collection.UpdateOne(
+		updateCtx,
+		bson.D{
+			{Key: "_id", Value: oid},
+			{Key: "status", Value: dbOrder.Status},
+			{Key: "next_run_at", Value: dbOrder.NextRunAt},
+			{Key: "occurrences", Value: dbOrder.Occurrences},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: string(updated.Status)},
+			{Key: "next_run_at", Value: updated.NextRunAt},
+			{Key: "occurrences", Value: updated.Occurrences},
+		}}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when updating standing order %s", err, id)
+		return stored, err
+	}
+	if result.MatchedCount == 0 {
+		s.log.Errorf("Standing order %s was changed while it was updated", id)
+		return stored, scheduling.ErrStandingOrderChanged
+	}
+	return updated, nil
+}
+
+func (s *Storage) findStandingOrders(ctx context.Context, filter bson.D) ([]scheduling.StandingOrder, error) {
+	collection := s.client.Database(databaseName).Collection(standingOrdersCollection)
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	orders := make([]scheduling.StandingOrder, 0)
+	cur, err := collection.Find(queryCtx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		s.log.Errorf("Err %v occurred when retrieving standing orders by %v", err, filter)
+		return orders, err
+	}
+	defer func() {
+		if closeErr := cur.Close(queryCtx); closeErr != nil {
+			s.log.Errorf("Err %v occurred when closing cursor", closeErr)
+		}
+	}()
+
+	for cur.Next(queryCtx) {
+		var o StandingOrder
+		if err = cur.Decode(&o); err != nil {
+			s.log.Errorf("Err %v occurred when decoding standing order from mongo repo", err)
+			continue
+		}
+		order, convErr := toStandingOrder(o)
+		if convErr != nil {
+			s.log.Errorf("Err %v occurred when converting standing order %s from mongo repo", convErr, o.ID.Hex())
+			continue
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func toStandingOrder(o StandingOrder) (scheduling.StandingOrder, error) {
+	amount, err := moneyFromDecimal(o.Amount)
+	if err != nil {
+		return scheduling.StandingOrder{}, err
+	}
+	return scheduling.StandingOrder{
+		ID:                   o.ID.Hex(),
+		OriginAccountID:      o.OriginAccountID.Hex(),
+		DestinationAccountID: o.DestinationAccountID.Hex(),
+		Amount:               amount,
+		Rule: scheduling.Rule{
+			Frequency:  scheduling.Frequency(o.Rule.Frequency),
+			Day:        o.Rule.Day,
+			Expression: o.Rule.Expression,
+		},
+		StartsAt:       o.StartsAt,
+		EndsAt:         o.EndsAt,
+		MaxOccurrences: o.MaxOccurrences,
+		Occurrences:    o.Occurrences,
+		NextRunAt:      o.NextRunAt,
+		Status:         scheduling.OrderStatus(o.Status),
+		CreatedAt:      o.CreatedAt,
+	}, nil
+}
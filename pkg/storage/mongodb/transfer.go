@@ -6,10 +6,19 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Transfer Status is missing on documents stored before it existed, all of them being completed transfers
 type Transfer struct {
-	ID                   primitive.ObjectID `bson:"_id"`
-	OriginAccountID      primitive.ObjectID `bson:"account_origin_id"`
-	DestinationAccountID primitive.ObjectID `bson:"account_destination_id"`
-	Amount               float64            `bson:"amount"`
-	CreatedAt            time.Time          `bson:"created_at"`
+	ID                   primitive.ObjectID    `bson:"_id"`
+	OriginAccountID      primitive.ObjectID    `bson:"account_origin_id"`
+	DestinationAccountID primitive.ObjectID    `bson:"account_destination_id"`
+	Amount               primitive.Decimal128  `bson:"amount"`
+	Status               string                `bson:"status"`
+	FailureReason        string                `bson:"failure_reason,omitempty"`
+	ReversedAmount       *primitive.Decimal128 `bson:"reversed_amount,omitempty"`
+	ReversalOf           *primitive.ObjectID   `bson:"reversal_of,omitempty"`
+	StandingOrderID      *primitive.ObjectID   `bson:"standing_order_id,omitempty"`
+	CreatedAt            time.Time             `bson:"created_at"`
+	CompletedAt          *time.Time            `bson:"completed_at,omitempty"`
+	FailedAt             *time.Time            `bson:"failed_at,omitempty"`
+	ReversedAt           *time.Time            `bson:"reversed_at,omitempty"`
 }
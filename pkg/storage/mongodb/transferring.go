@@ -0,0 +1,312 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *Storage) AddPendingTransfer(ctx context.Context, transfer transferring.Transfer) (string, error) {
+	collection := s.client.Database(databaseName).Collection(transfersCollection)
+	insertionCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Adding pending transfer %v to mongodb repo coll %s", transfer, collection.Name())
+	originOID, err := primitive.ObjectIDFromHex(transfer.OriginAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", transfer.OriginAccountID)
+		return "", ErrNoAccountWasFound
+	}
+	destinationOID, err := primitive.ObjectIDFromHex(transfer.DestinationAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", transfer.DestinationAccountID)
+		return "", ErrNoAccountWasFound
+	}
+
+	dbTransfer := Transfer{
+		ID:                   primitive.NewObjectID(),
+		OriginAccountID:      originOID,
+		DestinationAccountID: destinationOID,
+		Amount:               decimalFromMoney(transfer.Amount),
+		Status:               string(transferstatus.Pending),
+		CreatedAt:            transfer.CreatedAt,
+	}
+	if transfer.StandingOrderID != "" {
+		standingOrderOID, err := primitive.ObjectIDFromHex(transfer.StandingOrderID)
+		if err != nil {
+			s.log.Errorf("Err when serializing id %s to ObjectID", transfer.StandingOrderID)
+			return "", ErrNoStandingOrderWasFound
+		}
+		dbTransfer.StandingOrderID = &standingOrderOID
+	}
+	if _, err = collection.InsertOne(insertionCtx, dbTransfer); err != nil {
+		s.log.Errorf("Unexpected err %v when adding transfer %s of origin account %s", err, dbTransfer.ID, dbTransfer.OriginAccountID)
+		return "", err
+	}
+	return dbTransfer.ID.Hex(), nil
+}
+
+func (s *Storage) ExecuteTransfer(ctx context.Context, id string, transfer transferring.Transfer, balanceFn transferring.BalanceFunc) error {
+	db := s.client.Database(databaseName)
+	accounts := db.Collection(accountsCollection)
+	transfers := db.Collection(transfersCollection)
+	txnCtx, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	s.log.Infof("Executing transfer %s as a transaction over colls %s and %s", id, accounts.Name(), transfers.Name())
+	transferOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", id)
+		return ErrNoTransferWasFound
+	}
+	originOID, err := primitive.ObjectIDFromHex(transfer.OriginAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", transfer.OriginAccountID)
+		return ErrNoAccountWasFound
+	}
+	destinationOID, err := primitive.ObjectIDFromHex(transfer.DestinationAccountID)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", transfer.DestinationAccountID)
+		return ErrNoAccountWasFound
+	}
+
+	_, err = s.withTransaction(txnCtx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		origin, findErr := s.findAccountByOID(sessCtx, accounts, originOID)
+		if findErr != nil {
+			return nil, findErr
+		}
+		destination, findErr := s.findAccountByOID(sessCtx, accounts, destinationOID)
+		if findErr != nil {
+			return nil, findErr
+		}
+
+		originBalance, convErr := moneyFromDecimal(origin.Balance)
+		if convErr != nil {
+			return nil, convErr
+		}
+		destinationBalance, convErr := moneyFromDecimal(destination.Balance)
+		if convErr != nil {
+			return nil, convErr
+		}
+
+		newOriginBalance, newDestinationBalance, balanceErr := balanceFn(originBalance, destinationBalance)
+		if balanceErr != nil {
+			return nil, balanceErr
+		}
+
+		if updtErr := s.setBalance(sessCtx, accounts, originOID, newOriginBalance); updtErr != nil {
+			return nil, updtErr
+		}
+		if updtErr := s.setBalance(sessCtx, accounts, destinationOID, newDestinationBalance); updtErr != nil {
+			return nil, updtErr
+		}
+
+		now := time.Now().UTC()
+		if transitionErr := s.transitionTransfer(sessCtx, transfers, transferOID, transferstatus.Pending, transferstatus.Completed, now, nil); transitionErr != nil {
+			return nil, transitionErr
+		}
+		entries := ledger.TransferEntries(id, transfer.OriginAccountID, transfer.DestinationAccountID, transfer.Amount, now)
+		return nil, s.insertEntries(sessCtx, entries)
+	})
+	if err != nil {
+		s.log.Errorf("Transfer %s was not committed due to err %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) ExecuteReversal(ctx context.Context, id string, reversalFn transferring.ReversalFunc) (string, error) {
+	db := s.client.Database(databaseName)
+	accounts := db.Collection(accountsCollection)
+	transfers := db.Collection(transfersCollection)
+	txnCtx, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	s.log.Infof("Executing reversal of transfer %s as a transaction over colls %s and %s", id, accounts.Name(), transfers.Name())
+	originalOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", id)
+		return "", ErrNoTransferWasFound
+	}
+
+	reversalID, err := s.withTransaction(txnCtx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		var original Transfer
+		if findErr := transfers.FindOne(sessCtx, bson.D{{Key: "_id", Value: originalOID}}).Decode(&original); findErr != nil {
+			if findErr == mongo.ErrNoDocuments {
+				s.log.Errorf("No transfer was found with id %s", id)
+				return nil, ErrNoTransferWasFound
+			}
+			return nil, findErr
+		}
+		stored, convErr := toStoredTransfer(original)
+		if convErr != nil {
+			return nil, convErr
+		}
+		compensating, balanceFn, reversalErr := reversalFn(stored)
+		if reversalErr != nil {
+			return nil, reversalErr
+		}
+
+		// the compensating transfer goes back from the original destination to its origin
+		originOID, destinationOID := original.DestinationAccountID, original.OriginAccountID
+		origin, findErr := s.findAccountByOID(sessCtx, accounts, originOID)
+		if findErr != nil {
+			return nil, findErr
+		}
+		destination, findErr := s.findAccountByOID(sessCtx, accounts, destinationOID)
+		if findErr != nil {
+			return nil, findErr
+		}
+		originBalance, convErr := moneyFromDecimal(origin.Balance)
+		if convErr != nil {
+			return nil, convErr
+		}
+		destinationBalance, convErr := moneyFromDecimal(destination.Balance)
+		if convErr != nil {
+			return nil, convErr
+		}
+		newOriginBalance, newDestinationBalance, balanceErr := balanceFn(originBalance, destinationBalance)
+		if balanceErr != nil {
+			return nil, balanceErr
+		}
+		if updtErr := s.setBalance(sessCtx, accounts, originOID, newOriginBalance); updtErr != nil {
+			return nil, updtErr
+		}
+		if updtErr := s.setBalance(sessCtx, accounts, destinationOID, newDestinationBalance); updtErr != nil {
+			return nil, updtErr
+		}
+
+		now := time.Now().UTC()
+		reversedAmount := decimalFromMoney(stored.ReversedAmount + compensating.Amount)
+		set := bson.D{{Key: "reversed_amount", Value: reversedAmount}}
+		if stored.ReversedAmount+compensating.Amount == stored.Amount {
+			if transitionErr := s.transitionTransfer(sessCtx, transfers, originalOID, transferstatus.Completed, transferstatus.Reversed, now, set); transitionErr != nil {
+				return nil, transitionErr
+			}
+		} else if _, updtErr := transfers.UpdateOne(sessCtx, bson.D{{Key: "_id", Value: originalOID}}, bson.D{{Key: "$set", Value: set}}); updtErr != nil {
+			s.log.Errorf("Unexpected err %v when updating reversed amount of transfer %s", updtErr, id)
+			return nil, updtErr
+		}
+
+		dbReversal := Transfer{
+			ID:                   primitive.NewObjectID(),
+			OriginAccountID:      originOID,
+			DestinationAccountID: destinationOID,
+			Amount:               decimalFromMoney(compensating.Amount),
+			Status:               string(transferstatus.Completed),
+			ReversalOf:           &originalOID,
+			CreatedAt:            compensating.CreatedAt,
+			CompletedAt:          &now,
+		}
+		if _, insertErr := transfers.InsertOne(sessCtx, dbReversal); insertErr != nil {
+			s.log.Errorf("Unexpected err %v when adding reversal %s of transfer %s", insertErr, dbReversal.ID, id)
+			return nil, insertErr
+		}
+		entries := ledger.TransferEntries(dbReversal.ID.Hex(), originOID.Hex(), destinationOID.Hex(), compensating.Amount, now)
+		if entriesErr := s.insertEntries(sessCtx, entries); entriesErr != nil {
+			return nil, entriesErr
+		}
+		return dbReversal.ID.Hex(), nil
+	})
+	if err != nil {
+		s.log.Errorf("Reversal of transfer %s was not committed due to err %v", id, err)
+		return "", err
+	}
+	return reversalID.(string), nil
+}
+
+func (s *Storage) FailTransfer(ctx context.Context, id string, reason transferstatus.Reason) error {
+	collection := s.client.Database(databaseName).Collection(transfersCollection)
+	updateCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	s.log.Infof("Failing transfer %s of mongodb repo coll %s due to %s", id, collection.Name(), reason)
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		s.log.Errorf("Err when serializing id %s to ObjectID", id)
+		return ErrNoTransferWasFound
+	}
+	set := bson.D{{Key: "failure_reason", Value: string(reason)}}
+	return s.transitionTransfer(updateCtx, collection, oid, transferstatus.Pending, transferstatus.Failed, time.Now().UTC(), set)
+}
+
+// transitionTransfer moves the transfer oid from status from to status to only if it still is in from,
+// stamping the transition time along with any other field in set
+func (s *Storage) transitionTransfer(ctx context.Context, collection *mongo.Collection, oid primitive.ObjectID, from transferstatus.Status, to transferstatus.Status, at time.Time, set bson.D) error {
+	if !from.CanTransitionTo(to) {
+		return transferstatus.ErrInvalidTransition
+	}
+	set = append(set, bson.E{Key: "status", Value: string(to)}, bson.E{Key: string(to) + "_at", Value: at})
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: oid}, {Key: "status", Value: statusFilter(from)}},
+		bson.D{{Key: "$set", Value: set}},
+	)
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when moving transfer %s to %s", err, oid.Hex(), to)
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.D{{Key: "_id", Value: oid}})
+	if err != nil {
+		s.log.Errorf("Unexpected err %v when retrieving transfer %s", err, oid.Hex())
+		return err
+	}
+	if count == 0 {
+		s.log.Errorf("No transfer was found with id %s", oid.Hex())
+		return ErrNoTransferWasFound
+	}
+	s.log.Errorf("Transfer %s can't be moved from a status other than %s to %s", oid.Hex(), from, to)
+	return transferstatus.ErrInvalidTransition
+}
+
+// statusFilter matches transfers of the given status, also matching the ones stored before status existed as completed
+func statusFilter(status transferstatus.Status) bson.D {
+	values := bson.A{string(status)}
+	if status == transferstatus.Completed {
+		values = append(values, nil)
+	}
+	return bson.D{{Key: "$in", Value: values}}
+}
+
+func toStoredTransfer(t Transfer) (transferring.StoredTransfer, error) {
+	transfer, err := toListingTransfer(t)
+	if err != nil {
+		return transferring.StoredTransfer{}, err
+	}
+	return transferring.StoredTransfer{
+		Transfer: transferring.Transfer{
+			OriginAccountID:      transfer.OriginAccountID,
+			DestinationAccountID: transfer.DestinationAccountID,
+			Amount:               transfer.Amount,
+			CreatedAt:            transfer.CreatedAt,
+		},
+		ID:             transfer.ID,
+		Status:         transfer.Status,
+		ReversedAmount: transfer.ReversedAmount,
+		ReversalOf:     transfer.ReversalOf,
+	}, nil
+}
+
+func (s *Storage) findAccountByOID(ctx context.Context, collection *mongo.Collection, oid primitive.ObjectID) (Account, error) {
+	var account Account
+	result := collection.FindOne(ctx, bson.D{{Key: "_id", Value: oid}})
+	if err := result.Decode(&account); err != nil {
+		if err == mongo.ErrNoDocuments {
+			s.log.Errorf("No account was found with id %s", oid.Hex())
+			return Account{}, ErrNoAccountWasFound
+		}
+		s.log.Errorf("Unexpected err %v when retrieving account %s", err, oid.Hex())
+		return Account{}, err
+	}
+	return account, nil
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/pedroyremolo/transfer-api/pkg/ledger"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 	"github.com/pedroyremolo/transfer-api/pkg/updating"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (s *Storage) UpdateAccounts(ctx context.Context, accounts []updating.Account) error {
@@ -16,54 +19,44 @@ func (s *Storage) UpdateAccounts(ctx context.Context, accounts []updating.Accoun
 	s.log.Infof("Updating %v accounts of mongo repo coll %s", len(accounts), collection.Name())
 	updatesSessCtx, cancel := context.WithTimeout(ctx, time.Second*15)
 	defer cancel()
-	for _, account := range accounts {
-		id, decodeErr := primitive.ObjectIDFromHex(account.ID)
-		if decodeErr != nil {
-			return decodeErr
-		}
-		result, updtErr := collection.UpdateOne(
-			updatesSessCtx,
-			bson.D{{Key: "_id", Value: id}},
-			bson.D{{Key: "$set", Value: bson.D{{Key: "balance", Value: account.Balance}}}},
-		)
-		if updtErr != nil || result.ModifiedCount == 0 {
-			s.log.Errorf("Failed to update account %s", account.ID)
-			return fmt.Errorf("failed to update account %s", id)
+
+	now := time.Now().UTC()
+	_, err := s.withTransaction(updatesSessCtx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		var entries []ledger.Entry
+		for _, account := range accounts {
+			id, decodeErr := primitive.ObjectIDFromHex(account.ID)
+			if decodeErr != nil {
+				return nil, decodeErr
+			}
+			current, findErr := s.findAccountByOID(sessCtx, collection, id)
+			if findErr != nil {
+				return nil, findErr
+			}
+			currentBalance, convErr := moneyFromDecimal(current.Balance)
+			if convErr != nil {
+				return nil, convErr
+			}
+			if updtErr := s.setBalance(sessCtx, collection, id, account.Balance); updtErr != nil {
+				return nil, updtErr
+			}
+			entries = append(entries, ledger.AdjustmentEntries(account.ID, currentBalance, account.Balance, now)...)
 		}
-	}
-	// TODO Realize how to make it work (atomic transaction)
-	//updatesCb := func(sessCtx mongo.SessionContext) (interface{}, error) {
-	//	for _, account := range accounts {
-	//		id, decodeErr := primitive.ObjectIDFromHex(account.ID)
-	//		if decodeErr != nil {
-	//			_ = sessCtx.AbortTransaction(sessCtx)
-	//			return nil, decodeErr
-	//		}
-	//		result, updtErr := collection.UpdateOne(
-	//			sessCtx,
-	//			bson.D{{"_id", id}},
-	//			bson.D{{"$set", bson.D{{"balance", account.Balance}}}},
-	//		)
-	//		if updtErr != nil || result.ModifiedCount == 0 {
-	//			_ = sessCtx.AbortTransaction(sessCtx)
-	//			return nil, updtErr
-	//		}
-	//	}
-	//
-	//	return nil, nil
-	//}
-	//
-	//session, err := s.client.StartSession()
-	//if err != nil {
-	//	return err
-	//}
-	//defer session.EndSession(updatesSessCtx)
-	//
-	//_, err = session.WithTransaction(updatesSessCtx, updatesCb)
-	//if err != nil {
-	//
-	//	return err
-	//}
+		// balances are set through adjustment entries so they can still be rebuilt from the ledger
+		return nil, s.insertEntries(sessCtx, entries)
+	})
 
+	return err
+}
+
+func (s *Storage) setBalance(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, balance money.Money) error {
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "balance", Value: decimalFromMoney(balance)}}}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		s.log.Errorf("Failed to update account %s", id.Hex())
+		return fmt.Errorf("failed to update account %s", id.Hex())
+	}
 	return nil
 }
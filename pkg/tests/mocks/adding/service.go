@@ -14,7 +14,3 @@ type MockService struct {
 func (s *MockService) AddAccount(_ context.Context, _ adding.Account) (string, error) {
 	return s.ID, s.Err
 }
-
-func (s *MockService) AddTransfer(_ context.Context, _ adding.Transfer) (string, error) {
-	return s.ID, s.Err
-}
@@ -1,6 +1,10 @@
 package authenticating
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+)
 
 type HandlerMock struct {
 }
@@ -9,6 +13,78 @@ func (h HandlerMock) Login(w http.ResponseWriter, r *http.Request) {
 	panic("not implemented") // TODO: Implement
 }
 
-func (h HandlerMock) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+func (h HandlerMock) Authenticate(_ authenticating.Scope, next http.HandlerFunc) http.HandlerFunc {
 	return next
 }
+
+func (h HandlerMock) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) Logout(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) JWKS(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) Unlock(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) LoginTOTP(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) SetPIN(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) DisablePIN(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) OAuthToken(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) Introspect(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) Sessions(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
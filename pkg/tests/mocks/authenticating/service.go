@@ -2,19 +2,198 @@ package authenticating
 
 import (
 	"context"
+	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg/authenticating"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type MockService struct {
 	Token authenticating.Token
-	Err   error
+	// RefreshToken is the refresh token last exchanged
+	RefreshToken string
+	// LoggedOut is the token last logged out
+	LoggedOut primitive.ObjectID
+	Keys      authenticating.JWKSet
+	// Login and SecretDigest are the ones last signed
+	Login        authenticating.Login
+	SecretDigest string
+	// UnlockedCPF and UnlockedBy are the cpf last unlocked and the operator who did it
+	UnlockedCPF string
+	UnlockedBy  string
+	// Locked is until when the login of every cpf is locked out
+	Locked time.Time
+	// TOTPLogin is the second step of a login last signed
+	TOTPLogin     authenticating.TOTPLogin
+	Enrollment    authenticating.TOTPEnrollment
+	RecoveryCodes []string
+	// TOTPCode is the code last checked or confirmed
+	TOTPCode string
+	// PasswordChange is the change last made, along with SecretDigest
+	PasswordChange authenticating.PasswordChange
+	// ResetRecipient is the recipient of the password reset last requested
+	ResetRecipient authenticating.Recipient
+	// ThrottledCPF and ThrottledIP are the ones of the password reset last throttled, the throttle failing with
+	// ThrottleErr
+	ThrottledCPF string
+	ThrottledIP  string
+	ThrottleErr  error
+	// ResetToken and NewSecret are the ones of the password reset last made
+	ResetToken string
+	NewSecret  string
+	// PINChange is the pin change last made, along with SecretDigest
+	PINChange authenticating.PINChange
+	// PIN is the transaction pin last checked, the check failing with PINErr
+	PIN    string
+	PINErr error
+	// Authorization is the transfer authorization last made, checking its pin and, above the threshold, its TOTP code
+	Authorization authenticating.TransferAuthorization
+	// Client is the API client registered, and Credentials the ones last signed
+	Client      authenticating.APIClient
+	Credentials authenticating.ClientCredentials
+	// Introspected is the token last introspected
+	Introspected string
+	SessionList  []authenticating.Session
+	// CurrentToken is the token the sessions were last listed by, and RevokedSession the session last revoked
+	CurrentToken   primitive.ObjectID
+	RevokedSession string
+	// SessionsOf is the account whose sessions were last listed or revoked
+	SessionsOf string
+	Err        error
 }
 
-func (m *MockService) Sign(_ context.Context, _ authenticating.Login, _ string, _ string) (authenticating.Token, error) {
+func (m *MockService) Sign(_ context.Context, login authenticating.Login, secretDigest string, _ string, _ authenticating.Role) (authenticating.Token, error) {
+	m.Login = login
+	m.SecretDigest = secretDigest
 	return m.Token, m.Err
 }
 
 func (m *MockService) Verify(_ context.Context, _ string) (authenticating.Token, error) {
 	return m.Token, m.Err
 }
+
+func (m *MockService) Refresh(_ context.Context, refreshToken string) (authenticating.Token, error) {
+	m.RefreshToken = refreshToken
+	return m.Token, m.Err
+}
+
+func (m *MockService) Logout(_ context.Context, id primitive.ObjectID) error {
+	m.LoggedOut = id
+	return m.Err
+}
+
+func (m *MockService) PublicKeys(_ context.Context) authenticating.JWKSet {
+	return m.Keys
+}
+
+func (m *MockService) Unlock(_ context.Context, cpf string, operatorID string) error {
+	m.UnlockedCPF = cpf
+	m.UnlockedBy = operatorID
+	return m.Err
+}
+
+func (m *MockService) LockedUntil(_ context.Context, _ string) (time.Time, error) {
+	return m.Locked, m.Err
+}
+
+func (m *MockService) SignTOTP(_ context.Context, login authenticating.TOTPLogin) (authenticating.Token, error) {
+	m.TOTPLogin = login
+	return m.Token, m.Err
+}
+
+func (m *MockService) EnrollTOTP(_ context.Context, _ string, _ string) (authenticating.TOTPEnrollment, error) {
+	return m.Enrollment, m.Err
+}
+
+func (m *MockService) ConfirmTOTP(_ context.Context, _ string, code string) ([]string, error) {
+	m.TOTPCode = code
+	return m.RecoveryCodes, m.Err
+}
+
+func (m *MockService) CheckTOTP(_ context.Context, _ string, code string) error {
+	m.TOTPCode = code
+	return m.Err
+}
+
+func (m *MockService) ChangePassword(_ context.Context, change authenticating.PasswordChange, secretDigest string) error {
+	m.PasswordChange = change
+	m.SecretDigest = secretDigest
+	return m.Err
+}
+
+func (m *MockService) ThrottlePasswordReset(_ context.Context, cpf string, ip string) error {
+	m.ThrottledCPF = cpf
+	m.ThrottledIP = ip
+	return m.ThrottleErr
+}
+
+func (m *MockService) RequestPasswordReset(_ context.Context, recipient authenticating.Recipient) error {
+	m.ResetRecipient = recipient
+	return m.Err
+}
+
+func (m *MockService) ResetPassword(_ context.Context, token string, newSecret string) error {
+	m.ResetToken = token
+	m.NewSecret = newSecret
+	return m.Err
+}
+
+func (m *MockService) SetPIN(_ context.Context, change authenticating.PINChange, secretDigest string) error {
+	m.PINChange = change
+	m.SecretDigest = secretDigest
+	return m.Err
+}
+
+func (m *MockService) DisablePIN(_ context.Context, change authenticating.PINChange, secretDigest string) error {
+	m.PINChange = change
+	m.SecretDigest = secretDigest
+	return m.Err
+}
+
+func (m *MockService) AuthorizeTransfer(ctx context.Context, authorization authenticating.TransferAuthorization, totpThreshold money.Money) error {
+	m.Authorization = authorization
+	if err := m.CheckPIN(ctx, authorization.AccountID, authorization.PIN); err != nil {
+		return err
+	}
+	if authorization.Amount <= totpThreshold {
+		return nil
+	}
+	return m.CheckTOTP(ctx, authorization.AccountID, authorization.TOTPCode)
+}
+
+func (m *MockService) CheckPIN(_ context.Context, _ string, pin string) error {
+	m.PIN = pin
+	return m.PINErr
+}
+
+func (m *MockService) RegisterClient(_ context.Context, _ string, _ []authenticating.Scope) (authenticating.APIClient, error) {
+	return m.Client, m.Err
+}
+
+func (m *MockService) SignClient(_ context.Context, credentials authenticating.ClientCredentials) (authenticating.Token, error) {
+	m.Credentials = credentials
+	return m.Token, m.Err
+}
+
+func (m *MockService) Introspect(_ context.Context, tokenDigest string) (authenticating.Token, error) {
+	m.Introspected = tokenDigest
+	return m.Token, m.Err
+}
+
+func (m *MockService) Sessions(_ context.Context, accountID string, currentTokenID primitive.ObjectID) ([]authenticating.Session, error) {
+	m.SessionsOf = accountID
+	m.CurrentToken = currentTokenID
+	return m.SessionList, m.Err
+}
+
+func (m *MockService) RevokeSession(_ context.Context, accountID string, id string) error {
+	m.SessionsOf = accountID
+	m.RevokedSession = id
+	return m.Err
+}
+
+func (m *MockService) RevokeSessions(_ context.Context, accountID string) error {
+	m.SessionsOf = accountID
+	return m.Err
+}
@@ -0,0 +1,10 @@
+package idempotency
+
+import "net/http"
+
+type HandlerMock struct {
+}
+
+func (h HandlerMock) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return next
+}
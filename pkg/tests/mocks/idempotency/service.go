@@ -0,0 +1,35 @@
+package idempotency
+
+import (
+	"context"
+
+	"github.com/pedroyremolo/transfer-api/pkg/idempotency"
+)
+
+type MockService struct {
+	Record    idempotency.Record
+	Replay    bool
+	Err       error
+	Completed *idempotency.Record
+	Released  bool
+}
+
+func (m *MockService) Begin(_ context.Context, accountID string, key string, requestHash string) (idempotency.Record, bool, error) {
+	if m.Err != nil {
+		return idempotency.Record{}, false, m.Err
+	}
+	if m.Replay {
+		return m.Record, true, nil
+	}
+	return idempotency.Record{Key: key, AccountID: accountID, RequestHash: requestHash}, false, nil
+}
+
+func (m *MockService) Complete(_ context.Context, record idempotency.Record) error {
+	m.Completed = &record
+	return nil
+}
+
+func (m *MockService) Release(_ context.Context, _ idempotency.Record) error {
+	m.Released = true
+	return nil
+}
@@ -0,0 +1,22 @@
+package limiting
+
+import "net/http"
+
+type HandlerMock struct {
+}
+
+func (h HandlerMock) GetLimits(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) LowerLimits(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) GetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) SetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
@@ -0,0 +1,40 @@
+package limiting
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+type MockService struct {
+	Limits limiting.AccountLimits
+	// Lowered holds the limits last given to LowerLimits
+	Lowered limiting.Limits
+	// Set holds the settings last given to SetLimits
+	Set limiting.Settings
+	// AccountID is the account whose limits were last retrieved or set
+	AccountID string
+	Err       error
+}
+
+func (m *MockService) GetLimits(_ context.Context, accountID string) (limiting.AccountLimits, error) {
+	m.AccountID = accountID
+	return m.Limits, m.Err
+}
+
+func (m *MockService) LowerLimits(_ context.Context, _ string, limits limiting.Limits) (limiting.AccountLimits, error) {
+	m.Lowered = limits
+	return m.Limits, m.Err
+}
+
+func (m *MockService) SetLimits(_ context.Context, accountID string, settings limiting.Settings) (limiting.AccountLimits, error) {
+	m.AccountID = accountID
+	m.Set = settings
+	return m.Limits, m.Err
+}
+
+func (m *MockService) Check(_ context.Context, _ string, _ money.Money, _ time.Time) error {
+	return m.Err
+}
@@ -1,15 +1,15 @@
 package listing
 
-import (
-	"net/http"
-
-	"github.com/julienschmidt/httprouter"
-)
+import "net/http"
 
 type HandlerMock struct {
 }
 
-func (h HandlerMock) GetBalanceByID(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+func (h HandlerMock) GetAccount(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) GetBalanceByID(w http.ResponseWriter, r *http.Request) {
 	panic("not implemented") // TODO: Implement
 }
 
@@ -20,3 +20,11 @@ func (h HandlerMock) ListAllAccounts(w http.ResponseWriter, r *http.Request) {
 func (h HandlerMock) GetUserTransfers(w http.ResponseWriter, r *http.Request) {
 	panic("not implemented") // TODO: Implement
 }
+
+func (h HandlerMock) GetStatement(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
@@ -2,20 +2,32 @@ package listing
 
 import (
 	"context"
+	"time"
 
 	"github.com/pedroyremolo/transfer-api/pkg/listing"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 )
 
 type MockService struct {
-	Balance          float64
-	Accounts         []listing.Account
-	Account          listing.Account
-	AccountTransfers listing.AccountTransfers
-	CallsToFail      int
-	Err              error
+	Balance        money.Money
+	BalanceAt      time.Time
+	Accounts       []listing.Account
+	Account        listing.Account
+	Transfer       listing.TransferDetails
+	TransferPage   listing.TransferPage
+	TransferFilter listing.TransferFilter
+	PageRequest    listing.PageRequest
+	Statement      listing.Statement
+	// StatementFrom and StatementTo are the period of the statement last retrieved
+	StatementFrom time.Time
+	StatementTo   time.Time
+	// TransferOf is the account the transfer was last retrieved on behalf of
+	TransferOf  string
+	CallsToFail int
+	Err         error
 }
 
-func (s *MockService) GetAccountBalanceByID(_ context.Context, _ string) (float64, error) {
+func (s *MockService) GetAccountBalanceByID(_ context.Context, _ string) (money.Money, error) {
 	var err error
 	s.CallsToFail--
 	if s.CallsToFail <= 0 {
@@ -24,14 +36,55 @@ func (s *MockService) GetAccountBalanceByID(_ context.Context, _ string) (float6
 	return s.Balance, err
 }
 
+func (s *MockService) GetAccountBalanceAt(_ context.Context, _ string, at time.Time) (money.Money, error) {
+	s.BalanceAt = at
+	return s.Balance, s.Err
+}
+
 func (s *MockService) GetAccounts(_ context.Context) ([]listing.Account, error) {
 	return s.Accounts, s.Err
 }
 
+func (s *MockService) GetAccountByID(_ context.Context, _ string) (listing.Account, error) {
+	return s.Account, s.Err
+}
+
 func (s *MockService) GetAccountByCPF(_ context.Context, _ string) (listing.Account, error) {
 	return s.Account, s.Err
 }
 
-func (s *MockService) GetTransfersByAccountID(ctx context.Context, id string) (listing.AccountTransfers, error) {
-	return s.AccountTransfers, s.Err
+func (s *MockService) GetTransfer(_ context.Context, accountID string, _ string) (listing.TransferDetails, error) {
+	s.TransferOf = accountID
+	return s.Transfer, s.Err
+}
+
+func (s *MockService) GetTransfersByAccountID(_ context.Context, _ string, filter listing.TransferFilter, page listing.PageRequest) (listing.TransferPage, error) {
+	s.TransferFilter = filter
+	s.PageRequest = page
+	return s.TransferPage, s.Err
+}
+
+func (s *MockService) GetStatement(_ context.Context, _ string, from time.Time, to time.Time) (listing.Statement, error) {
+	s.StatementFrom = from
+	s.StatementTo = to
+	return s.Statement, s.Err
+}
+
+func (s *MockService) StreamStatement(_ context.Context, _ string, from time.Time, to time.Time, w listing.StatementWriter) error {
+	s.StatementFrom = from
+	s.StatementTo = to
+	if s.Err != nil {
+		return s.Err
+	}
+	statement := s.Statement
+	statement.Lines = nil
+	if err := w.WriteOpening(statement); err != nil {
+		return err
+	}
+	for _, line := range s.Statement.Lines {
+		if err := w.WriteLine(line); err != nil {
+			return err
+		}
+	}
+	return w.WriteClosing(statement)
 }
@@ -0,0 +1,14 @@
+package receipting
+
+import "net/http"
+
+type HandlerMock struct {
+}
+
+func (h HandlerMock) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) VerifyReceipt(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
@@ -0,0 +1,25 @@
+package receipting
+
+import (
+	"context"
+
+	"github.com/pedroyremolo/transfer-api/pkg/receipting"
+)
+
+type MockService struct {
+	Receipt receipting.Receipt
+	// AccountID is the account the receipt was last issued to, and Code the verification code last verified
+	AccountID string
+	Code      string
+	Err       error
+}
+
+func (m *MockService) IssueReceipt(_ context.Context, accountID string, _ string) (receipting.Receipt, error) {
+	m.AccountID = accountID
+	return m.Receipt, m.Err
+}
+
+func (m *MockService) VerifyReceipt(_ context.Context, code string) (receipting.Receipt, error) {
+	m.Code = code
+	return m.Receipt, m.Err
+}
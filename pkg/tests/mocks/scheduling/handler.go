@@ -0,0 +1,38 @@
+package scheduling
+
+import "net/http"
+
+type HandlerMock struct {
+}
+
+func (h HandlerMock) ScheduleTransfer(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) ListScheduledTransfers(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) CancelScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) CreateStandingOrder(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) ListStandingOrders(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) PauseStandingOrder(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) ResumeStandingOrder(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (h HandlerMock) CancelStandingOrder(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
@@ -0,0 +1,62 @@
+package scheduling
+
+import (
+	"context"
+
+	"github.com/pedroyremolo/transfer-api/pkg/scheduling"
+)
+
+type MockService struct {
+	ID        string
+	Transfer  scheduling.ScheduledTransfer
+	Transfers []scheduling.ScheduledTransfer
+	Cancelled string
+	Run       int
+	Order     scheduling.StandingOrder
+	Orders    []scheduling.StandingOrder
+	// Updated is the standing order last paused, resumed or cancelled
+	Updated string
+	Err     error
+}
+
+func (m *MockService) Schedule(_ context.Context, transfer scheduling.ScheduledTransfer) (string, error) {
+	m.Transfer = transfer
+	return m.ID, m.Err
+}
+
+func (m *MockService) GetScheduledTransfersByAccountID(_ context.Context, _ string) ([]scheduling.ScheduledTransfer, error) {
+	return m.Transfers, m.Err
+}
+
+func (m *MockService) Cancel(_ context.Context, _ string, id string) error {
+	m.Cancelled = id
+	return m.Err
+}
+
+func (m *MockService) RunDue(_ context.Context) (int, error) {
+	return m.Run, m.Err
+}
+
+func (m *MockService) CreateStandingOrder(_ context.Context, order scheduling.StandingOrder) (string, error) {
+	m.Order = order
+	return m.ID, m.Err
+}
+
+func (m *MockService) GetStandingOrdersByAccountID(_ context.Context, _ string) ([]scheduling.StandingOrder, error) {
+	return m.Orders, m.Err
+}
+
+func (m *MockService) PauseStandingOrder(_ context.Context, _ string, id string) error {
+	m.Updated = id
+	return m.Err
+}
+
+func (m *MockService) ResumeStandingOrder(_ context.Context, _ string, id string) error {
+	m.Updated = id
+	return m.Err
+}
+
+func (m *MockService) CancelStandingOrder(_ context.Context, _ string, id string) error {
+	m.Updated = id
+	return m.Err
+}
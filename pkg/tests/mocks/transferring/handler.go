@@ -8,3 +8,7 @@ type HandlerMock struct {
 func (h HandlerMock) MakeTransfer(w http.ResponseWriter, r *http.Request) {
 	panic("not implemented") // TODO: Implement
 }
+
+func (h HandlerMock) ReverseTransfer(w http.ResponseWriter, r *http.Request) {
+	panic("not implemented") // TODO: Implement
+}
@@ -1,9 +1,27 @@
 package transferring
 
+import (
+	"context"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferring"
+)
+
 type MockService struct {
-	Err error
+	ID       string
+	Reversal transferring.Reversal
+	Err      error
 }
 
-func (m *MockService) BalanceBetweenAccounts(originBalance float64, destinationBalance float64, _ float64) (_ float64, _ float64, _ error) {
+func (m *MockService) BalanceBetweenAccounts(originBalance money.Money, destinationBalance money.Money, _ money.Money) (_ money.Money, _ money.Money, _ error) {
 	return originBalance, destinationBalance, m.Err
 }
+
+func (m *MockService) MakeTransfer(_ context.Context, _ transferring.Transfer) (string, error) {
+	return m.ID, m.Err
+}
+
+func (m *MockService) ReverseTransfer(_ context.Context, reversal transferring.Reversal) (string, error) {
+	m.Reversal = reversal
+	return m.ID, m.Err
+}
@@ -0,0 +1,26 @@
+package transferring
+
+import (
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
+)
+
+// Reversal is a request to give back all or part of a completed transfer to its origin account
+type Reversal struct {
+	TransferID  string `json:"-"`
+	RequesterID string `json:"-"`
+	// ByOperator allows the requester to reverse a transfer it has not received
+	ByOperator bool `json:"-"`
+	// Amount to be given back, being zero for all that is left of the transfer
+	Amount money.Money `json:"amount"`
+}
+
+// StoredTransfer is a transfer as recorded by the repository
+type StoredTransfer struct {
+	Transfer
+	ID             string
+	Status         transferstatus.Status
+	ReversedAmount money.Money
+	// ReversalOf is the id of the transfer this one compensates, if any
+	ReversalOf string
+}
@@ -1,46 +1,183 @@
 package transferring
 
 import (
+	"context"
 	"errors"
-	"math"
-	"math/big"
+	"time"
 
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
 	"github.com/pedroyremolo/transfer-api/pkg/log/lgr"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
 	"github.com/sirupsen/logrus"
 )
 
 var ErrNotEnoughBalance = errors.New("not enough balance to execute this operation")
+var ErrSameAccount = errors.New("origin and destination accounts must be different")
+var ErrNonPositiveAmount = errors.New("amount to be transferred must be greater than zero")
+var ErrNotAllowedToReverse = errors.New("only the account that received the transfer can reverse it")
+var ErrNotReversible = errors.New("only completed transfers that are not reversals themselves can be reversed")
+var ErrReversalExceedsAmount = errors.New("reversal amount exceeds what is left to be reversed of the transfer")
 
 type Service interface {
-	BalanceBetweenAccounts(originBalance float64, destinationBalance float64, amount float64) (newOriBalance float64, newDstBalance float64, err error)
+	BalanceBetweenAccounts(originBalance money.Money, destinationBalance money.Money, amount money.Money) (newOriBalance money.Money, newDstBalance money.Money, err error)
+	// MakeTransfer records transfer as pending and then executes it, returning its id even when it fails once
+	// recorded, so that an empty id tells that nothing was persisted
+	MakeTransfer(ctx context.Context, transfer Transfer) (string, error)
+	// ReverseTransfer makes a compensating transfer, linked to the reversed one, from its destination back to its origin,
+	// all of it or nothing at all
+	ReverseTransfer(ctx context.Context, reversal Reversal) (string, error)
 }
 
+// Repository is the port through which a transfer goes from pending to either completed or failed
+type Repository interface {
+	// AddPendingTransfer records transfer as pending, before any balance is moved
+	AddPendingTransfer(ctx context.Context, transfer Transfer) (string, error)
+	// ExecuteTransfer reads origin and destination balances, applies balanceFn over them and persists
+	// the new balances along with the completion of the pending transfer id, committing all of it or nothing at all
+	ExecuteTransfer(ctx context.Context, id string, transfer Transfer, balanceFn BalanceFunc) error
+	// FailTransfer moves the pending transfer id to failed, recording the reason why
+	FailTransfer(ctx context.Context, id string, reason transferstatus.Reason) error
+	// ExecuteReversal reads the transfer id and hands it to reversalFn, executing the compensating transfer it returns
+	// as ExecuteTransfer would, along with the deduction of its amount from what is left to be reversed of the original
+	// one, which becomes reversed once nothing is left, committing all of it or nothing at all
+	ExecuteReversal(ctx context.Context, id string, reversalFn ReversalFunc) (string, error)
+}
+
+// ReversalFunc validates the reversal of original, returning the compensating transfer and how it changes balances
+type ReversalFunc func(original StoredTransfer) (compensating Transfer, balanceFn BalanceFunc, err error)
+
+// BalanceFunc calculates the new balances of the accounts involved in a transfer
+type BalanceFunc func(originBalance money.Money, destinationBalance money.Money) (newOriBalance money.Money, newDstBalance money.Money, err error)
+
 type service struct {
-	log *logrus.Logger
+	r       Repository
+	limiter limiting.Service
+	log     *logrus.Logger
 }
 
-func NewService() Service {
+func NewService(repository Repository, limiter limiting.Service) Service {
 	return &service{
-		log: lgr.NewDefaultLogger(),
+		r:       repository,
+		limiter: limiter,
+		log:     lgr.NewDefaultLogger(),
 	}
 }
 
-func (s *service) BalanceBetweenAccounts(oBalance float64, dBalance float64, amount float64) (newOBalance float64, newDBalance float64, err error) {
-	s.log.Infof("Transferring amount %.2f from balance %.2f to balance %.2f", amount, oBalance, dBalance)
-	preciseAmount := big.NewFloat(amount)
-	preciseOBalance := big.NewFloat(oBalance)
-	if preciseAmount.Cmp(preciseOBalance) > 0 {
-		s.log.Errorf("Balance %.2f is lower than amount %.2f", oBalance, amount)
+func (s *service) BalanceBetweenAccounts(oBalance money.Money, dBalance money.Money, amount money.Money) (newOBalance money.Money, newDBalance money.Money, err error) {
+	s.log.Infof("Transferring amount %s from balance %s to balance %s", amount, oBalance, dBalance)
+	if amount > oBalance {
+		s.log.Errorf("Balance %s is lower than amount %s", oBalance, amount)
 		err = ErrNotEnoughBalance
 		return
 	}
-	newOBalance = math.Round((oBalance-amount)*100) / 100
-	newDBalance = math.Round((dBalance+amount)*100) / 100
+	newOBalance = oBalance - amount
+	newDBalance = dBalance + amount
 	s.log.Infof(
-		"New origin balance %.2f and destination balance %.2f after transferring amount %.2f",
+		"New origin balance %s and destination balance %s after transferring amount %s",
 		newOBalance,
 		newDBalance,
 		amount,
 	)
 	return
 }
+
+func (s *service) MakeTransfer(ctx context.Context, transfer Transfer) (string, error) {
+	s.log.Infof("Making transfer %v", transfer)
+	if transfer.Amount <= 0 {
+		s.log.Errorf("Transfer %v has a non positive amount", transfer)
+		return "", ErrNonPositiveAmount
+	}
+	if transfer.OriginAccountID == transfer.DestinationAccountID {
+		s.log.Errorf("Transfer %v has the same origin and destination", transfer)
+		return "", ErrSameAccount
+	}
+
+	transfer.CreatedAt = time.Now().UTC()
+	id, err := s.r.AddPendingTransfer(ctx, transfer)
+	if err != nil {
+		s.log.Errorf("Err %v when adding pending transfer %v", err, transfer)
+		return "", err
+	}
+
+	// limits are checked once the transfer is pending, so that it's counted along with any concurrent one
+	if err = s.limiter.Check(ctx, transfer.OriginAccountID, transfer.Amount, transfer.CreatedAt); err != nil {
+		s.log.Errorf("Err %v when checking limits of transfer %s", err, id)
+		s.fail(ctx, id, err)
+		return id, err
+	}
+
+	err = s.r.ExecuteTransfer(ctx, id, transfer, func(oBalance money.Money, dBalance money.Money) (money.Money, money.Money, error) {
+		return s.BalanceBetweenAccounts(oBalance, dBalance, transfer.Amount)
+	})
+	if err != nil {
+		s.log.Errorf("Err %v when executing transfer %s", err, id)
+		s.fail(ctx, id, err)
+		return id, err
+	}
+
+	s.log.Infof("Transfer %s executed with success", id)
+	return id, nil
+}
+
+func (s *service) ReverseTransfer(ctx context.Context, reversal Reversal) (string, error) {
+	s.log.Infof("Reversing %s of transfer %s as requested by %s", reversal.Amount, reversal.TransferID, reversal.RequesterID)
+	if reversal.Amount < 0 {
+		s.log.Errorf("Reversal %v has a negative amount", reversal)
+		return "", ErrNonPositiveAmount
+	}
+
+	id, err := s.r.ExecuteReversal(ctx, reversal.TransferID, func(original StoredTransfer) (Transfer, BalanceFunc, error) {
+		if !reversal.ByOperator && reversal.RequesterID != original.DestinationAccountID {
+			return Transfer{}, nil, ErrNotAllowedToReverse
+		}
+		if original.Status != transferstatus.Completed || original.ReversalOf != "" {
+			return Transfer{}, nil, ErrNotReversible
+		}
+		left := original.Amount - original.ReversedAmount
+		amount := reversal.Amount
+		if amount == 0 {
+			amount = left
+		}
+		if amount > left {
+			return Transfer{}, nil, ErrReversalExceedsAmount
+		}
+
+		compensating := Transfer{
+			OriginAccountID:      original.DestinationAccountID,
+			DestinationAccountID: original.OriginAccountID,
+			Amount:               amount,
+			CreatedAt:            time.Now().UTC(),
+		}
+		return compensating, func(oBalance money.Money, dBalance money.Money) (money.Money, money.Money, error) {
+			return s.BalanceBetweenAccounts(oBalance, dBalance, amount)
+		}, nil
+	})
+	if err != nil {
+		s.log.Errorf("Err %v when reversing transfer %s", err, reversal.TransferID)
+		return "", err
+	}
+
+	s.log.Infof("Transfer %s reversed by transfer %s", reversal.TransferID, id)
+	return id, nil
+}
+
+func (s *service) fail(ctx context.Context, id string, err error) {
+	if failErr := s.r.FailTransfer(ctx, id, failureReason(err)); failErr != nil {
+		s.log.Errorf("Err %v when failing transfer %s, it is left pending", failErr, id)
+	}
+}
+
+func failureReason(err error) transferstatus.Reason {
+	switch err {
+	case ErrNotEnoughBalance:
+		return transferstatus.ReasonNotEnoughBalance
+	case storage.ErrNoAccountWasFound:
+		return transferstatus.ReasonAccountNotFound
+	case limiting.ErrPerTransactionLimitExceeded, limiting.ErrDailyLimitExceeded, limiting.ErrMonthlyLimitExceeded, limiting.ErrNightTimeLimitExceeded:
+		return transferstatus.ReasonLimitExceeded
+	default:
+		return transferstatus.ReasonStorageError
+	}
+}
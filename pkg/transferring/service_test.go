@@ -1,14 +1,22 @@
 package transferring
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/limiting"
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+	"github.com/pedroyremolo/transfer-api/pkg/storage"
+	"github.com/pedroyremolo/transfer-api/pkg/transferstatus"
 )
 
 func Test_service_BetweenAccounts(t *testing.T) {
 	type args struct {
-		originBalance      float64
-		destinationBalance float64
-		amount             float64
+		originBalance      money.Money
+		destinationBalance money.Money
+		amount             money.Money
 	}
 	tt := []struct {
 		name    string
@@ -18,25 +26,25 @@ func Test_service_BetweenAccounts(t *testing.T) {
 		{
 			name: "When transfer occurs successfully",
 			args: args{
-				originBalance:      112.52,
-				destinationBalance: 23.29,
-				amount:             112.51,
+				originBalance:      money.FromCents(11252),
+				destinationBalance: money.FromCents(2329),
+				amount:             money.FromCents(11251),
 			},
 			wantErr: false,
 		},
 		{
 			name: "When there's not enough balance",
 			args: args{
-				originBalance:      112.52,
-				destinationBalance: 23.29,
-				amount:             112.53,
+				originBalance:      money.FromCents(11252),
+				destinationBalance: money.FromCents(2329),
+				amount:             money.FromCents(11253),
 			},
 			wantErr: true,
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			s := NewService()
+			s := NewService(nil, nil)
 			newOBalance, newDBalance, err := s.BalanceBetweenAccounts(tc.args.originBalance, tc.args.destinationBalance, tc.args.amount)
 			tDBalance, tOBalance, _ := s.BalanceBetweenAccounts(newDBalance, newOBalance, tc.args.amount)
 
@@ -45,12 +53,349 @@ func Test_service_BetweenAccounts(t *testing.T) {
 			}
 
 			if !tc.wantErr && tOBalance != tc.args.originBalance {
-				t.Errorf("Expected reverse operation to lead to equality with originBalance %f, but got %f", tc.args.originBalance, tOBalance)
+				t.Errorf("Expected reverse operation to lead to equality with originBalance %s, but got %s", tc.args.originBalance, tOBalance)
 			}
 
 			if !tc.wantErr && tDBalance != tc.args.destinationBalance {
-				t.Errorf("Expected reverse operation to lead to equality with destinationBalance %f, but got %f", tc.args.destinationBalance, tDBalance)
+				t.Errorf("Expected reverse operation to lead to equality with destinationBalance %s, but got %s", tc.args.destinationBalance, tDBalance)
+			}
+		})
+	}
+}
+
+func TestService_MakeTransfer(t *testing.T) {
+	tt := []struct {
+		name       string
+		transfer   Transfer
+		repository *mockRepository
+		limitErr   error
+		wantErr    error
+		wantReason transferstatus.Reason
+	}{
+		{
+			name: "When transfer is executed successfully",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+			},
+			repository: &mockRepository{
+				id:                 "5f8f8ccb30a1cd7511c5cb72",
+				originBalance:      money.FromCents(2222),
+				destinationBalance: money.FromCents(1),
+			},
+		},
+		{
+			name: "When origin has not enough balance",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(2223),
+			},
+			repository: &mockRepository{
+				originBalance: money.FromCents(2222),
+			},
+			wantErr:    ErrNotEnoughBalance,
+			wantReason: transferstatus.ReasonNotEnoughBalance,
+		},
+		{
+			name: "When transfer exceeds a limit of the origin",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+			},
+			repository: &mockRepository{
+				originBalance: money.FromCents(2222),
+			},
+			limitErr:   limiting.ErrDailyLimitExceeded,
+			wantErr:    limiting.ErrDailyLimitExceeded,
+			wantReason: transferstatus.ReasonLimitExceeded,
+		},
+		{
+			name: "When amount is not greater than zero",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(-1111),
+			},
+			repository: &mockRepository{},
+			wantErr:    ErrNonPositiveAmount,
+		},
+		{
+			name: "When origin and destination are the same account",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb70",
+				Amount:               money.FromCents(1111),
+			},
+			repository: &mockRepository{},
+			wantErr:    ErrSameAccount,
+		},
+		{
+			name: "When repository fails to execute the transfer",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+			},
+			repository: &mockRepository{
+				err: errors.New("foo"),
+			},
+			wantErr:    errors.New("foo"),
+			wantReason: transferstatus.ReasonStorageError,
+		},
+		{
+			name: "When destination account doesn't exist",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+			},
+			repository: &mockRepository{
+				err: storage.ErrNoAccountWasFound,
+			},
+			wantErr:    storage.ErrNoAccountWasFound,
+			wantReason: transferstatus.ReasonAccountNotFound,
+		},
+		{
+			name: "When repository fails to add the pending transfer",
+			transfer: Transfer{
+				OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+				DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+				Amount:               money.FromCents(1111),
+			},
+			repository: &mockRepository{
+				addErr: errors.New("foo"),
+			},
+			wantErr: errors.New("foo"),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewService(tc.repository, &mockLimiter{err: tc.limitErr})
+			id, err := s.MakeTransfer(context.TODO(), tc.transfer)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Errorf("MakeTransfer() error = %v; wantErr = %v", err, tc.wantErr)
+				return
+			}
+
+			if tc.repository.failedReason != tc.wantReason {
+				t.Errorf("Expected failure reason %q, got %q", tc.wantReason, tc.repository.failedReason)
+			}
+
+			if tc.wantErr != nil {
+				if tc.repository.committed {
+					t.Error("Expected transfer not to be committed")
+				}
+				return
+			}
+
+			if id != tc.repository.id {
+				t.Errorf("Expected id %s, got %s", tc.repository.id, id)
+			}
+
+			if tc.repository.transfer.CreatedAt == (time.Time{}) {
+				t.Errorf("Expected transfer with CreatedAt near %s, got %s", time.Now().UTC(), tc.repository.transfer.CreatedAt)
+			}
+
+			if !tc.repository.committed {
+				t.Error("Expected transfer to be committed")
+			}
+		})
+	}
+}
+
+func TestService_ReverseTransfer(t *testing.T) {
+	original := StoredTransfer{
+		Transfer: Transfer{
+			OriginAccountID:      "5f8f8ccb30a1cd7511c5cb70",
+			DestinationAccountID: "5f8f8ccb30a1cd7511c5cb71",
+			Amount:               money.FromCents(1000),
+		},
+		ID:             "5f8f8ccb30a1cd7511c5cb72",
+		Status:         transferstatus.Completed,
+		ReversedAmount: money.FromCents(400),
+	}
+	reversed := original
+	reversed.Status = transferstatus.Reversed
+	reversal := original
+	reversal.ReversalOf = "5f8f8ccb30a1cd7511c5cb73"
+
+	tt := []struct {
+		name       string
+		reversal   Reversal
+		original   StoredTransfer
+		balance    money.Money
+		wantAmount money.Money
+		wantErr    error
+	}{
+		{
+			name:       "When what is left of the transfer is reversed",
+			reversal:   Reversal{TransferID: original.ID, RequesterID: original.DestinationAccountID},
+			original:   original,
+			balance:    money.FromCents(5000),
+			wantAmount: money.FromCents(600),
+		},
+		{
+			name:       "When part of the transfer is reversed",
+			reversal:   Reversal{TransferID: original.ID, RequesterID: original.DestinationAccountID, Amount: money.FromCents(250)},
+			original:   original,
+			balance:    money.FromCents(5000),
+			wantAmount: money.FromCents(250),
+		},
+		{
+			name:       "When an operator reverses the transfer",
+			reversal:   Reversal{TransferID: original.ID, RequesterID: "5f8f8ccb30a1cd7511c5cb79", ByOperator: true},
+			original:   original,
+			balance:    money.FromCents(5000),
+			wantAmount: money.FromCents(600),
+		},
+		{
+			name:     "When requester has not received the transfer",
+			reversal: Reversal{TransferID: original.ID, RequesterID: original.OriginAccountID},
+			original: original,
+			balance:  money.FromCents(5000),
+			wantErr:  ErrNotAllowedToReverse,
+		},
+		{
+			name:     "When amount exceeds what is left of the transfer",
+			reversal: Reversal{TransferID: original.ID, RequesterID: original.DestinationAccountID, Amount: money.FromCents(601)},
+			original: original,
+			balance:  money.FromCents(5000),
+			wantErr:  ErrReversalExceedsAmount,
+		},
+		{
+			name:     "When amount is negative",
+			reversal: Reversal{TransferID: original.ID, RequesterID: original.DestinationAccountID, Amount: money.FromCents(-1)},
+			original: original,
+			wantErr:  ErrNonPositiveAmount,
+		},
+		{
+			name:     "When transfer was already reversed",
+			reversal: Reversal{TransferID: original.ID, RequesterID: original.DestinationAccountID},
+			original: reversed,
+			balance:  money.FromCents(5000),
+			wantErr:  ErrNotReversible,
+		},
+		{
+			name:     "When transfer is a reversal itself",
+			reversal: Reversal{TransferID: original.ID, RequesterID: original.DestinationAccountID},
+			original: reversal,
+			balance:  money.FromCents(5000),
+			wantErr:  ErrNotReversible,
+		},
+		{
+			name:     "When receiving account has not enough balance",
+			reversal: Reversal{TransferID: original.ID, RequesterID: original.DestinationAccountID},
+			original: original,
+			balance:  money.FromCents(599),
+			wantErr:  ErrNotEnoughBalance,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := &mockRepository{
+				id:            "5f8f8ccb30a1cd7511c5cb74",
+				original:      tc.original,
+				originBalance: tc.balance,
+			}
+			s := NewService(repository, &mockLimiter{})
+			id, err := s.ReverseTransfer(context.TODO(), tc.reversal)
+
+			if (err == nil) != (tc.wantErr == nil) || (err != nil && err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("ReverseTransfer() error = %v; wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				if repository.committed {
+					t.Error("Expected reversal not to be committed")
+				}
+				return
+			}
+
+			if id != repository.id || !repository.committed {
+				t.Errorf("Expected reversal %s to be committed, got %s", repository.id, id)
+			}
+			compensating := repository.transfer
+			if compensating.OriginAccountID != original.DestinationAccountID || compensating.DestinationAccountID != original.OriginAccountID {
+				t.Errorf("Expected compensating transfer from %s to %s, got %v", original.DestinationAccountID, original.OriginAccountID, compensating)
+			}
+			if compensating.Amount != tc.wantAmount {
+				t.Errorf("Expected compensating amount %s, got %s", tc.wantAmount, compensating.Amount)
 			}
 		})
 	}
 }
+
+type mockRepository struct {
+	id                 string
+	originBalance      money.Money
+	destinationBalance money.Money
+	transfer           Transfer
+	committed          bool
+	failedReason       transferstatus.Reason
+	original           StoredTransfer
+	addErr             error
+	err                error
+}
+
+func (m *mockRepository) AddPendingTransfer(_ context.Context, transfer Transfer) (string, error) {
+	if m.addErr != nil {
+		return "", m.addErr
+	}
+	m.transfer = transfer
+	return m.id, nil
+}
+
+func (m *mockRepository) ExecuteTransfer(_ context.Context, _ string, _ Transfer, balanceFn BalanceFunc) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, _, err := balanceFn(m.originBalance, m.destinationBalance); err != nil {
+		return err
+	}
+	m.committed = true
+	return nil
+}
+
+func (m *mockRepository) FailTransfer(_ context.Context, _ string, reason transferstatus.Reason) error {
+	m.failedReason = reason
+	return nil
+}
+
+func (m *mockRepository) ExecuteReversal(_ context.Context, _ string, reversalFn ReversalFunc) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	compensating, balanceFn, err := reversalFn(m.original)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err = balanceFn(m.originBalance, m.destinationBalance); err != nil {
+		return "", err
+	}
+	m.transfer = compensating
+	m.committed = true
+	return m.id, nil
+}
+
+type mockLimiter struct {
+	err error
+}
+
+func (m *mockLimiter) GetLimits(_ context.Context, _ string) (limiting.AccountLimits, error) {
+	return limiting.AccountLimits{}, m.err
+}
+
+func (m *mockLimiter) LowerLimits(_ context.Context, _ string, _ limiting.Limits) (limiting.AccountLimits, error) {
+	return limiting.AccountLimits{}, m.err
+}
+
+func (m *mockLimiter) SetLimits(_ context.Context, _ string, _ limiting.Settings) (limiting.AccountLimits, error) {
+	return limiting.AccountLimits{}, m.err
+}
+
+func (m *mockLimiter) Check(_ context.Context, _ string, _ money.Money, _ time.Time) error {
+	return m.err
+}
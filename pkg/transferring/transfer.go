@@ -0,0 +1,21 @@
+package transferring
+
+import (
+	"time"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
+)
+
+// Transfer is the representation of an amount moved from an origin account to a destination one
+type Transfer struct {
+	OriginAccountID      string      `json:"account_origin_id"`
+	DestinationAccountID string      `json:"account_destination_id"`
+	Amount               money.Money `json:"amount"`
+	// StandingOrderID is the standing order this transfer is an occurrence of, which is never taken from clients
+	StandingOrderID string `json:"-"`
+	// TOTPCode is required from accounts enrolled in TOTP for transfers above a threshold, never being stored
+	TOTPCode string `json:"totp_code,omitempty"`
+	// PIN is required from accounts with a transaction pin enabled, never being stored
+	PIN       string `json:"pin,omitempty"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,53 @@
+// Package transferstatus holds the states a transfer goes through: it is created as pending and then either
+// completed or failed, and a completed transfer can later be reversed
+package transferstatus
+
+import "errors"
+
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Completed Status = "completed"
+	Failed    Status = "failed"
+	Reversed  Status = "reversed"
+)
+
+// Reason tells why a transfer has failed
+type Reason string
+
+const (
+	ReasonNotEnoughBalance Reason = "not_enough_balance"
+	ReasonAccountNotFound  Reason = "account_not_found"
+	ReasonStorageError     Reason = "storage_error"
+	ReasonLimitExceeded    Reason = "limit_exceeded"
+)
+
+var ErrInvalidStatus = errors.New("status must be one of pending, completed, failed or reversed")
+var ErrInvalidTransition = errors.New("transfer can't go to the requested status from its current one")
+
+var transitions = map[Status][]Status{
+	Pending:   {Completed, Failed},
+	Completed: {Reversed},
+}
+
+// Parse validates s as one of the known statuses
+func Parse(s string) (Status, error) {
+	status := Status(s)
+	switch status {
+	case Pending, Completed, Failed, Reversed:
+		return status, nil
+	default:
+		return "", ErrInvalidStatus
+	}
+}
+
+// CanTransitionTo reports whether a transfer in status s may go to next
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
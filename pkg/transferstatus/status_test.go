@@ -0,0 +1,37 @@
+package transferstatus
+
+import "testing"
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	tt := []struct {
+		from Status
+		to   Status
+		want bool
+	}{
+		{from: Pending, to: Completed, want: true},
+		{from: Pending, to: Failed, want: true},
+		{from: Pending, to: Reversed, want: false},
+		{from: Completed, to: Reversed, want: true},
+		{from: Completed, to: Failed, want: false},
+		{from: Failed, to: Completed, want: false},
+		{from: Reversed, to: Completed, want: false},
+	}
+	for _, tc := range tt {
+		t.Run(string(tc.from)+" to "+string(tc.to), func(t *testing.T) {
+			if got := tc.from.CanTransitionTo(tc.to); got != tc.want {
+				t.Errorf("CanTransitionTo() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"pending", "completed", "failed", "reversed"} {
+		if got, err := Parse(s); err != nil || string(got) != s {
+			t.Errorf("Parse(%s) = %s, %v", s, got, err)
+		}
+	}
+	if _, err := Parse("done"); err != ErrInvalidStatus {
+		t.Errorf("Expected err %v parsing an unknown status, got %v", ErrInvalidStatus, err)
+	}
+}
@@ -1,6 +1,8 @@
 package updating
 
+import "github.com/pedroyremolo/transfer-api/pkg/money"
+
 type Account struct {
 	ID      string
-	Balance float64
+	Balance money.Money
 }
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/pedroyremolo/transfer-api/pkg/money"
 )
 
 func TestService_UpdateAccounts(t *testing.T) {
@@ -15,12 +17,12 @@ func TestService_UpdateAccounts(t *testing.T) {
 	}{
 		{
 			name:        "When updating occurs successfully",
-			accounts:    []Account{{ID: "4896a4fs98a", Balance: 56.98}, {ID: "4f98a49f8", Balance: 89.63}},
+			accounts:    []Account{{ID: "4896a4fs98a", Balance: money.FromCents(5698)}, {ID: "4f98a49f8", Balance: money.FromCents(8963)}},
 			expectedErr: nil,
 		},
 		{
 			name:        "When updating occurs successfully",
-			accounts:    []Account{{ID: "4896a4fs98a", Balance: 56.98}, {ID: "4f98a49f8", Balance: 89.63}},
+			accounts:    []Account{{ID: "4896a4fs98a", Balance: money.FromCents(5698)}, {ID: "4f98a49f8", Balance: money.FromCents(8963)}},
 			expectedErr: errors.New("foo"),
 		},
 	}